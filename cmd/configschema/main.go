@@ -0,0 +1,43 @@
+// Command configschema writes the JSON Schema of the application
+// configuration, generated from the config structs' `json` and `validate`
+// tags, so deployment tooling (e.g., Helm values schemas) validates against
+// the same rules as the application.
+//
+// Usage:
+//
+//	configschema -out config.schema.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+	"github.com/iamBelugaa/go-boilerplate/pkg/validation"
+)
+
+func main() {
+	out := flag.String("out", "", "output file (defaults to stdout)")
+	flag.Parse()
+
+	if err := run(*out); err != nil {
+		fmt.Fprintln(os.Stderr, "configschema:", err)
+		os.Exit(1)
+	}
+}
+
+// run generates the schema and writes it to out, or stdout when empty.
+func run(out string) error {
+	data, err := validation.MarshalJSONSchema(&config.Config{}, "go-boilerplate configuration")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if out == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(out, data, 0o644)
+}
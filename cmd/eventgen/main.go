@@ -0,0 +1,302 @@
+// Command eventgen generates typed Go event structs, topic constants, and
+// JSON Schemas from a YAML domain-event catalog, so producers and consumers
+// share a single event contract.
+//
+// Usage:
+//
+//	eventgen -catalog catalog.yaml -out events_gen.go -schemas schemas
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Catalog is the root of the YAML event catalog.
+type Catalog struct {
+	Package string  `yaml:"package"`
+	Events  []Event `yaml:"events"`
+}
+
+// Event describes a single domain event.
+type Event struct {
+	Name        string  `yaml:"name"`
+	Topic       string  `yaml:"topic"`
+	Version     int     `yaml:"version"`
+	Description string  `yaml:"description"`
+	Fields      []Field `yaml:"fields"`
+}
+
+// Field describes a single event payload field.
+type Field struct {
+	Name        string `yaml:"name"`
+	Type        string `yaml:"type"`
+	Array       bool   `yaml:"array"`
+	Required    bool   `yaml:"required"`
+	Description string `yaml:"description"`
+}
+
+// fieldTypes maps catalog types to Go types and JSON Schema fragments.
+var fieldTypes = map[string]struct {
+	goType string
+	schema map[string]any
+}{
+	"string":    {"string", map[string]any{"type": "string"}},
+	"int":       {"int64", map[string]any{"type": "integer"}},
+	"float":     {"float64", map[string]any{"type": "number"}},
+	"bool":      {"bool", map[string]any{"type": "boolean"}},
+	"timestamp": {"time.Time", map[string]any{"type": "string", "format": "date-time"}},
+	"uuid":      {"string", map[string]any{"type": "string", "format": "uuid"}},
+}
+
+var (
+	identRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+	topicRe = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+)
+
+func main() {
+	catalogPath := flag.String("catalog", "catalog.yaml", "path to the YAML event catalog")
+	outPath := flag.String("out", "events_gen.go", "path of the generated Go file")
+	schemaDir := flag.String("schemas", "schemas", "directory for generated JSON Schemas")
+	flag.Parse()
+
+	if err := run(*catalogPath, *outPath, *schemaDir); err != nil {
+		fmt.Fprintln(os.Stderr, "eventgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(catalogPath, outPath, schemaDir string) error {
+	data, err := os.ReadFile(catalogPath)
+	if err != nil {
+		return err
+	}
+
+	var catalog Catalog
+	if err := yaml.Unmarshal(data, &catalog); err != nil {
+		return fmt.Errorf("parse catalog: %w", err)
+	}
+
+	if err := catalog.validate(); err != nil {
+		return err
+	}
+
+	src, err := generateGo(catalog)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(outPath, src, 0o644); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(schemaDir, 0o755); err != nil {
+		return err
+	}
+
+	for _, event := range catalog.Events {
+		schema, err := json.MarshalIndent(generateSchema(event), "", "  ")
+		if err != nil {
+			return err
+		}
+
+		name := fmt.Sprintf("%s.v%d.schema.json", event.Topic, event.Version)
+		if err := os.WriteFile(filepath.Join(schemaDir, name), append(schema, '\n'), 0o644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validate checks the catalog for missing or duplicate definitions.
+func (c Catalog) validate() error {
+	if !identRe.MatchString(c.Package) {
+		return fmt.Errorf("invalid package name %q", c.Package)
+	}
+
+	names := make(map[string]bool)
+	for _, e := range c.Events {
+		if !identRe.MatchString(e.Name) {
+			return fmt.Errorf("invalid event name %q", e.Name)
+		}
+		if names[e.Name] {
+			return fmt.Errorf("duplicate event %q", e.Name)
+		}
+		names[e.Name] = true
+
+		if !topicRe.MatchString(e.Topic) {
+			return fmt.Errorf("event %s: invalid topic %q", e.Name, e.Topic)
+		}
+		if e.Version < 1 {
+			return fmt.Errorf("event %s: version must be at least 1", e.Name)
+		}
+
+		fields := make(map[string]bool)
+		for _, f := range e.Fields {
+			if !identRe.MatchString(f.Name) {
+				return fmt.Errorf("event %s: invalid field name %q", e.Name, f.Name)
+			}
+			if fields[f.Name] {
+				return fmt.Errorf("event %s: duplicate field %q", e.Name, f.Name)
+			}
+			fields[f.Name] = true
+
+			if _, ok := fieldTypes[f.Type]; !ok {
+				return fmt.Errorf("event %s: field %s has unknown type %q", e.Name, f.Name, f.Type)
+			}
+		}
+	}
+
+	if len(c.Events) == 0 {
+		return errors.New("catalog defines no events")
+	}
+	return nil
+}
+
+// goName converts a camelCase catalog identifier into an exported Go name,
+// upper-casing common initialisms.
+func goName(s string) string {
+	name := strings.ToUpper(s[:1]) + s[1:]
+	for _, initialism := range []string{"Id", "Url", "Uri", "Api", "Ip"} {
+		if strings.HasSuffix(name, initialism) {
+			name = strings.TrimSuffix(name, initialism) + strings.ToUpper(initialism)
+		}
+	}
+	return name
+}
+
+// goType returns the Go type for a field.
+func goType(f Field) string {
+	t := fieldTypes[f.Type].goType
+	if f.Array {
+		return "[]" + t
+	}
+	return t
+}
+
+// jsonTag returns the struct tag for a field.
+func jsonTag(f Field) string {
+	if f.Required {
+		return fmt.Sprintf("`json:%q`", f.Name)
+	}
+	return fmt.Sprintf("`json:%q`", f.Name+",omitempty")
+}
+
+// usesTime reports whether any field requires the time package.
+func usesTime(c Catalog) bool {
+	for _, e := range c.Events {
+		for _, f := range e.Fields {
+			if f.Type == "timestamp" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var goTemplate = template.Must(template.New("events").Funcs(template.FuncMap{
+	"goName":  goName,
+	"goType":  goType,
+	"jsonTag": jsonTag,
+}).Parse(`// Code generated by eventgen. DO NOT EDIT.
+
+package {{.Catalog.Package}}
+
+{{if .UsesTime}}import "time"{{end}}
+
+// Topic names for every event in the catalog.
+const (
+{{- range .Catalog.Events}}
+	Topic{{.Name}} = "{{.Topic}}"
+{{- end}}
+)
+
+// Event is implemented by every generated event type.
+type Event interface {
+	EventName() string
+	EventTopic() string
+	EventVersion() int
+}
+{{range .Catalog.Events}}
+{{- $event := .}}
+// {{.Name}} {{if .Description}}{{.Description}}{{else}}is the payload of the {{.Topic}} event.{{end}}
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{if .Description}}// {{goName .Name}} {{.Description}}
+	{{end}}{{goName .Name}} {{goType .}} {{jsonTag .}}
+{{- end}}
+}
+
+// EventName returns the catalog name of the event.
+func ({{.Name}}) EventName() string { return "{{.Name}}" }
+
+// EventTopic returns the topic the event is published on.
+func ({{.Name}}) EventTopic() string { return Topic{{.Name}} }
+
+// EventVersion returns the schema version of the event.
+func ({{.Name}}) EventVersion() int { return {{.Version}} }
+{{end}}`))
+
+// generateGo renders and formats the Go source for the catalog.
+func generateGo(c Catalog) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := goTemplate.Execute(&buf, map[string]any{"Catalog": c, "UsesTime": usesTime(c)}); err != nil {
+		return nil, err
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w", err)
+	}
+	return src, nil
+}
+
+// generateSchema builds the JSON Schema document for an event payload.
+func generateSchema(e Event) map[string]any {
+	properties := make(map[string]any, len(e.Fields))
+	required := []string{}
+
+	for _, f := range e.Fields {
+		prop := make(map[string]any)
+		for k, v := range fieldTypes[f.Type].schema {
+			prop[k] = v
+		}
+		if f.Array {
+			prop = map[string]any{"type": "array", "items": prop}
+		}
+		if f.Description != "" {
+			prop["description"] = f.Description
+		}
+
+		properties[f.Name] = prop
+		if f.Required {
+			required = append(required, f.Name)
+		}
+	}
+
+	schema := map[string]any{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"$id":                  fmt.Sprintf("%s.v%d", e.Topic, e.Version),
+		"title":                e.Name,
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+	if e.Description != "" {
+		schema["description"] = e.Description
+	}
+	return schema
+}
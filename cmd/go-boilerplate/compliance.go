@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/compliance"
+	"github.com/iamBelugaa/go-boilerplate/pkg/audit"
+)
+
+// signingKeyEnv holds the key compliance archives are signed with.
+const signingKeyEnv = "BOILERPLATE_COMPLIANCE_SIGNING_KEY"
+
+// complianceReport compiles the audit events, configuration changes, and
+// access logs of a period into a signed archive and stores it at -out.
+func complianceReport(args []string) (int, error) {
+	fs := flag.NewFlagSet("compliance report", flag.ContinueOnError)
+	service := fs.String("service", "go-boilerplate", "service the report is about")
+	from := fs.String("from", "", "start of the period, inclusive (YYYY-MM-DD or RFC 3339)")
+	until := fs.String("to", "", "end of the period, exclusive (YYYY-MM-DD or RFC 3339)")
+	auditFile := fs.String("audit-file", "", "audit event file written by audit.FileSink")
+	out := fs.String("out", "", "file:// directory or presigned http(s) PUT URL to store the archive at")
+	keyID := fs.String("key-id", "default", "ID of the signing key in "+signingKeyEnv)
+	output := outputFlags(fs)
+	var accessLogs stringList
+	fs.Var(&accessLogs, "access-log", "JSON log file with access log entries (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage, err
+	}
+
+	if *auditFile == "" || *out == "" {
+		return exitUsage, errors.New("compliance report: -audit-file and -out are required")
+	}
+
+	start, err := parseTime(*from)
+	if err != nil {
+		return exitUsage, fmt.Errorf("invalid -from: %w", err)
+	}
+	end, err := parseTime(*until)
+	if err != nil {
+		return exitUsage, fmt.Errorf("invalid -to: %w", err)
+	}
+	if !start.Before(end) {
+		return exitUsage, errors.New("compliance report: -from must be before -to")
+	}
+
+	key := os.Getenv(signingKeyEnv)
+	if key == "" {
+		return exitUsage, fmt.Errorf("compliance report: %s is not set", signingKeyEnv)
+	}
+
+	sink, err := audit.OpenFile(*auditFile)
+	if err != nil {
+		return exitFailure, err
+	}
+	defer sink.Close()
+
+	ctx := context.Background()
+
+	var archive bytes.Buffer
+	manifest, err := compliance.Build(ctx, &archive, compliance.Options{
+		Service:    *service,
+		From:       start,
+		Until:      end,
+		Audit:      sink,
+		AccessLogs: accessLogs,
+		KeyID:      *keyID,
+		Key:        []byte(key),
+	})
+	if err != nil {
+		return exitFailure, err
+	}
+
+	name := fmt.Sprintf("%s-compliance-%s-%s.tar.gz", *service, start.Format("20060102"), end.Format("20060102"))
+	location, err := compliance.Upload(ctx, *out, name, bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	if err != nil {
+		return exitFailure, err
+	}
+
+	err = writeOutput(*output, map[string]any{"location": location, "manifest": manifest}, func(w io.Writer) {
+		fmt.Fprintln(w, "LOCATION\tAUDIT EVENTS\tCONFIG CHANGES\tACCESS LOG ENTRIES\tAUDIT CHAIN")
+		chain := "valid"
+		if !manifest.AuditChainValid {
+			chain = "broken"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\n", location,
+			manifest.Counts[compliance.FileAuditEvents],
+			manifest.Counts[compliance.FileConfigChanges],
+			manifest.Counts[compliance.FileAccessLogs],
+			chain)
+	})
+	if err != nil {
+		return exitFailure, err
+	}
+
+	if !manifest.AuditChainValid {
+		return exitFailure, fmt.Errorf("audit chain verification failed: %s", manifest.AuditChainError)
+	}
+	return exitOK, nil
+}
+
+// parseTime parses a date (YYYY-MM-DD, as UTC midnight) or an RFC 3339 time.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+// String implements flag.Value.
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set implements flag.Value.
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/scaffold"
+)
+
+// doctor reports what upgrading a project created from the boilerplate to
+// this version requires. Run it from the current boilerplate against the
+// project, since the project's own copy only knows the releases before it.
+// It fails when anything needs attention.
+func doctor(args []string) (int, error) {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	dir := fs.String("dir", ".", "root of the project to inspect")
+	prefix := fs.String("env-prefix", "", "environment variable prefix of the project (defaults to the one in its manifest)")
+	output := outputFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitUsage, err
+	}
+
+	report, err := scaffold.Diagnose(*dir, *prefix)
+	if err != nil {
+		return exitUsage, err
+	}
+
+	err = writeOutput(*output, report, func(w io.Writer) {
+		fmt.Fprintf(w, "Project created from boilerplate %s (detected by %s); current version %s.\n\n", report.Version, report.DetectedBy, report.Current)
+		if len(report.Findings) == 0 {
+			fmt.Fprintln(w, "Nothing to do.")
+			return
+		}
+		fmt.Fprintln(w, "KIND\tRELEASE\tLOCATION\tMESSAGE")
+		for _, f := range report.Findings {
+			location := f.Path
+			if f.Line > 0 {
+				location += ":" + strconv.Itoa(f.Line)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Kind, f.Release, location, f.Message)
+		}
+	})
+	if err != nil {
+		return exitFailure, err
+	}
+
+	if len(report.Findings) > 0 {
+		return exitFailure, fmt.Errorf("doctor: findings: %d", len(report.Findings))
+	}
+	return exitOK, nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/scaffold"
+)
+
+// initProject turns the checkout into a new project, asking for the module
+// path, name, environment variable prefix, and subsystems to keep unless
+// -yes is given. Prompts go to stderr, so -output json stays parseable.
+func initProject(args []string) (int, error) {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	dir := fs.String("dir", ".", "root of the checkout to rewrite")
+	module := fs.String("module", "", "Go module path of the new project")
+	name := fs.String("name", "", "binary and service name (defaults to the last element of -module)")
+	prefix := fs.String("env-prefix", "", "environment variable prefix (defaults to the name in upper case)")
+	remove := fs.String("remove", "", "comma-separated subsystems to remove")
+	yes := fs.Bool("yes", false, "do not prompt; use the flags and apply the changes")
+	dryRun := fs.Bool("dry-run", false, "print the changes without applying them")
+	output := outputFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitUsage, err
+	}
+
+	opts := scaffold.Options{Dir: *dir, Module: *module, Name: *name, EnvPrefix: *prefix}
+	if *remove != "" {
+		opts.Remove = strings.Split(*remove, ",")
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	if !*yes {
+		var err error
+		if opts, err = askOptions(in, opts); err != nil {
+			return exitUsage, err
+		}
+	}
+	if opts.Name == "" {
+		opts.Name = path.Base(opts.Module)
+	}
+	if opts.EnvPrefix == "" {
+		opts.EnvPrefix = envPrefix(opts.Name)
+	}
+
+	changes, err := scaffold.Plan(opts)
+	if err != nil {
+		return exitUsage, err
+	}
+
+	err = writeOutput(*output, map[string]any{"changes": changes, "dry_run": *dryRun}, func(w io.Writer) {
+		fmt.Fprintln(w, "CHANGE\tPATH\tTO")
+		for _, c := range changes {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", c.Kind, c.Path, c.To)
+		}
+	})
+	if err != nil || *dryRun {
+		return exitOK, err
+	}
+
+	if !*yes {
+		ok, err := confirm(in, fmt.Sprintf("Apply %d changes to %s?", len(changes), opts.Dir), false)
+		if err != nil {
+			return exitUsage, err
+		}
+		if !ok {
+			return exitFailure, errors.New("init: aborted")
+		}
+	}
+
+	if err := scaffold.Apply(opts, changes); err != nil {
+		return exitFailure, err
+	}
+	if *output == outputTable {
+		fmt.Fprintf(os.Stderr, "Project %s created. Run go build ./... and review the unused configuration blocks of removed subsystems.\n", opts.Module)
+	}
+	return exitOK, nil
+}
+
+// askOptions prompts for the options not given as flags.
+func askOptions(in *bufio.Reader, opts scaffold.Options) (scaffold.Options, error) {
+	var err error
+	if opts.Module == "" {
+		if opts.Module, err = ask(in, "Module path", ""); err != nil {
+			return opts, err
+		}
+	}
+	if opts.Name == "" {
+		if opts.Name, err = ask(in, "Service name", path.Base(opts.Module)); err != nil {
+			return opts, err
+		}
+	}
+	if opts.EnvPrefix == "" {
+		if opts.EnvPrefix, err = ask(in, "Environment variable prefix", envPrefix(opts.Name)); err != nil {
+			return opts, err
+		}
+		if !strings.HasSuffix(opts.EnvPrefix, "_") {
+			opts.EnvPrefix += "_"
+		}
+	}
+
+	for _, s := range scaffold.Subsystems {
+		if slices.Contains(opts.Remove, s.Name) {
+			continue
+		}
+		keep, err := confirm(in, fmt.Sprintf("Keep %s (%s)?", s.Name, s.Description), true)
+		if err != nil {
+			return opts, err
+		}
+		if !keep {
+			opts.Remove = append(opts.Remove, s.Name)
+		}
+	}
+	return opts, nil
+}
+
+// ask prompts for a value, returning def for an empty answer.
+func ask(in *bufio.Reader, question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(os.Stderr, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: ", question)
+	}
+
+	line, err := in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("init: read answer: %w", err)
+	}
+	if line = strings.TrimSpace(line); line != "" {
+		return line, nil
+	}
+	return def, nil
+}
+
+// confirm asks a yes/no question.
+func confirm(in *bufio.Reader, question string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	fmt.Fprintf(os.Stderr, "%s [%s]: ", question, hint)
+
+	line, err := in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return false, fmt.Errorf("init: read answer: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "":
+		return def, nil
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// envPrefix derives an environment variable prefix from a service name.
+func envPrefix(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// configLint loads the configuration from the environment and reports
+// security misconfigurations. It exits with 1 when a finding is at or above
+// the -fail-on severity, so it can gate CI pipelines.
+func configLint(args []string) (int, error) {
+	fs := flag.NewFlagSet("config lint", flag.ContinueOnError)
+	output := outputFlags(fs)
+	failOn := fs.String("fail-on", "high", "lowest severity failing the run: low, medium, high, or critical")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage, err
+	}
+
+	threshold, err := config.ParseSeverity(*failOn)
+	if err != nil {
+		return exitUsage, err
+	}
+
+	conf, err := config.LoadFromEnv()
+	if err != nil {
+		return exitUsage, fmt.Errorf("load config: %w", err)
+	}
+	if err := config.Validate(conf); err != nil {
+		return exitUsage, fmt.Errorf("invalid config: %w", err)
+	}
+
+	findings := config.Lint(conf)
+
+	failed := false
+	for _, f := range findings {
+		failed = failed || f.Severity >= threshold
+	}
+
+	err = writeOutput(*output, map[string]any{"findings": findings, "failed": failed}, func(w io.Writer) {
+		if len(findings) == 0 {
+			fmt.Fprintln(w, "no findings")
+			return
+		}
+		fmt.Fprintln(w, "SEVERITY\tRULE\tKEY\tMESSAGE")
+		for _, f := range findings {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Severity, f.Rule, f.Key, f.Message)
+		}
+	})
+	if err != nil {
+		return exitFailure, err
+	}
+
+	if failed {
+		return exitFailure, nil
+	}
+	return exitOK, nil
+}
@@ -0,0 +1,74 @@
+// Command go-boilerplate is the service binary. Operational tasks are
+// exposed as subcommands. Commands reporting results accept -output table
+// (the default), json for a single JSON document on stdout, or quiet; all
+// exit with 0 on success, 1 when they ran but failed or found problems,
+// and 2 on an invalid command line or configuration. Errors go to stderr.
+//
+// Usage:
+//
+//	go-boilerplate init [-module PATH] [-name NAME] [-env-prefix PREFIX] [-remove LIST] [-yes] [-dry-run]
+//	go-boilerplate doctor [-dir DIR] [-env-prefix PREFIX] [-output table|json|quiet]
+//	go-boilerplate serve [-mock SPEC] [-mock-latency D] [-mock-jitter D] [-mock-error-rate R]
+//	go-boilerplate routes [-output table|json|quiet] [-listener public|admin] [-mock]
+//	go-boilerplate migrate up|down|status [-steps N] [-output table|json|quiet]
+//	go-boilerplate migrate create [-dir DIR] [-output table|json|quiet] NAME
+//	go-boilerplate config lint [-output table|json|quiet] [-fail-on high]
+//	go-boilerplate compliance report -from DATE -to DATE -audit-file PATH -out URL [-access-log PATH]... [-output table|json|quiet]
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // the "pgx" database/sql driver
+)
+
+// errUsage is returned when the command line cannot be parsed.
+var errUsage = errors.New("usage: go-boilerplate init [flags] | doctor [flags] | serve [flags] | routes [flags] | migrate up|down|status|create [flags] | config lint [flags] | compliance report [flags]")
+
+func main() {
+	code, err := run(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "go-boilerplate:", err)
+	}
+	os.Exit(code)
+}
+
+// run dispatches to the subcommand named by args and returns the process
+// exit code.
+func run(args []string) (int, error) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "init":
+			return initProject(args[1:])
+		case "doctor":
+			return doctor(args[1:])
+		case "serve":
+			return serve(args[1:])
+		case "routes":
+			return listRoutes(args[1:])
+		}
+	}
+
+	if len(args) < 2 {
+		return exitUsage, errUsage
+	}
+
+	switch args[0] + " " + args[1] {
+	case "migrate up":
+		return migrateUp(args[2:])
+	case "migrate down":
+		return migrateDown(args[2:])
+	case "migrate status":
+		return migrateStatus(args[2:])
+	case "migrate create":
+		return migrateCreate(args[2:])
+	case "config lint":
+		return configLint(args[2:])
+	case "compliance report":
+		return complianceReport(args[2:])
+	default:
+		return exitUsage, errUsage
+	}
+}
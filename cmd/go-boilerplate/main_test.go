@@ -0,0 +1,17 @@
+package main
+
+import (
+	"database/sql"
+	"slices"
+	"testing"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/database"
+)
+
+// TestDefaultDriverRegistered guards the driver import, without which every
+// migrate command and auto-migration fails.
+func TestDefaultDriverRegistered(t *testing.T) {
+	if !slices.Contains(sql.Drivers(), database.DefaultDriver) {
+		t.Fatalf("database/sql driver %q is not registered; drivers: %v", database.DefaultDriver, sql.Drivers())
+	}
+}
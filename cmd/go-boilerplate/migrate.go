@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+	"github.com/iamBelugaa/go-boilerplate/internal/database/migrations"
+	"github.com/iamBelugaa/go-boilerplate/pkg/database"
+	"github.com/iamBelugaa/go-boilerplate/pkg/migrate"
+)
+
+// migrationsDir is where "migrate create" writes migrations by default.
+const migrationsDir = "internal/database/migrations"
+
+// migrateUp applies the pending migrations to the primary database.
+func migrateUp(args []string) (int, error) {
+	fs := flag.NewFlagSet("migrate up", flag.ContinueOnError)
+	output := outputFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitUsage, err
+	}
+	if fs.NArg() != 0 {
+		return exitUsage, errUsage
+	}
+
+	return withMigrator(func(ctx context.Context, m *migrate.Migrator) (int, error) {
+		applied, err := m.Up(ctx)
+		if werr := writeMigrations(*output, "applied", applied); werr != nil && err == nil {
+			err = werr
+		}
+		if err != nil {
+			return exitFailure, err
+		}
+		return exitOK, nil
+	})
+}
+
+// migrateDown reverts the latest -steps applied migrations.
+func migrateDown(args []string) (int, error) {
+	fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+	steps := fs.Int("steps", 1, "number of migrations to revert, newest first")
+	output := outputFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitUsage, err
+	}
+	if fs.NArg() != 0 {
+		return exitUsage, errUsage
+	}
+	if *steps < 1 {
+		return exitUsage, errors.New("migrate down: -steps must be at least 1")
+	}
+
+	return withMigrator(func(ctx context.Context, m *migrate.Migrator) (int, error) {
+		reverted, err := m.Down(ctx, *steps)
+		if werr := writeMigrations(*output, "reverted", reverted); werr != nil && err == nil {
+			err = werr
+		}
+		if err != nil {
+			return exitFailure, err
+		}
+		return exitOK, nil
+	})
+}
+
+// migrateStatus lists the migrations and when they were applied. It
+// fails when any is pending, so deployments can check the schema is
+// current.
+func migrateStatus(args []string) (int, error) {
+	fs := flag.NewFlagSet("migrate status", flag.ContinueOnError)
+	output := outputFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitUsage, err
+	}
+	if fs.NArg() != 0 {
+		return exitUsage, errUsage
+	}
+
+	return withMigrator(func(ctx context.Context, m *migrate.Migrator) (int, error) {
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return exitFailure, err
+		}
+
+		pending := 0
+		for _, s := range statuses {
+			if s.AppliedAt == nil {
+				pending++
+			}
+		}
+
+		err = writeOutput(*output, map[string]any{"migrations": statuses, "pending": pending}, func(w io.Writer) {
+			fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+			for _, s := range statuses {
+				applied := "pending"
+				switch {
+				case s.Missing:
+					applied = s.AppliedAt.Format(time.RFC3339) + " (missing from this binary)"
+				case s.AppliedAt != nil:
+					applied = s.AppliedAt.Format(time.RFC3339)
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, applied)
+			}
+		})
+		if err != nil {
+			return exitFailure, err
+		}
+		if pending > 0 {
+			return exitFailure, nil
+		}
+		return exitOK, nil
+	})
+}
+
+// migrateCreate writes an empty migration, numbered after the latest one,
+// into -dir.
+func migrateCreate(args []string) (int, error) {
+	fs := flag.NewFlagSet("migrate create", flag.ContinueOnError)
+	dir := fs.String("dir", migrationsDir, "directory of the migrations")
+	output := outputFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitUsage, err
+	}
+	if fs.NArg() != 1 {
+		return exitUsage, errors.New("usage: go-boilerplate migrate create [-dir DIR] [-output table|json|quiet] NAME")
+	}
+
+	file, err := migrate.Create(*dir, fs.Arg(0))
+	if err != nil {
+		return exitFailure, err
+	}
+
+	err = writeOutput(*output, map[string]any{"file": file}, func(w io.Writer) {
+		fmt.Fprintln(w, file)
+	})
+	if err != nil {
+		return exitFailure, err
+	}
+	return exitOK, nil
+}
+
+// withMigrator loads the configuration from the environment, opens the
+// primary database, and runs fn with a Migrator of the embedded
+// migrations, cancelled on SIGINT or SIGTERM.
+func withMigrator(fn func(ctx context.Context, m *migrate.Migrator) (int, error)) (int, error) {
+	conf, err := config.LoadFromEnv()
+	if err != nil {
+		return exitUsage, fmt.Errorf("load config: %w", err)
+	}
+	if err := config.Validate(conf); err != nil {
+		return exitUsage, fmt.Errorf("invalid config: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := database.Open(ctx, conf.Database, database.WithPingRetry(1, 0, 0))
+	if err != nil {
+		return exitFailure, err
+	}
+	defer db.Close(context.Background())
+
+	m, err := migrate.New(db.DB, migrations.FS, conf.Migrations, zap.NewNop())
+	if err != nil {
+		return exitFailure, err
+	}
+	return fn(ctx, m)
+}
+
+// writeMigrations prints the migrations a command applied or reverted.
+func writeMigrations(mode, verb string, done []migrate.Migration) error {
+	return writeOutput(mode, map[string]any{verb: done}, func(w io.Writer) {
+		if len(done) == 0 {
+			fmt.Fprintln(w, "nothing "+verb)
+			return
+		}
+		fmt.Fprintln(w, "VERSION\tNAME")
+		for _, mig := range done {
+			fmt.Fprintf(w, "%d\t%s\n", mig.Version, mig.Name)
+		}
+	})
+}
+
+// autoMigrate applies the pending migrations when the server starts, if
+// the configuration asks for it. Validation refuses it in production.
+func autoMigrate(ctx context.Context, conf *config.Config, logger *zap.Logger) error {
+	if conf.Migrations == nil || !conf.Migrations.AutoMigrate {
+		return nil
+	}
+
+	db, err := database.Open(ctx, conf.Database, database.WithLogger(logger))
+	if err != nil {
+		return err
+	}
+	defer db.Close(context.Background())
+
+	m, err := migrate.New(db.DB, migrations.FS, conf.Migrations, logger)
+	if err != nil {
+		return err
+	}
+	applied, err := m.Up(ctx)
+	if err != nil {
+		return err
+	}
+	logger.Info("migrations applied", zap.Int("count", len(applied)))
+	return nil
+}
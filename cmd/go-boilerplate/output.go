@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// Exit codes shared by every command, so pipelines can tell a failed
+// check from a broken invocation.
+const (
+	exitOK      = 0 // the command succeeded
+	exitFailure = 1 // the command ran but failed or found problems
+	exitUsage   = 2 // the command line or configuration is invalid
+)
+
+// Output modes.
+const (
+	outputTable = "table" // aligned columns for humans
+	outputJSON  = "json"  // a single JSON document on stdout
+	outputQuiet = "quiet" // nothing; only the exit code reports the result
+)
+
+// outputFlags registers -output on fs, with -format kept as an alias for
+// scripts written before -output existed ("text" meaning "table").
+func outputFlags(fs *flag.FlagSet) *string {
+	mode := outputTable
+	set := func(v string) error {
+		if v == "text" {
+			v = outputTable
+		}
+		if v != outputTable && v != outputJSON && v != outputQuiet {
+			return fmt.Errorf("unknown output %q", v)
+		}
+		mode = v
+		return nil
+	}
+	fs.Func("output", "output mode: table, json, or quiet", set)
+	fs.Func("format", "deprecated alias of -output", set)
+	return &mode
+}
+
+// writeOutput prints a command's result in mode: data as JSON, or the
+// table written by table, or nothing.
+func writeOutput(mode string, data any, table func(w io.Writer)) error {
+	switch mode {
+	case outputJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(data)
+	case outputQuiet:
+		return nil
+	default:
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		table(tw)
+		return tw.Flush()
+	}
+}
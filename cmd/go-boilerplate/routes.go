@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+	"github.com/iamBelugaa/go-boilerplate/internal/server"
+	"github.com/iamBelugaa/go-boilerplate/pkg/audit"
+	"github.com/iamBelugaa/go-boilerplate/pkg/lifecycle"
+	"github.com/iamBelugaa/go-boilerplate/pkg/logging"
+	"github.com/iamBelugaa/go-boilerplate/pkg/maintenance"
+	"github.com/iamBelugaa/go-boilerplate/pkg/readonly"
+)
+
+// listRoutes prints the routes the service registers on the public or
+// admin listener, with their names and route middleware. The routes
+// depend on the configuration, loaded from the environment as serve does:
+// without an admin port, the probes and metrics are served on the public
+// listener, and there is no admin listener.
+func listRoutes(args []string) (int, error) {
+	fs := flag.NewFlagSet("routes", flag.ContinueOnError)
+	output := outputFlags(fs)
+	listener := fs.String("listener", "public", "listener whose routes to list: public or admin")
+	mock := fs.Bool("mock", false, "list the routes of serve -mock")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage, err
+	}
+
+	conf, err := config.LoadFromEnv()
+	if err != nil {
+		return exitUsage, fmt.Errorf("load config: %w", err)
+	}
+	if err := config.Validate(conf); err != nil {
+		return exitUsage, fmt.Errorf("invalid config: %w", err)
+	}
+
+	var router *server.Router
+	switch *listener {
+	case "public":
+		st := publicState{hooks: lifecycle.New(), gatherer: prometheus.NewRegistry()}
+		if *mock {
+			st.mock = http.NotFoundHandler() // only its registration is listed
+		}
+		router, err = newPublicRouter(conf.Server, st)
+	case "admin":
+		if conf.Server.AdminPort == 0 {
+			return exitUsage, errors.New("no admin listener: the admin port is not configured")
+		}
+		events := audit.NewMemorySink(1)
+		router, err = newAdminRouter(adminState{
+			hooks:    lifecycle.New(),
+			gatherer: prometheus.NewRegistry(),
+			reloader: config.NewReloader(conf, config.LoadFromEnv, config.NewHistory(1)),
+			levels:   logging.NewLevels(zap.NewAtomicLevel()),
+			maint:    maintenance.New(0),
+			readOnly: readonly.New(),
+			audit:    audit.New(events),
+			events:   events,
+		})
+	default:
+		return exitUsage, fmt.Errorf("unknown listener %q", *listener)
+	}
+	if err != nil {
+		return exitFailure, err
+	}
+	routes := router.Routes()
+
+	err = writeOutput(*output, map[string]any{"routes": routes}, func(w io.Writer) {
+		fmt.Fprintln(w, "METHOD\tPATH\tNAME\tMIDDLEWARE")
+		for _, r := range routes {
+			method := r.Method
+			if method == "" {
+				method = "*"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", method, r.Path, r.Name, strings.Join(r.Middleware, ","))
+		}
+	})
+	if err != nil {
+		return exitFailure, err
+	}
+	return exitOK, nil
+}
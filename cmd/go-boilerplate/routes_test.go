@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+	"github.com/iamBelugaa/go-boilerplate/pkg/lifecycle"
+)
+
+// TestPublicRoutes checks the public router, listed by the routes command
+// as serve builds it, follows the configuration.
+func TestPublicRoutes(t *testing.T) {
+	tests := []struct {
+		name string
+		conf *config.Server
+		mock bool
+		want []string
+	}{
+		{name: "probes without admin listener", conf: &config.Server{}, want: []string{"healthz", "readyz", "metrics", "version"}},
+		{name: "probes on admin listener", conf: &config.Server{AdminPort: 9090}},
+		{name: "static", conf: &config.Server{AdminPort: 9090, Static: &config.Static{Enabled: true, Dir: t.TempDir()}}, want: []string{"static"}},
+		{name: "mock", conf: &config.Server{AdminPort: 9090}, mock: true, want: []string{"mock"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := publicState{hooks: lifecycle.New(), gatherer: prometheus.NewRegistry()}
+			if tt.mock {
+				st.mock = http.NotFoundHandler()
+			}
+			router, err := newPublicRouter(tt.conf, st)
+			if err != nil {
+				t.Fatalf("newPublicRouter: %v", err)
+			}
+			var names []string
+			for _, r := range router.Routes() {
+				names = append(names, r.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Fatalf("routes = %q, want %q", names, tt.want)
+			}
+		})
+	}
+}
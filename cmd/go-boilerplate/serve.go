@@ -0,0 +1,435 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/admin"
+	"github.com/iamBelugaa/go-boilerplate/internal/buildinfo"
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+	"github.com/iamBelugaa/go-boilerplate/internal/server"
+	"github.com/iamBelugaa/go-boilerplate/pkg/audit"
+	"github.com/iamBelugaa/go-boilerplate/pkg/contracttest"
+	apperrors "github.com/iamBelugaa/go-boilerplate/pkg/errors"
+	"github.com/iamBelugaa/go-boilerplate/pkg/errreport"
+	"github.com/iamBelugaa/go-boilerplate/pkg/i18n"
+	"github.com/iamBelugaa/go-boilerplate/pkg/lifecycle"
+	"github.com/iamBelugaa/go-boilerplate/pkg/logging"
+	"github.com/iamBelugaa/go-boilerplate/pkg/maintenance"
+	"github.com/iamBelugaa/go-boilerplate/pkg/middleware"
+	"github.com/iamBelugaa/go-boilerplate/pkg/readonly"
+	"github.com/iamBelugaa/go-boilerplate/pkg/throttle"
+	"github.com/iamBelugaa/go-boilerplate/pkg/web/ipacl"
+	"github.com/iamBelugaa/go-boilerplate/pkg/web/static"
+	"github.com/iamBelugaa/go-boilerplate/pkg/web/wire"
+)
+
+// configHistorySize is the number of configuration reloads kept for the
+// admin API.
+const configHistorySize = 20
+
+// auditHistorySize is the number of audit events kept for the admin API.
+const auditHistorySize = 1000
+
+// serve loads the configuration from the environment and runs the HTTP
+// server until the process is asked to stop.
+func serve(args []string) (int, error) {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	mockSpec := fs.String("mock", "", "serve example responses from this OpenAPI document instead of the real handlers")
+	mockLatency := fs.Duration("mock-latency", 0, "delay added to every mock response")
+	mockJitter := fs.Duration("mock-jitter", 0, "random extra delay, up to this much, for mock responses")
+	mockErrorRate := fs.Float64("mock-error-rate", 0, "fraction of mock requests failing with 500, from 0 to 1")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage, err
+	}
+	if fs.NArg() != 0 {
+		return exitUsage, errUsage
+	}
+
+	conf, err := config.LoadFromEnv()
+	if err != nil {
+		return exitUsage, fmt.Errorf("load config: %w", err)
+	}
+	if err := config.Validate(conf); err != nil {
+		return exitUsage, fmt.Errorf("invalid config: %w", err)
+	}
+
+	reporter, err := errreport.New(conf.ErrorReporting, conf.Service)
+	if err != nil {
+		return exitFailure, fmt.Errorf("create error reporter: %w", err)
+	}
+	defer reporter.Flush(2 * time.Second)
+
+	otlpLogs, err := logging.NewOTLPProvider(context.Background(), conf.Telemetry, conf.Service)
+	if err != nil {
+		return exitFailure, fmt.Errorf("create otlp log exporter: %w", err)
+	}
+
+	levels := logging.NewLevels(zap.NewAtomicLevel())
+	logger, err := logging.New(conf.Logging, conf.Service,
+		logging.WithErrorReporter(reporter),
+		logging.WithLevels(levels),
+		logging.WithOTLP(otlpLogs),
+	)
+	if err != nil {
+		return exitFailure, fmt.Errorf("create logger: %w", err)
+	}
+	defer logger.Sync()
+
+	for _, w := range config.Warnings(conf) {
+		logger.Warn("suspicious configuration", zap.String("field", w.Field), zap.String("warning", w.Err))
+	}
+
+	// The reloader owns the live configuration from here on: what can
+	// change at runtime reads reloader.Current or subscribes to reloads.
+	// With a remote source, reloads apply its latest payload over the
+	// environment, and the "remote-config" component polls for changes.
+	loader := config.LoadFromEnv
+	var remote *config.Remote
+	if conf.RemoteConfig != nil {
+		remote = config.NewRemote(conf.RemoteConfig, nil)
+		loader = remote.Loader()
+	}
+	reloader := config.NewReloader(conf, loader, config.NewHistory(configHistorySize))
+	reloader.Subscribe(func(_, _ *config.Config, snap config.Snapshot) {
+		logger.Info("configuration reloaded", zap.Int("version", snap.Version), zap.String("actor", snap.Actor), zap.Int("changes", len(snap.Changes)))
+	})
+
+	// Runtime changes, such as reloads and switches flipped through the
+	// admin API, are audited; the recent events are served at /debug/audit.
+	events := audit.NewMemorySink(auditHistorySize)
+	auditLog := audit.New(events)
+	onAuditError := func(err error) {
+		logger.Error("audit event not recorded", zap.Error(err))
+	}
+	config.AuditReloads(reloader, auditLog, onAuditError)
+
+	onLevelError := func(err error) {
+		logger.Error("reloaded log level not applied", zap.Error(err))
+	}
+	logging.FollowConfig(reloader, levels.Root(), onLevelError)
+	logging.FollowConfigModules(reloader, levels, onLevelError)
+
+	if err := autoMigrate(context.Background(), conf, logger); err != nil {
+		return exitFailure, fmt.Errorf("migrate database: %w", err)
+	}
+
+	hooks := lifecycle.New()
+	monitor := wire.NewMonitor(func(e wire.Event) {
+		logger.Debug("protocol error", zap.String("kind", string(e.Kind)), zap.String("remote_addr", e.RemoteAddr), zap.String("error", e.Message))
+	})
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	chain, err := middleware.Defaults(conf, logger, reg, reporter)
+	if err != nil {
+		return exitFailure, fmt.Errorf("build middleware: %w", err)
+	}
+
+	// Error settings wrap panic recovery too, so every error response of
+	// the chain, its own included, is rendered as configured.
+	chain = chain.Before(middleware.NameRecover, middleware.Named{
+		Name: apperrors.NameErrors,
+		Wrap: apperrors.Middleware(conf.Service.Environment, conf.Server.Problems),
+	})
+
+	bundle, err := newBundle(conf.Server.I18n)
+	if err != nil {
+		return exitUsage, fmt.Errorf("load message catalogs: %w", err)
+	}
+	chain = chain.After(middleware.NameRecover, middleware.Named{
+		Name: i18n.NameI18n,
+		Wrap: i18n.Middleware(bundle),
+	})
+
+	maint := newMaintenance(conf.Server.Maintenance)
+	maint.FollowConfig(reloader)
+	chain = chain.After(middleware.NameRecover, middleware.Named{
+		Name: "maintenance",
+		Wrap: maintenance.Middleware(maint, maintenanceExempt(conf.Server.Maintenance)),
+	})
+	if maint.Enabled() {
+		logger.Warn("starting in maintenance mode")
+	}
+
+	readOnly := readonly.New()
+	if conf.Server.ReadOnly {
+		readOnly.Enable("config")
+		logger.Warn("starting in read-only mode")
+	}
+	readOnly.FollowConfig(reloader)
+	chain = chain.After("maintenance", middleware.Named{
+		Name: "readonly",
+		Wrap: readonly.Middleware(readOnly),
+	})
+
+	// Requests are admitted in fair shares per principal, weighted by tier;
+	// authentication added to the chain before "throttle" keys them by
+	// principal rather than client IP.
+	if t := conf.Server.Throttle; t != nil && t.Enabled {
+		chain = chain.Append(middleware.Named{
+			Name: throttle.NameThrottle,
+			Wrap: throttle.Middleware(throttle.New(t), throttle.Principal(t)),
+		})
+	}
+
+	opts := []server.Option{
+		server.WithHooks(hooks),
+		server.WithMonitor(monitor),
+		server.WithMiddleware(chain),
+	}
+	if conf.Server.AdminPort != 0 {
+		adminRouter, err := newAdminRouter(adminState{
+			hooks:    hooks,
+			gatherer: reg,
+			reloader: reloader,
+			levels:   levels,
+			maint:    maint,
+			readOnly: readOnly,
+			audit:    auditLog,
+			events:   events,
+			onError:  onAuditError,
+		})
+		if err != nil {
+			return exitUsage, fmt.Errorf("build admin routes: %w", err)
+		}
+		opts = append(opts, server.WithAdmin(adminRouter))
+	}
+
+	var mock http.Handler
+	if *mockSpec != "" {
+		mock, err = newMock(*mockSpec, contracttest.MockOptions{
+			Latency:   *mockLatency,
+			Jitter:    *mockJitter,
+			ErrorRate: *mockErrorRate,
+		})
+		if err != nil {
+			return exitUsage, err
+		}
+		logger.Warn("serving mock responses", zap.String("spec", *mockSpec))
+	}
+	router, err := newPublicRouter(conf.Server, publicState{hooks: hooks, gatherer: reg, mock: mock})
+	if err != nil {
+		return exitUsage, err
+	}
+
+	// Subsystems such as database pools, workers, and consumers join the
+	// group, so they start before and stop after the server using them.
+	srv := server.New(conf.Server, router, logger, opts...)
+	group := lifecycle.NewGroup()
+	if otlpLogs != nil {
+		// Registered first so it stops last, flushing the logs of every
+		// other component's shutdown.
+		group.Add(lifecycle.Component{Name: "otlp-logs", Stop: otlpLogs.Shutdown})
+	}
+	group.Add(lifecycle.Component{
+		Name: "loglevel",
+		Run: func(ctx context.Context) error {
+			logging.WatchSignals(ctx, levels.Root(), logger)
+			return nil
+		},
+	})
+	group.Add(lifecycle.Component{
+		Name: "config",
+		Run: func(ctx context.Context) error {
+			reloader.Watch(ctx, func(err error) {
+				logger.Error("configuration reload failed", zap.Error(err))
+			})
+			return nil
+		},
+	})
+	if remote != nil {
+		group.Add(lifecycle.Component{
+			Name:      "remote-config",
+			DependsOn: []string{"config"},
+			Run: func(ctx context.Context) error {
+				remote.Run(ctx, reloader, func(err error) {
+					logger.Error("remote configuration poll failed", zap.Error(err))
+				})
+				return nil
+			},
+		})
+	}
+	group.Add(lifecycle.Component{
+		Name:        "http",
+		DependsOn:   []string{"config"},
+		Run:         srv.Run,
+		StopTimeout: 2*conf.Server.ShutdownTimeout + 5*time.Second, // post-traffic hooks, then the drain
+	})
+
+	err = group.Run(context.Background())
+	for _, res := range group.StopResults() {
+		logger.Info("component stopped", zap.String("component", res.Name), zap.Duration("elapsed", res.Duration), zap.Bool("timed_out", res.TimedOut))
+	}
+	if err != nil {
+		return exitFailure, err
+	}
+	return exitOK, nil
+}
+
+// newRouter registers the service's API routes. Versioned API routes go
+// in groups such as "/api/v1", wrapped in the middleware of a
+// versioning.Registry to announce their deprecation.
+func newRouter() *server.Router {
+	return server.NewRouter()
+}
+
+// publicState is what the public listener serves besides the API.
+type publicState struct {
+	hooks    *lifecycle.Hooks
+	gatherer prometheus.Gatherer
+
+	// mock serves example responses in place of the API; nil serves the
+	// real handlers.
+	mock http.Handler
+}
+
+// newPublicRouter registers the routes of the public listener, for serve
+// and the routes command alike: the API routes, the probes and metrics
+// when there is no admin listener, the static assets when enabled, and
+// the mock responses when given.
+func newPublicRouter(conf *config.Server, st publicState) (*server.Router, error) {
+	router := newRouter()
+	if conf.AdminPort == 0 {
+		handleProbes(router, st.hooks, st.gatherer)
+	}
+
+	if conf.Static != nil && conf.Static.Enabled {
+		assets, err := static.New(conf.Static, nil)
+		if err != nil {
+			return nil, err
+		}
+		router.Handle("GET "+assets.Prefix(), assets).Name("static")
+	}
+
+	if st.mock != nil {
+		router.Handle("/", st.mock).Name("mock")
+	}
+	return router, nil
+}
+
+// adminState is what the admin routes report on and change.
+type adminState struct {
+	hooks    *lifecycle.Hooks
+	gatherer prometheus.Gatherer
+	reloader *config.Reloader
+	levels   *logging.Levels
+	maint    *maintenance.Switch
+	readOnly *readonly.Switch
+
+	// audit records the changes made through the admin routes, and events
+	// serves the recorded events. Write failures are reported to onError.
+	audit   *audit.Logger
+	events  audit.Querier
+	onError func(error)
+}
+
+// newAdminRouter registers the operational routes served on the admin
+// listener. Probes and metrics are open to orchestrators and scrapers;
+// debug endpoints require the admin token and pass the "admin" network ACL.
+// The token is read from the live configuration, so a reload rotates it.
+func newAdminRouter(st adminState) (*server.Router, error) {
+	acl, err := config.BuildNetworkACL(st.reloader.Current(), "admin")
+	if err != nil {
+		return nil, err
+	}
+
+	router := server.NewRouter()
+	handleProbes(router, st.hooks, st.gatherer)
+
+	debug := router.Group("/debug",
+		middleware.Named{Name: "network_acl", Wrap: ipacl.Middleware(acl, ipacl.RemoteIP, nil)},
+		middleware.Named{Name: "admin_token", Wrap: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				admin.RequireToken(st.reloader.Current().Server.AdminToken, next).ServeHTTP(w, r)
+			})
+		}},
+	)
+	debug.HandleFunc("GET /pprof/", pprof.Index).Name("pprof")
+	debug.HandleFunc("GET /pprof/cmdline", pprof.Cmdline).Name("pprof.cmdline")
+	debug.HandleFunc("GET /pprof/profile", pprof.Profile).Name("pprof.profile")
+	debug.HandleFunc("GET /pprof/symbol", pprof.Symbol).Name("pprof.symbol")
+	debug.HandleFunc("POST /pprof/symbol", pprof.Symbol)
+	debug.HandleFunc("GET /pprof/trace", pprof.Trace).Name("pprof.trace")
+	debug.Handle("/config", admin.ConfigHandler(st.reloader.Current)).Name("config")
+	debug.Handle("/config/history", admin.ConfigHistoryHandler(st.reloader.History())).Name("config.history")
+	debug.Handle("/audit", admin.AuditHandler(st.events)).Name("audit")
+	debug.Handle("/loglevel", admin.LogLevelHandler(st.levels, st.audit, st.onError)).Name("loglevel")
+	debug.Handle("/maintenance", admin.MaintenanceHandler(st.maint, st.audit, st.onError)).Name("maintenance")
+	debug.Handle("/readonly", admin.ReadOnlyHandler(st.readOnly, st.audit, st.onError)).Name("readonly")
+	return router, nil
+}
+
+// handleProbes registers the liveness and readiness probes, the metrics
+// endpoint, and the build metadata.
+func handleProbes(router *server.Router, hooks *lifecycle.Hooks, gatherer prometheus.Gatherer) {
+	router.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"alive":true}` + "\n"))
+	}).Name("healthz")
+	router.Handle("GET /readyz", hooks.ReadyHandler()).Name("readyz")
+	router.Handle("GET /metrics", promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})).Name("metrics")
+	router.Handle("GET /version", buildinfo.Handler()).Name("version")
+}
+
+// newBundle loads the message catalogs, the embedded ones overlaid with
+// those of the configured directory.
+func newBundle(conf *config.I18n) (*i18n.Bundle, error) {
+	if conf == nil {
+		return i18n.NewBundle("")
+	}
+	bundle, err := i18n.NewBundle(conf.DefaultLocale)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Dir != "" {
+		if err := bundle.LoadDir(conf.Dir); err != nil {
+			return nil, err
+		}
+	}
+	return bundle, nil
+}
+
+// newMaintenance builds the maintenance switch, turned on when conf says so.
+func newMaintenance(conf *config.Maintenance) *maintenance.Switch {
+	if conf == nil {
+		return maintenance.New(0)
+	}
+	s := maintenance.New(conf.RetryAfter)
+	if conf.Enabled {
+		s.Enable("config", time.Time{})
+	}
+	return s
+}
+
+// maintenanceExempt returns the paths configured to stay available in
+// maintenance mode.
+func maintenanceExempt(conf *config.Maintenance) []string {
+	if conf == nil {
+		return nil
+	}
+	return conf.ExemptPaths
+}
+
+// newMock builds the handler serving example responses from the OpenAPI
+// document at path.
+func newMock(path string, opts contracttest.MockOptions) (http.Handler, error) {
+	if opts.ErrorRate < 0 || opts.ErrorRate > 1 {
+		return nil, fmt.Errorf("mock error rate must be between 0 and 1")
+	}
+	spec, err := contracttest.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("load mock spec: %w", err)
+	}
+	return spec.Mock(opts)
+}
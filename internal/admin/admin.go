@@ -0,0 +1,38 @@
+// Package admin provides operational HTTP handlers (debug and inspection
+// endpoints) that must never be exposed without authentication.
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/audit"
+)
+
+// TokenActor is the audit actor of requests authenticated by RequireToken:
+// the admin token is shared, so it does not tell operators apart.
+const TokenActor = "admin"
+
+// RequireToken wraps next so that it is only reachable with a matching
+// "Authorization: Bearer <token>" header. An empty token denies every request.
+// Changes made by the requests let through are audited as TokenActor.
+func RequireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(audit.WithActor(r.Context(), TokenActor)))
+	})
+}
+
+// writeJSON encodes data as the JSON response body with the given status.
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(data)
+}
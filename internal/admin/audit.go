@@ -0,0 +1,83 @@
+package admin
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/audit"
+)
+
+// AuditHandler returns recorded audit events, newest first. It accepts the
+// query parameters "actor", "action", "resource", "since", "until"
+// (RFC 3339), and "limit" (default 100).
+func AuditHandler(querier audit.Querier) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+
+		q := r.URL.Query()
+		filter := audit.Filter{
+			Actor:    q.Get("actor"),
+			Action:   q.Get("action"),
+			Resource: q.Get("resource"),
+			Limit:    100,
+		}
+
+		for name, dst := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+			if v := q.Get(name); v != "" {
+				t, err := time.Parse(time.RFC3339, v)
+				if err != nil {
+					writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid '" + name + "' parameter"})
+					return
+				}
+				*dst = t
+			}
+		}
+
+		if v := q.Get("limit"); v != "" {
+			limit, err := strconv.Atoi(v)
+			if err != nil || limit < 1 {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid 'limit' parameter"})
+				return
+			}
+			filter.Limit = limit
+		}
+
+		events, err := querier.Query(r.Context(), filter)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to query audit events"})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]any{"events": events})
+	})
+}
+
+// recordChange records the change r made to resource as an audit event
+// attributed to the actor of r's context. A nil logger records nothing;
+// write failures are reported to onError and never fail the request.
+func recordChange(r *http.Request, logger *audit.Logger, onError func(error), action, resource string, before, after any) {
+	if logger == nil {
+		return
+	}
+
+	e := audit.Event{
+		Action:    action,
+		Resource:  resource,
+		Before:    before,
+		After:     after,
+		RequestID: r.Header.Get("X-Request-ID"),
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		e.Metadata = map[string]any{"ip": host}
+	}
+	if err := logger.Record(context.WithoutCancel(r.Context()), e); err != nil && onError != nil {
+		onError(err)
+	}
+}
@@ -0,0 +1,35 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// ConfigHandler returns the redacted effective configuration along with
+// the source of every value: env, remote (the remote configuration
+// overlay), build (stamped into the binary, such as the service version),
+// or default. There is no file source. current is called on every request
+// so reloaded configuration is reflected immediately.
+func ConfigHandler(current func() *config.Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"config": config.Effective(current())})
+	})
+}
+
+// ConfigHistoryHandler returns the most recent configuration reload diffs.
+func ConfigHistoryHandler(history *config.History) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"snapshots": history.Recent()})
+	})
+}
@@ -0,0 +1,83 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/audit"
+	"github.com/iamBelugaa/go-boilerplate/pkg/logging"
+)
+
+// LogLevelHandler reports the root and module log levels on GET and changes
+// them without restarting the process: PUT with {"level":"debug"} sets the
+// root level, {"module":"http","level":"warn"} overrides a module, and
+// DELETE with ?module=http removes an override. It is meant to be served at
+// /debug/loglevel. Every change is recorded to logger with the effective
+// level before and after it; write failures are reported to onError.
+func LogLevelHandler(levels *logging.Levels, logger *audit.Logger, onError func(error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, levelsResponse(levels))
+
+		case http.MethodPut:
+			var req struct {
+				Module string `json:"module"`
+				Level  string `json:"level"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+				return
+			}
+
+			l, err := zapcore.ParseLevel(req.Level)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+
+			before := levels.For(req.Module)
+			if req.Module == "" {
+				levels.Root().SetLevel(l)
+			} else {
+				levels.Set(req.Module, l)
+			}
+			recordChange(r, logger, onError, "loglevel.update", levelResource(req.Module), before.String(), l.String())
+			writeJSON(w, http.StatusOK, levelsResponse(levels))
+
+		case http.MethodDelete:
+			module := r.URL.Query().Get("module")
+			if module == "" {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "module is required"})
+				return
+			}
+			before := levels.For(module)
+			levels.Reset(module)
+			recordChange(r, logger, onError, "loglevel.reset", levelResource(module), before.String(), levels.For(module).String())
+			writeJSON(w, http.StatusOK, levelsResponse(levels))
+
+		default:
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		}
+	})
+}
+
+// levelResource names the logger whose level is changed in audit events.
+func levelResource(module string) string {
+	if module == "" {
+		return "loglevel"
+	}
+	return "loglevel:" + module
+}
+
+// levelsResponse renders the current root and module levels.
+func levelsResponse(levels *logging.Levels) map[string]any {
+	modules := make(map[string]string)
+	for module, l := range levels.Modules() {
+		modules[module] = l.String()
+	}
+	return map[string]any{"level": levels.Root().Level().String(), "modules": modules}
+}
@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/audit"
+	"github.com/iamBelugaa/go-boilerplate/pkg/maintenance"
+)
+
+// MaintenanceHandler reports the maintenance switch on GET and flips it on
+// PUT with a body such as
+// {"enabled":true,"reason":"deploy","until":"2025-01-02T15:04:05Z"}, where
+// until is optional. Every flip is recorded to logger; write failures are
+// reported to onError.
+func MaintenanceHandler(s *maintenance.Switch, logger *audit.Logger, onError func(error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, s.State())
+
+		case http.MethodPut:
+			var req struct {
+				Enabled bool      `json:"enabled"`
+				Reason  string    `json:"reason"`
+				Until   time.Time `json:"until"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+				return
+			}
+
+			before := s.State()
+			if req.Enabled {
+				s.Enable(req.Reason, req.Until)
+			} else {
+				s.Disable()
+			}
+			after := s.State()
+			recordChange(r, logger, onError, "maintenance.update", "maintenance", before, after)
+			writeJSON(w, http.StatusOK, after)
+
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		}
+	})
+}
@@ -0,0 +1,80 @@
+// Package buildinfo exposes metadata about the running binary. Values are
+// injected at build time via -ldflags, for example:
+//
+//	go build -ldflags "\
+//	  -X github.com/iamBelugaa/go-boilerplate/internal/buildinfo.version=v1.2.3 \
+//	  -X github.com/iamBelugaa/go-boilerplate/internal/buildinfo.gitSHA=$(git rev-parse HEAD) \
+//	  -X github.com/iamBelugaa/go-boilerplate/internal/buildinfo.buildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// When a value is not injected, it falls back to the VCS information
+// embedded by the Go toolchain.
+package buildinfo
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// Set via -ldflags at build time.
+var (
+	version   string
+	gitSHA    string
+	buildDate string
+)
+
+// Info describes the running binary.
+type Info struct {
+	Version   string `json:"version"`
+	GitSHA    string `json:"gitSha"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+}
+
+var (
+	once sync.Once
+	info Info
+)
+
+// Get returns the build metadata of the running binary.
+func Get() Info {
+	once.Do(func() {
+		info = Info{
+			Version:   version,
+			GitSHA:    gitSHA,
+			BuildDate: buildDate,
+			GoVersion: runtime.Version(),
+		}
+
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			for _, s := range bi.Settings {
+				switch {
+				case s.Key == "vcs.revision" && info.GitSHA == "":
+					info.GitSHA = s.Value
+				case s.Key == "vcs.time" && info.BuildDate == "":
+					info.BuildDate = s.Value
+				}
+			}
+
+			if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+				info.Version = bi.Main.Version
+			}
+		}
+
+		if info.Version == "" {
+			info.Version = "dev"
+		}
+	})
+
+	return info
+}
+
+// Handler serves the build metadata as JSON, typically at /version.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Get())
+	})
+}
@@ -0,0 +1,210 @@
+// Package compliance compiles the evidence auditors ask for (audit events,
+// configuration changes, and access logs for a period) into a signed
+// archive.
+package compliance
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/audit"
+	"github.com/iamBelugaa/go-boilerplate/pkg/signing"
+)
+
+// Archive member names.
+const (
+	FileAuditEvents   = "audit_events.jsonl"
+	FileConfigChanges = "config_changes.jsonl"
+	FileAccessLogs    = "access_logs.jsonl"
+	FileManifest      = "manifest.json"
+	FileSignature     = "manifest.sig"
+)
+
+// Options selects what goes into a report.
+type Options struct {
+	// Service names the service the report is about.
+	Service string
+
+	// From and Until bound the reported period, [From, Until).
+	From, Until time.Time
+
+	// Audit is the audit store to read events from.
+	Audit audit.Querier
+
+	// AccessLogs are paths of JSON log files holding access log entries
+	// (see logging.AccessLog).
+	AccessLogs []string
+
+	// KeyID and Key sign the manifest with HMAC-SHA256 (see signing.SignPayload).
+	KeyID string
+	Key   []byte
+}
+
+// Manifest describes the contents of a report.
+type Manifest struct {
+	Service     string            `json:"service"`
+	From        time.Time         `json:"from"`
+	Until       time.Time         `json:"until"`
+	GeneratedAt time.Time         `json:"generatedAt"`
+	KeyID       string            `json:"keyId"`
+	Files       map[string]string `json:"files"`
+	Counts      map[string]int    `json:"counts"`
+
+	// AuditChainValid reports whether the period's audit events form an
+	// unbroken hash chain; AuditChainError explains why not.
+	AuditChainValid bool   `json:"auditChainValid"`
+	AuditChainError string `json:"auditChainError,omitempty"`
+}
+
+// Build writes a gzipped tar archive of the report to w. The archive holds
+// one JSON-lines file per evidence type, a manifest with the SHA-256 of
+// each, and the manifest's signature.
+func Build(ctx context.Context, w io.Writer, opts Options) (*Manifest, error) {
+	events, err := opts.Audit.Query(ctx, audit.Filter{Since: opts.From, Until: opts.Until})
+	if err != nil {
+		return nil, fmt.Errorf("compliance: query audit events: %w", err)
+	}
+	slices.Reverse(events) // oldest first
+
+	manifest := &Manifest{
+		Service:         opts.Service,
+		From:            opts.From.UTC(),
+		Until:           opts.Until.UTC(),
+		GeneratedAt:     time.Now().UTC(),
+		KeyID:           opts.KeyID,
+		Files:           make(map[string]string),
+		Counts:          make(map[string]int),
+		AuditChainValid: true,
+	}
+	if err := audit.Verify(events); err != nil {
+		manifest.AuditChainValid = false
+		manifest.AuditChainError = err.Error()
+	}
+
+	var auditLines, configLines [][]byte
+	for _, e := range events {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		auditLines = append(auditLines, line)
+		if e.Action == "config.reload" {
+			configLines = append(configLines, line)
+		}
+	}
+
+	accessLines, err := accessLogLines(opts.AccessLogs, opts.From, opts.Until)
+	if err != nil {
+		return nil, err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	for _, f := range []struct {
+		name  string
+		lines [][]byte
+	}{
+		{FileAuditEvents, auditLines},
+		{FileConfigChanges, configLines},
+		{FileAccessLogs, accessLines},
+	} {
+		body := bytes.Join(f.lines, []byte("\n"))
+		if len(body) > 0 {
+			body = append(body, '\n')
+		}
+
+		sum := sha256.Sum256(body)
+		manifest.Files[f.name] = hex.EncodeToString(sum[:])
+		manifest.Counts[f.name] = len(f.lines)
+
+		if err := writeMember(tw, f.name, body, manifest.GeneratedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	body, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeMember(tw, FileManifest, body, manifest.GeneratedAt); err != nil {
+		return nil, err
+	}
+
+	sig := fmt.Sprintf("keyId=%s\ntimestamp=%d\nsignature=%s\n",
+		opts.KeyID, manifest.GeneratedAt.Unix(), signing.SignPayload(opts.Key, manifest.GeneratedAt, body))
+	if err := writeMember(tw, FileSignature, []byte(sig), manifest.GeneratedAt); err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// writeMember adds a file to the archive.
+func writeMember(tw *tar.Writer, name string, body []byte, modTime time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o600,
+		Size:    int64(len(body)),
+		ModTime: modTime,
+	}); err != nil {
+		return fmt.Errorf("compliance: write %s: %w", name, err)
+	}
+	if _, err := tw.Write(body); err != nil {
+		return fmt.Errorf("compliance: write %s: %w", name, err)
+	}
+	return nil
+}
+
+// accessLogLines returns the access log entries in [from, until) found in
+// the given JSON log files, skipping other log entries.
+func accessLogLines(paths []string, from, until time.Time) ([][]byte, error) {
+	var lines [][]byte
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("compliance: read access log: %w", err)
+		}
+
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(nil, 16<<20)
+		for scanner.Scan() {
+			var entry struct {
+				Msg       string    `json:"msg"`
+				Timestamp time.Time `json:"timestamp"`
+				Method    string    `json:"method"`
+			}
+			if json.Unmarshal(scanner.Bytes(), &entry) != nil || entry.Msg != "request" || entry.Method == "" {
+				continue
+			}
+			if entry.Timestamp.Before(from) || !entry.Timestamp.Before(until) {
+				continue
+			}
+			lines = append(lines, bytes.Clone(scanner.Bytes()))
+		}
+
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("compliance: read access log %s: %w", path, err)
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,64 @@
+package compliance
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// Upload stores the archive read from body at dst, which is either a
+// file:// URL of a directory, receiving the archive as name, or an
+// http(s) URL presigned for a PUT by the object store (S3, GCS, and Azure
+// Blob Storage all issue them), receiving the archive as is. It returns
+// the location of the stored archive.
+func Upload(ctx context.Context, dst, name string, body io.Reader, size int64) (string, error) {
+	u, err := url.Parse(dst)
+	if err != nil {
+		return "", fmt.Errorf("compliance: invalid destination: %w", err)
+	}
+
+	switch u.Scheme {
+	case "file":
+		path := filepath.Join(u.Path, name)
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return "", fmt.Errorf("compliance: store archive: %w", err)
+		}
+		if _, err := io.Copy(f, body); err != nil {
+			f.Close()
+			return "", fmt.Errorf("compliance: store archive: %w", err)
+		}
+		if err := f.Close(); err != nil {
+			return "", fmt.Errorf("compliance: store archive: %w", err)
+		}
+		return path, nil
+
+	case "http", "https":
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, dst, body)
+		if err != nil {
+			return "", err
+		}
+		req.ContentLength = size
+		req.Header.Set("Content-Type", "application/gzip")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("compliance: upload archive: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode/100 != 2 {
+			return "", fmt.Errorf("compliance: upload archive: unexpected status %d", resp.StatusCode)
+		}
+
+		u.RawQuery = "" // drop the presigned credentials
+		return u.String(), nil
+
+	default:
+		return "", fmt.Errorf("compliance: unsupported destination scheme %q", u.Scheme)
+	}
+}
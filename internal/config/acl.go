@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/web/ipacl"
+)
+
+// BuildNetworkACL builds the access control list for a route group or listener
+// from Server.NetworkACLs. Groups without configuration admit every address.
+func BuildNetworkACL(conf *Config, group string) (*ipacl.ACL, error) {
+	allow, deny := networkACLRanges(conf, group)
+	return ipacl.New(group, allow, deny)
+}
+
+// WatchNetworkACL keeps acl in sync with the group's ranges across
+// configuration reloads. Invalid ranges keep the previous lists in place
+// and are reported to onError.
+func WatchNetworkACL(r *Reloader, acl *ipacl.ACL, onError func(error)) {
+	r.Subscribe(func(_, next *Config, _ Snapshot) {
+		allow, deny := networkACLRanges(next, acl.Name())
+		if err := acl.Update(allow, deny); err != nil && onError != nil {
+			onError(fmt.Errorf("network acl %q: %w", acl.Name(), err))
+		}
+	})
+}
+
+// networkACLRanges returns the configured ranges for group.
+func networkACLRanges(conf *Config, group string) (allow, deny []string) {
+	if conf.Server == nil {
+		return nil, nil
+	}
+
+	acl, ok := conf.Server.NetworkACLs[group]
+	if !ok || acl == nil {
+		return nil, nil
+	}
+	return acl.Allow, acl.Deny
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"context"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/audit"
+)
+
+// AuditReloads records every applied configuration reload as an audit event
+// carrying the reload's actor and the redacted before/after values of each
+// changed key. Write failures are reported to onError.
+func AuditReloads(r *Reloader, logger *audit.Logger, onError func(error)) {
+	r.Subscribe(func(_, _ *Config, snap Snapshot) {
+		if len(snap.Changes) == 0 {
+			return
+		}
+
+		before := make(map[string]any, len(snap.Changes))
+		after := make(map[string]any, len(snap.Changes))
+		for _, c := range snap.Changes {
+			before[c.Key] = c.Old
+			after[c.Key] = c.New
+		}
+
+		err := logger.Record(context.Background(), audit.Event{
+			Time:     snap.LoadedAt,
+			Actor:    snap.Actor,
+			Action:   "config.reload",
+			Resource: "config",
+			Before:   before,
+			After:    after,
+			Metadata: map[string]any{"version": snap.Version},
+		})
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	})
+}
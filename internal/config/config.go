@@ -6,17 +6,32 @@
 //     (e.g., "MYAPP_", "PAYMENTS_", "ORDERS_") to avoid collisions.
 package config
 
+//go:generate go run ../../cmd/configschema -out config.schema.json
+
 import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/knadh/koanf/providers/env"
 	"github.com/knadh/koanf/v2"
 
+	"github.com/iamBelugaa/go-boilerplate/internal/buildinfo"
 	"github.com/iamBelugaa/go-boilerplate/pkg/validation"
 )
 
 // LoadFromEnv loads application configuration from environment variables.
 func LoadFromEnv() (*Config, error) {
+	return load(nil)
+}
+
+// load loads the configuration from environment variables, then applies
+// overlay, a nested map of koanf keys fetched from the remote source, on
+// top of them.
+func load(overlay map[string]any) (*Config, error) {
 	k := koanf.New(".")
 
 	if err := k.Load(
@@ -31,17 +46,43 @@ func LoadFromEnv() (*Config, error) {
 		return nil, err
 	}
 
-	conf := &Config{}
+	remote := koanf.New(".")
+	if err := remote.Load(mapProvider(overlay), nil); err != nil {
+		return nil, err
+	}
+
+	// The remote source must not be able to repoint or disable itself.
+	remote.Delete("remote_config")
+
+	if err := k.Merge(remote); err != nil {
+		return nil, err
+	}
+
+	conf := &Config{sources: make(map[string]Source)}
 	if err := k.Unmarshal("", conf); err != nil {
 		return nil, err
 	}
 
+	for _, key := range k.Keys() {
+		conf.sources[key] = SourceEnv
+	}
+	for _, key := range remote.Keys() {
+		conf.sources[key] = SourceRemote
+	}
+
+	// Default the service version to the one stamped into the binary.
+	if conf.Service != nil && conf.Service.Version == "" {
+		conf.Service.Version = buildinfo.Get().Version
+		conf.sources["application.service_version"] = SourceBuild
+	}
+
 	return conf, nil
 }
 
-// Validate checks the loaded configuration for correctness.
+// Validate checks the loaded configuration for correctness. Tags such as
+// "required_if_production" are evaluated against the service environment.
 func Validate(conf *Config) error {
-	if err := validation.Check(conf); err != nil {
+	if err := validation.CheckCtx(validationContext(conf), conf); err != nil {
 		return err
 	}
 
@@ -63,6 +104,12 @@ func Validate(conf *Config) error {
 		}
 	}
 
+	for _, name := range slices.Sorted(maps.Keys(conf.Databases)) {
+		if err := conf.Databases[name].Validate(); err != nil {
+			return fmt.Errorf("databases.%s: %w", name, err)
+		}
+	}
+
 	if conf.Service != nil {
 		if err := conf.Service.Validate(); err != nil {
 			return err
@@ -75,5 +122,105 @@ func Validate(conf *Config) error {
 		}
 	}
 
+	if conf.Messaging != nil {
+		if err := conf.Messaging.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if conf.FeatureFlags != nil {
+		if err := conf.FeatureFlags.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if conf.Auth != nil {
+		if err := conf.Auth.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if conf.HTTPClient != nil {
+		if err := conf.HTTPClient.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if conf.ErrorReporting != nil {
+		if err := conf.ErrorReporting.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if conf.Telemetry != nil {
+		if err := conf.Telemetry.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if conf.RemoteConfig != nil {
+		if err := conf.RemoteConfig.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if conf.Sharding != nil {
+		if err := conf.Sharding.Validate(); err != nil {
+			return err
+		}
+		for _, name := range conf.Sharding.Shards {
+			if _, ok := conf.DB(name); !ok {
+				return fmt.Errorf("sharding: shard %q is not a configured database", name)
+			}
+		}
+	}
+
+	if conf.Replication != nil {
+		if err := conf.Replication.Validate(); err != nil {
+			return err
+		}
+		for _, name := range conf.Replication.Replicas {
+			if _, ok := conf.DB(name); !ok {
+				return fmt.Errorf("replication: replica %q is not a configured database", name)
+			}
+		}
+	}
+
+	if conf.Migrations != nil {
+		if err := conf.Migrations.Validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+// Warnings reports suspicious but acceptable configuration values (e.g.,
+// an unusually large connection pool), declared with `warn` struct tags.
+// Warnings never prevent startup; they are meant to be logged.
+func Warnings(conf *Config) validation.FieldErrors {
+	return validation.Warnings(validationContext(conf), conf)
+}
+
+// validationContext returns the context tags are evaluated in, carrying
+// the service environment.
+func validationContext(conf *Config) context.Context {
+	ctx := context.Background()
+	if conf.Service != nil {
+		ctx = validation.WithEnvironment(ctx, conf.Service.Environment.String())
+	}
+	return ctx
+}
+
+// mapProvider is a koanf provider serving an already decoded nested map.
+type mapProvider map[string]any
+
+// ReadBytes implements koanf.Provider.
+func (p mapProvider) ReadBytes() ([]byte, error) {
+	return nil, errors.New("config: map provider does not support ReadBytes")
+}
+
+// Read implements koanf.Provider.
+func (p mapProvider) Read() (map[string]any, error) {
+	return p, nil
+}
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+// setValidEnv sets the environment variables of a minimal valid
+// configuration.
+func setValidEnv(t *testing.T) {
+	t.Helper()
+	for key, value := range map[string]string{
+		"BOILERPLATE_SERVER.SERVER_HOST":              "localhost",
+		"BOILERPLATE_SERVER.SERVER_PORT":              "8080",
+		"BOILERPLATE_SERVER.SERVER_READ_TIMEOUT":      "5s",
+		"BOILERPLATE_SERVER.SERVER_WRITE_TIMEOUT":     "10s",
+		"BOILERPLATE_SERVER.SERVER_IDLE_TIMEOUT":      "60s",
+		"BOILERPLATE_SERVER.SERVER_SHUTDOWN_TIMEOUT":  "15s",
+		"BOILERPLATE_LOGGING.LEVEL":                   "info",
+		"BOILERPLATE_LOGGING.OUTPUT_PATHS":            "stdout",
+		"BOILERPLATE_DATABASE.DB_HOST":                "localhost",
+		"BOILERPLATE_DATABASE.DB_PORT":                "5432",
+		"BOILERPLATE_DATABASE.DB_USER":                "app",
+		"BOILERPLATE_DATABASE.DB_PASSWORD":            "hunter2",
+		"BOILERPLATE_DATABASE.DB_NAME":                "app",
+		"BOILERPLATE_DATABASE.DB_MAX_OPEN_CONNS":      "10",
+		"BOILERPLATE_DATABASE.DB_MAX_IDLE_CONNS":      "5",
+		"BOILERPLATE_DATABASE.DB_CONN_MAX_LIFETIME":   "300",
+		"BOILERPLATE_DATABASE.DB_CONN_MAX_IDLE_TIME":  "60",
+		"BOILERPLATE_APPLICATION.SERVICE_NAME":        "boilerplate",
+		"BOILERPLATE_APPLICATION.SERVICE_VERSION":     "1.0.0",
+		"BOILERPLATE_APPLICATION.SERVICE_ENVIRONMENT": "DEVELOPMENT",
+		"BOILERPLATE_HEALTH_CHECKS.TIMEOUT":           "2s",
+		"BOILERPLATE_HEALTH_CHECKS.INTERVAL":          "30s",
+		"BOILERPLATE_REMOTE_CONFIG.ENDPOINT":          "https://config.example.com/",
+		"BOILERPLATE_REMOTE_CONFIG.POLL_INTERVAL":     "30s",
+	} {
+		t.Setenv(key, value)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	setValidEnv(t)
+	conf, err := load(map[string]any{
+		"logging":       map[string]any{"level": "debug"},
+		"remote_config": map[string]any{"endpoint": "https://attacker.example.com/"},
+	})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if err := Validate(conf); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	if conf.Server.Port != 8080 || conf.Server.ReadTimeout.String() != "5s" || len(conf.Logging.OutputPaths) != 1 {
+		t.Errorf("environment values not decoded: %+v %+v", conf.Server, conf.Logging)
+	}
+	if conf.Logging.Level != "debug" {
+		t.Errorf("logging level = %q, want the remote debug over the env info", conf.Logging.Level)
+	}
+	if conf.RemoteConfig.Endpoint != "https://config.example.com/" {
+		t.Errorf("remote endpoint = %q, want the remote unable to repoint itself", conf.RemoteConfig.Endpoint)
+	}
+
+	tests := []struct {
+		key  string
+		want Source
+	}{
+		{key: "server.server_host", want: SourceEnv},
+		{key: "logging.level", want: SourceRemote},
+		{key: "remote_config.endpoint", want: SourceEnv},
+		{key: "server.server_admin_token", want: SourceDefault},
+	}
+	for _, tt := range tests {
+		if got := conf.Source(tt.key); got != tt.want {
+			t.Errorf("Source(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestLoadDefaultsVersionToBuild(t *testing.T) {
+	setValidEnv(t)
+	t.Setenv("BOILERPLATE_APPLICATION.SERVICE_VERSION", "")
+	conf, err := LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv: %v", err)
+	}
+	if conf.Service.Version == "" || conf.Source("application.service_version") != SourceBuild {
+		t.Errorf("version %q from %q, want the build version", conf.Service.Version, conf.Source("application.service_version"))
+	}
+}
+
+func TestReloader(t *testing.T) {
+	setValidEnv(t)
+	initial, err := LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv: %v", err)
+	}
+
+	r := NewReloader(initial, LoadFromEnv, NewHistory(2))
+	var notified []Snapshot
+	r.Subscribe(func(prev, next *Config, snap Snapshot) {
+		if prev != initial || next != r.Current() {
+			t.Error("subscriber not given the swapped configurations")
+		}
+		notified = append(notified, snap)
+	})
+
+	t.Setenv("BOILERPLATE_LOGGING.LEVEL", "warn")
+	snap, err := r.Reload("test")
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if r.Current().Logging.Level != "warn" || len(notified) != 1 {
+		t.Fatalf("level %q after %d notifications, want warn after 1", r.Current().Logging.Level, len(notified))
+	}
+	if snap.Version != 1 || snap.Actor != "test" || len(snap.Changes) != 1 || snap.Changes[0].Key != "logging.level" {
+		t.Errorf("snapshot = %+v, want version 1 by test changing logging.level", snap)
+	}
+
+	t.Setenv("BOILERPLATE_SERVER.SERVER_HOST", "")
+	if _, err := r.Reload("test"); err == nil {
+		t.Fatal("Reload of an invalid configuration succeeded")
+	}
+	if r.Current().Server.Host != "localhost" || len(notified) != 1 || len(r.History().Recent()) != 1 {
+		t.Error("an invalid configuration replaced the current one")
+	}
+
+	failing := NewReloader(initial, func() (*Config, error) { return nil, errors.New("unreachable") }, NewHistory(1))
+	if _, err := failing.Reload("test"); err == nil || failing.Current() != initial {
+		t.Errorf("Reload with a failing loader = %v, want the error and the current configuration kept", err)
+	}
+}
+
+func TestHistoryKeepsNewest(t *testing.T) {
+	h := NewHistory(2)
+	conf := &Config{}
+	for _, actor := range []string{"a", "b", "c"} {
+		h.Record(conf, conf, actor)
+	}
+	recent := h.Recent()
+	if len(recent) != 2 || recent[0].Actor != "c" || recent[1].Actor != "b" || recent[0].Version != 3 {
+		t.Fatalf("Recent = %+v, want c then b", recent)
+	}
+}
+
+func TestDiffRedacts(t *testing.T) {
+	prev := &Config{
+		Server:   &Server{Host: "localhost", AdminToken: "old-token"},
+		Database: &Database{Password: "old"},
+	}
+	next := &Config{
+		Server:   &Server{Host: "0.0.0.0", AdminToken: "new-token"},
+		Database: &Database{},
+	}
+
+	want := []Change{
+		{Key: "database.db_password", Old: redactedValue, New: ""},
+		{Key: "server.server_admin_token", Old: redactedValue, New: redactedValue},
+		{Key: "server.server_host", Old: "localhost", New: "0.0.0.0"},
+	}
+	got := Diff(prev, next)
+	if len(got) != len(want) {
+		t.Fatalf("Diff = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("change %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if changes := Diff(prev, prev); len(changes) != 0 {
+		t.Errorf("Diff of a configuration with itself = %+v, want none", changes)
+	}
+}
+
+func TestEffectiveRedacts(t *testing.T) {
+	conf := &Config{
+		Server:   &Server{Host: "localhost", AdminToken: "secret"},
+		Database: &Database{},
+		sources:  map[string]Source{"server.server_host": SourceEnv},
+	}
+	values := make(map[string]Value)
+	for _, v := range Effective(conf) {
+		values[v.Key] = v
+	}
+
+	tests := []struct {
+		key        string
+		wantValue  any
+		wantSource Source
+	}{
+		{key: "server.server_host", wantValue: "localhost", wantSource: SourceEnv},
+		{key: "server.server_admin_token", wantValue: redactedValue, wantSource: SourceDefault},
+		{key: "database.db_password", wantValue: "", wantSource: SourceDefault},
+	}
+	for _, tt := range tests {
+		v, ok := values[tt.key]
+		if !ok || v.Value != tt.wantValue || v.Source != tt.wantSource {
+			t.Errorf("%s = %+v, want %v from %q", tt.key, v, tt.wantValue, tt.wantSource)
+		}
+	}
+}
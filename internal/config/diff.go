@@ -0,0 +1,101 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Change describes a single configuration key whose value differs between
+// two configurations. Sensitive values are redacted.
+type Change struct {
+	Key string `json:"key"`
+	Old any    `json:"old"`
+	New any    `json:"new"`
+}
+
+// Diff returns the keys whose values differ between prev and next, sorted by key.
+func Diff(prev, next *Config) []Change {
+	before := make(map[string]leaf)
+	after := make(map[string]leaf)
+	flatten("", reflect.ValueOf(prev), false, before)
+	flatten("", reflect.ValueOf(next), false, after)
+
+	var changes []Change
+	for key, old := range before {
+		cur, ok := after[key]
+		if !ok {
+			changes = append(changes, Change{Key: key, Old: old.redacted()})
+			continue
+		}
+		if !reflect.DeepEqual(old.value, cur.value) {
+			changes = append(changes, Change{Key: key, Old: old.redacted(), New: cur.redacted()})
+		}
+	}
+
+	for key, cur := range after {
+		if _, ok := before[key]; !ok {
+			changes = append(changes, Change{Key: key, New: cur.redacted()})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+
+	return changes
+}
+
+// Snapshot records the outcome of a single configuration load.
+type Snapshot struct {
+	Version  int       `json:"version"`
+	LoadedAt time.Time `json:"loadedAt"`
+	Actor    string    `json:"actor"`
+	Changes  []Change  `json:"changes"`
+}
+
+// History keeps the most recent configuration snapshots in memory.
+type History struct {
+	mu        sync.RWMutex
+	size      int
+	version   int
+	snapshots []Snapshot
+}
+
+// NewHistory constructs a History retaining at most size snapshots.
+func NewHistory(size int) *History {
+	if size < 1 {
+		size = 1
+	}
+	return &History{size: size}
+}
+
+// Record diffs prev against next and stores the result as a new snapshot
+// attributed to actor.
+func (h *History) Record(prev, next *Config, actor string) Snapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.version++
+	snap := Snapshot{Version: h.version, LoadedAt: time.Now().UTC(), Actor: actor, Changes: Diff(prev, next)}
+
+	h.snapshots = append(h.snapshots, snap)
+	if len(h.snapshots) > h.size {
+		h.snapshots = h.snapshots[len(h.snapshots)-h.size:]
+	}
+
+	return snap
+}
+
+// Recent returns the retained snapshots, newest first.
+func (h *History) Recent() []Snapshot {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	recent := make([]Snapshot, len(h.snapshots))
+	for i, snap := range h.snapshots {
+		recent[len(h.snapshots)-1-i] = snap
+	}
+	return recent
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Source identifies where a configuration value was loaded from.
+type Source string
+
+// Supported configuration sources.
+const (
+	SourceEnv     Source = "env"
+	SourceRemote  Source = "remote"
+	SourceBuild   Source = "build"
+	SourceDefault Source = "default"
+)
+
+// redactedValue replaces the value of fields tagged with `redact:"true"`.
+const redactedValue = "[REDACTED]"
+
+// Value is a single flattened configuration entry with its origin.
+type Value struct {
+	Key    string `json:"key"`
+	Value  any    `json:"value"`
+	Source Source `json:"source"`
+}
+
+// leaf is a single flattened configuration value.
+type leaf struct {
+	value     any
+	sensitive bool
+}
+
+// redacted returns the value safe for display, masking sensitive values.
+func (l leaf) redacted() any {
+	if !l.sensitive {
+		return l.value
+	}
+	if l.value == nil || reflect.ValueOf(l.value).IsZero() {
+		return ""
+	}
+	return redactedValue
+}
+
+// Effective flattens the configuration into koanf-style keys
+// (e.g., "server.server_host"), redacting sensitive values and
+// annotating each entry with the source it was loaded from.
+func Effective(conf *Config) []Value {
+	flat := make(map[string]leaf)
+	flatten("", reflect.ValueOf(conf), false, flat)
+
+	values := make([]Value, 0, len(flat))
+	for key, l := range flat {
+		values = append(values, Value{Key: key, Value: l.redacted(), Source: conf.Source(key)})
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Key < values[j].Key
+	})
+
+	return values
+}
+
+// Source reports where the value for the given key was loaded from.
+// Keys that were not provided by any source carry their default value.
+func (c *Config) Source(key string) Source {
+	if src, ok := c.sources[key]; ok {
+		return src
+	}
+	return SourceDefault
+}
+
+// flatten walks v and records every leaf value under its koanf path.
+// Values below a field tagged with `redact:"true"` are marked sensitive.
+func flatten(prefix string, v reflect.Value, sensitive bool, out map[string]leaf) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name := field.Tag.Get("koanf")
+			if name == "" || name == "-" {
+				continue
+			}
+
+			redact := sensitive || field.Tag.Get("redact") == "true"
+			flatten(joinKey(prefix, name), v.Field(i), redact, out)
+		}
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			out[prefix] = leaf{value: v.Interface(), sensitive: sensitive}
+			return
+		}
+		for _, mk := range v.MapKeys() {
+			flatten(joinKey(prefix, mk.String()), v.MapIndex(mk), sensitive, out)
+		}
+
+	default:
+		if d, ok := v.Interface().(time.Duration); ok {
+			out[prefix] = leaf{value: d.String(), sensitive: sensitive}
+			return
+		}
+		out[prefix] = leaf{value: v.Interface(), sensitive: sensitive}
+	}
+}
+
+// Keys returns the koanf key of every configuration value, sorted. Map
+// entries appear as a "*" segment (e.g., "databases.*.db_host").
+func Keys() []string {
+	var keys []string
+	collectKeys("", reflect.TypeFor[Config](), &keys)
+	sort.Strings(keys)
+	return keys
+}
+
+// collectKeys records the koanf path of every leaf of t.
+func collectKeys(prefix string, t reflect.Type, keys *[]string) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t.Kind() == reflect.Struct && t != reflect.TypeFor[time.Time]():
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name := field.Tag.Get("koanf")
+			if name == "" || name == "-" {
+				continue
+			}
+			collectKeys(joinKey(prefix, name), field.Type, keys)
+		}
+
+	case t.Kind() == reflect.Map && t.Key().Kind() == reflect.String:
+		collectKeys(joinKey(prefix, "*"), t.Elem(), keys)
+
+	default:
+		*keys = append(*keys, prefix)
+	}
+}
+
+// joinKey joins a koanf key path using the "." delimiter.
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return strings.Join([]string{prefix, name}, ".")
+}
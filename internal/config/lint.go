@@ -0,0 +1,227 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Severity ranks how dangerous a lint finding is.
+type Severity int
+
+// Supported severities, from least to most severe.
+const (
+	SeverityLow Severity = iota + 1
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+// severityNames maps severities to their names.
+var severityNames = map[Severity]string{
+	SeverityLow:      "low",
+	SeverityMedium:   "medium",
+	SeverityHigh:     "high",
+	SeverityCritical: "critical",
+}
+
+// String returns the lowercase name of the severity.
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// MarshalJSON encodes the severity by name.
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// ParseSeverity returns the severity with the given name.
+func ParseSeverity(name string) (Severity, error) {
+	for s, n := range severityNames {
+		if strings.EqualFold(n, name) {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("config: unknown severity %q", name)
+}
+
+// Finding is a security misconfiguration reported by Lint.
+type Finding struct {
+	// Rule identifies the lint rule (e.g., "db-tls-disabled").
+	Rule string `json:"rule"`
+
+	// Severity ranks the finding for CI gating.
+	Severity Severity `json:"severity"`
+
+	// Key is the configuration key the finding is about.
+	Key string `json:"key"`
+
+	// Message explains the problem and how to fix it.
+	Message string `json:"message"`
+}
+
+// lintRule inspects a configuration for one class of misconfiguration.
+type lintRule func(conf *Config) []Finding
+
+// lintRules are the rules applied by Lint.
+var lintRules = []lintRule{
+	lintAdminExposure,
+	lintDatabaseTLS,
+	lintMessagingTLS,
+	lintDebugLogging,
+	lintCORS,
+}
+
+// Lint checks conf for security misconfigurations, such as settings that
+// are acceptable in development but dangerous in production. Findings are
+// sorted by descending severity, then by key.
+func Lint(conf *Config) []Finding {
+	var findings []Finding
+	for _, rule := range lintRules {
+		findings = append(findings, rule(conf)...)
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Severity != findings[j].Severity {
+			return findings[i].Severity > findings[j].Severity
+		}
+		return findings[i].Key < findings[j].Key
+	})
+	return findings
+}
+
+// isProduction reports whether conf describes a production deployment.
+func isProduction(conf *Config) bool {
+	return conf.Service != nil && conf.Service.Environment == EnvironmentProduction
+}
+
+// minAdminTokenLength is the shortest admin token considered strong.
+const minAdminTokenLength = 32
+
+// lintAdminExposure flags admin and debug endpoints that are reachable with
+// weak credentials or from any network in production.
+func lintAdminExposure(conf *Config) []Finding {
+	if !isProduction(conf) || conf.Server == nil {
+		return nil
+	}
+
+	var findings []Finding
+	if token := conf.Server.AdminToken; token != "" && len(token) < minAdminTokenLength {
+		findings = append(findings, Finding{
+			Rule:     "admin-weak-token",
+			Severity: SeverityHigh,
+			Key:      "server.server_admin_token",
+			Message:  fmt.Sprintf("admin token is shorter than %d characters", minAdminTokenLength),
+		})
+	}
+
+	if conf.Server.AdminToken != "" && isWildcardHost(conf.Server.Host) {
+		if acl := conf.Server.NetworkACLs["admin"]; acl == nil || len(acl.Allow) == 0 {
+			findings = append(findings, Finding{
+				Rule:     "admin-public-bind",
+				Severity: SeverityMedium,
+				Key:      "server.server_host",
+				Message:  "admin endpoints are served on all interfaces; restrict them with network_acls.admin.allow",
+			})
+		}
+	}
+	return findings
+}
+
+// isWildcardHost reports whether host binds every interface.
+func isWildcardHost(host string) bool {
+	return host == "" || host == "0.0.0.0" || host == "::" || host == "[::]"
+}
+
+// lintDatabaseTLS flags production databases connecting without verified TLS.
+func lintDatabaseTLS(conf *Config) []Finding {
+	if !isProduction(conf) {
+		return nil
+	}
+
+	databases := map[string]*Database{}
+	if conf.Database != nil {
+		databases["database.db_ssl_mode"] = conf.Database
+	}
+	for name, db := range conf.Databases {
+		databases["databases."+name+".db_ssl_mode"] = db
+	}
+
+	var findings []Finding
+	for _, key := range slices.Sorted(maps.Keys(databases)) {
+		switch databases[key].SSLMode {
+		case "disable", "allow", "prefer":
+			findings = append(findings, Finding{
+				Rule:     "db-tls-disabled",
+				Severity: SeverityHigh,
+				Key:      key,
+				Message:  "database connections may be unencrypted; use require, verify-ca, or verify-full",
+			})
+		case "require":
+			findings = append(findings, Finding{
+				Rule:     "db-tls-unverified",
+				Severity: SeverityLow,
+				Key:      key,
+				Message:  "database server certificate is not verified; use verify-full",
+			})
+		}
+	}
+	return findings
+}
+
+// lintMessagingTLS flags production brokers sending SASL credentials in
+// plain text.
+func lintMessagingTLS(conf *Config) []Finding {
+	m := conf.Messaging
+	if !isProduction(conf) || m == nil || m.SASL == nil || m.TLS != nil && m.TLS.Enabled {
+		return nil
+	}
+
+	return []Finding{{
+		Rule:     "messaging-plaintext-credentials",
+		Severity: SeverityHigh,
+		Key:      "messaging.sasl",
+		Message:  "SASL credentials are sent without TLS; enable messaging.tls",
+	}}
+}
+
+// lintDebugLogging flags debug logging in production, which can leak
+// request payloads and personal data.
+func lintDebugLogging(conf *Config) []Finding {
+	if !isProduction(conf) || conf.Logging == nil || conf.Logging.Level != "debug" {
+		return nil
+	}
+
+	return []Finding{{
+		Rule:     "debug-logging",
+		Severity: SeverityLow,
+		Key:      "logging.level",
+		Message:  "debug logging is enabled in production",
+	}}
+}
+
+// lintCORS flags CORS policies letting any website make credentialed
+// requests on behalf of signed-in users.
+func lintCORS(conf *Config) []Finding {
+	if conf.Server == nil || conf.Server.CORS == nil {
+		return nil
+	}
+
+	cors := conf.Server.CORS
+	if !cors.AllowCredentials || !slices.Contains(cors.AllowedOrigins, "*") {
+		return nil
+	}
+
+	return []Finding{{
+		Rule:     "cors-wildcard-credentials",
+		Severity: SeverityHigh,
+		Key:      "server.cors.allowed_origins",
+		Message:  "any origin may make credentialed requests; list the allowed origins explicitly",
+	}}
+}
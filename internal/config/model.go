@@ -51,10 +51,25 @@ func ToEnvironment(str string) Environment {
 // Logging defines the log level and output destinations for the service.
 type Logging struct {
 	// Level defines the log verbosity: "debug", "info", "warn", or "error".
-	Level string `json:"level" koanf:"level" validate:"required"`
+	Level string `json:"level" koanf:"level" validate:"required,loglevel"`
+
+	// Levels overrides the level of named module loggers (e.g., {"http": "warn"}).
+	Levels map[string]string `json:"levels" koanf:"levels" validate:"dive,loglevel"`
 
 	// OutputPaths defines destinations for logs: "stderr", "stdout", or file paths.
 	OutputPaths []string `json:"outputPaths" koanf:"output_paths" validate:"required"`
+
+	// Rotation rotates file output paths by size and age; nil disables it.
+	Rotation *LogRotation `json:"rotation" koanf:"rotation"`
+
+	// Sampling thins out repeated entries; nil keeps the environment's default.
+	Sampling *LogSampling `json:"sampling" koanf:"sampling"`
+
+	// Redaction extends the secret scrubbing applied to every log entry.
+	Redaction *LogRedaction `json:"redaction" koanf:"redaction"`
+
+	// AccessLog configures the HTTP access log; nil logs every request without bodies.
+	AccessLog *AccessLog `json:"accessLog" koanf:"access_log"`
 }
 
 // Validate checks that the Logging configuration is valid.
@@ -62,16 +77,82 @@ func (l *Logging) Validate() error {
 	return validation.Check(l)
 }
 
+// LogRotation configures size- and age-based rotation of log files.
+type LogRotation struct {
+	// MaxSizeMB is the size in megabytes at which a file is rotated (defaults to 100).
+	MaxSizeMB int `json:"maxSizeMb" koanf:"max_size_mb" validate:"min=0"`
+
+	// MaxBackups is the number of rotated files kept; 0 keeps all of them.
+	MaxBackups int `json:"maxBackups" koanf:"max_backups" validate:"min=0"`
+
+	// MaxAgeDays is the number of days rotated files are kept; 0 keeps them forever.
+	MaxAgeDays int `json:"maxAgeDays" koanf:"max_age_days" validate:"min=0"`
+
+	// Compress gzips rotated files.
+	Compress bool `json:"compress" koanf:"compress"`
+}
+
+// LogSampling configures zap-style sampling and per-key rate limiting of
+// log entries, so error storms do not overwhelm the log pipeline.
+type LogSampling struct {
+	// Initial is the number of entries per message and level logged each tick; 0 disables sampling.
+	Initial int `json:"initial" koanf:"initial" validate:"min=0"`
+
+	// Thereafter logs every Nth entry beyond Initial within a tick; 0 drops them all.
+	Thereafter int `json:"thereafter" koanf:"thereafter" validate:"min=0"`
+
+	// Tick is the sampling period (defaults to 1s).
+	Tick time.Duration `json:"tick" koanf:"tick" validate:"min=0"`
+
+	// RateLimit caps the entries per key (see logging.Key) and level per RateInterval; 0 disables it.
+	RateLimit int `json:"rateLimit" koanf:"rate_limit" validate:"min=0"`
+
+	// RateInterval is the rate limiting window (defaults to 1s).
+	RateInterval time.Duration `json:"rateInterval" koanf:"rate_interval" validate:"min=0"`
+}
+
+// LogRedaction extends the built-in rules scrubbing secrets (authorization
+// headers, passwords, tokens, card numbers) from log messages and fields.
+type LogRedaction struct {
+	// Disabled turns scrubbing off entirely; meant for local debugging only.
+	Disabled bool `json:"disabled" koanf:"disabled"`
+
+	// Keys are additional field and object key fragments whose values are redacted.
+	Keys []string `json:"keys" koanf:"keys" validate:"dive,required"`
+
+	// Patterns are additional regular expressions scrubbed from text.
+	Patterns []string `json:"patterns" koanf:"patterns" validate:"dive,required"`
+}
+
+// AccessLog configures the per-request HTTP access log.
+type AccessLog struct {
+	// SkipPaths are request paths not logged (e.g., "/healthz"); a trailing "/" matches a prefix.
+	SkipPaths []string `json:"skipPaths" koanf:"skip_paths" validate:"dive,startswith=/"`
+
+	// Bodies logs request and response bodies while the logger is at debug level.
+	Bodies bool `json:"bodies" koanf:"bodies"`
+
+	// MaxBodyBytes caps each logged body (defaults to 4096).
+	MaxBodyBytes int `json:"maxBodyBytes" koanf:"max_body_bytes" validate:"min=0"`
+}
+
 // Service contains high-level application metadata and environment details.
 type Service struct {
 	// Name uniquely identifies the service/application.
 	Name string `json:"serviceName" koanf:"service_name" validate:"required"`
 
-	// Version specifies the application release version.
+	// Version specifies the application release version. Defaults to the
+	// version stamped into the binary (see package buildinfo).
 	Version string `json:"serviceVersion" koanf:"service_version" validate:"required"`
 
 	// Environment specifies the deployment environment.
-	Environment Environment `json:"environment" koanf:"service_environment" validate:"required"`
+	Environment Environment `json:"environment" koanf:"service_environment" validate:"required,oneof=STAGING PRODUCTION DEVELOPMENT"`
+
+	// Region is the cloud region the instance runs in (e.g., "eu-west-1").
+	Region string `json:"region" koanf:"service_region"`
+
+	// Zone is the availability zone the instance runs in (e.g., "eu-west-1a").
+	Zone string `json:"zone" koanf:"service_zone"`
 }
 
 // IsProduction returns true if the service is running in the Production environment.
@@ -87,22 +168,119 @@ func (s *Service) Validate() error {
 // Server defines HTTP server settings for request handling and timeouts.
 type Server struct {
 	// Host is the IP or hostname where the server binds (e.g., "0.0.0.0").
-	Host string `json:"host" koanf:"server_host" validate:"required"`
+	Host string `json:"host" koanf:"server_host" validate:"required,hostname_rfc1123|ip"`
 
 	// Port is the TCP port number the server listens on.
-	Port uint `json:"port" koanf:"server_port" validate:"required"`
+	Port uint `json:"port" koanf:"server_port" validate:"required_without=Listen,max=65535"`
+
+	// Listen replaces Host:Port with one or more addresses: "host:port", "unix:PATH", "systemd", or "systemd:NAME" for socket-activated sockets.
+	Listen []string `json:"listen" koanf:"server_listen" validate:"dive,required"`
+
+	// AdminPort is the port of a separate listener for health, metrics, and debug endpoints; 0 keeps health and metrics on Port and serves no debug endpoints.
+	AdminPort uint `json:"adminPort" koanf:"server_admin_port" validate:"omitempty,max=65535,nefield=Port"`
 
 	// ReadTimeout is the maximum duration allowed for reading the request.
-	ReadTimeout time.Duration `json:"readTimeout" koanf:"server_read_timeout" validate:"required"`
+	ReadTimeout time.Duration `json:"readTimeout" koanf:"server_read_timeout" validate:"required,duration_min=1ms"`
+
+	// ReadHeaderTimeout bounds reading request headers, cutting off slowloris clients (defaults to ReadTimeout).
+	ReadHeaderTimeout time.Duration `json:"readHeaderTimeout" koanf:"server_read_header_timeout" validate:"min=0"`
 
 	// WriteTimeout is the maximum duration before timing out the response write.
-	WriteTimeout time.Duration `json:"writeTimeout" koanf:"server_write_timeout" validate:"required"`
+	WriteTimeout time.Duration `json:"writeTimeout" koanf:"server_write_timeout" validate:"required,duration_min=1ms" warn:"duration_min=1s" warnmsg:"is unusually short and may cut off slow responses"`
 
 	// IdleTimeout is the maximum time to wait for the next request before closing.
-	IdleTimeout time.Duration `json:"idleTimeout" koanf:"server_idle_timeout" validate:"required"`
+	IdleTimeout time.Duration `json:"idleTimeout" koanf:"server_idle_timeout" validate:"required,duration_min=1ms"`
 
 	// ShutdownTimeout is the grace period before forcefully terminating the server.
-	ShutdownTimeout time.Duration `json:"shutdownTimeout" koanf:"server_shutdown_timeout" validate:"required"`
+	ShutdownTimeout time.Duration `json:"shutdownTimeout" koanf:"server_shutdown_timeout" validate:"required,duration_min=1s" warn:"duration_max=2m" warnmsg:"is longer than most orchestrators wait before killing the process"`
+
+	// MaxHeaderBytes caps the size of request headers (defaults to 1 MiB).
+	MaxHeaderBytes int `json:"maxHeaderBytes" koanf:"server_max_header_bytes" validate:"min=0"`
+
+	// MaxBodyBytes caps the size of request bodies, answering 413 beyond it; 0 disables it.
+	MaxBodyBytes int64 `json:"maxBodyBytes" koanf:"server_max_body_bytes" validate:"min=0"`
+
+	// MaxConns caps concurrently open connections per listener; further clients wait to be accepted (0 is unlimited).
+	MaxConns int `json:"maxConns" koanf:"server_max_conns" validate:"min=0"`
+
+	// MaxConnsPerIP caps concurrently open connections per peer address, so a proxy counts as one (0 is unlimited).
+	MaxConnsPerIP int `json:"maxConnsPerIp" koanf:"server_max_conns_per_ip" validate:"min=0"`
+
+	// DisableKeepAlives closes every connection after a single request.
+	DisableKeepAlives bool `json:"disableKeepAlives" koanf:"server_disable_keep_alives"`
+
+	// TCPKeepAlive is the TCP keep-alive probe interval for accepted connections.
+	TCPKeepAlive time.Duration `json:"tcpKeepAlive" koanf:"server_tcp_keep_alive" validate:"min=0"`
+
+	// ReadOnly rejects mutating requests and pauses background writes.
+	ReadOnly bool `json:"readOnly" koanf:"server_read_only"`
+
+	// RequestTimeout bounds the time handlers may take per request; 0 disables it.
+	RequestTimeout time.Duration `json:"requestTimeout" koanf:"server_request_timeout" validate:"min=0"`
+
+	// RouteTimeouts overrides RequestTimeout by "[METHOD ]path" (a trailing "/" covers subpaths); 0 disables it. Connection upgrades and event streams never get a deadline.
+	RouteTimeouts map[string]time.Duration `json:"routeTimeouts" koanf:"server_route_timeouts" validate:"omitempty,dive,keys,required,endkeys,min=0"`
+
+	// TrustedProxies are the proxies (IPs or CIDRs) whose forwarding headers reveal the client IP.
+	TrustedProxies []string `json:"trustedProxies" koanf:"server_trusted_proxies" validate:"dive,cidr|ip"`
+
+	// ClientIPHeader is the forwarding header trusted proxies set: "x-forwarded-for" (defaults, falling back to X-Real-IP), "forwarded", or "x-real-ip".
+	ClientIPHeader string `json:"clientIpHeader" koanf:"server_client_ip_header" validate:"omitempty,oneof=x-forwarded-for forwarded x-real-ip"`
+
+	// TLS configures HTTPS on the public listener; nil serves plain HTTP.
+	TLS *ServerTLS `json:"tls" koanf:"tls"`
+
+	// HTTP2 configures HTTP/2; nil serves it over TLS with the defaults and never in cleartext.
+	HTTP2 *HTTP2 `json:"http2" koanf:"http2"`
+
+	// CORS configures cross-origin resource sharing; nil rejects cross-origin requests.
+	CORS *CORS `json:"cors" koanf:"cors"`
+
+	// Compression configures response compression; nil compresses with the defaults.
+	Compression *Compression `json:"compression" koanf:"compression"`
+
+	// Problems configures RFC 7807 problem+json error responses; nil negotiates them with the defaults.
+	Problems *Problems `json:"problems" koanf:"problems"`
+
+	// SecurityHeaders configures HSTS, CSP, and the other security headers; nil uses the environment's defaults.
+	SecurityHeaders *SecurityHeaders `json:"securityHeaders" koanf:"security_headers"`
+
+	// Dedup configures duplicate-submission detection for route groups using middleware.Dedup.
+	Dedup *Dedup `json:"dedup" koanf:"dedup"`
+
+	// Idempotency configures replaying responses to retried requests for route groups using idempotency.Middleware.
+	Idempotency *Idempotency `json:"idempotency" koanf:"idempotency"`
+
+	// Maintenance configures maintenance mode, answering application routes 503 Service Unavailable.
+	Maintenance *Maintenance `json:"maintenance" koanf:"maintenance"`
+
+	// Static configures serving a frontend's static assets; nil serves none.
+	Static *Static `json:"static" koanf:"static"`
+
+	// WebSocket configures the connections of websocket hubs; nil uses the defaults.
+	WebSocket *WebSocket `json:"webSocket" koanf:"websocket"`
+
+	// Uploads configures file uploads received by uploads.Uploader; nil stores them on local disk with the defaults.
+	Uploads *Uploads `json:"uploads" koanf:"uploads"`
+
+	// I18n configures the localization of user-facing messages; nil uses the embedded catalogs with English as the fallback.
+	I18n *I18n `json:"i18n" koanf:"i18n"`
+
+	// AdminToken is the bearer token required by the admin and debug endpoints.
+	AdminToken string `json:"adminToken" koanf:"server_admin_token" redact:"true"`
+
+	// Throttle configures fair-queuing of requests per authenticated principal.
+	Throttle *Throttle `json:"throttle" koanf:"throttle"`
+
+	// RateLimit configures request rate limiting.
+	RateLimit *RateLimit `json:"rateLimit" koanf:"rate_limit"`
+
+	// NetworkACLs restricts route groups or listeners (e.g., "admin") to
+	// client networks, keyed by group name.
+	NetworkACLs map[string]*NetworkACL `json:"networkAcls" koanf:"network_acls" validate:"dive"`
+
+	// RequestSigning configures HMAC signature checks for internal APIs.
+	RequestSigning *RequestSigning `json:"requestSigning" koanf:"request_signing"`
 }
 
 // Validate checks that the Server configuration is valid.
@@ -110,37 +288,378 @@ func (s *Server) Validate() error {
 	return validation.Check(s)
 }
 
+// ServerTLS configures HTTPS, and optionally client certificate
+// verification (mutual TLS), for the public listener. Certificate files are
+// reloaded when they change or the process receives SIGHUP.
+type ServerTLS struct {
+	// Enabled turns HTTPS on or off.
+	Enabled bool `json:"enabled" koanf:"enabled"`
+
+	// CertFile is the PEM certificate chain presented to clients.
+	CertFile string `json:"certFile" koanf:"cert_file" validate:"required_if=Enabled true"`
+
+	// KeyFile is the PEM private key for CertFile.
+	KeyFile string `json:"keyFile" koanf:"key_file" validate:"required_if=Enabled true"`
+
+	// MinVersion is the oldest TLS version accepted, "1.2" or "1.3" (defaults to 1.2).
+	MinVersion string `json:"minVersion" koanf:"min_version" validate:"omitempty,oneof=1.2 1.3"`
+
+	// ClientAuth requests client certificates: "optional" verifies those sent, "require" rejects clients without one.
+	ClientAuth string `json:"clientAuth" koanf:"client_auth" validate:"omitempty,oneof=optional require"`
+
+	// ClientCAFile is the PEM bundle of CAs trusted to issue client certificates.
+	ClientCAFile string `json:"clientCaFile" koanf:"client_ca_file" validate:"required_with=ClientAuth"`
+
+	// ReloadInterval is how often the files are checked for changes (defaults to 30s).
+	ReloadInterval time.Duration `json:"reloadInterval" koanf:"reload_interval" validate:"min=0"`
+}
+
+// HTTP2 configures HTTP/2 on the public listener. Idle HTTP/2 connections
+// are closed after the server's IdleTimeout, like HTTP/1.1 ones.
+type HTTP2 struct {
+	// Disabled stops serving HTTP/2 to TLS clients negotiating it; HTTP/1.1 is always served.
+	Disabled bool `json:"disabled" koanf:"disabled"`
+
+	// H2C serves HTTP/2 in cleartext to clients with prior knowledge, such as load balancers and gRPC gateways, even when Disabled.
+	H2C bool `json:"h2c" koanf:"h2c"`
+
+	// MaxConcurrentStreams caps the requests a client may have in flight per connection (defaults to 250).
+	MaxConcurrentStreams int `json:"maxConcurrentStreams" koanf:"max_concurrent_streams" validate:"min=0"`
+
+	// MaxReadFrameSize is the largest frame read, from 16 KiB to 16 MiB (defaults to 1 MiB).
+	MaxReadFrameSize int `json:"maxReadFrameSize" koanf:"max_read_frame_size" validate:"omitempty,min=16384,max=16777216"`
+
+	// PingInterval is the silence after which a connection is health-checked with a ping; 0 disables it.
+	PingInterval time.Duration `json:"pingInterval" koanf:"ping_interval" validate:"min=0"`
+
+	// PingTimeout is how long a ping may go unanswered before the connection is closed (defaults to 15s).
+	PingTimeout time.Duration `json:"pingTimeout" koanf:"ping_timeout" validate:"min=0"`
+}
+
+// CORS configures which cross-origin browser requests are allowed.
+type CORS struct {
+	// AllowedOrigins are the origins allowed to call the API (e.g., "https://app.example.com"), or "*".
+	AllowedOrigins []string `json:"allowedOrigins" koanf:"allowed_origins" validate:"required,dive,required"`
+
+	// AllowedMethods are the methods allowed in cross-origin requests (defaults to GET, HEAD, POST).
+	AllowedMethods []string `json:"allowedMethods" koanf:"allowed_methods"`
+
+	// AllowedHeaders are the request headers allowed in cross-origin requests.
+	AllowedHeaders []string `json:"allowedHeaders" koanf:"allowed_headers"`
+
+	// ExposedHeaders are the response headers readable by cross-origin callers.
+	ExposedHeaders []string `json:"exposedHeaders" koanf:"exposed_headers"`
+
+	// AllowCredentials lets cross-origin requests carry cookies and authorization headers.
+	AllowCredentials bool `json:"allowCredentials" koanf:"allow_credentials"`
+
+	// MaxAge is how long browsers may cache preflight responses.
+	MaxAge time.Duration `json:"maxAge" koanf:"max_age" validate:"min=0"`
+}
+
+// Compression configures gzip compression of responses.
+type Compression struct {
+	// Enabled turns response compression on or off.
+	Enabled bool `json:"enabled" koanf:"enabled"`
+
+	// Level is the gzip level, 1 (fastest) to 9 (smallest); 0 uses the default.
+	Level int `json:"level" koanf:"level" validate:"min=0,max=9"`
+
+	// MinSize is the smallest response body, in bytes, worth compressing (defaults to 1024).
+	MinSize int `json:"minSize" koanf:"min_size" validate:"min=0"`
+
+	// ContentTypes are the media types compressed; entries ending in "/" match a whole type (e.g., "text/").
+	ContentTypes []string `json:"contentTypes" koanf:"content_types" validate:"dive,required"`
+}
+
+// Problems configures how errors written by the errors package are
+// rendered: as RFC 7807 problem details or in the JSON response envelope.
+type Problems struct {
+	// Mode is "negotiate", answering problem+json unless the Accept header prefers application/json, "always", or "never" (defaults to negotiate).
+	Mode string `json:"mode" koanf:"mode" validate:"omitempty,oneof=negotiate always never"`
+
+	// TypeBaseURI is joined with the error code to form problem type URIs (e.g., "https://docs.example.com/errors/"); empty uses "about:blank".
+	TypeBaseURI string `json:"typeBaseUri" koanf:"type_base_uri" validate:"omitempty,url"`
+}
+
+// SecurityHeaders configures the security headers set on every response.
+// Unset values follow the environment: strict in production and staging,
+// relaxed in development, where HSTS is not sent and the CSP only reports.
+type SecurityHeaders struct {
+	// Disabled turns the security headers off, such as when a proxy in front sets them.
+	Disabled bool `json:"disabled" koanf:"disabled"`
+
+	// HSTSMaxAge is the Strict-Transport-Security max age (defaults to 1 year outside development, where it is not sent).
+	HSTSMaxAge time.Duration `json:"hstsMaxAge" koanf:"hsts_max_age" validate:"min=0"`
+
+	// HSTSPreload asks browsers to preload HSTS for the domain; it requires a max age of at least 1 year.
+	HSTSPreload bool `json:"hstsPreload" koanf:"hsts_preload"`
+
+	// FrameOptions is the X-Frame-Options value, "DENY" or "SAMEORIGIN" (defaults to DENY, SAMEORIGIN in development).
+	FrameOptions string `json:"frameOptions" koanf:"frame_options" validate:"omitempty,oneof=DENY SAMEORIGIN"`
+
+	// ReferrerPolicy is the Referrer-Policy value (defaults to "no-referrer", "strict-origin-when-cross-origin" in development).
+	ReferrerPolicy string `json:"referrerPolicy" koanf:"referrer_policy" validate:"omitempty,oneof=no-referrer no-referrer-when-downgrade origin origin-when-cross-origin same-origin strict-origin strict-origin-when-cross-origin unsafe-url"`
+
+	// CSP overrides Content-Security-Policy directives by name (e.g., "script-src": ["'self'", "https://cdn.example.com"]).
+	CSP map[string][]string `json:"csp" koanf:"csp"`
+
+	// CSPMode is "enforce", "report-only", or "off" (defaults to enforce, report-only in development).
+	CSPMode string `json:"cspMode" koanf:"csp_mode" validate:"omitempty,oneof=enforce report-only off"`
+
+	// CSPReportURI is where browsers report CSP violations.
+	CSPReportURI string `json:"cspReportUri" koanf:"csp_report_uri" validate:"omitempty,url"`
+}
+
+// Dedup configures the detection of identical mutating requests submitted
+// by the same principal in quick succession, such as double-clicks.
+type Dedup struct {
+	// Window is how long after a request completes identical ones count as duplicates (defaults to 2s).
+	Window time.Duration `json:"window" koanf:"window" validate:"min=0"`
+
+	// Mode is "coalesce", replaying the first response to duplicates, or "reject", answering them 409 Conflict.
+	Mode string `json:"mode" koanf:"mode" validate:"omitempty,oneof=coalesce reject"`
+
+	// MaxBodyBytes is the largest body fingerprinted; larger requests are never deduplicated (defaults to 1 MiB).
+	MaxBodyBytes int64 `json:"maxBodyBytes" koanf:"max_body_bytes" validate:"min=0"`
+}
+
+// Idempotency configures the Idempotency-Key support of unsafe methods:
+// the response to the first request carrying a key is stored and replayed
+// to retries, so clients can safely retry after a timeout.
+type Idempotency struct {
+	// TTL is how long responses are kept for replay (defaults to 24h).
+	TTL time.Duration `json:"ttl" koanf:"ttl" validate:"min=0"`
+
+	// LockTimeout is how long a request in flight holds its key should its instance fail (defaults to 1m).
+	LockTimeout time.Duration `json:"lockTimeout" koanf:"lock_timeout" validate:"min=0"`
+
+	// MaxBodyBytes is the largest request body accepted with a key; larger ones are answered 413 (defaults to 1 MiB).
+	MaxBodyBytes int64 `json:"maxBodyBytes" koanf:"max_body_bytes" validate:"min=0"`
+
+	// Required answers unsafe requests without an Idempotency-Key 400 Bad Request.
+	Required bool `json:"required" koanf:"required"`
+
+	// Store selects where responses are kept: "memory" (the default) or "redis", shared by replicas.
+	Store string `json:"store" koanf:"store" validate:"omitempty,oneof=memory redis"`
+
+	// RedisURL is the Redis connection URL, required when Store is "redis".
+	RedisURL string `json:"redisUrl" koanf:"redis_url" validate:"required_if=Store redis,omitempty,url" redact:"true"`
+}
+
+// Maintenance configures maintenance mode for deploy windows and incident
+// response. Probes and metrics stay available, and the admin listener is
+// never affected.
+type Maintenance struct {
+	// Enabled turns maintenance mode on at startup; the admin endpoint toggles it at runtime.
+	Enabled bool `json:"enabled" koanf:"enabled"`
+
+	// RetryAfter is sent to clients when the end of the window is unknown (defaults to 60s).
+	RetryAfter time.Duration `json:"retryAfter" koanf:"retry_after" validate:"min=0"`
+
+	// ExemptPaths are additional paths kept available; a trailing "/" covers subpaths.
+	ExemptPaths []string `json:"exemptPaths" koanf:"exempt_paths" validate:"dive,startswith=/"`
+}
+
+// Static configures the static assets handler, for services shipping a
+// small frontend.
+type Static struct {
+	// Enabled turns static file serving on or off.
+	Enabled bool `json:"enabled" koanf:"enabled"`
+
+	// Dir is the directory served; empty serves the file system embedded by the service.
+	Dir string `json:"dir" koanf:"dir"`
+
+	// Prefix is the URL path the assets are served under (defaults to "/").
+	Prefix string `json:"prefix" koanf:"prefix" validate:"omitempty,startswith=/,endswith=/"`
+
+	// SPA serves index.html for unknown extensionless paths requested by browsers, for client-side routing.
+	SPA bool `json:"spa" koanf:"spa"`
+
+	// MaxAge is how long browsers may cache assets (defaults to 1h); index.html is always revalidated.
+	MaxAge time.Duration `json:"maxAge" koanf:"max_age" validate:"min=0"`
+
+	// Immutable are fingerprinted asset paths, below Prefix, cached for a year (e.g., "/assets/"); a trailing "/" covers subpaths.
+	Immutable []string `json:"immutable" koanf:"immutable" validate:"dive,startswith=/"`
+}
+
+// WebSocket configures websocket connections accepted by websocket.Hub.
+type WebSocket struct {
+	// AllowedOrigins are the origins (e.g., "https://app.example.com") browsers may connect from; empty allows only the server's own host.
+	AllowedOrigins []string `json:"allowedOrigins" koanf:"allowed_origins" validate:"dive,required"`
+
+	// PingInterval is how often idle connections are pinged (defaults to 30s).
+	PingInterval time.Duration `json:"pingInterval" koanf:"ping_interval" validate:"min=0"`
+
+	// PongTimeout is how long a connection may stay silent before it is closed; it must exceed PingInterval (defaults to 60s).
+	PongTimeout time.Duration `json:"pongTimeout" koanf:"pong_timeout" validate:"omitempty,gtfield=PingInterval"`
+
+	// WriteTimeout bounds the time to send each message (defaults to 10s).
+	WriteTimeout time.Duration `json:"writeTimeout" koanf:"write_timeout" validate:"min=0"`
+
+	// MaxMessageBytes caps the size of messages received (defaults to 64 KiB).
+	MaxMessageBytes int64 `json:"maxMessageBytes" koanf:"max_message_bytes" validate:"min=0"`
+
+	// SendBuffer is the number of messages queued per connection before it is closed as too slow (defaults to 64).
+	SendBuffer int `json:"sendBuffer" koanf:"send_buffer" validate:"min=0"`
+}
+
+// Uploads configures file uploads: their limits, the content types
+// accepted, and the storage backend they are streamed to.
+type Uploads struct {
+	// MaxFileBytes caps the size of each uploaded file (defaults to 10 MiB).
+	MaxFileBytes int64 `json:"maxFileBytes" koanf:"max_file_bytes" validate:"min=0"`
+
+	// MaxFiles caps the number of files per request (defaults to 10).
+	MaxFiles int `json:"maxFiles" koanf:"max_files" validate:"min=0"`
+
+	// AllowedTypes are the content types accepted, detected from the file's content (e.g., "image/png", "image/*"); empty accepts all.
+	AllowedTypes []string `json:"allowedTypes" koanf:"allowed_types" validate:"dive,required,contains=/"`
+
+	// KeyPrefix is prepended to the keys of stored files (e.g., "avatars/").
+	KeyPrefix string `json:"keyPrefix" koanf:"key_prefix"`
+
+	// Storage selects the backend: "local" (the default), "s3", or "gcs".
+	Storage string `json:"storage" koanf:"storage" validate:"omitempty,oneof=local s3 gcs"`
+
+	// Dir is the directory of local storage (defaults to "uploads").
+	Dir string `json:"dir" koanf:"dir"`
+
+	// Bucket is the bucket of S3 or GCS storage.
+	Bucket string `json:"bucket" koanf:"bucket" validate:"required_if=Storage s3,required_if=Storage gcs"`
+
+	// Region is the region of S3 storage (defaults to "us-east-1").
+	Region string `json:"region" koanf:"region"`
+
+	// Endpoint overrides the storage endpoint, for S3-compatible services such as MinIO.
+	Endpoint string `json:"endpoint" koanf:"endpoint" validate:"omitempty,url"`
+
+	// PathStyle addresses buckets in the URL path rather than the host name, as most S3-compatible services need.
+	PathStyle bool `json:"pathStyle" koanf:"path_style"`
+
+	// AccessKeyID is the access key of S3 storage, or the HMAC key of GCS storage.
+	AccessKeyID string `json:"accessKeyId" koanf:"access_key_id" validate:"required_if=Storage s3,required_if=Storage gcs"`
+
+	// SecretAccessKey is the secret of AccessKeyID.
+	SecretAccessKey string `json:"secretAccessKey" koanf:"secret_access_key" validate:"required_if=Storage s3,required_if=Storage gcs" redact:"true"`
+
+	// PresignExpiry is how long presigned upload URLs stay valid (defaults to 15m).
+	PresignExpiry time.Duration `json:"presignExpiry" koanf:"presign_expiry" validate:"min=0"`
+}
+
+// I18n configures the message catalogs user-facing messages are
+// localized with, per the Accept-Language header of each request.
+type I18n struct {
+	// DefaultLocale is the locale of requests matching no catalog (defaults to "en").
+	DefaultLocale string `json:"defaultLocale" koanf:"default_locale" validate:"omitempty,bcp47_language_tag"`
+
+	// Dir holds catalog files named after their locale (e.g., "fr.json"), overriding the embedded messages.
+	Dir string `json:"dir" koanf:"dir" validate:"omitempty,dir"`
+}
+
+// NetworkACL lists the client networks allowed to, or denied from, reaching
+// a route group or listener. Deny entries take precedence.
+type NetworkACL struct {
+	// Allow lists the CIDR ranges or addresses admitted; empty admits all.
+	Allow []string `json:"allow" koanf:"allow" validate:"dive,cidr|ip"`
+
+	// Deny lists the CIDR ranges or addresses rejected.
+	Deny []string `json:"deny" koanf:"deny" validate:"dive,cidr|ip"`
+}
+
+// RequestSigning configures HMAC request signing between internal services.
+type RequestSigning struct {
+	// Enabled turns signature verification on or off.
+	Enabled bool `json:"enabled" koanf:"enabled"`
+
+	// Keys maps key IDs (a shared ID or calling service names) to secrets.
+	Keys map[string]string `json:"keys" koanf:"keys" validate:"required_if=Enabled true,dive,min=32" redact:"true"`
+
+	// MaxSkew is the tolerated clock difference for signed timestamps.
+	MaxSkew time.Duration `json:"maxSkew" koanf:"max_skew" validate:"min=0"`
+}
+
+// Throttle configures the per-principal fair-queuing throttle.
+type Throttle struct {
+	// Enabled turns the throttle on or off.
+	Enabled bool `json:"enabled" koanf:"enabled"`
+
+	// MaxConcurrent is the number of requests served at the same time.
+	MaxConcurrent int `json:"maxConcurrent" koanf:"max_concurrent" validate:"required_if=Enabled true,omitempty,min=1"`
+
+	// MaxQueuePerKey is the number of requests a single principal may have waiting.
+	MaxQueuePerKey int `json:"maxQueuePerKey" koanf:"max_queue_per_key" validate:"min=0"`
+
+	// MaxWait bounds how long a request may wait for capacity.
+	MaxWait time.Duration `json:"maxWait" koanf:"max_wait" validate:"min=0"`
+
+	// TierWeights maps a plan/tier name to its relative share of capacity.
+	TierWeights map[string]int `json:"tierWeights" koanf:"tier_weights" validate:"dive,min=1"`
+}
+
+// Validate checks that the Throttle configuration is valid.
+func (t *Throttle) Validate() error {
+	return validation.Check(t)
+}
+
+// RateLimit configures token-bucket request rate limiting.
+type RateLimit struct {
+	// Enabled turns rate limiting on or off.
+	Enabled bool `json:"enabled" koanf:"enabled"`
+
+	// RequestsPerSecond is the sustained request rate allowed per key.
+	RequestsPerSecond float64 `json:"requestsPerSecond" koanf:"requests_per_second" validate:"required_if=Enabled true,omitempty,gt=0"`
+
+	// Burst is the maximum number of requests allowed in a single burst.
+	Burst int `json:"burst" koanf:"burst" validate:"required_if=Enabled true,omitempty,min=1"`
+
+	// Strategy selects the rate limiting key: "ip", "user", or "api-key".
+	Strategy string `json:"strategy" koanf:"strategy" validate:"required_if=Enabled true,omitempty,oneof=ip user api-key"`
+
+	// Store selects where counters are kept: "memory" or "redis".
+	Store string `json:"store" koanf:"store" validate:"required_if=Enabled true,omitempty,oneof=memory redis"`
+
+	// RedisURL is the Redis connection URL, required when Store is "redis".
+	RedisURL string `json:"redisUrl" koanf:"redis_url" validate:"required_if=Store redis,omitempty,url" redact:"true"`
+}
+
+// Validate checks that the RateLimit configuration is valid.
+func (rl *RateLimit) Validate() error {
+	return validation.Check(rl)
+}
+
 // Database contains all database connection pool and authentication settings.
 type Database struct {
 	// Host is the database server address.
-	Host string `json:"host" koanf:"db_host" validate:"required"`
+	Host string `json:"host" koanf:"db_host" validate:"required,hostname_rfc1123|ip"`
 
 	// Port is the database server TCP port.
-	Port int `json:"port" koanf:"db_port" validate:"required"`
+	Port int `json:"port" koanf:"db_port" validate:"required,min=1,max=65535"`
 
 	// User is the username for authentication.
 	User string `json:"user" koanf:"db_user" validate:"required"`
 
 	// Password is the authentication password (optional for some DBs).
-	Password string `json:"password" koanf:"db_password"`
+	Password string `json:"password" koanf:"db_password" redact:"true"`
 
 	// Name is the specific database/schema to connect to.
 	Name string `json:"name" koanf:"db_name" validate:"required"`
 
 	// SSLMode controls SSL behavior ("disable", "require", etc.).
-	SSLMode string `json:"sslMode" koanf:"db_ssl_mode" validate:"required"`
+	// Mandatory in production, optional elsewhere.
+	SSLMode string `json:"sslMode" koanf:"db_ssl_mode" validate:"required_if_production,omitempty,oneof=disable allow prefer require verify-ca verify-full"`
 
 	// MaxOpenConns is the maximum number of open connections.
-	MaxOpenConns int `json:"maxOpenConns" koanf:"db_max_open_conns" validate:"required"`
+	MaxOpenConns int `json:"maxOpenConns" koanf:"db_max_open_conns" validate:"required,min=1" warn:"max=200" warnmsg:"is unusually high; most Postgres servers accept 100 connections by default"`
 
 	// MaxIdleConns is the maximum number of idle connections.
-	MaxIdleConns int `json:"maxIdleConns" koanf:"db_max_idle_conns" validate:"required"`
+	MaxIdleConns int `json:"maxIdleConns" koanf:"db_max_idle_conns" validate:"required,min=1,ltefield=MaxOpenConns"`
 
 	// ConnMaxLifetime is the maximum lifetime (in seconds) of a connection.
-	ConnMaxLifetime int `json:"connMaxLifetime" koanf:"db_conn_max_lifetime" validate:"required"`
+	ConnMaxLifetime int `json:"connMaxLifetime" koanf:"db_conn_max_lifetime" validate:"required,min=1"`
 
 	// ConnMaxIdleTime is the maximum idle time (in seconds) for a connection.
-	ConnMaxIdleTime int `json:"connMaxIdleTime" koanf:"db_conn_max_idle_time" validate:"required"`
+	ConnMaxIdleTime int `json:"connMaxIdleTime" koanf:"db_conn_max_idle_time" validate:"required,min=1"`
 }
 
 // Validate checks that the Database configuration is valid.
@@ -148,6 +667,339 @@ func (db *Database) Validate() error {
 	return validation.Check(db)
 }
 
+// PrimaryDatabase is the name under which Config.Database is addressable.
+const PrimaryDatabase = "primary"
+
+// DB returns the named database configuration. The name "primary" resolves to
+// Config.Database unless it is explicitly overridden in Config.Databases.
+func (c *Config) DB(name string) (*Database, bool) {
+	if db, ok := c.Databases[name]; ok {
+		return db, true
+	}
+	if name == PrimaryDatabase && c.Database != nil {
+		return c.Database, true
+	}
+	return nil, false
+}
+
+// Sharding spreads data across several databases by key.
+type Sharding struct {
+	// Strategy picks the hashing scheme: "rendezvous" or "ring" (consistent hashing).
+	Strategy string `json:"strategy" koanf:"strategy" validate:"required,oneof=rendezvous ring"`
+
+	// Shards names the databases (see Config.DB) holding the shards.
+	Shards []string `json:"shards" koanf:"shards" validate:"required,min=1,unique,dive,required"`
+
+	// VirtualNodes is the number of ring points per shard; 0 uses the default.
+	VirtualNodes int `json:"virtualNodes" koanf:"virtual_nodes" validate:"min=0,max=4096"`
+}
+
+// Validate checks that the Sharding configuration is valid.
+func (s *Sharding) Validate() error {
+	return validation.Check(s)
+}
+
+// Replication spreads read-only queries across replicas of the primary
+// database.
+type Replication struct {
+	// Replicas names the databases (see Config.DB) replicating the primary.
+	Replicas []string `json:"replicas" koanf:"replicas" validate:"required,min=1,unique,dive,required,ne=primary"`
+
+	// HealthCheckInterval is how often replicas are pinged; 0 uses the default.
+	HealthCheckInterval time.Duration `json:"healthCheckInterval" koanf:"health_check_interval" validate:"min=0"`
+}
+
+// Validate checks that the Replication configuration is valid.
+func (r *Replication) Validate() error {
+	return validation.Check(r)
+}
+
+// Migrations configures the schema migrations of the primary database.
+type Migrations struct {
+	// AutoMigrate applies pending migrations when the server starts.
+	// Refused in production, where "migrate up" is a deployment step.
+	AutoMigrate bool `json:"autoMigrate" koanf:"auto_migrate" validate:"excluded_if_production"`
+
+	// Table records the applied migrations (defaults to "schema_migrations").
+	Table string `json:"table" koanf:"table" validate:"omitempty,max=63"`
+
+	// LockTimeout bounds the wait for other migrators to finish; 0 waits indefinitely.
+	LockTimeout time.Duration `json:"lockTimeout" koanf:"lock_timeout" validate:"min=0"`
+}
+
+// Validate checks that the Migrations configuration is valid.
+func (m *Migrations) Validate() error {
+	return validation.Check(m)
+}
+
+// Messaging configures the message broker used by the eventing subsystem.
+type Messaging struct {
+	// Driver selects the broker implementation: "kafka", "nats", or "rabbitmq".
+	Driver string `json:"driver" koanf:"messaging_driver" validate:"required,oneof=kafka nats rabbitmq"`
+
+	// Brokers lists the broker URLs or host:port addresses to connect to.
+	Brokers []string `json:"brokers" koanf:"messaging_brokers" validate:"required,dive,hostname_port|url"`
+
+	// ClientID identifies this service to the broker.
+	ClientID string `json:"clientId" koanf:"messaging_client_id" validate:"required"`
+
+	// ConsumerGroup is the group (or queue) this service consumes as.
+	ConsumerGroup string `json:"consumerGroup" koanf:"messaging_consumer_group"`
+
+	// TLS configures transport encryption to the brokers.
+	TLS *MessagingTLS `json:"tls" koanf:"tls"`
+
+	// SASL configures broker authentication.
+	SASL *MessagingSASL `json:"sasl" koanf:"sasl"`
+
+	// Topics holds per-topic settings keyed by logical topic name.
+	Topics map[string]*Topic `json:"topics" koanf:"topics" validate:"omitempty,dive,required"`
+}
+
+// Validate checks that the Messaging configuration is valid.
+func (m *Messaging) Validate() error {
+	return validation.Check(m)
+}
+
+// MessagingTLS configures TLS for broker connections.
+type MessagingTLS struct {
+	// Enabled turns TLS on or off.
+	Enabled bool `json:"enabled" koanf:"enabled"`
+
+	// CAFile is the PEM bundle used to verify broker certificates.
+	CAFile string `json:"caFile" koanf:"ca_file"`
+
+	// CertFile is the client certificate presented to the brokers.
+	CertFile string `json:"certFile" koanf:"cert_file" validate:"required_with=KeyFile"`
+
+	// KeyFile is the private key for CertFile.
+	KeyFile string `json:"keyFile" koanf:"key_file" validate:"required_with=CertFile"`
+
+	// InsecureSkipVerify disables broker certificate verification.
+	InsecureSkipVerify bool `json:"insecureSkipVerify" koanf:"insecure_skip_verify"`
+}
+
+// MessagingSASL configures SASL authentication for broker connections.
+type MessagingSASL struct {
+	// Mechanism is the SASL mechanism: "PLAIN", "SCRAM-SHA-256", or "SCRAM-SHA-512".
+	Mechanism string `json:"mechanism" koanf:"mechanism" validate:"required,oneof=PLAIN SCRAM-SHA-256 SCRAM-SHA-512"`
+
+	// Username is the SASL user.
+	Username string `json:"username" koanf:"username" validate:"required"`
+
+	// Password is the SASL password.
+	Password string `json:"password" koanf:"password" validate:"required" redact:"true"`
+}
+
+// Topic holds settings for a single topic, subject, or exchange.
+type Topic struct {
+	// Name is the physical topic name on the broker.
+	Name string `json:"name" koanf:"name" validate:"required"`
+
+	// Partitions is the number of partitions (Kafka only).
+	Partitions int `json:"partitions" koanf:"partitions" validate:"min=0"`
+
+	// ReplicationFactor is the number of replicas (Kafka only).
+	ReplicationFactor int `json:"replicationFactor" koanf:"replication_factor" validate:"min=0"`
+
+	// Retention is how long messages are kept on the topic.
+	Retention time.Duration `json:"retention" koanf:"retention" validate:"min=0"`
+}
+
+// FeatureFlags configures static feature flags and an optional remote provider.
+type FeatureFlags struct {
+	// Flags maps a flag name to "true", "false", or a variant name.
+	Flags map[string]string `json:"flags" koanf:"flags"`
+
+	// Provider configures a remote flag service whose values override Flags.
+	Provider *FlagProvider `json:"provider" koanf:"provider"`
+}
+
+// Validate checks that the FeatureFlags configuration is valid.
+func (ff *FeatureFlags) Validate() error {
+	return validation.Check(ff)
+}
+
+// FlagProvider configures a remote feature flag service.
+type FlagProvider struct {
+	// Kind selects the provider API: "unleash" or "http".
+	Kind string `json:"kind" koanf:"kind" validate:"required,oneof=unleash http"`
+
+	// Endpoint is the base URL of the provider.
+	Endpoint string `json:"endpoint" koanf:"endpoint" validate:"required,url"`
+
+	// APIKey authenticates this service with the provider.
+	APIKey string `json:"apiKey" koanf:"api_key" redact:"true"`
+
+	// RefreshInterval is how often flags are fetched from the provider.
+	RefreshInterval time.Duration `json:"refreshInterval" koanf:"refresh_interval" validate:"duration_min=1s"`
+}
+
+// Auth configures how incoming access tokens are authenticated.
+type Auth struct {
+	// Introspection configures RFC 7662 validation of opaque access tokens.
+	Introspection *Introspection `json:"introspection" koanf:"introspection"`
+}
+
+// Validate checks that the Auth configuration is valid.
+func (a *Auth) Validate() error {
+	return validation.Check(a)
+}
+
+// Introspection configures the IdP token introspection endpoint.
+type Introspection struct {
+	// Endpoint is the IdP's introspection URL.
+	Endpoint string `json:"endpoint" koanf:"endpoint" validate:"required,url_scheme=https"`
+
+	// ClientID authenticates this service to the introspection endpoint.
+	ClientID string `json:"clientId" koanf:"client_id" validate:"required"`
+
+	// ClientSecret authenticates this service to the introspection endpoint.
+	ClientSecret string `json:"clientSecret" koanf:"client_secret" validate:"required" redact:"true"`
+
+	// CacheTTL bounds how long an active introspection response is reused.
+	CacheTTL time.Duration `json:"cacheTtl" koanf:"cache_ttl" validate:"min=0"`
+
+	// NegativeTTL is how long an inactive introspection response is reused.
+	NegativeTTL time.Duration `json:"negativeTtl" koanf:"negative_ttl" validate:"min=0"`
+
+	// StaleTTL is how long cached responses are served while the endpoint is down.
+	StaleTTL time.Duration `json:"staleTtl" koanf:"stale_ttl" validate:"min=0"`
+}
+
+// HTTPClient tunes the connection pool of outbound HTTP clients. Zero
+// values fall back to the defaults of package httpclient.
+type HTTPClient struct {
+	// Timeout bounds a whole request, including reading the body.
+	Timeout time.Duration `json:"timeout" koanf:"timeout" validate:"min=0"`
+
+	// DialTimeout bounds establishing a TCP connection.
+	DialTimeout time.Duration `json:"dialTimeout" koanf:"dial_timeout" validate:"min=0"`
+
+	// TLSHandshakeTimeout bounds the TLS handshake.
+	TLSHandshakeTimeout time.Duration `json:"tlsHandshakeTimeout" koanf:"tls_handshake_timeout" validate:"min=0"`
+
+	// ResponseHeaderTimeout bounds waiting for response headers.
+	ResponseHeaderTimeout time.Duration `json:"responseHeaderTimeout" koanf:"response_header_timeout" validate:"min=0"`
+
+	// IdleConnTimeout is how long an idle pooled connection is kept.
+	IdleConnTimeout time.Duration `json:"idleConnTimeout" koanf:"idle_conn_timeout" validate:"min=0"`
+
+	// MaxIdleConns caps idle connections across all hosts.
+	MaxIdleConns int `json:"maxIdleConns" koanf:"max_idle_conns" validate:"min=0"`
+
+	// MaxIdleConnsPerHost caps idle connections kept per downstream host.
+	MaxIdleConnsPerHost int `json:"maxIdleConnsPerHost" koanf:"max_idle_conns_per_host" validate:"min=0"`
+
+	// MaxConnsPerHost caps total connections per downstream host (0 is unlimited).
+	MaxConnsPerHost int `json:"maxConnsPerHost" koanf:"max_conns_per_host" validate:"min=0"`
+
+	// Downstreams configures cost tracking and budgets by downstream name (e.g., "openai").
+	Downstreams map[string]*Downstream `json:"downstreams" koanf:"downstreams" validate:"omitempty,dive,required"`
+}
+
+// Downstream describes the pricing and budget of a paid downstream API.
+type Downstream struct {
+	// Hosts are the hostnames identifying calls to the downstream.
+	Hosts []string `json:"hosts" koanf:"hosts" validate:"required,min=1,dive,hostname_rfc1123"`
+
+	// CostPerCall is the estimated cost of one call, in the budget's currency.
+	CostPerCall float64 `json:"costPerCall" koanf:"cost_per_call" validate:"min=0"`
+
+	// CostPerToken is the estimated cost of one token reported with RecordTokens.
+	CostPerToken float64 `json:"costPerToken" koanf:"cost_per_token" validate:"min=0"`
+
+	// Budget is the spend allowed per BudgetPeriod; 0 disables budget alerts.
+	Budget float64 `json:"budget" koanf:"budget" validate:"min=0"`
+
+	// BudgetPeriod is the window the budget applies to (defaults to 24h).
+	BudgetPeriod time.Duration `json:"budgetPeriod" koanf:"budget_period" validate:"omitempty,duration_min=1m"`
+
+	// AlertAt is the fraction of the budget raising an early warning (defaults to 0.8).
+	AlertAt float64 `json:"alertAt" koanf:"alert_at" validate:"min=0,max=1"`
+}
+
+// Validate checks that the HTTPClient configuration is valid.
+func (hc *HTTPClient) Validate() error {
+	return validation.Check(hc)
+}
+
+// ErrorReporting configures the error tracking integration (Sentry).
+type ErrorReporting struct {
+	// Enabled turns error reporting on or off.
+	Enabled bool `json:"enabled" koanf:"enabled"`
+
+	// DSN is the Sentry project DSN.
+	DSN string `json:"dsn" koanf:"dsn" validate:"required_if=Enabled true,omitempty,url" redact:"true"`
+
+	// SampleRate is the fraction of errors reported, from 0 to 1 (defaults to 1).
+	SampleRate float64 `json:"sampleRate" koanf:"sample_rate" validate:"min=0,max=1"`
+}
+
+// Validate checks that the ErrorReporting configuration is valid.
+func (e *ErrorReporting) Validate() error {
+	return validation.Check(e)
+}
+
+// Telemetry configures exporting telemetry to an OpenTelemetry collector.
+type Telemetry struct {
+	// Endpoint is the collector's OTLP/HTTP base URL (e.g., "http://otel-collector:4318").
+	Endpoint string `json:"endpoint" koanf:"endpoint" validate:"required,url"`
+
+	// Headers are sent with every export request (e.g., authentication).
+	Headers map[string]string `json:"headers" koanf:"headers" redact:"true"`
+
+	// Logs configures OTLP log export alongside the local output paths.
+	Logs *TelemetryLogs `json:"logs" koanf:"logs"`
+}
+
+// Validate checks that the Telemetry configuration is valid.
+func (t *Telemetry) Validate() error {
+	return validation.Check(t)
+}
+
+// TelemetryLogs configures batching and retries of OTLP log export.
+type TelemetryLogs struct {
+	// Enabled turns OTLP log export on or off.
+	Enabled bool `json:"enabled" koanf:"enabled"`
+
+	// BatchSize is the maximum number of entries per export (defaults to 512).
+	BatchSize int `json:"batchSize" koanf:"batch_size" validate:"min=0"`
+
+	// MaxQueueSize caps buffered entries; beyond it entries are dropped (defaults to 2048).
+	MaxQueueSize int `json:"maxQueueSize" koanf:"max_queue_size" validate:"min=0"`
+
+	// ExportInterval is the maximum delay between exports (defaults to 1s).
+	ExportInterval time.Duration `json:"exportInterval" koanf:"export_interval" validate:"min=0"`
+
+	// MaxRetryElapsed bounds retrying a failed export (defaults to 1m).
+	MaxRetryElapsed time.Duration `json:"maxRetryElapsed" koanf:"max_retry_elapsed" validate:"min=0"`
+}
+
+// RemoteConfig configures polling a remote configuration service. Its
+// payload is a JSON object of koanf keys applied over environment values.
+type RemoteConfig struct {
+	// Endpoint is the URL the configuration payload is fetched from.
+	Endpoint string `json:"endpoint" koanf:"endpoint" validate:"required,url_scheme=https http"`
+
+	// Token authenticates this service with the configuration service.
+	Token string `json:"token" koanf:"token" redact:"true"`
+
+	// PollInterval is the average time between polls.
+	PollInterval time.Duration `json:"pollInterval" koanf:"poll_interval" validate:"duration_min=1s"`
+
+	// Jitter spreads polls by up to this fraction of PollInterval either way (e.g., 0.2).
+	Jitter float64 `json:"jitter" koanf:"jitter" validate:"min=0,max=1"`
+
+	// MaxBytes caps the size of the payload accepted (defaults to 1 MiB).
+	MaxBytes int64 `json:"maxBytes" koanf:"max_bytes" validate:"min=0"`
+}
+
+// Validate checks that the RemoteConfig configuration is valid.
+func (rc *RemoteConfig) Validate() error {
+	return validation.Check(rc)
+}
+
 // HealthChecks configures periodic health verification for dependencies
 // like databases or APIs or other services.
 type HealthChecks struct {
@@ -158,10 +1010,14 @@ type HealthChecks struct {
 	Checks []string `json:"checks" koanf:"checks"`
 
 	// Timeout is the maximum time to wait for each check before failing.
-	Timeout time.Duration `json:"timeout" koanf:"timeout" validate:"min=1s"`
+	Timeout time.Duration `json:"timeout" koanf:"timeout" validate:"duration_min=1s"`
 
 	// Interval is the frequency between running checks.
-	Interval time.Duration `json:"interval" koanf:"interval" validate:"min=1s"`
+	Interval time.Duration `json:"interval" koanf:"interval" validate:"duration_min=1s"`
+
+	// MaxDegraded is how long routes may serve degraded responses while a
+	// critical dependency fails before the instance reports down; 0 never does.
+	MaxDegraded time.Duration `json:"maxDegraded" koanf:"max_degraded" validate:"min=0"`
 }
 
 // Validate checks that the HealthChecks configuration is valid.
@@ -180,9 +1036,46 @@ type Config struct {
 	// Database configures database connection pooling and authentication.
 	Database *Database `json:"database" koanf:"database" validate:"required"`
 
+	// Databases configures additional named databases (e.g., "replica",
+	// "analytics"), each with its own pool settings.
+	Databases map[string]*Database `json:"databases" koanf:"databases" validate:"omitempty,dive,required"`
+
 	// Service contains application name, version, and environment.
 	Service *Service `json:"application" koanf:"application" validate:"required"`
 
 	// HealthChecks defines periodic checks for service dependencies.
 	HealthChecks *HealthChecks `json:"healthChecks" koanf:"health_checks" validate:"required"`
+
+	// Messaging configures the message broker (optional).
+	Messaging *Messaging `json:"messaging" koanf:"messaging"`
+
+	// FeatureFlags configures runtime feature toggles (optional).
+	FeatureFlags *FeatureFlags `json:"featureFlags" koanf:"feature_flags"`
+
+	// Auth configures access token authentication (optional).
+	Auth *Auth `json:"auth" koanf:"auth"`
+
+	// HTTPClient tunes outbound HTTP connection pooling (optional).
+	HTTPClient *HTTPClient `json:"httpClient" koanf:"http_client"`
+
+	// ErrorReporting configures sending errors and panics to an error tracker (optional).
+	ErrorReporting *ErrorReporting `json:"errorReporting" koanf:"error_reporting"`
+
+	// Telemetry configures OpenTelemetry export (optional).
+	Telemetry *Telemetry `json:"telemetry" koanf:"telemetry"`
+
+	// RemoteConfig configures a remote source overriding environment values (optional).
+	RemoteConfig *RemoteConfig `json:"remoteConfig" koanf:"remote_config"`
+
+	// Sharding spreads data across several of the configured databases (optional).
+	Sharding *Sharding `json:"sharding" koanf:"sharding"`
+
+	// Replication sends read-only queries to replicas of the primary database (optional).
+	Replication *Replication `json:"replication" koanf:"replication"`
+
+	// Migrations configures the schema migrations of the primary database (optional).
+	Migrations *Migrations `json:"migrations" koanf:"migrations"`
+
+	// sources records where each loaded key came from.
+	sources map[string]Source
 }
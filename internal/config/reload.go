@@ -0,0 +1,97 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+// Loader produces a fresh configuration, typically LoadFromEnv.
+type Loader func() (*Config, error)
+
+// ReloadFunc is notified after a configuration reload has been applied.
+type ReloadFunc func(prev, next *Config, snap Snapshot)
+
+// Reloader owns the live configuration and swaps it atomically on reload.
+// Every reload is validated, diffed against the previous configuration,
+// and recorded in the reloader's History.
+type Reloader struct {
+	load        Loader
+	current     atomic.Pointer[Config]
+	history     *History
+	mu          sync.Mutex
+	subscribers []ReloadFunc
+}
+
+// NewReloader constructs a Reloader serving conf until the first reload.
+func NewReloader(conf *Config, load Loader, history *History) *Reloader {
+	r := &Reloader{load: load, history: history}
+	r.current.Store(conf)
+	return r
+}
+
+// Current returns the live configuration.
+func (r *Reloader) Current() *Config {
+	return r.current.Load()
+}
+
+// History returns the snapshots recorded by the reloader.
+func (r *Reloader) History() *History {
+	return r.history
+}
+
+// Subscribe registers fn to be called after every successful reload.
+func (r *Reloader) Subscribe(fn ReloadFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// Reload loads and validates a new configuration and, if valid, makes it
+// the live configuration. actor identifies who or what triggered the reload.
+// An invalid configuration leaves the current one in place and is returned
+// as an error.
+func (r *Reloader) Reload(actor string) (Snapshot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := r.load()
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	if err := Validate(next); err != nil {
+		return Snapshot{}, err
+	}
+
+	prev := r.current.Swap(next)
+	snap := r.history.Record(prev, next, actor)
+
+	for _, fn := range r.subscribers {
+		fn(prev, next, snap)
+	}
+
+	return snap, nil
+}
+
+// Watch reloads the configuration every time the process receives SIGHUP,
+// reporting failed reloads to onError, until ctx is cancelled.
+func (r *Reloader) Watch(ctx context.Context, onError func(error)) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigCh:
+			if _, err := r.Reload("signal:SIGHUP"); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultRemoteMaxBytes is the largest payload accepted when
+// RemoteConfig.MaxBytes is 0.
+const DefaultRemoteMaxBytes = 1 << 20
+
+// Remote polls a remote configuration service for a JSON object of koanf
+// keys (e.g., {"logging": {"level": "debug"}}) applied over environment
+// values. Polls are conditional on the last ETag, and payloads are hashed,
+// so an unchanged configuration never triggers a reload, even when the
+// service does not support ETags.
+type Remote struct {
+	conf   *RemoteConfig
+	client *http.Client
+
+	mu      sync.Mutex
+	etag    string
+	hash    [sha256.Size]byte
+	overlay map[string]any
+}
+
+// NewRemote constructs a Remote polling conf.Endpoint with client, or
+// http.DefaultClient when client is nil.
+func NewRemote(conf *RemoteConfig, client *http.Client) *Remote {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Remote{conf: conf, client: client}
+}
+
+// Loader returns a Loader for NewReloader that loads environment values
+// and applies the most recently fetched payload over them.
+func (rm *Remote) Loader() Loader {
+	return func() (*Config, error) {
+		rm.mu.Lock()
+		overlay := rm.overlay
+		rm.mu.Unlock()
+		return load(overlay)
+	}
+}
+
+// Poll fetches the payload and reports whether it changed since the
+// previous successful poll.
+func (rm *Remote) Poll(ctx context.Context) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rm.conf.Endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if rm.conf.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+rm.conf.Token)
+	}
+
+	rm.mu.Lock()
+	if rm.etag != "" {
+		req.Header.Set("If-None-Match", rm.etag)
+	}
+	rm.mu.Unlock()
+
+	resp, err := rm.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("config: poll remote: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return false, nil
+	case http.StatusOK:
+	default:
+		return false, fmt.Errorf("config: poll remote: unexpected status %d", resp.StatusCode)
+	}
+
+	limit := rm.conf.MaxBytes
+	if limit <= 0 {
+		limit = DefaultRemoteMaxBytes
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return false, fmt.Errorf("config: poll remote: %w", err)
+	}
+	if int64(len(body)) > limit {
+		return false, fmt.Errorf("config: poll remote: payload exceeds %d bytes", limit)
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.etag = resp.Header.Get("ETag")
+
+	hash := sha256.Sum256(body)
+	if rm.overlay != nil && hash == rm.hash {
+		return false, nil
+	}
+
+	var overlay map[string]any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&overlay); err != nil {
+		return false, fmt.Errorf("config: decode remote: %w", err)
+	}
+	if overlay == nil {
+		overlay = make(map[string]any)
+	}
+
+	rm.hash = hash
+	rm.overlay = overlay
+	return true, nil
+}
+
+// Run polls every PollInterval, spread by Jitter, and reloads r whenever
+// the payload changes, reporting failures to onError, until ctx is
+// cancelled. r must have been constructed with rm.Loader.
+func (rm *Remote) Run(ctx context.Context, r *Reloader, onError func(error)) {
+	for {
+		changed, err := rm.Poll(ctx)
+		if err == nil && changed {
+			_, err = r.Reload("remote:" + rm.conf.Endpoint)
+		}
+		if err != nil && ctx.Err() == nil && onError != nil {
+			onError(err)
+		}
+
+		timer := time.NewTimer(jitter(rm.conf.PollInterval, rm.conf.Jitter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// jitter returns d randomly spread by up to frac of d either way, so a
+// fleet started together does not poll in lockstep.
+func jitter(d time.Duration, frac float64) time.Duration {
+	if frac <= 0 {
+		return d
+	}
+	return d + time.Duration((rand.Float64()*2-1)*frac*float64(d))
+}
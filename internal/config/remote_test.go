@@ -0,0 +1,96 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRemotePoll(t *testing.T) {
+	payload := `{"logging": {"level": "debug"}}`
+	var gotToken, gotETag string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotToken, gotETag = r.Header.Get("Authorization"), r.Header.Get("If-None-Match")
+		if gotETag == `"v1"` && payload == `{"logging": {"level": "debug"}}` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(payload))
+	}))
+	defer srv.Close()
+
+	rm := NewRemote(&RemoteConfig{Endpoint: srv.URL, Token: "t0ken"}, srv.Client())
+	ctx := context.Background()
+
+	if changed, err := rm.Poll(ctx); err != nil || !changed {
+		t.Fatalf("first Poll = %v, %v; want changed", changed, err)
+	}
+	if gotToken != "Bearer t0ken" || gotETag != "" {
+		t.Errorf("first request sent token %q and ETag %q", gotToken, gotETag)
+	}
+	if changed, err := rm.Poll(ctx); err != nil || changed {
+		t.Fatalf("Poll answered 304 = %v, %v; want unchanged", changed, err)
+	}
+	if gotETag != `"v1"` {
+		t.Errorf("If-None-Match = %q, want the last ETag", gotETag)
+	}
+
+	// A service ignoring ETags resends the payload: its hash is unchanged.
+	payload = `{"logging": {"level": "debug"}} `
+	rm.etag = ""
+	if changed, err := rm.Poll(ctx); err != nil || !changed {
+		t.Fatalf("Poll of a new payload = %v, %v; want changed", changed, err)
+	}
+	rm.etag = ""
+	if changed, err := rm.Poll(ctx); err != nil || changed {
+		t.Fatalf("Poll of the same payload = %v, %v; want unchanged", changed, err)
+	}
+}
+
+func TestRemotePollRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		maxBytes int64
+		wantErr  string
+	}{
+		{name: "server error", status: http.StatusBadGateway, wantErr: "unexpected status 502"},
+		{name: "not an object", status: http.StatusOK, body: `["a"]`, wantErr: "decode remote"},
+		{name: "over the cap", status: http.StatusOK, body: `{"a": "` + strings.Repeat("x", 64) + `"}`, maxBytes: 64, wantErr: "exceeds 64 bytes"},
+		{name: "over the default cap", status: http.StatusOK, body: strings.Repeat(" ", DefaultRemoteMaxBytes) + "{}", wantErr: "exceeds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			rm := NewRemote(&RemoteConfig{Endpoint: srv.URL, MaxBytes: tt.maxBytes}, srv.Client())
+			changed, err := rm.Poll(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || changed {
+				t.Fatalf("Poll = %v, %v; want an error containing %q", changed, err, tt.wantErr)
+			}
+			if rm.overlay != nil {
+				t.Error("a rejected payload was kept")
+			}
+		})
+	}
+}
+
+func TestJitter(t *testing.T) {
+	if got := jitter(time.Second, 0); got != time.Second {
+		t.Errorf("jitter without a fraction = %v, want 1s", got)
+	}
+	for range 100 {
+		if got := jitter(time.Second, 0.2); got < 800*time.Millisecond || got > 1200*time.Millisecond {
+			t.Fatalf("jitter(1s, 0.2) = %v, want within 20%%", got)
+		}
+	}
+}
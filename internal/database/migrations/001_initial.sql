@@ -0,0 +1,4 @@
+-- The service's first schema change goes above the separator and its
+-- reversal below it.
+
+---- create above / drop below ----
@@ -0,0 +1,13 @@
+// Package migrations embeds the service's SQL migrations, applied by
+// "go-boilerplate migrate up" or, outside production, when the server
+// starts with migrations.auto_migrate set. Add one with
+// "go-boilerplate migrate create NAME"; see the migrate package for the
+// file format.
+package migrations
+
+import "embed"
+
+// FS holds the migration files.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,6 @@
+// Package events contains the typed domain events generated from
+// catalog.yaml. Producers and consumers must use these types instead of
+// hand-written payloads so event contracts cannot drift.
+package events
+
+//go:generate go run ../../cmd/eventgen -catalog catalog.yaml -out events_gen.go -schemas schemas
@@ -0,0 +1,36 @@
+// Code generated by eventgen. DO NOT EDIT.
+
+package events
+
+import "time"
+
+// Topic names for every event in the catalog.
+const (
+	TopicServiceStarted = "service.started"
+)
+
+// Event is implemented by every generated event type.
+type Event interface {
+	EventName() string
+	EventTopic() string
+	EventVersion() int
+}
+
+// ServiceStarted is emitted when a service instance has finished booting.
+type ServiceStarted struct {
+	// InstanceID uniquely identifies the service instance.
+	InstanceID     string    `json:"instanceId"`
+	ServiceName    string    `json:"serviceName"`
+	ServiceVersion string    `json:"serviceVersion"`
+	StartedAt      time.Time `json:"startedAt"`
+	Tags           []string  `json:"tags,omitempty"`
+}
+
+// EventName returns the catalog name of the event.
+func (ServiceStarted) EventName() string { return "ServiceStarted" }
+
+// EventTopic returns the topic the event is published on.
+func (ServiceStarted) EventTopic() string { return TopicServiceStarted }
+
+// EventVersion returns the schema version of the event.
+func (ServiceStarted) EventVersion() int { return 1 }
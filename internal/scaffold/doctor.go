@@ -0,0 +1,353 @@
+package scaffold
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// Finding kinds reported by Diagnose, besides the migration kinds.
+const (
+	FindingVersion    = "version"     // the project is behind the current version
+	FindingUnknownKey = "unknown-key" // a configuration key is not read by the current version
+)
+
+// Finding is something to fix before a project can upgrade.
+type Finding struct {
+	Kind string `json:"kind"`
+
+	// Release is the version requiring the change, for migrations.
+	Release string `json:"release,omitempty"`
+
+	// Path and Line locate the finding in the project, when it is tied to a file.
+	Path string `json:"path,omitempty"`
+	Line int    `json:"line,omitempty"`
+
+	Message string `json:"message"`
+}
+
+// Report is the result of Diagnose.
+type Report struct {
+	// Version is the boilerplate version the project was created from.
+	Version string `json:"version"`
+
+	// DetectedBy tells how Version was found: "manifest" or "markers".
+	DetectedBy string `json:"detectedBy"`
+
+	// Current is the version the project is compared with.
+	Current string `json:"current"`
+
+	Findings []Finding `json:"findings"`
+}
+
+// otherEnvKeys are environment variables with the prefix read outside
+// internal/config.
+var otherEnvKeys = []string{"compliance_signing_key"}
+
+// configExtensions are the extensions of the files scanned for
+// configuration keys, besides ".env" files and Dockerfiles.
+var configExtensions = []string{".env", ".yml", ".yaml", ".toml", ".sh", ".md"}
+
+// Diagnose inspects the project in dir, created from the boilerplate,
+// detects the version it was created from, and reports what upgrading to
+// Version requires: the migrations of every later release, and the
+// configuration keys set in env, YAML, TOML, shell, Dockerfile, and
+// Markdown files that Version no longer reads. envPrefix overrides the
+// prefix recorded in the manifest. Keys declared by the project's own
+// configuration structs are left alone.
+func Diagnose(dir, envPrefix string) (*Report, error) {
+	module, err := modulePath(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Current: Version, Findings: []Finding{}}
+	from := -1
+	if manifest != nil {
+		from = slices.IndexFunc(Releases, func(r Release) bool { return r.Version == manifest.Version })
+		if from < 0 {
+			return nil, fmt.Errorf("scaffold: project created from unknown boilerplate version %q; use a newer doctor", manifest.Version)
+		}
+		report.DetectedBy = "manifest"
+		if envPrefix == "" {
+			envPrefix = manifest.EnvPrefix
+		}
+	} else {
+		for i, r := range Releases {
+			if allExist(dir, r.Markers) {
+				from = i
+			}
+		}
+		if from < 0 {
+			return nil, fmt.Errorf("scaffold: %s does not look like a project created from the boilerplate", dir)
+		}
+		report.DetectedBy = "markers"
+	}
+	if envPrefix == "" {
+		envPrefix = EnvPrefix
+	}
+	report.Version = Releases[from].Version
+
+	if from < len(Releases)-1 {
+		report.Findings = append(report.Findings, Finding{
+			Kind:    FindingVersion,
+			Message: fmt.Sprintf("created from boilerplate %s; the current version is %s", report.Version, Version),
+		})
+	}
+
+	refs, err := scanEnvKeys(dir, envPrefix)
+	if err != nil {
+		return nil, err
+	}
+	migrated := make(map[string]bool)
+
+	for _, r := range Releases[from+1:] {
+		for _, m := range r.Migrations {
+			switch m.Kind {
+			case MigrateStep:
+				report.Findings = append(report.Findings, Finding{Kind: m.Kind, Release: r.Version, Message: m.Note})
+
+			case MigrateConfigKey:
+				migrated[m.From] = true
+				for _, ref := range refs {
+					if ref.key == m.From {
+						report.Findings = append(report.Findings, Finding{
+							Kind: m.Kind, Release: r.Version, Path: ref.path, Line: ref.line,
+							Message: fmt.Sprintf("%s: %s", ref.name, m.Note),
+						})
+					}
+				}
+
+			case MigratePackage:
+				users, err := importers(dir, path.Join(module, m.From))
+				if err != nil {
+					return nil, err
+				}
+				for _, file := range users {
+					report.Findings = append(report.Findings, Finding{
+						Kind: m.Kind, Release: r.Version, Path: file,
+						Message: fmt.Sprintf("imports %s: %s", m.From, m.Note),
+					})
+				}
+			}
+		}
+	}
+
+	known := config.Keys()
+	own, err := projectTags(dir, known)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range refs {
+		if migrated[ref.key] || knownKey(known, ref.key) || slices.Contains(otherEnvKeys, ref.key) {
+			continue
+		}
+		if own[ref.key[strings.LastIndex(ref.key, ".")+1:]] {
+			continue
+		}
+		report.Findings = append(report.Findings, Finding{
+			Kind: FindingUnknownKey, Path: ref.path, Line: ref.line,
+			Message: fmt.Sprintf("%s is not read by boilerplate %s", ref.name, Version),
+		})
+	}
+	return report, nil
+}
+
+// modulePath returns the module path declared by dir's go.mod.
+func modulePath(dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("scaffold: %s is not a Go module: %w", dir, err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`), nil
+		}
+	}
+	return "", errors.New("scaffold: go.mod declares no module")
+}
+
+// allExist reports whether every path exists in dir.
+func allExist(dir string, paths []string) bool {
+	for _, p := range paths {
+		if _, err := os.Stat(filepath.Join(dir, p)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// envRef is a configuration key set in a project file.
+type envRef struct {
+	path string
+	line int
+	name string // the environment variable
+	key  string // its koanf key
+}
+
+// scanEnvKeys finds the environment variables with prefix in the
+// project's configuration and documentation files.
+func scanEnvKeys(dir, prefix string) ([]envRef, error) {
+	pattern := regexp.MustCompile(`(?:^|[^A-Z0-9_])(` + regexp.QuoteMeta(prefix) + `[A-Z0-9_]+(?:\.[A-Z0-9_]+)*)`)
+
+	var refs []envRef
+	err := walkProject(dir, func(rel string, d fs.DirEntry) error {
+		name := d.Name()
+		if name != ".env" && !strings.HasPrefix(name, ".env.") && name != "Dockerfile" && !slices.Contains(configExtensions, path.Ext(name)) {
+			return nil
+		}
+
+		f, err := os.Open(filepath.Join(dir, rel))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		sc := bufio.NewScanner(f)
+		sc.Buffer(nil, 1<<20)
+		for line := 1; sc.Scan(); line++ {
+			for _, m := range pattern.FindAllStringSubmatch(sc.Text(), -1) {
+				refs = append(refs, envRef{
+					path: rel,
+					line: line,
+					name: m[1],
+					key:  strings.ToLower(strings.TrimPrefix(m[1], prefix)),
+				})
+			}
+		}
+		return sc.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scaffold: %w", err)
+	}
+	return refs, nil
+}
+
+// importers returns the Go files of the project importing pkg or a
+// package below it.
+func importers(dir, pkg string) ([]string, error) {
+	var files []string
+	err := walkProject(dir, func(rel string, _ fs.DirEntry) error {
+		if path.Ext(rel) != ".go" {
+			return nil
+		}
+		data, err := os.ReadFile(filepath.Join(dir, rel))
+		if err != nil {
+			return err
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), rel, data, parser.ImportsOnly)
+		if err != nil {
+			return nil // unparsable files are left to the compiler
+		}
+		for _, imp := range f.Imports {
+			p, err := strconv.Unquote(imp.Path.Value)
+			if err == nil && (p == pkg || strings.HasPrefix(p, pkg+"/")) {
+				files = append(files, rel)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scaffold: %w", err)
+	}
+	return files, nil
+}
+
+// koanfTag matches the koanf struct tags of configuration fields.
+var koanfTag = regexp.MustCompile(`koanf:"([^",]+)`)
+
+// projectTags returns the koanf tags declared by the project's
+// internal/config that the boilerplate's configuration does not use.
+func projectTags(dir string, known []string) (map[string]bool, error) {
+	ours := make(map[string]bool)
+	for _, key := range known {
+		for _, seg := range strings.Split(key, ".") {
+			ours[seg] = true
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "internal", "config", "*.go"))
+	if err != nil {
+		return nil, fmt.Errorf("scaffold: %w", err)
+	}
+	tags := make(map[string]bool)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("scaffold: %w", err)
+		}
+		for _, m := range koanfTag.FindAllSubmatch(data, -1) {
+			if tag := string(m[1]); !ours[tag] {
+				tags[tag] = true
+			}
+		}
+	}
+	return tags, nil
+}
+
+// knownKey reports whether key matches one of known, where a "*" segment
+// matches any segment.
+func knownKey(known []string, key string) bool {
+	segs := strings.Split(key, ".")
+	for _, k := range known {
+		ks := strings.Split(k, ".")
+		if len(ks) != len(segs) {
+			continue
+		}
+		match := true
+		for i := range ks {
+			if ks[i] != "*" && ks[i] != segs[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// walkProject calls fn for every regular file of the project, skipping
+// hidden, vendored, and build output directories.
+func walkProject(dir string, fn func(rel string, d fs.DirEntry) error) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if rel != "." && (strings.HasPrefix(d.Name(), ".") || d.Name() == "vendor" || d.Name() == "bin" || d.Name() == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		return fn(rel, d)
+	})
+}
@@ -0,0 +1,41 @@
+package scaffold
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ManifestFile is the file, at the root of a project, recording how init
+// created it.
+const ManifestFile = ".boilerplate.json"
+
+// Manifest records the boilerplate version a project was created from and
+// the identifiers it was given.
+type Manifest struct {
+	Version   string   `json:"version"`
+	Module    string   `json:"module"`
+	EnvPrefix string   `json:"envPrefix"`
+	Name      string   `json:"name"`
+	Removed   []string `json:"removed,omitempty"`
+}
+
+// ReadManifest reads the manifest of the project in dir. It returns nil
+// and no error for projects created before init wrote manifests.
+func ReadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scaffold: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("scaffold: parse %s: %w", ManifestFile, err)
+	}
+	return &m, nil
+}
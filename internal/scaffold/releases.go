@@ -0,0 +1,72 @@
+package scaffold
+
+// Version is the boilerplate version of this checkout. Init records it in
+// the project's manifest, so Diagnose knows where an upgrade starts from.
+const Version = "1.2.0"
+
+// Migration kinds.
+const (
+	MigrateConfigKey = "config-key" // a configuration key was renamed or removed
+	MigratePackage   = "package"    // a package was moved or removed
+	MigrateStep      = "step"       // a manual change is needed
+)
+
+// Migration is a change a project must make when upgrading past a release.
+type Migration struct {
+	Kind string `json:"kind"`
+
+	// From is the old koanf key or package directory, empty for steps.
+	From string `json:"from,omitempty"`
+
+	// To is the new koanf key or package directory, empty when removed.
+	To string `json:"to,omitempty"`
+
+	// Note explains what to do.
+	Note string `json:"note"`
+}
+
+// Release is a boilerplate version.
+type Release struct {
+	Version string
+
+	// Markers are paths the release introduced, used to detect the
+	// version of projects without a manifest. They lie outside the
+	// removable subsystems.
+	Markers []string
+
+	// Migrations are the changes needed when upgrading to the release.
+	Migrations []Migration
+}
+
+// Releases lists the boilerplate versions, oldest first. The last one is
+// Version.
+var Releases = []Release{
+	{
+		Version: "1.0.0",
+		Markers: []string{"internal/config/model.go", "internal/server/server.go"},
+	},
+	{
+		Version: "1.1.0",
+		Markers: []string{"internal/server/tls.go", "pkg/middleware/dedup.go"},
+		Migrations: []Migration{
+			{
+				Kind: MigrateStep,
+				Note: "pprof and the /debug endpoints are only served on the admin listener: set server.server_admin_port and point tooling at it",
+			},
+		},
+	},
+	{
+		Version: "1.2.0",
+		Markers: []string{"internal/server/listen.go", "pkg/maintenance/maintenance.go"},
+		Migrations: []Migration{
+			{
+				Kind: MigrateStep,
+				Note: "middleware.RealIP takes the client IP header as a second argument: pass conf.Server.ClientIPHeader",
+			},
+			{
+				Kind: MigrateStep,
+				Note: "the CLI's -format flag is deprecated: use -output, which also accepts quiet",
+			},
+		},
+	},
+}
@@ -0,0 +1,276 @@
+// Package scaffold turns a checkout of the boilerplate into a new project:
+// it rewrites the module path, the environment variable prefix, and the
+// binary name, and removes the subsystems the project does not need.
+// Changes are planned first, so they can be reviewed before being applied.
+package scaffold
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Identifiers of the boilerplate, replaced by those of the new project.
+const (
+	Module    = "github.com/iamBelugaa/go-boilerplate"
+	EnvPrefix = "BOILERPLATE_"
+	Name      = "go-boilerplate"
+)
+
+// Subsystem is an optional part of the boilerplate that can be removed.
+type Subsystem struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Dirs        []string `json:"dirs"`
+}
+
+// Subsystems lists the removable parts of the boilerplate. Their
+// configuration blocks stay in internal/config, unused, so existing
+// environments keep loading.
+var Subsystems = []Subsystem{
+	{"database", "sharded database routing and masked production copies", []string{"pkg/sharding", "pkg/mask"}},
+	{"messaging", "typed domain events generated from the event catalog", []string{"internal/events"}},
+	{"cache", "tag-based response caching and startup cache warming", []string{"pkg/httpcache", "pkg/cachewarm"}},
+	{"streaming", "relaying SSE and JSON Lines streams", []string{"pkg/web/stream"}},
+	{"approvals", "multi-party approval of sensitive operations", []string{"pkg/approval"}},
+	{"features", "feature flags", []string{"pkg/features"}},
+	{"metering", "usage metering", []string{"pkg/metering"}},
+	{"probabilistic", "Bloom and cuckoo filters and HyperLogLog", []string{"pkg/probabilistic"}},
+}
+
+// Options describes the project to create.
+type Options struct {
+	// Dir is the root of the checkout, rewritten in place.
+	Dir string
+
+	// Module is the new Go module path (e.g., "github.com/acme/billing").
+	Module string
+
+	// EnvPrefix is the new environment variable prefix (e.g., "BILLING_").
+	EnvPrefix string
+
+	// Name is the new binary and service name (e.g., "billing").
+	Name string
+
+	// Remove names the subsystems to remove.
+	Remove []string
+}
+
+// Change kinds.
+const (
+	KindCreate  = "create"  // the file is written
+	KindRewrite = "rewrite" // the file's identifiers are replaced
+	KindRemove  = "remove"  // the directory is deleted
+	KindRename  = "rename"  // the directory is moved to To
+)
+
+// Change is a planned modification of the checkout.
+type Change struct {
+	Kind string `json:"kind"`
+	Path string `json:"path"`
+	To   string `json:"to,omitempty"`
+
+	data []byte
+	mode fs.FileMode
+}
+
+var (
+	modulePattern = regexp.MustCompile(`^[a-z0-9.-]+(/[A-Za-z0-9._~-]+)+$`)
+	prefixPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*_$`)
+	namePattern   = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+)
+
+// textExtensions are the files whose identifiers are rewritten.
+var textExtensions = []string{".go", ".mod", ".yml", ".yaml", ".json", ".md", ".txt", ".env", ".toml"}
+
+// Validate checks that the options describe a valid project.
+func (o Options) Validate() error {
+	var errs []error
+	if !modulePattern.MatchString(o.Module) {
+		errs = append(errs, fmt.Errorf("scaffold: invalid module path %q", o.Module))
+	}
+	if !prefixPattern.MatchString(o.EnvPrefix) {
+		errs = append(errs, fmt.Errorf("scaffold: invalid env prefix %q: use upper case letters, digits, and underscores, ending in \"_\"", o.EnvPrefix))
+	}
+	if !namePattern.MatchString(o.Name) {
+		errs = append(errs, fmt.Errorf("scaffold: invalid name %q: use lower case letters, digits, and dashes", o.Name))
+	}
+	for _, name := range o.Remove {
+		if !slices.ContainsFunc(Subsystems, func(s Subsystem) bool { return s.Name == name }) {
+			errs = append(errs, fmt.Errorf("scaffold: unknown subsystem %q", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Plan returns the changes turning the checkout into the project, ending
+// with the creation of its manifest. It fails when code that is kept
+// imports a package that would be removed.
+func Plan(opts Options) ([]Change, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	removed := make(map[string]bool)
+	var changes []Change
+	for _, s := range Subsystems {
+		if !slices.Contains(opts.Remove, s.Name) {
+			continue
+		}
+		for _, dir := range s.Dirs {
+			if _, err := os.Stat(filepath.Join(opts.Dir, dir)); err == nil {
+				removed[dir] = true
+				changes = append(changes, Change{Kind: KindRemove, Path: dir})
+			}
+		}
+	}
+
+	replacer := newReplacer(opts)
+	var importErrs []error
+	err := filepath.WalkDir(opts.Dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(opts.Dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if rel != "." && (strings.HasPrefix(d.Name(), ".") || d.Name() == "vendor" || d.Name() == "bin" || removed[rel]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if rel == ManifestFile || !slices.Contains(textExtensions, path.Ext(rel)) && d.Name() != "Taskfile.yml" {
+			return nil
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if path.Ext(rel) == ".go" {
+			importErrs = append(importErrs, checkImports(rel, data, removed)...)
+		}
+
+		out := replacer(data)
+		if bytes.Equal(out, data) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		changes = append(changes, Change{Kind: KindRewrite, Path: rel, data: out, mode: info.Mode().Perm()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scaffold: %w", err)
+	}
+	if len(importErrs) > 0 {
+		return nil, errors.Join(importErrs...)
+	}
+
+	if opts.Name != Name {
+		from, to := path.Join("cmd", Name), path.Join("cmd", opts.Name)
+		if _, err := os.Stat(filepath.Join(opts.Dir, from)); err == nil {
+			changes = append(changes, Change{Kind: KindRename, Path: from, To: to})
+		}
+	}
+
+	manifest, err := json.MarshalIndent(Manifest{
+		Version:   Version,
+		Module:    opts.Module,
+		EnvPrefix: opts.EnvPrefix,
+		Name:      opts.Name,
+		Removed:   opts.Remove,
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("scaffold: %w", err)
+	}
+	changes = append(changes, Change{Kind: KindCreate, Path: ManifestFile, data: append(manifest, '\n'), mode: 0o644})
+	return changes, nil
+}
+
+// Apply performs the planned changes: rewrites and creations first, then
+// removals and renames.
+func Apply(opts Options, changes []Change) error {
+	for _, c := range changes {
+		if c.Kind == KindRewrite || c.Kind == KindCreate {
+			if err := os.WriteFile(filepath.Join(opts.Dir, c.Path), c.data, c.mode); err != nil {
+				return fmt.Errorf("scaffold: %w", err)
+			}
+		}
+	}
+	for _, c := range changes {
+		var err error
+		switch c.Kind {
+		case KindRemove:
+			err = os.RemoveAll(filepath.Join(opts.Dir, c.Path))
+		case KindRename:
+			err = os.Rename(filepath.Join(opts.Dir, c.Path), filepath.Join(opts.Dir, c.To))
+		}
+		if err != nil {
+			return fmt.Errorf("scaffold: %w", err)
+		}
+	}
+	return nil
+}
+
+// newReplacer returns a function replacing the boilerplate's identifiers.
+// The module path goes first, as it contains the name.
+func newReplacer(opts Options) func([]byte) []byte {
+	const placeholder = "\x00module\x00"
+	steps := []struct{ old, new string }{
+		{Module, placeholder},
+		{EnvPrefix, opts.EnvPrefix},
+		{Name, opts.Name},
+		{placeholder, opts.Module},
+	}
+	return func(data []byte) []byte {
+		for _, s := range steps {
+			data = bytes.ReplaceAll(data, []byte(s.old), []byte(s.new))
+		}
+		return data
+	}
+}
+
+// checkImports reports the imports of the Go file at rel of packages in
+// removed directories.
+func checkImports(rel string, data []byte, removed map[string]bool) []error {
+	f, err := parser.ParseFile(token.NewFileSet(), rel, data, parser.ImportsOnly)
+	if err != nil {
+		return nil // unparsable files are left to the compiler
+	}
+
+	var errs []error
+	for _, imp := range f.Imports {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		dir, ok := strings.CutPrefix(p, Module+"/")
+		if !ok {
+			continue
+		}
+		for r := range removed {
+			if dir == r || strings.HasPrefix(dir, r+"/") {
+				errs = append(errs, fmt.Errorf("scaffold: %s imports %s, which would be removed", rel, p))
+			}
+		}
+	}
+	return errs
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"net"
+	"sync"
+)
+
+// limitListener caps open connections, in total and per peer address.
+// Once the total is reached Accept waits for a connection to close, so
+// excess clients queue in the kernel backlog; connections over the
+// per-peer cap are closed as soon as they are accepted.
+type limitListener struct {
+	net.Listener
+	slots    chan struct{} // nil when the total is unlimited
+	perPeer  int
+	mu       sync.Mutex
+	peers    map[string]int
+	closing  chan struct{}
+	closeOne sync.Once
+}
+
+// newLimitListener wraps ln; a limit of 0 is unlimited.
+func newLimitListener(ln net.Listener, total, perPeer int) *limitListener {
+	l := &limitListener{Listener: ln, perPeer: perPeer, peers: make(map[string]int), closing: make(chan struct{})}
+	if total > 0 {
+		l.slots = make(chan struct{}, total)
+	}
+	return l
+}
+
+// Accept waits for a free slot and returns the next connection within the
+// per-peer cap.
+func (l *limitListener) Accept() (net.Conn, error) {
+	for {
+		if l.slots != nil {
+			select {
+			case l.slots <- struct{}{}:
+			case <-l.closing:
+				return nil, net.ErrClosed
+			}
+		}
+
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			l.release()
+			return nil, err
+		}
+
+		peer := peerHost(conn)
+		if !l.acquirePeer(peer) {
+			conn.Close()
+			l.release()
+			continue
+		}
+		return &limitConn{Conn: conn, listener: l, peer: peer}, nil
+	}
+}
+
+// Close closes the listener and wakes a pending Accept.
+func (l *limitListener) Close() error {
+	l.closeOne.Do(func() { close(l.closing) })
+	return l.Listener.Close()
+}
+
+// acquirePeer counts a connection from peer, reporting false over the cap.
+func (l *limitListener) acquirePeer(peer string) bool {
+	if l.perPeer <= 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.peers[peer] >= l.perPeer {
+		return false
+	}
+	l.peers[peer]++
+	return true
+}
+
+// releasePeer uncounts a connection from peer.
+func (l *limitListener) releasePeer(peer string) {
+	if l.perPeer <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.peers[peer]--; l.peers[peer] <= 0 {
+		delete(l.peers, peer)
+	}
+}
+
+// release frees a slot of the total cap.
+func (l *limitListener) release() {
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+// limitConn releases its slots when closed.
+type limitConn struct {
+	net.Conn
+	listener  *limitListener
+	peer      string
+	closeOnce sync.Once
+}
+
+// Close closes the connection and frees its slots.
+func (c *limitConn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(func() {
+		c.listener.releasePeer(c.peer)
+		c.listener.release()
+	})
+	return err
+}
+
+// peerHost returns the host part of the connection's remote address.
+func peerHost(conn net.Conn) string {
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		return conn.RemoteAddr().String()
+	}
+	return host
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd socket
+// activation.
+const listenFDsStart = 3
+
+// listen opens the listeners for addrs, each "host:port", "unix:PATH", or
+// "systemd" (every socket passed by systemd) or "systemd:NAME" (the
+// sockets named NAME by FileDescriptorName=). Already opened listeners are
+// closed when one fails.
+func listen(ctx context.Context, lc net.ListenConfig, addrs []string) ([]net.Listener, error) {
+	var (
+		lns       []net.Listener
+		activated []net.Listener
+		names     []string
+		used      = make(map[int]bool)
+	)
+	fail := func(err error) ([]net.Listener, error) {
+		for _, ln := range lns {
+			ln.Close()
+		}
+		for i, ln := range activated {
+			if !used[i] {
+				ln.Close()
+			}
+		}
+		return nil, err
+	}
+
+	for _, addr := range addrs {
+		switch {
+		case addr == "systemd" || strings.HasPrefix(addr, "systemd:"):
+			if activated == nil {
+				var err error
+				if activated, names, err = systemdListeners(); err != nil {
+					return fail(err)
+				}
+			}
+
+			name, filtered := strings.CutPrefix(addr, "systemd:")
+			found := false
+			for i, ln := range activated {
+				if used[i] || filtered && names[i] != name {
+					continue
+				}
+				used[i], found = true, true
+				lns = append(lns, ln)
+			}
+			if !found {
+				return fail(fmt.Errorf("server: no systemd socket for %q", addr))
+			}
+
+		case strings.HasPrefix(addr, "unix:"):
+			path := strings.TrimPrefix(addr, "unix:")
+			if err := removeStaleSocket(path); err != nil {
+				return fail(err)
+			}
+			ln, err := lc.Listen(ctx, "unix", path)
+			if err != nil {
+				return fail(fmt.Errorf("server: listen: %w", err))
+			}
+			lns = append(lns, ln)
+
+		default:
+			ln, err := lc.Listen(ctx, "tcp", addr)
+			if err != nil {
+				return fail(fmt.Errorf("server: listen: %w", err))
+			}
+			lns = append(lns, ln)
+		}
+	}
+
+	// Sockets passed by systemd but not configured are not served.
+	for i, ln := range activated {
+		if !used[i] {
+			ln.Close()
+		}
+	}
+	return lns, nil
+}
+
+// systemdListeners returns the sockets passed by systemd socket activation
+// (LISTEN_PID, LISTEN_FDS, and LISTEN_FDNAMES) with their names, and
+// unsets the variables so child processes do not claim them too.
+func systemdListeners() ([]net.Listener, []string, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil, errors.New("server: no sockets passed by systemd")
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil, errors.New("server: no sockets passed by systemd")
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	lns := make([]net.Listener, 0, n)
+	outNames := make([]string, 0, n)
+	for i := range n {
+		fd := listenFDsStart + i
+		name := "LISTEN_FD_" + strconv.Itoa(fd)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+
+		f := os.NewFile(uintptr(fd), name)
+		ln, err := net.FileListener(f)
+		f.Close() // FileListener duplicates the descriptor
+		if err != nil {
+			for _, ln := range lns {
+				ln.Close()
+			}
+			return nil, nil, fmt.Errorf("server: systemd socket %s: %w", name, err)
+		}
+		lns = append(lns, ln)
+		outNames = append(outNames, name)
+	}
+	return lns, outNames, nil
+}
+
+// removeStaleSocket removes a unix socket left behind by a process that
+// did not shut down cleanly. A socket still accepting connections means
+// another instance is running, which is an error.
+func removeStaleSocket(path string) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("server: %w", err)
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("server: %s exists and is not a socket", path)
+	}
+
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return fmt.Errorf("server: %s is in use by another process", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("server: remove stale socket: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+// Package server runs the service's HTTP server from its configuration and
+// shuts it down gracefully when its context ends. Router organizes the
+// routes it serves. Operational endpoints can be served on a separate
+// admin listener, so they are never exposed with the public API.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+	"github.com/iamBelugaa/go-boilerplate/pkg/lifecycle"
+	"github.com/iamBelugaa/go-boilerplate/pkg/middleware"
+	"github.com/iamBelugaa/go-boilerplate/pkg/web/wire"
+)
+
+// Option customizes a Server.
+type Option func(*Server)
+
+// WithHooks runs the pre-traffic hooks once the server is listening and
+// the post-traffic hooks before it starts draining.
+func WithHooks(hooks *lifecycle.Hooks) Option {
+	return func(s *Server) {
+		s.hooks = hooks
+	}
+}
+
+// WithMiddleware wraps the handler in chain, typically middleware.Defaults
+// adjusted to the service's needs.
+func WithMiddleware(chain middleware.Chain) Option {
+	return func(s *Server) {
+		s.chain = chain
+	}
+}
+
+// WithMonitor reports protocol-level failures (malformed requests, TLS
+// handshake errors) to monitor and answers them with structured errors.
+func WithMonitor(monitor *wire.Monitor) Option {
+	return func(s *Server) {
+		s.monitor = monitor
+	}
+}
+
+// WithAdmin serves handler, typically health, metrics, and debug
+// endpoints, on a separate listener at AdminPort. It has no effect when
+// AdminPort is 0.
+func WithAdmin(handler http.Handler) Option {
+	return func(s *Server) {
+		s.adminHandler = handler
+	}
+}
+
+// Server is an HTTP server built from the Server configuration.
+type Server struct {
+	conf    *config.Server
+	http    *http.Server
+	admin   *http.Server
+	logger  *zap.Logger
+	hooks   *lifecycle.Hooks
+	monitor *wire.Monitor
+	chain   middleware.Chain
+
+	adminHandler http.Handler
+}
+
+// New constructs a Server serving handler as configured by conf.
+func New(conf *config.Server, handler http.Handler, logger *zap.Logger, opts ...Option) *Server {
+	s := &Server{conf: conf, logger: logger}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.chain != nil {
+		handler = s.chain.Then(handler)
+	}
+
+	s.http = &http.Server{
+		Addr:              net.JoinHostPort(conf.Host, strconv.FormatUint(uint64(conf.Port), 10)),
+		Handler:           handler,
+		ReadTimeout:       conf.ReadTimeout,
+		ReadHeaderTimeout: conf.ReadHeaderTimeout,
+		WriteTimeout:      conf.WriteTimeout,
+		IdleTimeout:       conf.IdleTimeout,
+		MaxHeaderBytes:    conf.MaxHeaderBytes,
+	}
+	s.http.SetKeepAlivesEnabled(!conf.DisableKeepAlives)
+	s.http.Protocols, s.http.HTTP2 = http2Settings(conf.HTTP2)
+
+	if s.monitor != nil {
+		s.http.ErrorLog = s.monitor.ErrorLog()
+	}
+
+	if s.adminHandler != nil && conf.AdminPort != 0 {
+		// There is no write timeout: profiles and traces stream for as
+		// long as they were asked to.
+		s.admin = &http.Server{
+			Addr:              net.JoinHostPort(conf.Host, strconv.FormatUint(uint64(conf.AdminPort), 10)),
+			Handler:           s.adminHandler,
+			ReadTimeout:       conf.ReadTimeout,
+			ReadHeaderTimeout: conf.ReadHeaderTimeout,
+			IdleTimeout:       conf.IdleTimeout,
+			MaxHeaderBytes:    conf.MaxHeaderBytes,
+		}
+	}
+
+	return s
+}
+
+// Run listens on the configured addresses and serves until ctx is cancelled,
+// typically by the lifecycle.Group running it on SIGINT or SIGTERM (use
+// signal.NotifyContext when running it alone). It then stops accepting
+// connections and waits up to ShutdownTimeout for in-flight requests,
+// closing any still open afterwards. The admin listener starts before the
+// pre-traffic hooks run and stops once the public one has drained, so
+// probes and metrics cover the whole lifetime. With TLS enabled, the public
+// listener serves HTTPS and reloads its certificates when the files change
+// or on SIGHUP; the admin listener stays plain HTTP. Startup, serving, and
+// shutdown errors are returned together.
+func (s *Server) Run(ctx context.Context) error {
+	var certs *certificates
+	if s.conf.TLS != nil && s.conf.TLS.Enabled {
+		var err error
+		if certs, err = newCertificates(s.conf.TLS); err != nil {
+			return err
+		}
+		if s.http.TLSConfig, err = certs.tlsConfig(s.http.Protocols.HTTP2()); err != nil {
+			return err
+		}
+	}
+
+	lc := net.ListenConfig{KeepAlive: s.conf.TCPKeepAlive}
+
+	adminErr := make(chan error, 1)
+	if s.admin != nil {
+		adminLn, err := lc.Listen(ctx, "tcp", s.admin.Addr)
+		if err != nil {
+			return fmt.Errorf("server: admin listen: %w", err)
+		}
+		go func() {
+			adminErr <- s.admin.Serve(adminLn)
+		}()
+		s.logger.Info("admin server started", zap.String("addr", adminLn.Addr().String()))
+	}
+
+	addrs := s.conf.Listen
+	if len(addrs) == 0 {
+		addrs = []string{s.http.Addr}
+	}
+	lns, err := listen(ctx, lc, addrs)
+	if err != nil {
+		return errors.Join(err, s.closeAdmin(adminErr))
+	}
+
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	serveErr := make(chan error, len(lns))
+	bound := make([]string, len(lns))
+	for i, ln := range lns {
+		bound[i] = ln.Addr().Network() + ":" + ln.Addr().String()
+
+		if s.conf.MaxConns > 0 || s.conf.MaxConnsPerIP > 0 {
+			perPeer := s.conf.MaxConnsPerIP
+			if ln.Addr().Network() == "unix" {
+				perPeer = 0 // every unix client is the same peer
+			}
+			ln = newLimitListener(ln, s.conf.MaxConns, perPeer)
+		}
+		if s.monitor != nil && certs == nil {
+			ln = s.monitor.Listener(ln)
+		}
+
+		go func() {
+			if certs != nil {
+				serveErr <- s.http.ServeTLS(ln, "", "")
+				return
+			}
+			serveErr <- s.http.Serve(ln)
+		}()
+	}
+
+	if certs != nil {
+		go certs.watch(ctx, s.logger)
+	}
+
+	s.logger.Info("server started", zap.Strings("addrs", bound), zap.Bool("tls", certs != nil), zap.Stringer("protocols", s.http.Protocols))
+
+	var errs []error
+	if s.hooks != nil {
+		if err := s.hooks.RunPreTraffic(ctx); err != nil {
+			errs = append(errs, err)
+			stop()
+		}
+	}
+
+	select {
+	case err := <-serveErr:
+		// Serve failed before shutdown was requested; stop the other
+		// listeners too.
+		errs = append(errs, fmt.Errorf("server: serve: %w", err))
+		if err := s.http.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("server: close: %w", err))
+		}
+		for range len(lns) - 1 {
+			if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errs = append(errs, fmt.Errorf("server: serve: %w", err))
+			}
+		}
+		errs = append(errs, s.closeAdmin(adminErr))
+		return errors.Join(errs...)
+	case err := <-adminErr:
+		// Without probes the process is unmanageable, so shut down too.
+		// closeAdmin reports the error.
+		adminErr <- err
+	case <-ctx.Done():
+	}
+	stop()
+
+	s.logger.Info("server shutting down", zap.Duration("timeout", s.conf.ShutdownTimeout))
+	errs = append(errs, s.shutdown())
+
+	for range lns {
+		if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errs = append(errs, fmt.Errorf("server: serve: %w", err))
+		}
+	}
+	errs = append(errs, s.closeAdmin(adminErr))
+
+	s.logger.Info("server stopped")
+	return errors.Join(errs...)
+}
+
+// http2Settings returns the protocols and HTTP/2 parameters configured by
+// conf: HTTP/1.1 always, HTTP/2 over TLS unless disabled, and cleartext
+// HTTP/2 only on request.
+func http2Settings(conf *config.HTTP2) (*http.Protocols, *http.HTTP2Config) {
+	h2 := &http.HTTP2Config{MaxConcurrentStreams: 250, MaxReadFrameSize: 1 << 20}
+
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	if conf == nil {
+		protocols.SetHTTP2(true)
+		return &protocols, h2
+	}
+
+	protocols.SetHTTP2(!conf.Disabled)
+	protocols.SetUnencryptedHTTP2(conf.H2C)
+	if conf.MaxConcurrentStreams > 0 {
+		h2.MaxConcurrentStreams = conf.MaxConcurrentStreams
+	}
+	if conf.MaxReadFrameSize > 0 {
+		h2.MaxReadFrameSize = conf.MaxReadFrameSize
+	}
+	h2.SendPingTimeout = conf.PingInterval
+	h2.PingTimeout = conf.PingTimeout
+	return &protocols, h2
+}
+
+// shutdown runs the post-traffic hooks, then drains in-flight requests,
+// each within its own ShutdownTimeout, so slow hooks never eat into the
+// drain. Connections still open once the drain budget elapses are closed.
+func (s *Server) shutdown() error {
+	var errs []error
+	if s.hooks != nil {
+		hookCtx, cancel := context.WithTimeout(context.Background(), s.conf.ShutdownTimeout)
+		err := s.hooks.RunPostTraffic(hookCtx)
+		cancel()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.conf.ShutdownTimeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := s.http.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("server: graceful shutdown: %w", err))
+		if err := s.http.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("server: close: %w", err))
+		}
+	}
+	s.logger.Info("server drained", zap.Duration("elapsed", time.Since(start)))
+
+	return errors.Join(errs...)
+}
+
+// closeAdmin stops the admin server, if it runs, and waits for Serve to
+// return on errc.
+func (s *Server) closeAdmin(errc <-chan error) error {
+	if s.admin == nil {
+		return nil
+	}
+
+	var errs []error
+	if err := s.admin.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("server: admin close: %w", err))
+	}
+	if err := <-errc; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		errs = append(errs, fmt.Errorf("server: admin serve: %w", err))
+	}
+	return errors.Join(errs...)
+}
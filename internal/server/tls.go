@@ -0,0 +1,196 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// defaultReloadInterval is how often certificate files are checked for
+// changes when no interval is configured.
+const defaultReloadInterval = 30 * time.Second
+
+// certificates holds the key pair and client CA pool served by the TLS
+// listener, swapped atomically when the files change so established
+// connections are unaffected and new handshakes use the new files.
+type certificates struct {
+	conf *config.ServerTLS
+
+	cert     atomic.Pointer[tls.Certificate]
+	clientCA atomic.Pointer[x509.CertPool]
+	modTimes atomic.Pointer[[3]time.Time]
+}
+
+// newCertificates loads the files named by conf.
+func newCertificates(conf *config.ServerTLS) (*certificates, error) {
+	c := &certificates{conf: conf}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// load reads the key pair and client CA bundle, replacing the current ones
+// only when all of them are valid.
+func (c *certificates) load() error {
+	mod, err := c.modified()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.conf.CertFile, c.conf.KeyFile)
+	if err != nil {
+		return fmt.Errorf("server: load key pair: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if c.conf.ClientCAFile != "" {
+		pem, err := os.ReadFile(c.conf.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("server: read client CA file: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("server: no certificates in client CA file %q", c.conf.ClientCAFile)
+		}
+	}
+
+	c.cert.Store(&cert)
+	c.clientCA.Store(pool)
+	c.modTimes.Store(&mod)
+	return nil
+}
+
+// modified returns the modification times of the certificate, key, and
+// client CA files.
+func (c *certificates) modified() ([3]time.Time, error) {
+	var mod [3]time.Time
+	for i, name := range []string{c.conf.CertFile, c.conf.KeyFile, c.conf.ClientCAFile} {
+		if name == "" {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return mod, fmt.Errorf("server: %w", err)
+		}
+		mod[i] = info.ModTime()
+	}
+	return mod, nil
+}
+
+// changed reports whether any file was modified since it was loaded.
+func (c *certificates) changed() bool {
+	mod, err := c.modified()
+	if err != nil {
+		// Files are often replaced non-atomically; try again later.
+		return false
+	}
+	return mod != *c.modTimes.Load()
+}
+
+// watch reloads the files when they change or the process receives
+// SIGHUP, until ctx is cancelled. Failed reloads keep the current files
+// and are logged.
+func (c *certificates) watch(ctx context.Context, logger *zap.Logger) {
+	interval := c.conf.ReloadInterval
+	if interval <= 0 {
+		interval = defaultReloadInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !c.changed() {
+				continue
+			}
+		case <-sigCh:
+		}
+
+		if err := c.load(); err != nil {
+			logger.Error("tls certificate reload failed", zap.Error(err))
+			continue
+		}
+		logger.Info("tls certificates reloaded", zap.Time("not_after", c.leafExpiry()))
+	}
+}
+
+// leafExpiry returns the expiry of the served certificate.
+func (c *certificates) leafExpiry() time.Time {
+	if leaf := c.cert.Load().Leaf; leaf != nil {
+		return leaf.NotAfter
+	}
+	return time.Time{}
+}
+
+// tlsConfig returns a configuration with modern defaults: TLS 1.2 or
+// later, forward-secret AEAD cipher suites only, and HTTP/2 when h2 is set.
+func (c *certificates) tlsConfig(h2 bool) (*tls.Config, error) {
+	base := &tls.Config{
+		MinVersion:       tls.VersionTLS12,
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256},
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+		NextProtos: []string{"http/1.1"},
+	}
+	if h2 {
+		base.NextProtos = []string{"h2", "http/1.1"}
+	}
+
+	switch c.conf.MinVersion {
+	case "", "1.2":
+	case "1.3":
+		base.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("server: unsupported TLS version %q", c.conf.MinVersion)
+	}
+
+	switch c.conf.ClientAuth {
+	case "":
+	case "optional":
+		base.ClientAuth = tls.VerifyClientCertIfGiven
+	case "require":
+		base.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("server: unsupported client auth %q", c.conf.ClientAuth)
+	}
+
+	if base.ClientAuth != tls.NoClientCert && c.clientCA.Load() == nil {
+		return nil, errors.New("server: client auth requires a client CA file")
+	}
+
+	// Every handshake reads the current files, so reloads apply to new
+	// connections without restarting the listener.
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		conf := base.Clone()
+		conf.GetConfigForClient = nil
+		conf.Certificates = []tls.Certificate{*c.cert.Load()}
+		conf.ClientCAs = c.clientCA.Load()
+		return conf, nil
+	}
+	return base, nil
+}
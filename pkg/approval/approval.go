@@ -0,0 +1,328 @@
+// Package approval implements the two-person rule for sensitive operations
+// (e.g., refunds above a limit, deleting a tenant): requesting one creates
+// a pending approval, and the operation only runs once a second, authorized
+// principal approves it before it expires. Every step is audited.
+package approval
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/audit"
+	"github.com/iamBelugaa/go-boilerplate/pkg/auth"
+)
+
+// Errors returned by the workflow.
+var (
+	ErrUnknownOperation = errors.New("approval: unknown operation")
+	ErrNotFound         = errors.New("approval: request not found")
+	ErrNotPending       = errors.New("approval: request is not pending")
+	ErrExpired          = errors.New("approval: request expired")
+	ErrSelfApproval     = errors.New("approval: requester cannot decide their own request")
+	ErrForbidden        = errors.New("approval: principal may not decide this request")
+)
+
+// Status is the state of an approval request.
+type Status string
+
+// Supported statuses.
+const (
+	StatusPending  Status = "pending"
+	StatusRejected Status = "rejected"
+	StatusExpired  Status = "expired"
+	StatusExecuted Status = "executed"
+	StatusFailed   Status = "failed"
+)
+
+// Operation is a sensitive operation requiring approval.
+type Operation struct {
+	// Name identifies the operation (e.g., "tenant.delete").
+	Name string
+
+	// ApproverScope is the scope a principal needs to approve or reject
+	// requests for the operation.
+	ApproverScope string
+
+	// TTL is how long a request can wait for approval (defaults to 24h).
+	TTL time.Duration
+
+	// Execute performs the operation once approved, with the payload given
+	// when it was requested.
+	Execute func(ctx context.Context, payload json.RawMessage) error
+}
+
+// Request is a request to perform an operation.
+type Request struct {
+	ID        string          `json:"id"`
+	Operation string          `json:"operation"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Reason    string          `json:"reason,omitempty"`
+	Requester string          `json:"requester"`
+	Status    Status          `json:"status"`
+	CreatedAt time.Time       `json:"createdAt"`
+	ExpiresAt time.Time       `json:"expiresAt"`
+	DecidedBy string          `json:"decidedBy,omitempty"`
+	DecidedAt time.Time       `json:"decidedAt,omitzero"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// Notifier tells approvers about requests awaiting their decision, e.g., by
+// chat message or email.
+type Notifier interface {
+	Notify(ctx context.Context, req Request, approverScope string) error
+}
+
+// NotifierFunc adapts a function to the Notifier interface.
+type NotifierFunc func(ctx context.Context, req Request, approverScope string) error
+
+// Notify implements Notifier.
+func (f NotifierFunc) Notify(ctx context.Context, req Request, approverScope string) error {
+	return f(ctx, req, approverScope)
+}
+
+// Workflow registers operations and moves their requests from pending to
+// a final status.
+type Workflow struct {
+	store    Store
+	notifier Notifier
+	audit    *audit.Logger
+	now      func() time.Time
+
+	mu         sync.RWMutex
+	operations map[string]Operation
+	deciding   sync.Mutex
+}
+
+// New constructs a Workflow persisting requests in store, notifying
+// approvers through notifier (may be nil), and auditing to logger.
+func New(store Store, notifier Notifier, logger *audit.Logger) *Workflow {
+	return &Workflow{
+		store:      store,
+		notifier:   notifier,
+		audit:      logger,
+		now:        time.Now,
+		operations: make(map[string]Operation),
+	}
+}
+
+// Register adds an operation. It fails if the name is taken or the
+// operation has no Execute function or approver scope.
+func (w *Workflow) Register(op Operation) error {
+	if op.Name == "" || op.Execute == nil || op.ApproverScope == "" {
+		return fmt.Errorf("approval: operation %q needs a name, approver scope, and Execute", op.Name)
+	}
+	if op.TTL <= 0 {
+		op.TTL = 24 * time.Hour
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.operations[op.Name]; ok {
+		return fmt.Errorf("approval: operation %q already registered", op.Name)
+	}
+	w.operations[op.Name] = op
+	return nil
+}
+
+// operation returns the registered operation called name.
+func (w *Workflow) operation(name string) (Operation, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	op, ok := w.operations[name]
+	if !ok {
+		return Operation{}, fmt.Errorf("%w: %q", ErrUnknownOperation, name)
+	}
+	return op, nil
+}
+
+// Submit creates a pending request to run operation with payload on behalf
+// of the authenticated principal, and notifies approvers. A notification
+// failure is returned alongside the created request.
+func (w *Workflow) Submit(ctx context.Context, operation string, payload any, reason string) (*Request, error) {
+	op, err := w.operation(operation)
+	if err != nil {
+		return nil, err
+	}
+
+	p := auth.PrincipalFromContext(ctx)
+	if p == nil {
+		return nil, fmt.Errorf("%w: no authenticated principal", ErrForbidden)
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("approval: encode payload: %w", err)
+	}
+
+	now := w.now().UTC()
+	req := Request{
+		ID:        newID(),
+		Operation: op.Name,
+		Payload:   raw,
+		Reason:    reason,
+		Requester: p.Subject,
+		Status:    StatusPending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(op.TTL),
+	}
+
+	if err := w.store.Create(ctx, req); err != nil {
+		return nil, fmt.Errorf("approval: store request: %w", err)
+	}
+	w.record(ctx, p.Subject, "approval.requested", req, nil)
+
+	if w.notifier != nil {
+		if err := w.notifier.Notify(ctx, req, op.ApproverScope); err != nil {
+			return &req, fmt.Errorf("approval: notify approvers: %w", err)
+		}
+	}
+	return &req, nil
+}
+
+// Approve approves a pending request on behalf of the authenticated
+// principal, who must hold the operation's approver scope and differ from
+// the requester, and executes the operation. The returned request carries
+// the final status; an execution failure is also returned as an error.
+func (w *Workflow) Approve(ctx context.Context, id string) (*Request, error) {
+	return w.decide(ctx, id, "", true)
+}
+
+// Reject rejects a pending request on behalf of the authenticated
+// principal, under the same rules as Approve.
+func (w *Workflow) Reject(ctx context.Context, id, reason string) (*Request, error) {
+	return w.decide(ctx, id, reason, false)
+}
+
+// decide applies an approval decision.
+func (w *Workflow) decide(ctx context.Context, id, reason string, approve bool) (*Request, error) {
+	// Decisions are serialized so a request is never executed twice.
+	w.deciding.Lock()
+	defer w.deciding.Unlock()
+
+	req, err := w.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *req
+
+	if req.Status != StatusPending {
+		return nil, fmt.Errorf("%w: %s", ErrNotPending, req.Status)
+	}
+
+	op, err := w.operation(req.Operation)
+	if err != nil {
+		return nil, err
+	}
+
+	p := auth.PrincipalFromContext(ctx)
+	switch {
+	case p == nil || !p.HasScope(op.ApproverScope):
+		return nil, ErrForbidden
+	case p.Subject == req.Requester:
+		return nil, ErrSelfApproval
+	}
+
+	now := w.now().UTC()
+	if !now.Before(req.ExpiresAt) {
+		req.Status = StatusExpired
+		if err := w.store.Update(ctx, *req); err != nil {
+			return nil, fmt.Errorf("approval: store request: %w", err)
+		}
+		w.record(ctx, "system", "approval.expired", *req, &before)
+		return nil, ErrExpired
+	}
+
+	req.DecidedBy = p.Subject
+	req.DecidedAt = now
+
+	if !approve {
+		req.Status = StatusRejected
+		req.Error = reason
+		if err := w.store.Update(ctx, *req); err != nil {
+			return nil, fmt.Errorf("approval: store request: %w", err)
+		}
+		w.record(ctx, p.Subject, "approval.rejected", *req, &before)
+		return req, nil
+	}
+
+	execErr := op.Execute(ctx, req.Payload)
+	req.Status = StatusExecuted
+	if execErr != nil {
+		req.Status = StatusFailed
+		req.Error = execErr.Error()
+	}
+
+	if err := w.store.Update(ctx, *req); err != nil {
+		return nil, fmt.Errorf("approval: store request: %w", err)
+	}
+	w.record(ctx, p.Subject, "approval.approved", *req, &before)
+
+	if execErr != nil {
+		return req, fmt.Errorf("approval: execute %s: %w", op.Name, execErr)
+	}
+	return req, nil
+}
+
+// Pending returns the requests awaiting a decision, oldest first. Requests
+// past their expiry are marked expired instead.
+func (w *Workflow) Pending(ctx context.Context) ([]Request, error) {
+	w.deciding.Lock()
+	defer w.deciding.Unlock()
+
+	all, err := w.store.List(ctx, StatusPending)
+	if err != nil {
+		return nil, err
+	}
+
+	now := w.now()
+	pending := make([]Request, 0, len(all))
+	for _, req := range all {
+		if now.Before(req.ExpiresAt) {
+			pending = append(pending, req)
+			continue
+		}
+
+		before := req
+		req.Status = StatusExpired
+		if err := w.store.Update(ctx, req); err != nil {
+			return nil, fmt.Errorf("approval: store request: %w", err)
+		}
+		w.record(ctx, "system", "approval.expired", req, &before)
+	}
+	return pending, nil
+}
+
+// record audits a step of a request. Audit failures never block the
+// workflow; the request itself remains in the store.
+func (w *Workflow) record(ctx context.Context, actor, action string, req Request, before *Request) {
+	if w.audit == nil {
+		return
+	}
+
+	e := audit.Event{
+		Actor:    actor,
+		Action:   action,
+		Resource: "approval:" + req.ID,
+		After:    req,
+		Metadata: map[string]any{"operation": req.Operation},
+	}
+	if before != nil {
+		e.Before = *before
+	}
+	_ = w.audit.Record(context.WithoutCancel(ctx), e)
+}
+
+// newID returns a random 128-bit hex identifier.
+func newID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
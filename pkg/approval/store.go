@@ -0,0 +1,84 @@
+package approval
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Store persists approval requests.
+type Store interface {
+	// Create stores a new request.
+	Create(ctx context.Context, req Request) error
+
+	// Get returns the request with the given ID, or ErrNotFound.
+	Get(ctx context.Context, id string) (*Request, error)
+
+	// Update replaces a stored request.
+	Update(ctx context.Context, req Request) error
+
+	// List returns the requests with the given status, oldest first.
+	List(ctx context.Context, status Status) ([]Request, error)
+}
+
+// MemoryStore keeps requests in memory. It is suitable for development and
+// single-instance deployments, as pending requests are lost on restart.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	requests map[string]Request
+}
+
+// NewMemoryStore constructs an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{requests: make(map[string]Request)}
+}
+
+// Create implements Store.
+func (s *MemoryStore) Create(_ context.Context, req Request) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[req.ID] = req
+	return nil
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(_ context.Context, id string) (*Request, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	req, ok := s.requests[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &req, nil
+}
+
+// Update implements Store.
+func (s *MemoryStore) Update(_ context.Context, req Request) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.requests[req.ID]; !ok {
+		return ErrNotFound
+	}
+	s.requests[req.ID] = req
+	return nil
+}
+
+// List implements Store.
+func (s *MemoryStore) List(_ context.Context, status Status) ([]Request, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var requests []Request
+	for _, req := range s.requests {
+		if req.Status == status {
+			requests = append(requests, req)
+		}
+	}
+
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].CreatedAt.Before(requests[j].CreatedAt)
+	})
+	return requests, nil
+}
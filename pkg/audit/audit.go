@@ -0,0 +1,196 @@
+// Package audit records who changed what and when, separately from the
+// application logs, so changes can be reviewed by operators and auditors.
+//
+// Events are hash-chained: each carries the hash of its predecessor, so
+// Verify detects events that were modified, removed, or reordered after
+// being written to an append-only sink.
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Event is a single audited action.
+type Event struct {
+	// ID uniquely identifies the event.
+	ID string `json:"id"`
+
+	// Time is when the action happened.
+	Time time.Time `json:"time"`
+
+	// Actor is the user, service, or mechanism that performed the action.
+	Actor string `json:"actor"`
+
+	// Action names what was done (e.g., "config.reload").
+	Action string `json:"action"`
+
+	// Resource identifies what the action was performed on.
+	Resource string `json:"resource"`
+
+	// Before is the state prior to the action, if any.
+	Before any `json:"before,omitempty"`
+
+	// After is the state following the action, if any.
+	After any `json:"after,omitempty"`
+
+	// Metadata holds additional context about the action.
+	Metadata map[string]any `json:"metadata,omitempty"`
+
+	// RequestID correlates the event with the request that caused it.
+	RequestID string `json:"requestId,omitempty"`
+
+	// PrevHash is the Hash of the event recorded before this one.
+	PrevHash string `json:"prevHash,omitempty"`
+
+	// Hash covers every other field, including PrevHash.
+	Hash string `json:"hash"`
+}
+
+// Sink persists audit events.
+type Sink interface {
+	Write(ctx context.Context, e Event) error
+}
+
+// Chained is implemented by durable sinks, so a restarted Logger continues
+// the hash chain of the events already written.
+type Chained interface {
+	// LastHash returns the Hash of the most recent event, or "" if none.
+	LastHash(ctx context.Context) (string, error)
+}
+
+// Filter narrows the events returned by a Querier. Zero fields match all.
+type Filter struct {
+	Actor    string
+	Action   string
+	Resource string
+	Since    time.Time
+	Until    time.Time
+	Limit    int
+}
+
+// Matches reports whether e satisfies every set field of the filter.
+func (f Filter) Matches(e Event) bool {
+	switch {
+	case f.Actor != "" && e.Actor != f.Actor:
+		return false
+	case f.Action != "" && e.Action != f.Action:
+		return false
+	case f.Resource != "" && e.Resource != f.Resource:
+		return false
+	case !f.Since.IsZero() && e.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !e.Time.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// Querier retrieves previously recorded events, newest first.
+type Querier interface {
+	Query(ctx context.Context, f Filter) ([]Event, error)
+}
+
+// Logger records audit events to a sink.
+type Logger struct {
+	sink Sink
+	now  func() time.Time
+
+	mu      sync.Mutex
+	head    string
+	resumed bool
+}
+
+// New constructs a Logger writing to sink.
+func New(sink Sink) *Logger {
+	return &Logger{sink: sink, now: time.Now}
+}
+
+// Record fills in the event ID, time, actor, and request ID (from ctx) when
+// they are not set, chains the event to the previously recorded one, and
+// writes it to the sink.
+func (l *Logger) Record(ctx context.Context, e Event) error {
+	if e.ID == "" {
+		e.ID = newID()
+	}
+	if e.Time.IsZero() {
+		e.Time = l.now().UTC()
+	}
+	if e.Actor == "" {
+		e.Actor = ActorFromContext(ctx)
+	}
+	if e.RequestID == "" {
+		e.RequestID = RequestIDFromContext(ctx)
+	}
+
+	// Events are chained and written one at a time so the chain in the
+	// sink matches the order the hashes were computed in.
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.resumed {
+		if chained, ok := l.sink.(Chained); ok {
+			head, err := chained.LastHash(ctx)
+			if err != nil {
+				return fmt.Errorf("audit: resume chain: %w", err)
+			}
+			l.head = head
+		}
+		l.resumed = true
+	}
+
+	e.PrevHash = l.head
+	hash, err := e.digest()
+	if err != nil {
+		return fmt.Errorf("audit: hash event: %w", err)
+	}
+	e.Hash = hash
+
+	if err := l.sink.Write(ctx, e); err != nil {
+		return fmt.Errorf("audit: write event: %w", err)
+	}
+	l.head = e.Hash
+	return nil
+}
+
+// actorKey is the context key for the acting principal.
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying the acting principal.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the acting principal, or "system" if none is set.
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return "system"
+}
+
+// requestIDKey is the context key for the request ID.
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the ID of the request being
+// served.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, or "".
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newID returns a random 128-bit hex identifier.
+func newID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrTampered is returned by Verify when the chain of events is broken.
+var ErrTampered = errors.New("audit: event chain broken")
+
+// digest returns the hex SHA-256 of the event's canonical JSON encoding,
+// excluding Hash. The encoding is canonicalized (object keys sorted,
+// numbers kept verbatim) so events read back from a sink, whose Before,
+// After, and Metadata are decoded into generic maps, hash identically.
+func (e Event) digest() (string, error) {
+	e.Hash = ""
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return "", err
+	}
+
+	canonical, err := json.Marshal(generic)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Verify checks that events, oldest first, form an unbroken chain: every
+// event's Hash matches its content and its PrevHash matches the Hash of the
+// event before it. The first event may link to any predecessor, so a
+// window of a longer chain can be verified on its own.
+func Verify(events []Event) error {
+	for i, e := range events {
+		hash, err := e.digest()
+		if err != nil {
+			return fmt.Errorf("audit: hash event %q: %w", e.ID, err)
+		}
+		if hash != e.Hash {
+			return fmt.Errorf("%w: event %q was modified", ErrTampered, e.ID)
+		}
+		if i > 0 && e.PrevHash != events[i-1].Hash {
+			return fmt.Errorf("%w: event %q does not follow %q", ErrTampered, e.ID, events[i-1].ID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileSink appends events to a file as JSON lines. The file is opened in
+// append-only mode and synced after every event, so a recorded event
+// survives a crash; it should be shipped or backed up to storage the
+// service cannot rewrite.
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// OpenFile opens or creates the audit file at path.
+func OpenFile(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("audit: open file: %w", err)
+	}
+	return &FileSink{path: path, file: f}, nil
+}
+
+// Write implements Sink.
+func (s *FileSink) Write(_ context.Context, e Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// LastHash implements Chained.
+func (s *FileSink) LastHash(_ context.Context) (string, error) {
+	events, err := s.read()
+	if err != nil || len(events) == 0 {
+		return "", err
+	}
+	return events[len(events)-1].Hash, nil
+}
+
+// Query implements Querier by scanning the whole file.
+func (s *FileSink) Query(_ context.Context, f Filter) ([]Event, error) {
+	all, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	for i := len(all) - 1; i >= 0; i-- {
+		if !f.Matches(all[i]) {
+			continue
+		}
+		events = append(events, all[i])
+		if f.Limit > 0 && len(events) == f.Limit {
+			break
+		}
+	}
+	return events, nil
+}
+
+// Verify checks the hash chain of every event in the file.
+func (s *FileSink) Verify() error {
+	events, err := s.read()
+	if err != nil {
+		return err
+	}
+	return Verify(events)
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// read decodes every event in the file, oldest first.
+func (s *FileSink) read() ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("audit: read file: %w", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+		dec.UseNumber()
+
+		var e Event
+		if err := dec.Decode(&e); err != nil {
+			return nil, fmt.Errorf("audit: read file: line %d: %w", line, err)
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("audit: read file: %w", err)
+	}
+	return events, nil
+}
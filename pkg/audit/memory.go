@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"context"
+	"sync"
+)
+
+// MemorySink keeps the most recent events in memory. It is suitable for
+// development and for serving recent history from the admin API.
+type MemorySink struct {
+	mu     sync.RWMutex
+	size   int
+	events []Event
+}
+
+// NewMemorySink constructs a MemorySink retaining at most size events.
+func NewMemorySink(size int) *MemorySink {
+	if size < 1 {
+		size = 1
+	}
+	return &MemorySink{size: size}
+}
+
+// Write implements Sink.
+func (s *MemorySink) Write(_ context.Context, e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = append(s.events, e)
+	if len(s.events) > s.size {
+		s.events = s.events[len(s.events)-s.size:]
+	}
+	return nil
+}
+
+// Query implements Querier.
+func (s *MemorySink) Query(_ context.Context, f Filter) ([]Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var events []Event
+	for i := len(s.events) - 1; i >= 0; i-- {
+		if !f.Matches(s.events[i]) {
+			continue
+		}
+		events = append(events, s.events[i])
+		if f.Limit > 0 && len(events) == f.Limit {
+			break
+		}
+	}
+	return events, nil
+}
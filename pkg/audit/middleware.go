@@ -0,0 +1,124 @@
+package audit
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/auth"
+)
+
+// headerRequestID is the header carrying the request's correlation ID,
+// matching logging.HeaderRequestID.
+const headerRequestID = "X-Request-ID"
+
+// change is filled in by handlers through Change and recorded by Middleware.
+type change struct {
+	resource string
+	before   any
+	after    any
+	set      bool
+}
+
+// changeKey is the context key for the pending change of a request.
+type changeKey struct{}
+
+// Change describes the change made by the current request: the resource
+// it was made to and its state before and after. It has no effect outside
+// Middleware.
+func Change(ctx context.Context, resource string, before, after any) {
+	if c, ok := ctx.Value(changeKey{}).(*change); ok {
+		c.resource, c.before, c.after, c.set = resource, before, after, true
+	}
+}
+
+// Middleware records an event for every successful (status below 400)
+// POST, PUT, PATCH, or DELETE request. The action is the method and route
+// (e.g., "PATCH /users/{id}"), the actor is the authenticated principal
+// ("<staff> as <user>" while impersonating), and the resource defaults to
+// the request path unless the handler describes the change with Change.
+// The client IP, as resolved by middleware.RealIP, is kept in the "ip"
+// metadata.
+// Write failures are reported to onError and never fail the request.
+func Middleware(logger *Logger, onError func(error)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			if p := auth.PrincipalFromContext(ctx); p != nil {
+				actor := p.Subject
+				if p.ImpersonatedBy != "" {
+					actor = p.ImpersonatedBy + " as " + p.Subject
+				}
+				ctx = WithActor(ctx, actor)
+			}
+			if id := r.Header.Get(headerRequestID); id != "" {
+				ctx = WithRequestID(ctx, id)
+			}
+
+			c := &change{resource: r.URL.Path}
+			ctx = context.WithValue(ctx, changeKey{}, c)
+
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, r.WithContext(ctx))
+
+			if sw.status >= http.StatusBadRequest {
+				return
+			}
+
+			action := r.Pattern
+			if action == "" {
+				action = r.Method + " " + r.URL.Path
+			}
+
+			e := Event{
+				Action:   action,
+				Resource: c.resource,
+				Metadata: map[string]any{"status": sw.status},
+			}
+			if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+				e.Metadata["ip"] = host
+			}
+			if c.set {
+				e.Before, e.After = c.before, c.after
+			}
+
+			if err := logger.Record(context.WithoutCancel(ctx), e); err != nil && onError != nil {
+				onError(err)
+			}
+		})
+	}
+}
+
+// statusWriter records the status code of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+// WriteHeader records the status code.
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write marks the header as written.
+func (w *statusWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,31 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// PublishFunc publishes a message to a topic on the message broker.
+type PublishFunc func(ctx context.Context, topic string, payload []byte) error
+
+// QueueSink publishes events as JSON to a broker topic, for consumption by
+// a central audit store. Consumers verify the chain with Verify, so the
+// topic must preserve publish order (e.g., a single partition).
+type QueueSink struct {
+	publish PublishFunc
+	topic   string
+}
+
+// NewQueueSink constructs a QueueSink publishing to topic.
+func NewQueueSink(publish PublishFunc, topic string) *QueueSink {
+	return &QueueSink{publish: publish, topic: topic}
+}
+
+// Write implements Sink.
+func (s *QueueSink) Write(ctx context.Context, e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return s.publish(ctx, s.topic, payload)
+}
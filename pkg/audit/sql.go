@@ -0,0 +1,127 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Schema creates the PostgreSQL table used by SQLSink. A trigger rejects
+// every UPDATE and DELETE, making the table append-only even for the
+// service's own database user; the full event is kept verbatim in payload
+// so its hash can be verified.
+const Schema = `
+CREATE TABLE IF NOT EXISTS audit_events (
+	seq         BIGSERIAL PRIMARY KEY,
+	id          TEXT NOT NULL UNIQUE,
+	occurred_at TIMESTAMPTZ NOT NULL,
+	actor       TEXT NOT NULL,
+	action      TEXT NOT NULL,
+	resource    TEXT NOT NULL,
+	request_id  TEXT NOT NULL DEFAULT '',
+	hash        TEXT NOT NULL,
+	payload     TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_occurred_at_idx ON audit_events (occurred_at);
+
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events;
+CREATE TRIGGER audit_events_append_only
+	BEFORE UPDATE OR DELETE ON audit_events
+	FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+`
+
+// SQLSink is a PostgreSQL-backed Sink.
+type SQLSink struct {
+	db *sql.DB
+}
+
+// NewSQLSink constructs a SQLSink. The table in Schema must exist.
+func NewSQLSink(db *sql.DB) *SQLSink {
+	return &SQLSink{db: db}
+}
+
+// Write implements Sink.
+func (s *SQLSink) Write(ctx context.Context, e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO audit_events (id, occurred_at, actor, action, resource, request_id, hash, payload)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		e.ID, e.Time.UTC(), e.Actor, e.Action, e.Resource, e.RequestID, e.Hash, string(payload),
+	)
+	return err
+}
+
+// LastHash implements Chained.
+func (s *SQLSink) LastHash(ctx context.Context) (string, error) {
+	var hash string
+	err := s.db.QueryRowContext(ctx, `SELECT hash FROM audit_events ORDER BY seq DESC LIMIT 1`).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return hash, err
+}
+
+// Query implements Querier.
+func (s *SQLSink) Query(ctx context.Context, f Filter) ([]Event, error) {
+	var since, until any
+	if !f.Since.IsZero() {
+		since = f.Since.UTC()
+	}
+	if !f.Until.IsZero() {
+		until = f.Until.UTC()
+	}
+	var limit any
+	if f.Limit > 0 {
+		limit = f.Limit
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT payload
+		FROM audit_events
+		WHERE ($1 = '' OR actor = $1)
+		  AND ($2 = '' OR action = $2)
+		  AND ($3 = '' OR resource = $3)
+		  AND ($4::timestamptz IS NULL OR occurred_at >= $4)
+		  AND ($5::timestamptz IS NULL OR occurred_at < $5)
+		ORDER BY seq DESC
+		LIMIT $6`,
+		f.Actor, f.Action, f.Resource, since, until, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var payload string
+		if err := rows.Scan(&payload); err != nil {
+			return nil, err
+		}
+
+		dec := json.NewDecoder(bytes.NewReader([]byte(payload)))
+		dec.UseNumber()
+
+		var e Event
+		if err := dec.Decode(&e); err != nil {
+			return nil, fmt.Errorf("audit: decode event: %w", err)
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
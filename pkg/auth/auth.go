@@ -0,0 +1,68 @@
+// Package auth authenticates requests carrying bearer access tokens and
+// exposes the authenticated principal through the request context.
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	// Subject uniquely identifies the caller (the token's "sub").
+	Subject string `json:"sub"`
+
+	// ClientID is the OAuth client the token was issued to.
+	ClientID string `json:"clientId,omitempty"`
+
+	// Username is a human-readable identifier of the caller.
+	Username string `json:"username,omitempty"`
+
+	// Scopes are the scopes granted to the token.
+	Scopes []string `json:"scopes,omitempty"`
+
+	// Tier is the plan or tier of the caller's account (e.g., "pro"),
+	// weighting its share of throttled capacity.
+	Tier string `json:"tier,omitempty"`
+
+	// ImpersonatedBy is the subject of the support staff member acting as
+	// this principal, if any. User interfaces show a banner while it is set.
+	ImpersonatedBy string `json:"impersonatedBy,omitempty"`
+}
+
+// HasScope reports whether the principal was granted scope.
+func (p *Principal) HasScope(scope string) bool {
+	if p == nil {
+		return false
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// principalKey is the context key for the authenticated Principal.
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the authenticated principal, or nil.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// BearerToken extracts the token from an "Authorization: Bearer" header.
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
@@ -0,0 +1,269 @@
+// Package impersonate lets support staff act as a user, with guardrails:
+// the user (or an administrator) must explicitly grant it, impersonation
+// tokens are short-lived, destructive requests are refused, and every step
+// and every impersonated request is audited.
+package impersonate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/audit"
+	"github.com/iamBelugaa/go-boilerplate/pkg/auth"
+	"github.com/iamBelugaa/go-boilerplate/pkg/securecookie"
+)
+
+// HeaderToken carries the impersonation token, next to the staff member's
+// own bearer token.
+const HeaderToken = "X-Impersonation-Token"
+
+// Scopes governing impersonation.
+const (
+	// ScopeImpersonate lets support staff obtain impersonation tokens.
+	ScopeImpersonate = "impersonate"
+
+	// ScopeGrant lets administrators grant impersonation on a user's behalf.
+	ScopeGrant = "impersonate:grant"
+)
+
+// Errors returned by the Impersonator.
+var (
+	ErrForbidden = errors.New("impersonate: not allowed")
+	ErrNoGrant   = errors.New("impersonate: no active grant")
+	ErrToken     = errors.New("impersonate: invalid or expired token")
+)
+
+// Grant is permission for Actor to impersonate Subject until ExpiresAt.
+type Grant struct {
+	Subject   string    `json:"subject"`
+	Actor     string    `json:"actor"`
+	Reason    string    `json:"reason"`
+	GrantedBy string    `json:"grantedBy"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Claims are sealed inside an impersonation token.
+type Claims struct {
+	Actor     string    `json:"actor"`
+	Subject   string    `json:"subject"`
+	Scopes    []string  `json:"scopes,omitempty"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Config configures an Impersonator.
+type Config struct {
+	// Keys seal tokens with AES-GCM, newest first (16, 24, or 32 bytes).
+	Keys [][]byte
+
+	// MaxTTL caps the lifetime of tokens (defaults to 30m).
+	MaxTTL time.Duration
+
+	// Audit records grants, tokens, and impersonated requests.
+	Audit *audit.Logger
+
+	// Scopes are granted to impersonated principals (e.g., read scopes);
+	// impersonation never carries the staff member's own scopes.
+	Scopes []string
+
+	// DestructiveMethods are refused while impersonating (defaults to DELETE).
+	DestructiveMethods []string
+}
+
+// Impersonator manages grants and tokens.
+type Impersonator struct {
+	cfg    Config
+	tokens *securecookie.Codec[Claims]
+	now    func() time.Time
+
+	mu     sync.Mutex
+	grants map[[2]string]Grant
+}
+
+// New constructs an Impersonator.
+func New(cfg Config) (*Impersonator, error) {
+	if cfg.MaxTTL <= 0 {
+		cfg.MaxTTL = 30 * time.Minute
+	}
+	if len(cfg.DestructiveMethods) == 0 {
+		cfg.DestructiveMethods = []string{http.MethodDelete}
+	}
+
+	tokens, err := securecookie.New[Claims]("impersonation", cfg.MaxTTL, cfg.Keys...)
+	if err != nil {
+		return nil, fmt.Errorf("impersonate: %w", err)
+	}
+
+	return &Impersonator{
+		cfg:    cfg,
+		tokens: tokens,
+		now:    time.Now,
+		grants: make(map[[2]string]Grant),
+	}, nil
+}
+
+// Grant lets actor impersonate subject for ttl. The authenticated
+// principal must be the subject or hold ScopeGrant.
+func (i *Impersonator) Grant(ctx context.Context, subject, actor, reason string, ttl time.Duration) (Grant, error) {
+	p := auth.PrincipalFromContext(ctx)
+	if p == nil || p.ImpersonatedBy != "" || (p.Subject != subject && !p.HasScope(ScopeGrant)) {
+		return Grant{}, ErrForbidden
+	}
+	if actor == subject {
+		return Grant{}, fmt.Errorf("%w: cannot grant impersonation of oneself", ErrForbidden)
+	}
+
+	g := Grant{
+		Subject:   subject,
+		Actor:     actor,
+		Reason:    reason,
+		GrantedBy: p.Subject,
+		ExpiresAt: i.now().Add(ttl).UTC(),
+	}
+
+	i.mu.Lock()
+	i.grants[[2]string{subject, actor}] = g
+	i.mu.Unlock()
+
+	i.record(ctx, p.Subject, "impersonation.grant", subject, g)
+	return g, nil
+}
+
+// Revoke withdraws the grant letting actor impersonate subject. Tokens
+// already issued stop working at once.
+func (i *Impersonator) Revoke(ctx context.Context, subject, actor string) error {
+	p := auth.PrincipalFromContext(ctx)
+	if p == nil || p.ImpersonatedBy != "" || (p.Subject != subject && !p.HasScope(ScopeGrant)) {
+		return ErrForbidden
+	}
+
+	i.mu.Lock()
+	delete(i.grants, [2]string{subject, actor})
+	i.mu.Unlock()
+
+	i.record(ctx, p.Subject, "impersonation.revoke", subject, map[string]string{"actor": actor})
+	return nil
+}
+
+// activeGrant returns the unexpired grant for actor to impersonate subject.
+func (i *Impersonator) activeGrant(subject, actor string) (Grant, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	g, ok := i.grants[[2]string{subject, actor}]
+	if !ok || !i.now().Before(g.ExpiresAt) {
+		return Grant{}, false
+	}
+	return g, true
+}
+
+// Issue returns a token letting the authenticated principal, who must hold
+// ScopeImpersonate and an active grant, act as subject. The token expires
+// after MaxTTL or with the grant, whichever comes first.
+func (i *Impersonator) Issue(ctx context.Context, subject string) (string, Claims, error) {
+	p := auth.PrincipalFromContext(ctx)
+	if p == nil || p.ImpersonatedBy != "" || !p.HasScope(ScopeImpersonate) {
+		return "", Claims{}, ErrForbidden
+	}
+
+	g, ok := i.activeGrant(subject, p.Subject)
+	if !ok {
+		return "", Claims{}, ErrNoGrant
+	}
+
+	expires := i.now().Add(i.cfg.MaxTTL).UTC()
+	if g.ExpiresAt.Before(expires) {
+		expires = g.ExpiresAt
+	}
+
+	claims := Claims{Actor: p.Subject, Subject: subject, Scopes: i.cfg.Scopes, ExpiresAt: expires}
+	token, err := i.tokens.Encode(claims)
+	if err != nil {
+		return "", Claims{}, fmt.Errorf("impersonate: %w", err)
+	}
+
+	i.record(ctx, p.Subject, "impersonation.start", subject, claims)
+	return token, claims, nil
+}
+
+// Middleware switches the request's principal to the impersonated user when
+// it carries a valid impersonation token issued to the authenticated staff
+// member, refusing destructive methods with 403 and auditing every request.
+// It must run after authentication; requests without a token pass through.
+func (i *Impersonator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get(HeaderToken)
+		if token == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		staff := auth.PrincipalFromContext(r.Context())
+		claims, err := i.tokens.Decode(token)
+		if err != nil || staff == nil || claims.Actor != staff.Subject || !i.now().Before(claims.ExpiresAt) {
+			writeError(w, http.StatusUnauthorized, ErrToken)
+			return
+		}
+		if _, ok := i.activeGrant(claims.Subject, claims.Actor); !ok {
+			writeError(w, http.StatusForbidden, ErrNoGrant)
+			return
+		}
+
+		ctx := r.Context()
+		meta := map[string]string{"method": r.Method, "path": r.URL.Path}
+
+		if slices.Contains(i.cfg.DestructiveMethods, r.Method) {
+			i.record(ctx, claims.Actor, "impersonation.denied", claims.Subject, meta)
+			writeError(w, http.StatusForbidden, fmt.Errorf("%w: %s requests are disabled while impersonating", ErrForbidden, r.Method))
+			return
+		}
+
+		i.record(ctx, claims.Actor, "impersonation.request", claims.Subject, meta)
+
+		ctx = auth.WithPrincipal(ctx, &auth.Principal{
+			Subject:        claims.Subject,
+			Scopes:         claims.Scopes,
+			ImpersonatedBy: claims.Actor,
+		})
+		w.Header().Set("X-Impersonated-By", claims.Actor)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Refuse wraps handlers that must never run while impersonating (e.g.,
+// changing credentials or payment details), whatever their method.
+func Refuse(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p := auth.PrincipalFromContext(r.Context()); p != nil && p.ImpersonatedBy != "" {
+			writeError(w, http.StatusForbidden, fmt.Errorf("%w: endpoint is disabled while impersonating", ErrForbidden))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// record audits an impersonation step. Audit failures are ignored so they
+// cannot lock support staff out; the request itself is still served.
+func (i *Impersonator) record(ctx context.Context, actor, action, subject string, after any) {
+	if i.cfg.Audit == nil {
+		return
+	}
+	_ = i.cfg.Audit.Record(context.WithoutCancel(ctx), audit.Event{
+		Actor:    actor,
+		Action:   action,
+		Resource: "user:" + subject,
+		After:    after,
+	})
+}
+
+// writeError writes a JSON error response.
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Set of error variables for token introspection.
+var (
+	ErrInactiveToken = errors.New("auth: token is not active")
+	ErrUnavailable   = errors.New("auth: introspection endpoint unavailable")
+)
+
+// IntrospectionResponse is the RFC 7662 token introspection response.
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Iss       string `json:"iss,omitempty"`
+
+	// Tier is the caller's plan or tier, an extension member of the IdP.
+	Tier string `json:"tier,omitempty"`
+}
+
+// Principal converts an active response into a Principal.
+func (r *IntrospectionResponse) Principal() *Principal {
+	return &Principal{
+		Subject:  r.Sub,
+		ClientID: r.ClientID,
+		Username: r.Username,
+		Scopes:   strings.Fields(r.Scope),
+		Tier:     r.Tier,
+	}
+}
+
+// IntrospectorConfig configures an Introspector.
+type IntrospectorConfig struct {
+	// Endpoint is the IdP's RFC 7662 introspection URL.
+	Endpoint string
+
+	// ClientID and ClientSecret authenticate this service to the endpoint.
+	ClientID     string
+	ClientSecret string
+
+	// CacheTTL bounds how long an active response is reused. Responses are
+	// never reused beyond the token's own expiry.
+	CacheTTL time.Duration
+
+	// NegativeTTL is how long an inactive response is reused.
+	NegativeTTL time.Duration
+
+	// StaleTTL is how long past CacheTTL a cached active response may still
+	// be served while the endpoint is unavailable.
+	StaleTTL time.Duration
+
+	// Client is the HTTP client used for introspection requests.
+	Client *http.Client
+}
+
+// cacheEntry is a cached introspection response.
+type cacheEntry struct {
+	resp      *IntrospectionResponse
+	freshTill time.Time
+	staleTill time.Time
+}
+
+// Introspector validates opaque access tokens via RFC 7662 introspection,
+// caching responses and degrading gracefully when the endpoint is down.
+type Introspector struct {
+	cfg   IntrospectorConfig
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]cacheEntry
+	now   func() time.Time
+}
+
+// NewIntrospector constructs an Introspector.
+func NewIntrospector(cfg IntrospectorConfig) *Introspector {
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &Introspector{cfg: cfg, cache: make(map[[sha256.Size]byte]cacheEntry), now: time.Now}
+}
+
+// Introspect returns the principal for an active token. It returns
+// ErrInactiveToken for inactive tokens and ErrUnavailable when the endpoint
+// cannot be reached and no usable cached response exists.
+func (i *Introspector) Introspect(ctx context.Context, token string) (*Principal, error) {
+	key := sha256.Sum256([]byte(token))
+	now := i.now()
+
+	i.mu.Lock()
+	entry, cached := i.cache[key]
+	i.mu.Unlock()
+
+	if cached && now.Before(entry.freshTill) {
+		return principalOf(entry.resp)
+	}
+
+	resp, err := i.fetch(ctx, token)
+	if err != nil {
+		if cached && entry.resp.Active && now.Before(entry.staleTill) {
+			return principalOf(entry.resp)
+		}
+		return nil, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+
+	i.store(key, resp, now)
+	return principalOf(resp)
+}
+
+// principalOf converts a response into a principal or ErrInactiveToken.
+func principalOf(resp *IntrospectionResponse) (*Principal, error) {
+	if !resp.Active {
+		return nil, ErrInactiveToken
+	}
+	return resp.Principal(), nil
+}
+
+// store caches resp, bounding its lifetime by the token's expiry.
+func (i *Introspector) store(key [sha256.Size]byte, resp *IntrospectionResponse, now time.Time) {
+	ttl := i.cfg.CacheTTL
+	if !resp.Active {
+		ttl = i.cfg.NegativeTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	entry := cacheEntry{resp: resp, freshTill: now.Add(ttl), staleTill: now.Add(ttl + i.cfg.StaleTTL)}
+	if resp.Exp > 0 {
+		exp := time.Unix(resp.Exp, 0)
+		if exp.Before(entry.freshTill) {
+			entry.freshTill = exp
+		}
+		if exp.Before(entry.staleTill) {
+			entry.staleTill = exp
+		}
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for k, e := range i.cache {
+		if now.After(e.staleTill) {
+			delete(i.cache, k)
+		}
+	}
+	i.cache[key] = entry
+}
+
+// fetch calls the introspection endpoint.
+func (i *Introspector) fetch(ctx context.Context, token string) (*IntrospectionResponse, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.cfg.Endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(i.cfg.ClientID), url.QueryEscape(i.cfg.ClientSecret))
+
+	resp, err := i.cfg.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var out IntrospectionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &out, nil
+}
+
+// Middleware authenticates requests with an introspected bearer token,
+// storing the principal in the request context. Missing or inactive tokens
+// are rejected with 401 and an unreachable IdP with 503.
+func (i *Introspector) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := BearerToken(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		principal, err := i.Introspect(r.Context(), token)
+		switch {
+		case errors.Is(err, ErrInactiveToken):
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		case err != nil:
+			w.Header().Set("Retry-After", "5")
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	})
+}
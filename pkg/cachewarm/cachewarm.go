@@ -0,0 +1,150 @@
+// Package cachewarm warms caches at startup so the first requests after a
+// deploy do not pay for cold caches. Modules register loaders (critical
+// configuration, reference data, hot keys) that run concurrently within a
+// time budget, optionally gating readiness on their completion.
+package cachewarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/lifecycle"
+)
+
+// Loader loads data into a cache. It must return promptly once ctx is done.
+type Loader func(ctx context.Context) error
+
+// Result reports the outcome of a single loader.
+type Result struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Report summarizes a warmup run.
+type Report struct {
+	Results  []Result      `json:"results"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Err returns the joined errors of the failed loaders, or nil.
+func (r Report) Err() error {
+	var errs []error
+	for _, res := range r.Results {
+		if res.Error != "" {
+			errs = append(errs, fmt.Errorf("cachewarm: %s: %s", res.Name, res.Error))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Warmer holds the registered loaders.
+type Warmer struct {
+	mu      sync.Mutex
+	loaders map[string]Loader
+}
+
+// New constructs a Warmer without loaders.
+func New() *Warmer {
+	return &Warmer{loaders: make(map[string]Loader)}
+}
+
+// Register adds a loader under a unique name, replacing any loader
+// previously registered with it.
+func (w *Warmer) Register(name string, fn Loader) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.loaders[name] = fn
+}
+
+// Run executes every loader concurrently and waits for them, for at most
+// budget (zero for no limit). Loaders still running when the budget is
+// spent see their context cancelled and are reported as failed.
+func (w *Warmer) Run(ctx context.Context, budget time.Duration) Report {
+	w.mu.Lock()
+	loaders := make(map[string]Loader, len(w.loaders))
+	for name, fn := range w.loaders {
+		loaders[name] = fn
+	}
+	w.mu.Unlock()
+
+	if budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, budget)
+		defer cancel()
+	}
+
+	start := time.Now()
+	results := make(chan Result, len(loaders))
+
+	var wg sync.WaitGroup
+	for name, fn := range loaders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- load(ctx, name, fn)
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	report := Report{Duration: time.Since(start)}
+	for res := range results {
+		report.Results = append(report.Results, res)
+	}
+	sort.Slice(report.Results, func(i, j int) bool {
+		return report.Results[i].Name < report.Results[j].Name
+	})
+	return report
+}
+
+// load runs a single loader, recovering from panics so one broken loader
+// cannot take the process down during startup.
+func load(ctx context.Context, name string, fn Loader) (res Result) {
+	start := time.Now()
+	res.Name = name
+
+	defer func() {
+		if r := recover(); r != nil {
+			res.Error = fmt.Sprintf("panic: %v", r)
+		}
+		res.Duration = time.Since(start)
+	}()
+
+	err := fn(ctx)
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// PreTrafficHook returns a lifecycle hook running the warmup within budget.
+// With gate set, readiness waits for the warmup and fails if any loader
+// failed; otherwise the warmup runs in the background and its report is
+// passed to done, which may be nil.
+func (w *Warmer) PreTrafficHook(budget time.Duration, gate bool, done func(Report)) lifecycle.HookFunc {
+	return func(ctx context.Context) error {
+		if !gate {
+			go func() {
+				report := w.Run(context.WithoutCancel(ctx), budget)
+				if done != nil {
+					done(report)
+				}
+			}()
+			return nil
+		}
+
+		report := w.Run(ctx, budget)
+		if done != nil {
+			done(report)
+		}
+		return report.Err()
+	}
+}
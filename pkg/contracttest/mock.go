@@ -0,0 +1,120 @@
+package contracttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MockOptions tunes the behaviour of a mock server.
+type MockOptions struct {
+	// Latency delays every response, plus up to Jitter at random.
+	Latency, Jitter time.Duration
+
+	// ErrorRate is the fraction of requests failing with 500, from 0 to 1.
+	ErrorRate float64
+}
+
+// Mock returns a handler serving every documented operation with an
+// example response, taken from the spec's examples or synthesized from
+// the response schema, so clients can be built before the real handlers
+// exist. Requests may choose another documented response with a
+// "Prefer: code=404" header. Operations whose paths http.ServeMux cannot
+// express are reported as an error.
+func (s *Spec) Mock(opts MockOptions) (http.Handler, error) {
+	mux := http.NewServeMux()
+
+	var errs []string
+	for _, op := range s.Operations {
+		if err := register(mux, op.Method+" "+op.Path, s.mockOperation(op, opts)); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", op.ID, err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("contracttest: cannot mock:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return mux, nil
+}
+
+// mockOperation returns the handler answering op.
+func (s *Spec) mockOperation(op Operation, opts MockOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		delay := opts.Latency
+		if opts.Jitter > 0 {
+			delay += rand.N(opts.Jitter)
+		}
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		status := mockStatus(op, r.Header.Get("Prefer"))
+		if opts.ErrorRate > 0 && rand.Float64() < opts.ErrorRate {
+			status = http.StatusInternalServerError
+		}
+
+		schema, documented := op.response(status)
+		if !documented && status == http.StatusInternalServerError {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "mock: injected failure"})
+			return
+		}
+
+		w.Header().Set("X-Mock", "true")
+		if schema == nil || status == http.StatusNoContent {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(s.generate(schema, 0))
+	}
+}
+
+// mockStatus returns the status requested with "Prefer: code=N" when op
+// documents it, and op's first documented success status otherwise.
+func mockStatus(op Operation, prefer string) int {
+	for _, part := range strings.Split(prefer, ",") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(part), "code="); ok {
+			if code, err := strconv.Atoi(v); err == nil {
+				if _, documented := op.response(code); documented {
+					return code
+				}
+			}
+		}
+	}
+
+	codes := make([]int, 0, len(op.Responses))
+	for key := range op.Responses {
+		key = strings.Replace(key, "XX", "00", 1)
+		if code, err := strconv.Atoi(key); err == nil && code >= 200 && code < 300 {
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) == 0 {
+		return http.StatusOK
+	}
+	sort.Ints(codes)
+	return codes[0]
+}
+
+// register adds h to mux, turning the panic ServeMux raises on invalid or
+// conflicting patterns into an error.
+func register(mux *http.ServeMux, pattern string, h http.Handler) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("%v", p)
+		}
+	}()
+	mux.Handle(pattern, h)
+	return nil
+}
@@ -0,0 +1,200 @@
+package contracttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Case is a single generated request and its expected outcome.
+type Case struct {
+	Name      string
+	Operation Operation
+	Path      string
+	Query     url.Values
+	Body      any
+
+	// ExpectClientError is set for out-of-bounds cases, which must be
+	// rejected with a 4xx status.
+	ExpectClientError bool
+}
+
+// Cases generates the happy-path, boundary, and out-of-bounds cases for
+// every operation in the spec.
+func (s *Spec) Cases() []Case {
+	var cases []Case
+
+	for _, op := range s.Operations {
+		pathValues := make(map[string]any)
+		query := url.Values{}
+		for _, p := range op.Parameters {
+			v := p.Example
+			if v == nil {
+				v = s.generate(p.Schema, 0)
+			}
+			switch {
+			case p.In == "path":
+				pathValues[p.Name] = v
+			case p.In == "query" && p.Required:
+				query.Set(p.Name, format(v))
+			}
+		}
+
+		var body any
+		if op.RequestBody != nil {
+			body = s.generate(op.RequestBody, 0)
+		}
+
+		base := Case{Operation: op, Path: expand(op.Path, pathValues), Query: query, Body: body}
+
+		happy := base
+		happy.Name = op.ID + "/happy"
+		cases = append(cases, happy)
+
+		for _, p := range op.Parameters {
+			if p.In != "query" {
+				continue
+			}
+			for _, b := range s.boundaries(p.Schema) {
+				c := base
+				c.Name = fmt.Sprintf("%s/query.%s/%s", op.ID, p.Name, b.name)
+				c.Query = cloneValues(query)
+				c.Query.Set(p.Name, format(b.value))
+				c.ExpectClientError = !b.valid
+				cases = append(cases, c)
+			}
+		}
+
+		obj, _ := body.(map[string]any)
+		props, _ := s.resolve(op.RequestBody)["properties"].(map[string]any)
+		for _, name := range sortedKeys(props) {
+			for _, b := range s.boundaries(props[name]) {
+				c := base
+				c.Name = fmt.Sprintf("%s/body.%s/%s", op.ID, name, b.name)
+				c.Body = cloneWith(obj, name, b.value)
+				c.ExpectClientError = !b.valid
+				cases = append(cases, c)
+			}
+		}
+	}
+
+	return cases
+}
+
+// Run executes every generated case against handler as a subtest of t.
+func Run(t *testing.T, spec *Spec, handler http.Handler) {
+	t.Helper()
+
+	for _, c := range spec.Cases() {
+		t.Run(c.Name, func(t *testing.T) {
+			if err := spec.Check(handler, c); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// Check executes a single case against handler and verifies the response
+// status and body against the operation's documented responses.
+func (s *Spec) Check(handler http.Handler, c Case) error {
+	var body bytes.Buffer
+	if c.Body != nil {
+		if err := json.NewEncoder(&body).Encode(c.Body); err != nil {
+			return err
+		}
+	}
+
+	target := c.Path
+	if len(c.Query) > 0 {
+		target += "?" + c.Query.Encode()
+	}
+
+	req := httptest.NewRequest(c.Operation.Method, target, &body)
+	req.Header.Set("Accept", "application/json")
+	if c.Body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	status := rec.Code
+	switch {
+	case c.ExpectClientError && (status < 400 || status >= 500):
+		return fmt.Errorf("%s %s: expected a 4xx status, got %d", c.Operation.Method, target, status)
+	case !c.ExpectClientError && status >= 500:
+		return fmt.Errorf("%s %s: unexpected server error %d", c.Operation.Method, target, status)
+	}
+
+	schema, documented := c.Operation.response(status)
+	if !documented {
+		return fmt.Errorf("%s %s: status %d is not documented", c.Operation.Method, target, status)
+	}
+
+	if schema == nil || rec.Body.Len() == 0 {
+		return nil
+	}
+
+	var payload any
+	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+		return fmt.Errorf("%s %s: response is not valid JSON: %w", c.Operation.Method, target, err)
+	}
+
+	if errs := s.validate("$", payload, schema, 0); len(errs) > 0 {
+		return fmt.Errorf("%s %s: response does not match schema:\n  %s", c.Operation.Method, target, strings.Join(errs, "\n  "))
+	}
+	return nil
+}
+
+// response returns the documented schema for status, honouring "2XX"-style
+// ranges and the "default" response.
+func (op Operation) response(status int) (Schema, bool) {
+	code := strconv.Itoa(status)
+	for _, key := range []string{code, code[:1] + "XX", "default"} {
+		if schema, ok := op.Responses[key]; ok {
+			return schema, true
+		}
+	}
+	return nil, false
+}
+
+// expand substitutes {name} path templates with URL-escaped values.
+func expand(path string, values map[string]any) string {
+	for name, v := range values {
+		path = strings.ReplaceAll(path, "{"+name+"}", url.PathEscape(format(v)))
+	}
+	return path
+}
+
+// format renders a generated value as a query or path string.
+func format(v any) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// cloneValues returns a deep copy of v.
+func cloneValues(v url.Values) url.Values {
+	out := make(url.Values, len(v))
+	for k, vals := range v {
+		out[k] = append([]string(nil), vals...)
+	}
+	return out
+}
+
+// cloneWith returns a shallow copy of obj with key set to value.
+func cloneWith(obj map[string]any, key string, value any) map[string]any {
+	out := make(map[string]any, len(obj)+1)
+	for k, v := range obj {
+		out[k] = v
+	}
+	out[key] = value
+	return out
+}
@@ -0,0 +1,274 @@
+package contracttest
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// maxDepth bounds recursion through self-referencing schemas.
+const maxDepth = 8
+
+// generate synthesizes a value that satisfies schema, preferring declared
+// examples, defaults, and enum values.
+func (s *Spec) generate(raw any, depth int) any {
+	schema := s.resolve(raw)
+	if schema == nil || depth > maxDepth {
+		return nil
+	}
+
+	if v, ok := schema["example"]; ok {
+		return v
+	}
+	if v, ok := schema["default"]; ok {
+		return v
+	}
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+		return enum[0]
+	}
+
+	switch schema["type"] {
+	case "string":
+		return generateString(schema)
+	case "integer":
+		return math.Round(generateNumber(schema))
+	case "number":
+		return generateNumber(schema)
+	case "boolean":
+		return true
+	case "array":
+		n := max(1, int(number(schema, "minItems", 0)))
+		items := make([]any, n)
+		for i := range items {
+			items[i] = s.generate(schema["items"], depth+1)
+		}
+		return items
+	default:
+		props, _ := schema["properties"].(map[string]any)
+		obj := make(map[string]any, len(props))
+		for name, prop := range props {
+			if v := s.generate(prop, depth+1); v != nil {
+				obj[name] = v
+			}
+		}
+		return obj
+	}
+}
+
+// generateString returns a string matching the schema's format and length.
+func generateString(schema map[string]any) string {
+	var str string
+	switch schema["format"] {
+	case "date-time":
+		str = "2024-01-01T00:00:00Z"
+	case "date":
+		str = "2024-01-01"
+	case "uuid":
+		str = "00000000-0000-4000-8000-000000000000"
+	case "email":
+		str = "user@example.com"
+	case "uri", "url":
+		str = "https://example.com"
+	default:
+		str = "example"
+	}
+
+	if minLen := int(number(schema, "minLength", 0)); len(str) < minLen {
+		str += strings.Repeat("x", minLen-len(str))
+	}
+	if maxLen, ok := schema["maxLength"]; ok && len(str) > int(toFloat(maxLen)) {
+		str = str[:int(toFloat(maxLen))]
+	}
+	return str
+}
+
+// generateNumber returns a number within the schema's bounds.
+func generateNumber(schema map[string]any) float64 {
+	lo, hasLo := schema["minimum"]
+	hi, hasHi := schema["maximum"]
+
+	switch {
+	case hasLo && hasHi:
+		return (toFloat(lo) + toFloat(hi)) / 2
+	case hasLo:
+		return toFloat(lo) + 1
+	case hasHi:
+		return toFloat(hi) - 1
+	default:
+		return 1
+	}
+}
+
+// boundary is a value at or just beyond one of a schema's limits.
+type boundary struct {
+	name  string
+	value any
+	valid bool
+}
+
+// boundaries returns the values at and beyond every declared limit of a
+// string, integer, or number schema.
+func (s *Spec) boundaries(raw any) []boundary {
+	schema := s.resolve(raw)
+	if schema == nil {
+		return nil
+	}
+
+	var out []boundary
+	switch schema["type"] {
+	case "string":
+		if v, ok := schema["minLength"]; ok {
+			n := int(toFloat(v))
+			out = append(out, boundary{"minLength", strings.Repeat("a", n), true})
+			if n > 0 {
+				out = append(out, boundary{"belowMinLength", strings.Repeat("a", n-1), false})
+			}
+		}
+		if v, ok := schema["maxLength"]; ok {
+			n := int(toFloat(v))
+			out = append(out,
+				boundary{"maxLength", strings.Repeat("a", n), true},
+				boundary{"aboveMaxLength", strings.Repeat("a", n+1), false},
+			)
+		}
+
+	case "integer", "number":
+		step := 1.0
+		if schema["type"] == "number" {
+			step = 0.5
+		}
+		if v, ok := schema["minimum"]; ok {
+			out = append(out,
+				boundary{"minimum", toFloat(v), true},
+				boundary{"belowMinimum", toFloat(v) - step, false},
+			)
+		}
+		if v, ok := schema["maximum"]; ok {
+			out = append(out,
+				boundary{"maximum", toFloat(v), true},
+				boundary{"aboveMaximum", toFloat(v) + step, false},
+			)
+		}
+	}
+	return out
+}
+
+// validate checks value against schema and returns every violation found,
+// each prefixed with its JSON path.
+func (s *Spec) validate(path string, value any, raw any, depth int) []string {
+	schema := s.resolve(raw)
+	if schema == nil || depth > maxDepth {
+		return nil
+	}
+
+	if value == nil {
+		if nullable, _ := schema["nullable"].(bool); nullable || schema["type"] == nil {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s: must not be null", path)}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && !contains(enum, value) {
+		return []string{fmt.Sprintf("%s: %v is not one of %v", path, value, enum)}
+	}
+
+	var errs []string
+	switch schema["type"] {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: must be an object", path)}
+		}
+
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := obj[fmt.Sprint(name)]; !ok {
+				errs = append(errs, fmt.Sprintf("%s.%v: is required", path, name))
+			}
+		}
+
+		props, _ := schema["properties"].(map[string]any)
+		for name, v := range obj {
+			if prop, ok := props[name]; ok {
+				errs = append(errs, s.validate(path+"."+name, v, prop, depth+1)...)
+			} else if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+				errs = append(errs, fmt.Sprintf("%s.%s: is not allowed", path, name))
+			}
+		}
+
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: must be an array", path)}
+		}
+		for i, item := range items {
+			errs = append(errs, s.validate(fmt.Sprintf("%s[%d]", path, i), item, schema["items"], depth+1)...)
+		}
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return []string{fmt.Sprintf("%s: must be a string", path)}
+		}
+		if v, ok := schema["minLength"]; ok && len(str) < int(toFloat(v)) {
+			errs = append(errs, fmt.Sprintf("%s: shorter than %v", path, v))
+		}
+		if v, ok := schema["maxLength"]; ok && len(str) > int(toFloat(v)) {
+			errs = append(errs, fmt.Sprintf("%s: longer than %v", path, v))
+		}
+
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok {
+			return []string{fmt.Sprintf("%s: must be a number", path)}
+		}
+		if schema["type"] == "integer" && n != math.Trunc(n) {
+			errs = append(errs, fmt.Sprintf("%s: must be an integer", path))
+		}
+		if v, ok := schema["minimum"]; ok && n < toFloat(v) {
+			errs = append(errs, fmt.Sprintf("%s: less than %v", path, v))
+		}
+		if v, ok := schema["maximum"]; ok && n > toFloat(v) {
+			errs = append(errs, fmt.Sprintf("%s: greater than %v", path, v))
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			errs = append(errs, fmt.Sprintf("%s: must be a boolean", path))
+		}
+	}
+
+	return errs
+}
+
+// number returns the numeric schema keyword or def when it is absent.
+func number(schema map[string]any, key string, def float64) float64 {
+	if v, ok := schema[key]; ok {
+		return toFloat(v)
+	}
+	return def
+}
+
+// toFloat converts YAML/JSON decoded numbers to float64.
+func toFloat(v any) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	default:
+		return 0
+	}
+}
+
+// contains reports whether enum holds value, comparing numbers numerically.
+func contains(enum []any, value any) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,222 @@
+// Package database opens the connection pools of the databases described
+// by the configuration. Open builds the DSN, applies the pool limits, and
+// pings the server, retrying with backoff while it is still starting (as
+// it often is next to the service in containers and CI):
+//
+//	db, err := database.Open(ctx, conf.Database, database.WithLogger(log))
+//	if err != nil {
+//		return err
+//	}
+//	group.Add(lifecycle.Component{Name: "database", Stop: db.Close})
+//
+// DB.WithinTx runs a unit of work in a transaction carried by the context,
+// which repositories querying through DB.Querier join without being
+// passed the transaction. OpenCluster adds the read replicas configured
+// under replication, serving read-only queries while they are healthy.
+//
+// The driver is registered by a blank import in the main package, such as
+// _ "github.com/jackc/pgx/v5/stdlib" for the default driver "pgx". Open
+// also fits sharding.NewRouter, with a context:
+//
+//	router, err := sharding.NewRouter(conf, func(_ string, c *config.Database) (*database.DB, error) {
+//		return database.Open(ctx, c)
+//	})
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// Defaults of Open.
+const (
+	DefaultDriver       = "pgx"
+	DefaultPingAttempts = 10
+	DefaultPingBackoff  = 250 * time.Millisecond
+	DefaultMaxBackoff   = 5 * time.Second
+	DefaultPingTimeout  = 5 * time.Second
+)
+
+// ErrDriverNotRegistered is returned by Open when no database/sql driver
+// is registered under the driver name, as when the main package lacks the
+// driver's blank import.
+var ErrDriverNotRegistered = errors.New("database: driver not registered")
+
+// Option customizes Open.
+type Option func(*options)
+
+// options holds the settings applied by Options.
+type options struct {
+	driver      string
+	log         *zap.Logger
+	attempts    int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	pingTimeout time.Duration
+}
+
+// WithDriver opens the pool with the database/sql driver registered as
+// name (defaults to DefaultDriver).
+func WithDriver(name string) Option {
+	return func(o *options) {
+		o.driver = name
+	}
+}
+
+// WithLogger logs failed startup pings to log.
+func WithLogger(log *zap.Logger) Option {
+	return func(o *options) {
+		o.log = log
+	}
+}
+
+// WithPingRetry pings the server up to attempts times at startup, waiting
+// backoff after the first failure and doubling the wait, with jitter, up
+// to maxBackoff. An attempts of 1 disables retries.
+func WithPingRetry(attempts int, backoff, maxBackoff time.Duration) Option {
+	return func(o *options) {
+		o.attempts = attempts
+		o.backoff = backoff
+		o.maxBackoff = maxBackoff
+	}
+}
+
+// WithPingTimeout bounds each startup ping (defaults to
+// DefaultPingTimeout).
+func WithPingTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.pingTimeout = d
+	}
+}
+
+// DB is a connection pool.
+type DB struct {
+	*sql.DB
+}
+
+// DSN returns the connection URL of conf, such as
+// "postgres://app:secret@db:5432/app?sslmode=require". It contains the
+// password: never log it.
+func DSN(conf *config.Database) string {
+	u := url.URL{
+		Scheme: "postgres",
+		Host:   net.JoinHostPort(conf.Host, strconv.Itoa(conf.Port)),
+		Path:   "/" + conf.Name,
+	}
+	if conf.Password != "" {
+		u.User = url.UserPassword(conf.User, conf.Password)
+	} else {
+		u.User = url.User(conf.User)
+	}
+	if conf.SSLMode != "" {
+		u.RawQuery = url.Values{"sslmode": {conf.SSLMode}}.Encode()
+	}
+	return u.String()
+}
+
+// Open opens the pool of conf, sized by its connection limits and
+// lifetimes, and pings the server until it answers, the attempts run out,
+// or ctx ends. The pool is closed when the server cannot be reached.
+func Open(ctx context.Context, conf *config.Database, opts ...Option) (*DB, error) {
+	o := newOptions(opts)
+	db, err := openPool(conf, o)
+	if err != nil {
+		return nil, err
+	}
+	if err := ping(ctx, db, o); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("database: ping %s/%s: %w", conf.Host, conf.Name, err)
+	}
+	return &DB{DB: db}, nil
+}
+
+// newOptions returns the defaults of Open with opts applied.
+func newOptions(opts []Option) options {
+	o := options{
+		driver:      DefaultDriver,
+		log:         zap.NewNop(),
+		attempts:    DefaultPingAttempts,
+		backoff:     DefaultPingBackoff,
+		maxBackoff:  DefaultMaxBackoff,
+		pingTimeout: DefaultPingTimeout,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.attempts < 1 {
+		o.attempts = 1
+	}
+	return o
+}
+
+// openPool opens the pool of conf, without connecting yet.
+func openPool(conf *config.Database, o options) (*sql.DB, error) {
+	if !slices.Contains(sql.Drivers(), o.driver) {
+		return nil, fmt.Errorf("%w: %q (blank-import it in the main package, e.g. github.com/jackc/pgx/v5/stdlib)", ErrDriverNotRegistered, o.driver)
+	}
+	db, err := sql.Open(o.driver, DSN(conf))
+	if err != nil {
+		return nil, fmt.Errorf("database: open %s/%s: %w", conf.Host, conf.Name, err)
+	}
+	db.SetMaxOpenConns(conf.MaxOpenConns)
+	db.SetMaxIdleConns(conf.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(conf.ConnMaxLifetime) * time.Second)
+	db.SetConnMaxIdleTime(time.Duration(conf.ConnMaxIdleTime) * time.Second)
+	return db, nil
+}
+
+// ping pings db until it answers, the attempts of o run out, or ctx ends.
+func ping(ctx context.Context, db *sql.DB, o options) error {
+	backoff := o.backoff
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, o.pingTimeout)
+		err := db.PingContext(pingCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt == o.attempts || ctx.Err() != nil {
+			return err
+		}
+
+		// Jitter keeps replicas restarted together from pinging in step.
+		wait := backoff/2 + rand.N(backoff/2+1)
+		o.log.Warn("database not reachable, retrying",
+			zap.Int("attempt", attempt),
+			zap.Duration("wait", wait),
+			zap.Error(err),
+		)
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(wait):
+		}
+		backoff = min(backoff*2, o.maxBackoff)
+	}
+}
+
+// Close closes the pool, waiting for the queries in flight to finish
+// until ctx ends, so it can be the Stop hook of a lifecycle.Component.
+// The pool is closed either way; the queries left are abandoned.
+func (db *DB) Close(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() { done <- db.DB.Close() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("database: close: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,185 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// DefaultHealthCheckInterval is how often replicas are pinged.
+const DefaultHealthCheckInterval = 5 * time.Second
+
+// replica is a read replica and whether its last ping succeeded.
+type replica struct {
+	name    string
+	db      *DB
+	healthy atomic.Bool
+}
+
+// Cluster is the primary database and its read replicas. Writes and
+// transactions go to the primary; read-only queries go to the healthy
+// replicas in turn, or to the primary when none is:
+//
+//	cluster, err := database.OpenCluster(ctx, conf, database.WithLogger(log))
+//	...
+//	group.Add(lifecycle.Component{Name: "database", Run: cluster.Run, Stop: cluster.Close})
+//
+//	rows, err := cluster.ReadQuerier(ctx).QueryContext(ctx, `SELECT ...`)
+//
+// Replicas lag behind the primary: read what was just written, such as
+// the resource a request created, through Querier instead.
+type Cluster struct {
+	primary  *DB
+	replicas []*replica
+	next     atomic.Uint64
+	interval time.Duration
+	timeout  time.Duration
+	log      *zap.Logger
+}
+
+// OpenCluster opens the primary database of conf, like Open, and the
+// replicas named by conf.Replication, if any. Replicas down at startup do
+// not fail it: they serve queries once a health check reaches them.
+func OpenCluster(ctx context.Context, conf *config.Config, opts ...Option) (*Cluster, error) {
+	primary, err := Open(ctx, conf.Database, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
+	c := &Cluster{
+		primary:  primary,
+		interval: DefaultHealthCheckInterval,
+		timeout:  o.pingTimeout,
+		log:      o.log,
+	}
+	if conf.Replication == nil {
+		return c, nil
+	}
+	if conf.Replication.HealthCheckInterval > 0 {
+		c.interval = conf.Replication.HealthCheckInterval
+	}
+
+	for _, name := range conf.Replication.Replicas {
+		db, ok := conf.DB(name)
+		if !ok {
+			_ = c.Close(context.WithoutCancel(ctx))
+			return nil, fmt.Errorf("database: replica %q is not a configured database", name)
+		}
+		pool, err := openPool(db, o)
+		if err != nil {
+			_ = c.Close(context.WithoutCancel(ctx))
+			return nil, err
+		}
+		r := &replica{name: name, db: &DB{DB: pool}}
+		r.healthy.Store(true) // until the check below, so failures are logged
+		c.replicas = append(c.replicas, r)
+	}
+	c.checkReplicas(ctx)
+	return c, nil
+}
+
+// Primary returns the primary database.
+func (c *Cluster) Primary() *DB {
+	return c.primary
+}
+
+// Reader returns the next healthy replica in turn, or the primary when no
+// replica is healthy.
+func (c *Cluster) Reader() *DB {
+	n := len(c.replicas)
+	if n == 0 {
+		return c.primary
+	}
+	start := int(c.next.Add(1) % uint64(n))
+	for i := range n {
+		if r := c.replicas[(start+i)%n]; r.healthy.Load() {
+			return r.db
+		}
+	}
+	return c.primary
+}
+
+// Querier returns the transaction of ctx started by WithinTx, or the
+// primary outside one.
+func (c *Cluster) Querier(ctx context.Context) Querier {
+	return c.primary.Querier(ctx)
+}
+
+// ReadQuerier returns the querier for read-only queries: the transaction
+// of ctx, whose reads must see its writes, or Reader outside one.
+func (c *Cluster) ReadQuerier(ctx context.Context) Querier {
+	if c.primary.InTx(ctx) {
+		return c.primary.Querier(ctx)
+	}
+	return c.Reader().DB
+}
+
+// WithinTx runs fn in a transaction of the primary (see DB.WithinTx).
+func (c *Cluster) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return c.primary.WithinTx(ctx, fn)
+}
+
+// Run pings the replicas at the health check interval until ctx is
+// cancelled, taking those failing out of rotation and returning those
+// answering again, so it can be the Run hook of a lifecycle.Component.
+func (c *Cluster) Run(ctx context.Context) error {
+	if len(c.replicas) == 0 {
+		<-ctx.Done()
+		return nil
+	}
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			c.checkReplicas(ctx)
+		}
+	}
+}
+
+// checkReplicas pings every replica and records whether it answered,
+// logging changes.
+func (c *Cluster) checkReplicas(ctx context.Context) {
+	for _, r := range c.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		err := r.db.PingContext(pingCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		healthy := err == nil
+		if r.healthy.Swap(healthy) == healthy {
+			continue
+		}
+		if healthy {
+			c.log.Info("database replica healthy", zap.String("replica", r.name))
+		} else {
+			c.log.Warn("database replica unhealthy, reading from the others", zap.String("replica", r.name), zap.Error(err))
+		}
+	}
+}
+
+// Close closes the replicas and the primary, like DB.Close.
+func (c *Cluster) Close(ctx context.Context) error {
+	var errs []error
+	for _, r := range c.replicas {
+		if err := r.db.Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("replica %q: %w", r.name, err))
+		}
+	}
+	if err := c.primary.Close(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Querier runs queries; *sql.DB, *sql.Conn, and *sql.Tx implement it.
+// Repositories take their Querier from DB.Querier, so they join the
+// transaction of the context when there is one.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// txKey is the context key of the transaction of a DB.
+type txKey struct{ db *DB }
+
+// txState is a transaction in progress and its savepoints.
+type txState struct {
+	tx         *sql.Tx
+	savepoints int
+}
+
+// Querier returns the transaction of ctx started by WithinTx, or the pool
+// outside one.
+func (db *DB) Querier(ctx context.Context) Querier {
+	if st, ok := ctx.Value(txKey{db}).(*txState); ok {
+		return st.tx
+	}
+	return db.DB
+}
+
+// InTx reports whether ctx carries a transaction of db.
+func (db *DB) InTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{db}).(*txState)
+	return ok
+}
+
+// WithinTx runs fn in a transaction, carried by the context fn receives,
+// which is committed when fn returns nil and rolled back when it returns
+// an error or panics:
+//
+//	err := db.WithinTx(ctx, func(ctx context.Context) error {
+//		if err := orders.Create(ctx, order); err != nil {
+//			return err
+//		}
+//		return stock.Reserve(ctx, order.Items)
+//	})
+//
+// Calls nested in fn run in a savepoint of the same transaction, so a
+// failing nested call is undone without aborting the outer one. The
+// transaction, like *sql.Tx, is not safe for concurrent use.
+func (db *DB) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return db.WithinTxOptions(ctx, nil, fn)
+}
+
+// WithinTxOptions is WithinTx beginning the transaction with opts, such as
+// an isolation level. Nested calls ignore opts.
+func (db *DB) WithinTxOptions(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	if st, ok := ctx.Value(txKey{db}).(*txState); ok {
+		return st.savepoint(ctx, fn)
+	}
+
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("database: begin: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{db}, &txState{tx: tx})); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			return errors.Join(err, fmt.Errorf("database: rollback: %w", rbErr))
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("database: commit: %w", err)
+	}
+	return nil
+}
+
+// savepoint runs fn in a savepoint of the transaction, released when fn
+// returns nil and rolled back to otherwise.
+func (st *txState) savepoint(ctx context.Context, fn func(ctx context.Context) error) error {
+	st.savepoints++
+	name := "sp_" + strconv.Itoa(st.savepoints)
+	if _, err := st.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("database: savepoint: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_, _ = st.tx.ExecContext(context.WithoutCancel(ctx), "ROLLBACK TO SAVEPOINT "+name)
+			panic(p)
+		}
+	}()
+
+	if err := fn(ctx); err != nil {
+		if _, rbErr := st.tx.ExecContext(context.WithoutCancel(ctx), "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("database: rollback to savepoint: %w", rbErr))
+		}
+		return err
+	}
+	if _, err := st.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("database: release savepoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+// Package degrade keeps designated routes serving cached or reduced
+// responses while a critical dependency (e.g., the database during a
+// failover) is unavailable, instead of failing every request. Degraded mode
+// is time-boxed: once a dependency has been failing for longer than allowed
+// the instance reports itself down.
+package degrade
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// HeaderDegraded marks responses served in degraded mode.
+const HeaderDegraded = "X-Degraded"
+
+// Status is the health of the instance's dependencies.
+type Status string
+
+// Supported statuses.
+const (
+	StatusOK       Status = "ok"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+// CheckFunc reports whether a dependency is available.
+type CheckFunc func(ctx context.Context) error
+
+// State is a point-in-time view of the dependencies.
+type State struct {
+	Status  Status            `json:"status"`
+	Since   time.Time         `json:"since,omitzero"`
+	Failing map[string]string `json:"failing,omitempty"`
+}
+
+// Config tunes a Monitor.
+type Config struct {
+	// Interval is how often checks run.
+	Interval time.Duration
+
+	// Timeout bounds each check.
+	Timeout time.Duration
+
+	// MaxDegraded is how long degraded mode lasts before the instance is
+	// reported down; zero keeps it degraded indefinitely.
+	MaxDegraded time.Duration
+}
+
+// Monitor runs critical dependency checks and tracks the degradation state.
+type Monitor struct {
+	cfg    Config
+	now    func() time.Time
+	mu     sync.RWMutex
+	checks map[string]CheckFunc
+	state  State
+}
+
+// NewMonitor constructs a Monitor without checks, reporting StatusOK.
+func NewMonitor(cfg Config) *Monitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = time.Second
+	}
+	return &Monitor{
+		cfg:    cfg,
+		now:    time.Now,
+		checks: make(map[string]CheckFunc),
+		state:  State{Status: StatusOK},
+	}
+}
+
+// Register adds a critical dependency check under name.
+func (m *Monitor) Register(name string, fn CheckFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checks[name] = fn
+}
+
+// State returns the current degradation state.
+func (m *Monitor) State() State {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state
+}
+
+// Check runs every check once and updates the state.
+func (m *Monitor) Check(ctx context.Context) State {
+	m.mu.RLock()
+	names := make([]string, 0, len(m.checks))
+	for name := range m.checks {
+		names = append(names, name)
+	}
+	m.mu.RUnlock()
+	sort.Strings(names)
+
+	failing := make(map[string]string)
+	for _, name := range names {
+		m.mu.RLock()
+		fn := m.checks[name]
+		m.mu.RUnlock()
+
+		checkCtx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+		err := fn(checkCtx)
+		cancel()
+
+		if err != nil {
+			failing[name] = err.Error()
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	switch {
+	case len(failing) == 0:
+		m.state = State{Status: StatusOK}
+	case m.state.Status == StatusOK:
+		m.state = State{Status: StatusDegraded, Since: now, Failing: failing}
+	default:
+		m.state.Failing = failing
+		if m.cfg.MaxDegraded > 0 && now.Sub(m.state.Since) >= m.cfg.MaxDegraded {
+			m.state.Status = StatusDown
+		}
+	}
+	return m.state
+}
+
+// Run checks the dependencies every interval until ctx is cancelled.
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+
+	m.Check(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Check(ctx)
+		}
+	}
+}
+
+// degradedKey is the context key marking requests served in degraded mode.
+type degradedKey struct{}
+
+// IsDegraded reports whether the request is served in degraded mode, so
+// handlers can fall back to cached or reduced behavior (serve-stale).
+func IsDegraded(ctx context.Context) bool {
+	degraded, _ := ctx.Value(degradedKey{}).(bool)
+	return degraded
+}
+
+// Middleware designates the routes it wraps as degradable. While degraded,
+// requests go to fallback, or to next with IsDegraded set when fallback is
+// nil, and responses carry the X-Degraded header. Once the monitor reports
+// down, requests are rejected with 503 Service Unavailable.
+func Middleware(m *Monitor, fallback http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch m.State().Status {
+			case StatusOK:
+				next.ServeHTTP(w, r)
+
+			case StatusDegraded:
+				w.Header().Set(HeaderDegraded, "true")
+				r = r.WithContext(context.WithValue(r.Context(), degradedKey{}, true))
+				if fallback != nil {
+					fallback.ServeHTTP(w, r)
+					return
+				}
+				next.ServeHTTP(w, r)
+
+			default:
+				w.Header().Set("Retry-After", strconv.Itoa(int(m.cfg.Interval.Seconds())+1))
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			}
+		})
+	}
+}
+
+// HealthHandler reports the degradation state. Degraded instances answer
+// 200 OK so load balancers keep routing to them; down instances answer
+// 503 Service Unavailable.
+func (m *Monitor) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := m.State()
+
+		status := http.StatusOK
+		if state.Status == StatusDown {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(state)
+	})
+}
@@ -0,0 +1,284 @@
+// Package errors defines the application's typed errors: each has a Kind
+// (not found, conflict, invalid, unauthorized, forbidden, gone, not
+// acceptable, too large, too many requests, unavailable, timeout,
+// internal) mapped to an HTTP status, a stable code for clients, a message
+// safe to show them, and the operations it passed through on its way up:
+//
+//	func (s *Store) User(ctx context.Context, id string) (*User, error) {
+//		...
+//		if errors.Is(err, sql.ErrNoRows) {
+//			return nil, apperrors.NotFound("user not found").WithCode("user_not_found").With("id", id)
+//		}
+//		if err != nil {
+//			return nil, apperrors.Wrap(err, "store.User")
+//		}
+//	}
+//
+// Handlers returning errors are adapted with Handle, which writes them as
+// RFC 7807 problem+json responses, or in the JSON envelope for clients
+// preferring it, as configured by Middleware (see Write).
+package errors
+
+import (
+	stderrors "errors"
+	"maps"
+	"net/http"
+	"strings"
+)
+
+// Kind classifies an error for clients.
+type Kind string
+
+// Supported kinds.
+const (
+	KindInternal        Kind = "internal"
+	KindNotFound        Kind = "not_found"
+	KindConflict        Kind = "conflict"
+	KindInvalid         Kind = "invalid"
+	KindUnauthorized    Kind = "unauthorized"
+	KindForbidden       Kind = "forbidden"
+	KindGone            Kind = "gone"
+	KindNotAcceptable   Kind = "not_acceptable"
+	KindTooLarge        Kind = "too_large"
+	KindTooManyRequests Kind = "too_many_requests"
+	KindUnavailable     Kind = "unavailable"
+	KindTimeout         Kind = "timeout"
+)
+
+// Status returns the HTTP status of the kind.
+func (k Kind) Status() int {
+	switch k {
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindInvalid:
+		return http.StatusBadRequest
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindGone:
+		return http.StatusGone
+	case KindNotAcceptable:
+		return http.StatusNotAcceptable
+	case KindTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case KindTooManyRequests:
+		return http.StatusTooManyRequests
+	case KindUnavailable:
+		return http.StatusServiceUnavailable
+	case KindTimeout:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Error is an application error. Every field is optional: an Error with
+// only Op and Err adds context to an error from further down, whose kind,
+// code, and message it inherits.
+type Error struct {
+	// Kind classifies the error; unset inherits from Err, or is internal.
+	Kind Kind
+
+	// Code is a stable, machine-readable identifier (e.g., "user_not_found");
+	// unset inherits from Err, or is the kind.
+	Code string
+
+	// Message describes the error to clients, so it must not leak internals.
+	Message string
+
+	// Op is the operation that failed (e.g., "store.User").
+	Op string
+
+	// Meta holds details for clients (e.g., the conflicting resource's ID).
+	Meta map[string]any
+
+	// Err is the underlying error.
+	Err error
+}
+
+// New returns an Error of kind with message.
+func New(kind Kind, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// NotFound returns a KindNotFound error with message.
+func NotFound(message string) *Error { return New(KindNotFound, message) }
+
+// Conflict returns a KindConflict error with message.
+func Conflict(message string) *Error { return New(KindConflict, message) }
+
+// Invalid returns a KindInvalid error with message.
+func Invalid(message string) *Error { return New(KindInvalid, message) }
+
+// Unauthorized returns a KindUnauthorized error with message.
+func Unauthorized(message string) *Error { return New(KindUnauthorized, message) }
+
+// Forbidden returns a KindForbidden error with message.
+func Forbidden(message string) *Error { return New(KindForbidden, message) }
+
+// Gone returns a KindGone error with message.
+func Gone(message string) *Error { return New(KindGone, message) }
+
+// TooLarge returns a KindTooLarge error with message.
+func TooLarge(message string) *Error { return New(KindTooLarge, message) }
+
+// TooManyRequests returns a KindTooManyRequests error with message.
+func TooManyRequests(message string) *Error { return New(KindTooManyRequests, message) }
+
+// Unavailable returns a KindUnavailable error with message.
+func Unavailable(message string) *Error { return New(KindUnavailable, message) }
+
+// Timeout returns a KindTimeout error with message.
+func Timeout(message string) *Error { return New(KindTimeout, message) }
+
+// Internal returns a KindInternal error wrapping err. Its message is never
+// shown to clients in production.
+func Internal(err error) *Error {
+	return &Error{Kind: KindInternal, Err: err}
+}
+
+// Wrap records that err happened during op, keeping its kind, code, and
+// message. It returns nil when err is nil.
+func Wrap(err error, op string) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Op: op, Err: err}
+}
+
+// WithCode sets the error's code and returns it.
+func (e *Error) WithCode(code string) *Error {
+	e.Code = code
+	return e
+}
+
+// WithOp sets the error's operation and returns it.
+func (e *Error) WithOp(op string) *Error {
+	e.Op = op
+	return e
+}
+
+// With adds a metadata entry and returns the error.
+func (e *Error) With(key string, value any) *Error {
+	if e.Meta == nil {
+		e.Meta = make(map[string]any)
+	}
+	e.Meta[key] = value
+	return e
+}
+
+// Wrapping sets the underlying error and returns the error.
+func (e *Error) Wrapping(err error) *Error {
+	e.Err = err
+	return e
+}
+
+// Error implements the error interface: the operation, message, and
+// underlying error, separated by colons.
+func (e *Error) Error() string {
+	var parts []string
+	if e.Op != "" {
+		parts = append(parts, e.Op)
+	}
+	if e.Message != "" {
+		parts = append(parts, e.Message)
+	} else if e.Kind != "" && e.Err == nil {
+		parts = append(parts, string(e.Kind))
+	}
+	if e.Err != nil {
+		parts = append(parts, e.Err.Error())
+	}
+	return strings.Join(parts, ": ")
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// KindOf returns the kind of the outermost Error in err's chain that sets
+// one, or KindInternal.
+func KindOf(err error) Kind {
+	for e := range chain(err) {
+		if e.Kind != "" {
+			return e.Kind
+		}
+	}
+	return KindInternal
+}
+
+// CodeOf returns the code of the outermost Error in err's chain that sets
+// one, or its kind.
+func CodeOf(err error) string {
+	for e := range chain(err) {
+		if e.Code != "" {
+			return e.Code
+		}
+	}
+	return string(KindOf(err))
+}
+
+// MessageOf returns the message of the outermost Error in err's chain that
+// sets one, or "".
+func MessageOf(err error) string {
+	for e := range chain(err) {
+		if e.Message != "" {
+			return e.Message
+		}
+	}
+	return ""
+}
+
+// MetaOf returns the metadata of every Error in err's chain, outer entries
+// taking precedence.
+func MetaOf(err error) map[string]any {
+	var layers []map[string]any
+	for e := range chain(err) {
+		if len(e.Meta) > 0 {
+			layers = append(layers, e.Meta)
+		}
+	}
+	if len(layers) == 0 {
+		return nil
+	}
+	meta := make(map[string]any)
+	for i := len(layers) - 1; i >= 0; i-- {
+		maps.Copy(meta, layers[i])
+	}
+	return meta
+}
+
+// Ops returns the operations of err's chain, outermost first.
+func Ops(err error) []string {
+	var ops []string
+	for e := range chain(err) {
+		if e.Op != "" {
+			ops = append(ops, e.Op)
+		}
+	}
+	return ops
+}
+
+// Status returns the HTTP status of err's kind.
+func Status(err error) int {
+	return KindOf(err).Status()
+}
+
+// chain yields the Errors in err's chain, outermost first.
+func chain(err error) func(yield func(*Error) bool) {
+	return func(yield func(*Error) bool) {
+		for err != nil {
+			var e *Error
+			if !stderrors.As(err, &e) {
+				return
+			}
+			if !yield(e) {
+				return
+			}
+			err = e.Err
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+	"github.com/iamBelugaa/go-boilerplate/pkg/i18n"
+	"github.com/iamBelugaa/go-boilerplate/pkg/logging"
+	"github.com/iamBelugaa/go-boilerplate/pkg/requestid"
+	"github.com/iamBelugaa/go-boilerplate/pkg/validation"
+	"github.com/iamBelugaa/go-boilerplate/pkg/web/negotiate"
+)
+
+// NameErrors names Middleware in route group chains.
+const NameErrors = "errors"
+
+// Problem is an RFC 7807 problem details body, with the error's code,
+// metadata, and the request ID for correlation with logs as extension
+// members. Debug is only set outside production.
+type Problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Code      string            `json:"code"`
+	RequestID string            `json:"requestId,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	Meta      map[string]any    `json:"meta,omitempty"`
+	Debug     *Debug            `json:"debug,omitempty"`
+}
+
+// Debug exposes an error's internals, for development.
+type Debug struct {
+	Error string   `json:"error"`
+	Ops   []string `json:"ops,omitempty"`
+}
+
+// HandlerFunc is an HTTP handler returning its error instead of writing it.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// Handle adapts h to http.Handler, writing its error with Write.
+func Handle(h HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := h(w, r); err != nil {
+			Write(w, r, err)
+		}
+	})
+}
+
+// Problem media types negotiated by Write.
+const (
+	ContentTypeProblem = "application/problem+json"
+	contentTypeJSON    = "application/json"
+)
+
+// settingsKey is the context key of the Middleware settings.
+type settingsKey struct{}
+
+// settings are how Write renders errors for a request.
+type settings struct {
+	expose   bool
+	mode     string
+	typeBase string
+}
+
+// Middleware sets how Write renders the errors of the requests it wraps,
+// as configured by conf (nil uses the defaults): in problem+json unless
+// the client prefers application/json, with type URIs under the
+// configured base. Outside production, problems include the error chain
+// and operations (see Debug); in production, server errors only carry
+// their status. Without it, Write negotiates and renders as in production.
+func Middleware(env config.Environment, conf *config.Problems) func(http.Handler) http.Handler {
+	s := &settings{expose: env != config.EnvironmentProduction, mode: "negotiate"}
+	if conf != nil {
+		if conf.Mode != "" {
+			s.mode = conf.Mode
+		}
+		s.typeBase = conf.TypeBaseURI
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), settingsKey{}, s)))
+		})
+	}
+}
+
+// Write answers err with the status of its kind, as problem+json or, when
+// the client prefers application/json or problems are disabled, in the
+// JSON envelope of the respond package. Request decoding and validation
+// errors (validation.RequestError, FieldErrors) are answered as invalid,
+// with their fields, and deadlines exceeded as timeouts. Server errors are
+// logged with the request's logger, which also reports them, unless they
+// were marked with Logged.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	s, _ := r.Context().Value(settingsKey{}).(*settings)
+	if s == nil {
+		s = &settings{mode: "negotiate"}
+	}
+
+	p := NewProblem(err, s.expose)
+	p.Instance = r.URL.Path
+	p.RequestID = requestid.FromContext(r.Context())
+	if s.typeBase != "" {
+		p.Type = strings.TrimSuffix(s.typeBase, "/") + "/" + url.PathEscape(p.Code)
+	}
+	localize(r.Context(), &p)
+
+	if p.Status >= http.StatusInternalServerError && !isLogged(err) {
+		logging.FromContext(r.Context()).Error("request failed",
+			zap.Error(err), zap.Strings("ops", Ops(err)), zap.String("code", p.Code))
+	}
+
+	w.Header().Add("Vary", "Accept")
+	problem := s.mode == "always" ||
+		s.mode == "negotiate" && negotiate.Best(r, ContentTypeProblem, contentTypeJSON) != contentTypeJSON
+	if !problem {
+		writeEnvelope(w, p)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentTypeProblem)
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// localize translates the title and detail of p into the request's
+// locale, from the "status.<status>" and "errors.<code>" messages of its
+// i18n bundle, with the error's metadata as arguments. Messages missing
+// from the locale, and requests in the bundle's fallback locale, keep the
+// errors' own.
+func localize(ctx context.Context, p *Problem) {
+	if i18n.IsFallback(ctx) {
+		return
+	}
+	if title, ok := i18n.LookupStrict(ctx, "status."+strconv.Itoa(p.Status)); ok {
+		p.Title = title
+	}
+	if p.Detail == "" {
+		return
+	}
+	args := make([]any, 0, 2*len(p.Meta))
+	for _, key := range slices.Sorted(maps.Keys(p.Meta)) {
+		args = append(args, key, p.Meta[key])
+	}
+	if detail, ok := i18n.LookupStrict(ctx, "errors."+p.Code, args...); ok {
+		p.Detail = detail
+	}
+}
+
+// writeEnvelope writes p in the shape of respond.Envelope, for clients
+// that only take application/json.
+func writeEnvelope(w http.ResponseWriter, p Problem) {
+	message := p.Detail
+	if message == "" {
+		message = strings.ToLower(p.Title)
+	}
+
+	type errorBody struct {
+		Code    string            `json:"code"`
+		Message string            `json:"message"`
+		Fields  map[string]string `json:"fields,omitempty"`
+	}
+	w.Header().Set("Content-Type", contentTypeJSON)
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(struct {
+		Error     errorBody `json:"error"`
+		RequestID string    `json:"requestId,omitempty"`
+	}{errorBody{Code: p.Code, Message: message, Fields: p.Fields}, p.RequestID})
+}
+
+// NewProblem describes err for clients. Unless expose is set, server
+// errors only carry their status, lest they leak internals.
+func NewProblem(err error, expose bool) Problem {
+	p := Problem{
+		Type:   "about:blank",
+		Status: Status(err),
+		Code:   CodeOf(err),
+		Detail: MessageOf(err),
+		Meta:   MetaOf(err),
+	}
+
+	if !hasKind(err) {
+		if re := validation.AsRequestError(err); re != nil {
+			p.Status, p.Code, p.Detail = re.Status, string(KindInvalid), re.Message
+			if p.Status == 0 {
+				p.Status = http.StatusBadRequest
+			}
+		} else if validation.IsFieldErrors(err) {
+			p.Status, p.Code, p.Detail = http.StatusBadRequest, string(KindInvalid), "validation failed"
+		} else if stderrors.Is(err, context.DeadlineExceeded) {
+			p.Status, p.Code, p.Detail = KindTimeout.Status(), string(KindTimeout), "request timed out"
+		}
+	}
+	if fe := validation.AsFieldErrors(err); fe != nil {
+		p.Fields = fe.Fields()
+	}
+	p.Title = http.StatusText(p.Status)
+
+	if p.Status >= http.StatusInternalServerError && !expose {
+		p.Detail, p.Meta = "", nil
+	}
+	if expose {
+		p.Debug = &Debug{Error: err.Error(), Ops: Ops(err)}
+	}
+	return p
+}
+
+// hasKind reports whether an Error in err's chain sets a kind.
+func hasKind(err error) bool {
+	for e := range chain(err) {
+		if e.Kind != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// loggedError is an error already logged and reported.
+type loggedError struct{ error }
+
+// Unwrap returns the error.
+func (e loggedError) Unwrap() error { return e.error }
+
+// Logged marks err as logged and reported by the caller, such as a
+// recovered panic, so Write does not log it again.
+func Logged(err error) error {
+	return loggedError{err}
+}
+
+// isLogged reports whether err was marked with Logged.
+func isLogged(err error) bool {
+	var l loggedError
+	return stderrors.As(err, &l)
+}
@@ -0,0 +1,82 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+	"github.com/iamBelugaa/go-boilerplate/pkg/validation"
+)
+
+func TestWriteProblem(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        config.Environment
+		err        error
+		wantStatus int
+		wantCode   string
+		wantDetail string
+	}{
+		{name: "kind", err: NotFound("user not found"), wantStatus: http.StatusNotFound, wantCode: "not_found", wantDetail: "user not found"},
+		{name: "custom code", err: Conflict("duplicate submission").WithCode("duplicate_submission"), wantStatus: http.StatusConflict, wantCode: "duplicate_submission", wantDetail: "duplicate submission"},
+		{name: "field errors", err: validation.NewFieldsError("email", errors.New("is required")), wantStatus: http.StatusBadRequest, wantCode: "invalid", wantDetail: "validation failed"},
+		{name: "deadline", err: fmt.Errorf("query: %w", context.DeadlineExceeded), wantStatus: http.StatusGatewayTimeout, wantCode: "timeout", wantDetail: "request timed out"},
+		{name: "too large", err: TooLarge("request body exceeds 10 bytes"), wantStatus: http.StatusRequestEntityTooLarge, wantCode: "too_large", wantDetail: "request body exceeds 10 bytes"},
+		{name: "internal in production", env: config.EnvironmentProduction, err: Internal(errors.New("connection refused")), wantStatus: http.StatusInternalServerError, wantCode: "internal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Middleware(tt.env, &config.Problems{TypeBaseURI: "https://errors.example.com/"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Write(w, r, Logged(tt.err))
+			}))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users", nil))
+
+			var p Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if rec.Code != tt.wantStatus || p.Status != tt.wantStatus || p.Code != tt.wantCode || p.Detail != tt.wantDetail {
+				t.Fatalf("got %d %+v, want %d %q %q", rec.Code, p, tt.wantStatus, tt.wantCode, tt.wantDetail)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != ContentTypeProblem {
+				t.Errorf("Content-Type = %q, want %q", ct, ContentTypeProblem)
+			}
+			if want := "https://errors.example.com/" + tt.wantCode; p.Type != want {
+				t.Errorf("type = %q, want %q", p.Type, want)
+			}
+			if p.Instance != "/users" {
+				t.Errorf("instance = %q, want /users", p.Instance)
+			}
+		})
+	}
+}
+
+func TestWriteEnvelope(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/users", nil)
+	r.Header.Set("Accept", "application/json")
+	rec := httptest.NewRecorder()
+	Write(rec, r, validation.NewFieldsError("email", errors.New("is required")))
+
+	var body struct {
+		Error struct {
+			Code    string            `json:"code"`
+			Message string            `json:"message"`
+			Fields  map[string]string `json:"fields"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest || body.Error.Code != "invalid" || body.Error.Fields["email"] != "is required" {
+		t.Fatalf("got %d %+v, want 400 invalid with the email field", rec.Code, body.Error)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+}
@@ -0,0 +1,122 @@
+package errreport
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// reportedKey is the field marking entries already reported directly.
+const reportedKey = "error_reported"
+
+// Reported marks a log entry whose error was already sent to the reporter
+// (e.g., with Panic), so the logging core does not report it twice.
+var Reported = zap.Bool(reportedKey, true)
+
+// correlationFields are log fields reported as tags rather than extras.
+var correlationFields = map[string]bool{
+	"request_id": true,
+	"trace_id":   true,
+	"route":      true,
+	"user_id":    true,
+	"service":    true,
+	"version":    true,
+}
+
+// core reports log entries at or above a level.
+type core struct {
+	reporter Reporter
+	level    zapcore.Level
+	fields   []zapcore.Field
+}
+
+// NewCore returns a zap core reporting entries at or above level (typically
+// error) to reporter, with their fields as tags and extras. It is meant to
+// be teed with the logger's output core.
+func NewCore(reporter Reporter, level zapcore.Level) zapcore.Core {
+	return &core{reporter: reporter, level: level}
+}
+
+// Enabled reports whether entries at l are reported.
+func (c *core) Enabled(l zapcore.Level) bool {
+	return l >= c.level
+}
+
+// With adds structured context.
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	return &core{reporter: c.reporter, level: c.level, fields: append(c.fields[:len(c.fields):len(c.fields)], fields...)}
+}
+
+// Check adds the core to ce when the entry is reported.
+func (c *core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write reports the entry. Cores teed together receive every write, so the
+// level is checked again here.
+func (c *core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !c.Enabled(ent.Level) {
+		return nil
+	}
+	for _, f := range fields {
+		if f.Key == reportedKey {
+			return nil
+		}
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range append(c.fields[:len(c.fields):len(c.fields)], fields...) {
+		f.AddTo(enc)
+	}
+
+	r := Report{
+		Level:   ent.Level.String(),
+		Message: ent.Message,
+		Tags:    map[string]string{},
+		Extra:   map[string]any{},
+	}
+	if ent.LoggerName != "" {
+		r.Tags["logger"] = ent.LoggerName
+	}
+	if ent.Caller.Defined {
+		r.Extra["caller"] = ent.Caller.String()
+	}
+
+	for key, value := range enc.Fields {
+		if s, ok := value.(string); ok && correlationFields[key] {
+			r.Tags[key] = s
+			continue
+		}
+		r.Extra[key] = value
+	}
+
+	// Errors logged with zap.Error may have been flattened to strings by
+	// the secret redaction core.
+	for _, f := range fields {
+		if f.Key != "error" {
+			continue
+		}
+		if err, ok := f.Interface.(error); ok && f.Type == zapcore.ErrorType {
+			r.Err = err
+		} else if f.Type == zapcore.StringType {
+			r.Err = errors.New(f.String)
+		}
+		delete(r.Extra, "error")
+	}
+	if r.Err == nil {
+		r.Err = errors.New(ent.Message)
+	}
+
+	c.reporter.Report(context.Background(), r)
+	return nil
+}
+
+// Sync flushes nothing; use Reporter.Flush before exiting.
+func (c *core) Sync() error {
+	return nil
+}
@@ -0,0 +1,68 @@
+// Package errreport sends errors, error-level logs, and panics to an error
+// tracking service (Sentry by default) with stack traces, request context,
+// and release information.
+package errreport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Report is a single error occurrence.
+type Report struct {
+	// Level is the severity ("error", "fatal", "panic").
+	Level string
+
+	// Message describes the occurrence.
+	Message string
+
+	// Err is the underlying error, if any.
+	Err error
+
+	// Tags are indexed, low-cardinality attributes (e.g., route).
+	Tags map[string]string
+
+	// Extra holds additional context.
+	Extra map[string]any
+
+	// Request is the request being served, if any.
+	Request *http.Request
+}
+
+// Reporter sends reports to an error tracking service.
+type Reporter interface {
+	// Report sends r asynchronously.
+	Report(ctx context.Context, r Report)
+
+	// Flush waits up to timeout for pending reports to be sent and reports
+	// whether all of them were.
+	Flush(timeout time.Duration) bool
+}
+
+// Nop is a Reporter discarding every report, used when error reporting is
+// disabled.
+type Nop struct{}
+
+// Report discards r.
+func (Nop) Report(context.Context, Report) {}
+
+// Flush returns true immediately.
+func (Nop) Flush(time.Duration) bool { return true }
+
+// Panic reports a recovered panic value, with the request being served
+// when r is not nil.
+func Panic(ctx context.Context, reporter Reporter, recovered any, r *http.Request) {
+	err, ok := recovered.(error)
+	if !ok {
+		err = fmt.Errorf("%v", recovered)
+	}
+
+	reporter.Report(ctx, Report{
+		Level:   "panic",
+		Message: "panic: " + err.Error(),
+		Err:     err,
+		Request: r,
+	})
+}
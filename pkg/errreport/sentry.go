@@ -0,0 +1,95 @@
+package errreport
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// New returns the Reporter configured by cfg, or Nop when cfg is nil or
+// disabled. The release is the service version, which defaults to the
+// build version.
+func New(cfg *config.ErrorReporting, svc *config.Service) (Reporter, error) {
+	if cfg == nil || !cfg.Enabled {
+		return Nop{}, nil
+	}
+
+	sampleRate := cfg.SampleRate
+	if sampleRate == 0 {
+		sampleRate = 1
+	}
+
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:              cfg.DSN,
+		Environment:      svc.Environment.String(),
+		Release:          svc.Name + "@" + svc.Version,
+		SampleRate:       sampleRate,
+		AttachStacktrace: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("errreport: %w", err)
+	}
+
+	tags := map[string]string{}
+	if svc.Region != "" {
+		tags["region"] = svc.Region
+	}
+	if svc.Zone != "" {
+		tags["zone"] = svc.Zone
+	}
+
+	return &Sentry{client: client, tags: tags}, nil
+}
+
+// Sentry reports to Sentry.
+type Sentry struct {
+	client *sentry.Client
+	tags   map[string]string
+}
+
+// Report sends r to Sentry.
+func (s *Sentry) Report(_ context.Context, r Report) {
+	hub := sentry.NewHub(s.client, sentry.NewScope())
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetLevel(sentryLevel(r.Level))
+		scope.SetTags(s.tags)
+		scope.SetTags(r.Tags)
+		scope.SetExtras(r.Extra)
+		if r.Request != nil {
+			scope.SetRequest(r.Request)
+		}
+		if userID := r.Tags["user_id"]; userID != "" {
+			scope.SetUser(sentry.User{ID: userID})
+		}
+
+		if r.Err == nil {
+			hub.CaptureMessage(r.Message)
+			return
+		}
+		if r.Message != "" && r.Message != r.Err.Error() {
+			scope.SetExtra("message", r.Message)
+		}
+		hub.CaptureException(r.Err)
+	})
+}
+
+// Flush waits for pending reports to be sent.
+func (s *Sentry) Flush(timeout time.Duration) bool {
+	return s.client.Flush(timeout)
+}
+
+// sentryLevel maps a log level name to a Sentry level.
+func sentryLevel(level string) sentry.Level {
+	switch level {
+	case "warn":
+		return sentry.LevelWarning
+	case "dpanic", "panic", "fatal":
+		return sentry.LevelFatal
+	default:
+		return sentry.LevelError
+	}
+}
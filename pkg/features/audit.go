@@ -0,0 +1,29 @@
+package features
+
+import (
+	"context"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/audit"
+)
+
+// AuditChanges records every flag flip originating from the remote provider
+// as an audit event. Flips caused by configuration reloads are already
+// audited as part of the reload. Write failures are reported to onError.
+func AuditChanges(f *Flags, logger *audit.Logger, onError func(error)) {
+	f.OnChange(func(c Change) {
+		if c.Source != "provider" {
+			return
+		}
+
+		err := logger.Record(context.Background(), audit.Event{
+			Actor:    "feature-flag-provider",
+			Action:   "feature_flag.update",
+			Resource: "feature_flag:" + c.Flag,
+			Before:   c.Old,
+			After:    c.New,
+		})
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	})
+}
@@ -0,0 +1,196 @@
+// Package features provides runtime feature flags backed by static
+// configuration and, optionally, a remote flag provider.
+//
+// A flag value is either a boolean ("true"/"false") or the name of a
+// variant. Values are swapped atomically, so flags can be refreshed from a
+// configuration reload or provider poll while requests are being served:
+//
+//	flags := features.New(conf.FeatureFlags.Flags)
+//	reloader.Subscribe(func(_, next *config.Config, _ config.Snapshot) {
+//		flags.Update(next.FeatureFlags.Flags)
+//	})
+//	features.SetDefault(flags)
+//
+//	if features.IsEnabled(ctx, "new-checkout") { ... }
+package features
+
+import (
+	"context"
+	"maps"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Flags holds the current flag values.
+type Flags struct {
+	mu       sync.Mutex
+	static   map[string]string
+	remote   map[string]string
+	values   atomic.Pointer[map[string]string]
+	onChange []func(Change)
+}
+
+// Change describes a flag whose value changed.
+type Change struct {
+	Flag string
+	Old  string
+	New  string
+
+	// Source is what triggered the change: "config" or "provider".
+	Source string
+}
+
+// New constructs Flags initialised with static values.
+func New(static map[string]string) *Flags {
+	f := &Flags{}
+	f.Update(static)
+	return f
+}
+
+// Update replaces the static flag values, e.g. after a configuration reload.
+func (f *Flags) Update(static map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.static = maps.Clone(static)
+	f.publish("config")
+}
+
+// OnChange registers fn to be called for every flag whose value changes.
+func (f *Flags) OnChange(fn func(Change)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onChange = append(f.onChange, fn)
+}
+
+// setRemote replaces the values fetched from the provider.
+func (f *Flags) setRemote(remote map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.remote = remote
+	f.publish("provider")
+}
+
+// publish merges static and remote values (remote wins) and notifies
+// listeners of changed flags. The caller must hold f.mu.
+func (f *Flags) publish(source string) {
+	next := maps.Clone(f.static)
+	if next == nil {
+		next = make(map[string]string)
+	}
+	maps.Copy(next, f.remote)
+
+	var prev map[string]string
+	if p := f.values.Swap(&next); p != nil {
+		prev = *p
+	}
+
+	for flag, val := range next {
+		if prev[flag] != val {
+			f.notify(Change{Flag: flag, Old: prev[flag], New: val, Source: source})
+		}
+	}
+	for flag, val := range prev {
+		if _, ok := next[flag]; !ok {
+			f.notify(Change{Flag: flag, Old: val, Source: source})
+		}
+	}
+}
+
+func (f *Flags) notify(c Change) {
+	for _, fn := range f.onChange {
+		fn(c)
+	}
+}
+
+// Variant returns the raw value of flag, or "" when it is not defined.
+func (f *Flags) Variant(flag string) string {
+	if f == nil {
+		return ""
+	}
+	return (*f.values.Load())[flag]
+}
+
+// IsEnabled reports whether flag is set to a true boolean or to any variant
+// other than "off". Undefined flags are disabled.
+func (f *Flags) IsEnabled(flag string) bool {
+	val := f.Variant(flag)
+	if val == "" || val == "off" {
+		return false
+	}
+	if enabled, err := strconv.ParseBool(val); err == nil {
+		return enabled
+	}
+	return true
+}
+
+// All returns a copy of every flag value.
+func (f *Flags) All() map[string]string {
+	return maps.Clone(*f.values.Load())
+}
+
+// Poll refreshes remote values from provider every interval until ctx is
+// cancelled. Fetch errors keep the last known values and are reported to
+// onError.
+func (f *Flags) Poll(ctx context.Context, provider Provider, interval time.Duration, onError func(error)) {
+	refresh := func() {
+		remote, err := provider.Fetch(ctx)
+		if err != nil {
+			if onError != nil && ctx.Err() == nil {
+				onError(err)
+			}
+			return
+		}
+		f.setRemote(remote)
+	}
+
+	refresh()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			refresh()
+		}
+	}
+}
+
+// flagsKey is the context key for Flags.
+type flagsKey struct{}
+
+// defaultFlags is used when no Flags are attached to the context.
+var defaultFlags atomic.Pointer[Flags]
+
+// SetDefault installs the Flags used by IsEnabled and Variant when the
+// context does not carry its own.
+func SetDefault(f *Flags) {
+	defaultFlags.Store(f)
+}
+
+// NewContext returns a copy of ctx carrying f.
+func NewContext(ctx context.Context, f *Flags) context.Context {
+	return context.WithValue(ctx, flagsKey{}, f)
+}
+
+// FromContext returns the Flags attached to ctx, or the default Flags.
+func FromContext(ctx context.Context) *Flags {
+	if f, ok := ctx.Value(flagsKey{}).(*Flags); ok {
+		return f
+	}
+	return defaultFlags.Load()
+}
+
+// IsEnabled reports whether flag is enabled for the Flags in ctx.
+func IsEnabled(ctx context.Context, flag string) bool {
+	return FromContext(ctx).IsEnabled(flag)
+}
+
+// Variant returns the variant of flag for the Flags in ctx.
+func Variant(ctx context.Context, flag string) string {
+	return FromContext(ctx).Variant(flag)
+}
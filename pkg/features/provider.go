@@ -0,0 +1,92 @@
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Provider fetches flag values from a remote flag service.
+type Provider interface {
+	Fetch(ctx context.Context) (map[string]string, error)
+}
+
+// HTTPProvider fetches a JSON object of flag name to value from Endpoint.
+type HTTPProvider struct {
+	Endpoint string
+	APIKey   string
+	Client   *http.Client
+}
+
+// Fetch implements Provider.
+func (p *HTTPProvider) Fetch(ctx context.Context) (map[string]string, error) {
+	var raw map[string]any
+	if err := getJSON(ctx, p.Client, p.Endpoint, "Bearer "+p.APIKey, &raw); err != nil {
+		return nil, err
+	}
+
+	flags := make(map[string]string, len(raw))
+	for name, val := range raw {
+		flags[name] = fmt.Sprint(val)
+	}
+	return flags, nil
+}
+
+// UnleashProvider fetches toggles from the Unleash client API
+// (GET <Endpoint>/api/client/features).
+type UnleashProvider struct {
+	Endpoint string
+	APIKey   string
+	Client   *http.Client
+}
+
+// Fetch implements Provider.
+func (p *UnleashProvider) Fetch(ctx context.Context) (map[string]string, error) {
+	var resp struct {
+		Features []struct {
+			Name    string `json:"name"`
+			Enabled bool   `json:"enabled"`
+		} `json:"features"`
+	}
+
+	if err := getJSON(ctx, p.Client, p.Endpoint+"/api/client/features", p.APIKey, &resp); err != nil {
+		return nil, err
+	}
+
+	flags := make(map[string]string, len(resp.Features))
+	for _, feature := range resp.Features {
+		flags[feature.Name] = strconv.FormatBool(feature.Enabled)
+	}
+	return flags, nil
+}
+
+// getJSON performs an authenticated GET and decodes the JSON response into dst.
+func getJSON(ctx context.Context, client *http.Client, url, authorization string, dst any) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("features: fetch flags: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("features: fetch flags: unexpected status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return fmt.Errorf("features: decode flags: %w", err)
+	}
+	return nil
+}
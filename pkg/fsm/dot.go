@@ -0,0 +1,45 @@
+package fsm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DOT renders the machine as a Graphviz digraph (e.g., for
+// `dot -Tsvg`), with the initial state drawn bold and states without
+// outgoing transitions drawn as double circles.
+func (m *Machine[T]) DOT() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "digraph %q {\n", m.name)
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=circle];\n")
+
+	for _, s := range m.states {
+		var attrs []string
+		if s == m.initial {
+			attrs = append(attrs, "style=bold")
+		}
+		if len(m.transitions[s]) == 0 {
+			attrs = append(attrs, "shape=doublecircle")
+		}
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, "\t%q [%s];\n", s, strings.Join(attrs, ", "))
+		} else {
+			fmt.Fprintf(&b, "\t%q;\n", s)
+		}
+	}
+
+	for _, t := range m.order {
+		label := string(t.Event)
+		if t.Guard != nil {
+			label += " [guarded]"
+		}
+		for _, from := range t.From {
+			fmt.Fprintf(&b, "\t%q -> %q [label=%q];\n", from, t.To, label)
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
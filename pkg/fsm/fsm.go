@@ -0,0 +1,184 @@
+// Package fsm provides declarative finite state machines for entity
+// lifecycles (e.g., orders, subscriptions): the states, the events moving
+// an entity between them, guards vetoing transitions, and actions run when
+// they happen.
+package fsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/audit"
+)
+
+// Errors returned by Define and Fire.
+var (
+	ErrInvalidTransition = errors.New("fsm: invalid transition")
+	ErrDefinition        = errors.New("fsm: invalid definition")
+)
+
+// State is a lifecycle state (e.g., "pending").
+type State string
+
+// Event triggers a transition between states (e.g., "pay").
+type Event string
+
+// Transition declares that Event moves an entity in one of the From states
+// to the To state.
+type Transition[T any] struct {
+	// Event triggers the transition.
+	Event Event
+
+	// From lists the states the transition may start from.
+	From []State
+
+	// To is the state the entity ends up in.
+	To State
+
+	// Guard, when set, is consulted first and vetoes the transition by
+	// returning an error.
+	Guard func(ctx context.Context, entity T) error
+
+	// Action, when set, runs after the guard passed; an error aborts the
+	// transition, leaving the entity in its current state.
+	Action func(ctx context.Context, entity T) error
+}
+
+// Change describes a transition that happened.
+type Change struct {
+	Machine string    `json:"machine"`
+	Event   Event     `json:"event"`
+	From    State     `json:"from"`
+	To      State     `json:"to"`
+	Actor   string    `json:"actor"`
+	Time    time.Time `json:"time"`
+}
+
+// Machine is a state machine for entities of type T. It holds no entity
+// state itself, so a single Machine serves every entity concurrently.
+type Machine[T any] struct {
+	name        string
+	initial     State
+	states      []State
+	transitions map[State]map[Event]*Transition[T]
+	order       []*Transition[T]
+	observers   []func(ctx context.Context, entity T, c Change)
+}
+
+// Define constructs a Machine named name whose entities start in initial.
+// It fails when two transitions share an event and a From state, or when a
+// transition is missing its event or states.
+func Define[T any](name string, initial State, transitions ...Transition[T]) (*Machine[T], error) {
+	m := &Machine[T]{
+		name:        name,
+		initial:     initial,
+		transitions: make(map[State]map[Event]*Transition[T]),
+	}
+	m.addState(initial)
+
+	for i := range transitions {
+		t := &transitions[i]
+		if t.Event == "" || t.To == "" || len(t.From) == 0 {
+			return nil, fmt.Errorf("%w: transition %d needs an event, From states, and a To state", ErrDefinition, i)
+		}
+
+		for _, from := range t.From {
+			byEvent := m.transitions[from]
+			if byEvent == nil {
+				byEvent = make(map[Event]*Transition[T])
+				m.transitions[from] = byEvent
+			}
+			if _, ok := byEvent[t.Event]; ok {
+				return nil, fmt.Errorf("%w: event %q is declared twice from state %q", ErrDefinition, t.Event, from)
+			}
+			byEvent[t.Event] = t
+			m.addState(from)
+		}
+		m.addState(t.To)
+		m.order = append(m.order, t)
+	}
+
+	return m, nil
+}
+
+// addState records s in declaration order.
+func (m *Machine[T]) addState(s State) {
+	if !slices.Contains(m.states, s) {
+		m.states = append(m.states, s)
+	}
+}
+
+// Name returns the machine's name.
+func (m *Machine[T]) Name() string {
+	return m.name
+}
+
+// Initial returns the state new entities start in.
+func (m *Machine[T]) Initial() State {
+	return m.initial
+}
+
+// States returns every state in declaration order.
+func (m *Machine[T]) States() []State {
+	return slices.Clone(m.states)
+}
+
+// Events returns the events accepted in state from, in declaration order.
+func (m *Machine[T]) Events(from State) []Event {
+	var events []Event
+	for _, t := range m.order {
+		if slices.Contains(t.From, from) {
+			events = append(events, t.Event)
+		}
+	}
+	return events
+}
+
+// Can reports whether event is accepted in state from, ignoring guards.
+func (m *Machine[T]) Can(from State, event Event) bool {
+	_, ok := m.transitions[from][event]
+	return ok
+}
+
+// OnTransition registers fn to be called after every transition, e.g., to
+// persist or publish it. It must be called before the machine is used.
+func (m *Machine[T]) OnTransition(fn func(ctx context.Context, entity T, c Change)) {
+	m.observers = append(m.observers, fn)
+}
+
+// Fire applies event to entity, currently in state from, running the
+// transition's guard and action. It returns the resulting change; the
+// caller stores c.To on the entity (see SQLStore for doing so atomically).
+func (m *Machine[T]) Fire(ctx context.Context, entity T, from State, event Event) (Change, error) {
+	t, ok := m.transitions[from][event]
+	if !ok {
+		return Change{}, fmt.Errorf("%w: %s: event %q in state %q", ErrInvalidTransition, m.name, event, from)
+	}
+
+	if t.Guard != nil {
+		if err := t.Guard(ctx, entity); err != nil {
+			return Change{}, fmt.Errorf("fsm: %s: %s rejected: %w", m.name, event, err)
+		}
+	}
+	if t.Action != nil {
+		if err := t.Action(ctx, entity); err != nil {
+			return Change{}, fmt.Errorf("fsm: %s: %s failed: %w", m.name, event, err)
+		}
+	}
+
+	c := Change{
+		Machine: m.name,
+		Event:   event,
+		From:    from,
+		To:      t.To,
+		Actor:   audit.ActorFromContext(ctx),
+		Time:    time.Now().UTC(),
+	}
+	for _, fn := range m.observers {
+		fn(ctx, entity, c)
+	}
+	return c, nil
+}
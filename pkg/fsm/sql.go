@@ -0,0 +1,128 @@
+package fsm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrConflict is returned by SQLStore.Apply when the entity is no longer in
+// the state the transition started from, e.g., because a concurrent
+// request moved it first.
+var ErrConflict = errors.New("fsm: entity state changed concurrently")
+
+// Schema creates the PostgreSQL table SQLStore records transitions in. The
+// entity tables themselves belong to the services using the machines.
+const Schema = `
+CREATE TABLE IF NOT EXISTS fsm_transitions (
+	id          BIGSERIAL PRIMARY KEY,
+	machine     TEXT NOT NULL,
+	entity_id   TEXT NOT NULL,
+	event       TEXT NOT NULL,
+	from_state  TEXT NOT NULL,
+	to_state    TEXT NOT NULL,
+	actor       TEXT NOT NULL,
+	occurred_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS fsm_transitions_entity_idx ON fsm_transitions (machine, entity_id, id);
+`
+
+// identifier matches the table and column names SQLStore interpolates.
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// SQLStore persists transitions of entities whose state is kept in a
+// column of a PostgreSQL table, recording each one in fsm_transitions.
+type SQLStore struct {
+	db     *sql.DB
+	update string
+}
+
+// NewSQLStore constructs a SQLStore for entities in table, identified by
+// idColumn and with their state in stateColumn. The table in Schema must
+// exist.
+func NewSQLStore(db *sql.DB, table, idColumn, stateColumn string) (*SQLStore, error) {
+	for _, name := range []string{table, idColumn, stateColumn} {
+		if !identifier.MatchString(name) {
+			return nil, fmt.Errorf("fsm: invalid SQL identifier %q", name)
+		}
+	}
+
+	return &SQLStore{
+		db: db,
+		update: fmt.Sprintf(
+			`UPDATE %s SET %s = $1 WHERE %s = $2 AND %s = $3`,
+			table, stateColumn, idColumn, stateColumn,
+		),
+	}, nil
+}
+
+// Apply stores c.To as the state of the entity and records c, in one
+// transaction. It returns ErrConflict when the entity is not in c.From.
+func (s *SQLStore) Apply(ctx context.Context, entityID string, c Change) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.ApplyTx(ctx, tx, entityID, c); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ApplyTx is Apply within tx, for callers updating the entity's other
+// columns in the same transaction.
+func (s *SQLStore) ApplyTx(ctx context.Context, tx *sql.Tx, entityID string, c Change) error {
+	res, err := tx.ExecContext(ctx, s.update, string(c.To), entityID, string(c.From))
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s %q is not %q", ErrConflict, c.Machine, entityID, c.From)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO fsm_transitions (machine, entity_id, event, from_state, to_state, actor, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		c.Machine, entityID, string(c.Event), string(c.From), string(c.To), c.Actor, c.Time.UTC(),
+	); err != nil {
+		return fmt.Errorf("record transition: %w", err)
+	}
+
+	return nil
+}
+
+// History returns the recorded transitions of an entity, oldest first.
+func (s *SQLStore) History(ctx context.Context, machine, entityID string) ([]Change, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT machine, event, from_state, to_state, actor, occurred_at
+		FROM fsm_transitions
+		WHERE machine = $1 AND entity_id = $2
+		ORDER BY id`,
+		machine, entityID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []Change
+	for rows.Next() {
+		var c Change
+		if err := rows.Scan(&c.Machine, &c.Event, &c.From, &c.To, &c.Actor, &c.Time); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+
+	return changes, rows.Err()
+}
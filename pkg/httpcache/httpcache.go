@@ -0,0 +1,370 @@
+// Package httpcache caches responses of read-only routes under declared
+// tags and invalidates them when mutating routes declare they change those
+// tags, so handlers never manage cache keys by hand:
+//
+//	router.Handle("GET /users/{id}", show, c.Tags(time.Minute, "user:{id}"))
+//	router.Handle("PUT /users/{id}", update, c.Invalidates("user:{id}", "users"))
+//
+// Tags may reference path wildcards, expanded per request. Invalidation
+// bumps a generation counter per tag rather than deleting entries: an entry
+// is only served while every tag it was stored under still has the
+// generation read before its handler ran, so a response computed from data
+// that changed mid-request is never served once the mutation completes.
+package httpcache
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/auth"
+	"github.com/iamBelugaa/go-boilerplate/pkg/middleware"
+)
+
+// Middleware names, as listed by the router.
+const (
+	NameCache      = "cache"
+	NameInvalidate = "cache_invalidate"
+)
+
+// HeaderCache reports whether a response was served from the cache ("HIT")
+// or computed ("MISS").
+const HeaderCache = "X-Cache"
+
+// maxBodyBytes caps the size of cached bodies; larger responses are not
+// stored.
+const maxBodyBytes = 1 << 20
+
+// ErrUnresolvedTag is returned when a tag references a path wildcard the
+// route does not define.
+var ErrUnresolvedTag = errors.New("httpcache: unresolved tag")
+
+// Entry is a cached response. Responses varying on request headers are
+// stored per variant, under an entry only holding the Vary header names.
+type Entry struct {
+	Status      int         `json:"status"`
+	Header      http.Header `json:"header"`
+	Body        []byte      `json:"body"`
+	Tags        []string    `json:"tags"`
+	Generations []uint64    `json:"generations"`
+	Vary        []string    `json:"vary,omitempty"`
+}
+
+// Store persists entries and tag generations. Implementations must be safe
+// for concurrent use; a shared store such as Redis keeps replicas
+// consistent.
+type Store interface {
+	// Get returns the entry stored under key, or nil when there is none.
+	Get(ctx context.Context, key string) (*Entry, error)
+
+	// Set stores e under key for ttl.
+	Set(ctx context.Context, key string, e *Entry, ttl time.Duration) error
+
+	// Generations returns the current generation of each tag; tags never
+	// invalidated are at generation 0.
+	Generations(ctx context.Context, tags []string) ([]uint64, error)
+
+	// Bump advances the generation of each tag, invalidating every entry
+	// stored under it.
+	Bump(ctx context.Context, tags []string) error
+}
+
+// Cache serves and invalidates cached responses.
+type Cache struct {
+	store   Store
+	onError func(error)
+
+	requests      *prometheus.CounterVec
+	invalidations prometheus.Counter
+}
+
+// New constructs a Cache backed by store, registering
+// http_cache_requests_total (by result: hit, miss, or bypass) and
+// http_cache_invalidations_total with reg. Store errors are reported to
+// onError, which may be nil, and degrade to serving uncached responses.
+func New(store Store, reg prometheus.Registerer, onError func(error)) (*Cache, error) {
+	c := &Cache{
+		store:   store,
+		onError: onError,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_cache_requests_total",
+			Help: "Requests to cached routes, by result (hit, miss, or bypass).",
+		}, []string{"result"}),
+		invalidations: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "http_cache_invalidations_total",
+			Help: "Tags invalidated by mutating routes.",
+		}),
+	}
+
+	for _, collector := range []prometheus.Collector{c.requests, c.invalidations} {
+		if err := reg.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Tags caches successful GET and HEAD responses for ttl under the given
+// tags. Entries are keyed by path, query, and the authenticated principal,
+// so responses are never shared between users, and by the request headers
+// named in the response's Vary, so clients negotiating another format or
+// language get their own. Requests with "Cache-Control: no-cache" and
+// responses with "Cache-Control: no-store" or "private", or "Vary: *",
+// bypass the cache.
+func (c *Cache) Tags(ttl time.Duration, tags ...string) middleware.Named {
+	return middleware.Named{Name: NameCache, Wrap: func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead || strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+				c.requests.WithLabelValues("bypass").Inc()
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			expanded, err := Expand(r, tags)
+			if err != nil {
+				c.report(err)
+				c.requests.WithLabelValues("bypass").Inc()
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			key := cacheKey(r)
+			if e, err := c.lookup(ctx, key, r); err != nil {
+				c.report(err)
+			} else if e != nil && c.fresh(ctx, e) {
+				c.requests.WithLabelValues("hit").Inc()
+				serve(w, r, e)
+				return
+			}
+
+			// Generations are read before the handler runs, so an
+			// invalidation racing with it makes the entry stale at once.
+			gens, err := c.store.Generations(ctx, expanded)
+			if err != nil {
+				c.report(err)
+				c.requests.WithLabelValues("bypass").Inc()
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			c.requests.WithLabelValues("miss").Inc()
+			w.Header().Set(HeaderCache, "MISS")
+			rec := middleware.NewRecorder(w, maxBodyBytes)
+			next.ServeHTTP(rec, r)
+
+			status, header, body, complete := rec.Result()
+			if !complete || !cacheable(status, header) || r.Method == http.MethodHead {
+				return
+			}
+			e := &Entry{Status: status, Header: header, Body: body, Tags: expanded, Generations: gens}
+			e.Header.Del(HeaderCache)
+			if err := c.set(context.WithoutCancel(ctx), key, r, e, ttl); err != nil {
+				c.report(err)
+			}
+		})
+	}}
+}
+
+// Invalidates invalidates the given tags once a request succeeds with a 2xx
+// status. Tags are expanded like those of Tags.
+func (c *Cache) Invalidates(tags ...string) middleware.Named {
+	return middleware.Named{Name: NameInvalidate, Wrap: func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sw := &statusWriter{ResponseWriter: w}
+			next.ServeHTTP(sw, r)
+			if sw.status != 0 && (sw.status < 200 || sw.status >= 300) {
+				return
+			}
+
+			expanded, err := Expand(r, tags)
+			if err != nil {
+				c.report(err)
+				return
+			}
+			if err := c.Invalidate(context.WithoutCancel(r.Context()), expanded...); err != nil {
+				c.report(err)
+			}
+		})
+	}}
+}
+
+// Invalidate invalidates every entry stored under any of tags, for changes
+// made outside HTTP handlers such as by consumers or jobs.
+func (c *Cache) Invalidate(ctx context.Context, tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	if err := c.store.Bump(ctx, tags); err != nil {
+		return err
+	}
+	c.invalidations.Add(float64(len(tags)))
+	return nil
+}
+
+// Expand replaces the "{name}" references of tags with the request's path
+// values.
+func Expand(r *http.Request, tags []string) ([]string, error) {
+	out := make([]string, len(tags))
+	for i, tag := range tags {
+		var b strings.Builder
+		for {
+			start := strings.IndexByte(tag, '{')
+			if start < 0 {
+				break
+			}
+			end := strings.IndexByte(tag[start:], '}')
+			if end < 0 {
+				break
+			}
+			name := tag[start+1 : start+end]
+			value := r.PathValue(name)
+			if value == "" {
+				return nil, ErrUnresolvedTag
+			}
+			b.WriteString(tag[:start])
+			b.WriteString(value)
+			tag = tag[start+end+1:]
+		}
+		b.WriteString(tag)
+		out[i] = b.String()
+	}
+	return out, nil
+}
+
+// fresh reports whether none of e's tags was invalidated since it was
+// stored.
+func (c *Cache) fresh(ctx context.Context, e *Entry) bool {
+	gens, err := c.store.Generations(ctx, e.Tags)
+	if err != nil {
+		c.report(err)
+		return false
+	}
+	for i := range gens {
+		if i >= len(e.Generations) || gens[i] != e.Generations[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// report passes err to onError.
+func (c *Cache) report(err error) {
+	if c.onError != nil {
+		c.onError(err)
+	}
+}
+
+// lookup returns the entry stored for r under key, following an entry
+// holding Vary header names to the variant of r's values for them.
+func (c *Cache) lookup(ctx context.Context, key string, r *http.Request) (*Entry, error) {
+	e, err := c.store.Get(ctx, key)
+	if err != nil || e == nil || len(e.Vary) == 0 {
+		return e, err
+	}
+	return c.store.Get(ctx, variantKey(key, r, e.Vary))
+}
+
+// set stores e, the response to r, under key, or under its variant key
+// when it varies on request headers.
+func (c *Cache) set(ctx context.Context, key string, r *http.Request, e *Entry, ttl time.Duration) error {
+	vary := varyHeaders(e.Header)
+	if len(vary) == 0 {
+		return c.store.Set(ctx, key, e, ttl)
+	}
+	if err := c.store.Set(ctx, key, &Entry{Vary: vary}, ttl); err != nil {
+		return err
+	}
+	return c.store.Set(ctx, variantKey(key, r, vary), e, ttl)
+}
+
+// cacheKey identifies the response to r, before the headers it varies on.
+func cacheKey(r *http.Request) string {
+	key := r.URL.Path + "?" + r.URL.Query().Encode()
+	if p := auth.PrincipalFromContext(r.Context()); p != nil {
+		key += "#" + p.Subject + "|" + p.ClientID + "|" + p.ImpersonatedBy
+	}
+	return key
+}
+
+// variantKey extends key with r's values of the vary headers.
+func variantKey(key string, r *http.Request, vary []string) string {
+	var b strings.Builder
+	b.WriteString(key)
+	for _, name := range vary {
+		b.WriteString("\n")
+		b.WriteString(name)
+		b.WriteString(": ")
+		b.WriteString(strings.Join(r.Header.Values(name), ", "))
+	}
+	return b.String()
+}
+
+// varyHeaders returns the canonical, sorted, and deduplicated header names
+// of h's Vary.
+func varyHeaders(h http.Header) []string {
+	var names []string
+	for _, v := range h.Values("Vary") {
+		for name := range strings.SplitSeq(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// serve writes the cached response e.
+func serve(w http.ResponseWriter, r *http.Request, e *Entry) {
+	h := w.Header()
+	for k, v := range e.Header {
+		h[k] = v
+	}
+	h.Set(HeaderCache, "HIT")
+	w.WriteHeader(e.Status)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(e.Body)
+	}
+}
+
+// statusWriter records the response status.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 && status >= http.StatusOK {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter.
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// cacheable reports whether a response may be stored.
+func cacheable(status int, header http.Header) bool {
+	if status != http.StatusOK || slices.Contains(varyHeaders(header), "*") {
+		return false
+	}
+	cc := strings.ToLower(header.Get("Cache-Control"))
+	return !strings.Contains(cc, "no-store") && !strings.Contains(cc, "private") && header.Get("Set-Cookie") == ""
+}
@@ -0,0 +1,120 @@
+package httpcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func newTestCache(t *testing.T) *Cache {
+	t.Helper()
+	c, err := New(NewMemory(100), prometheus.NewRegistry(), func(err error) { t.Errorf("store error: %v", err) })
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+// get requests path through h with the given headers, returning the
+// response and the X-Cache result.
+func get(h http.Handler, path string, header map[string]string) (*httptest.ResponseRecorder, string) {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec, rec.Header().Get(HeaderCache)
+}
+
+func TestCacheVariesOnRequestHeaders(t *testing.T) {
+	calls := 0
+	h := newTestCache(t).Tags(time.Minute, "users").Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Add("Vary", "Accept")
+		w.Header().Add("Vary", "accept-language")
+		if r.Header.Get("Accept") == "application/xml" {
+			_, _ = w.Write([]byte("<users/>"))
+			return
+		}
+		_, _ = w.Write([]byte("[]"))
+	}))
+
+	tests := []struct {
+		header    map[string]string
+		want      string
+		wantCache string
+	}{
+		{header: map[string]string{"Accept": "application/json"}, want: "[]", wantCache: "MISS"},
+		{header: map[string]string{"Accept": "application/xml"}, want: "<users/>", wantCache: "MISS"},
+		{header: map[string]string{"Accept": "application/json"}, want: "[]", wantCache: "HIT"},
+		{header: map[string]string{"Accept": "application/xml"}, want: "<users/>", wantCache: "HIT"},
+		{header: map[string]string{"Accept": "application/xml", "Accept-Language": "de"}, want: "<users/>", wantCache: "MISS"},
+		{header: map[string]string{"Accept": "application/xml", "Accept-Language": "de"}, want: "<users/>", wantCache: "HIT"},
+	}
+	for i, tt := range tests {
+		rec, result := get(h, "/users", tt.header)
+		if rec.Body.String() != tt.want || result != tt.wantCache {
+			t.Fatalf("request %d: body %q, %s; want %q, %s", i, rec.Body.String(), result, tt.want, tt.wantCache)
+		}
+	}
+	if calls != 3 {
+		t.Errorf("handler ran %d times, want once per variant", calls)
+	}
+}
+
+func TestCacheBypass(t *testing.T) {
+	tests := []struct {
+		name   string
+		handle func(w http.ResponseWriter)
+	}{
+		{name: "vary star", handle: func(w http.ResponseWriter) { w.Header().Set("Vary", "*") }},
+		{name: "no-store", handle: func(w http.ResponseWriter) { w.Header().Set("Cache-Control", "no-store") }},
+		{name: "private", handle: func(w http.ResponseWriter) { w.Header().Set("Cache-Control", "private, max-age=60") }},
+		{name: "cookie", handle: func(w http.ResponseWriter) { w.Header().Set("Set-Cookie", "a=b") }},
+		{name: "not found", handle: func(w http.ResponseWriter) { w.WriteHeader(http.StatusNotFound) }},
+		{name: "streamed", handle: func(w http.ResponseWriter) { _ = http.NewResponseController(w).Flush() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			h := newTestCache(t).Tags(time.Minute, "users").Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				tt.handle(w)
+				_, _ = w.Write([]byte("body"))
+			}))
+			for range 2 {
+				if _, result := get(h, "/users", nil); result != "MISS" {
+					t.Fatalf("X-Cache = %q, want MISS", result)
+				}
+			}
+			if calls != 2 {
+				t.Errorf("handler ran %d times, want 2", calls)
+			}
+		})
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	c := newTestCache(t)
+	body := "v1"
+	mux := http.NewServeMux()
+	mux.Handle("GET /users/{id}", c.Tags(time.Minute, "user:{id}").Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	})))
+	mux.Handle("PUT /users/{id}", c.Invalidates("user:{id}").Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = "v2"
+	})))
+
+	get(mux, "/users/1", nil)
+	if rec, result := get(mux, "/users/1", nil); rec.Body.String() != "v1" || result != "HIT" {
+		t.Fatalf("body %q, %s; want the cached v1", rec.Body.String(), result)
+	}
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/users/1", nil))
+	if rec, result := get(mux, "/users/1", nil); rec.Body.String() != "v2" || result != "MISS" {
+		t.Fatalf("body %q, %s after invalidation; want v2 computed", rec.Body.String(), result)
+	}
+}
@@ -0,0 +1,145 @@
+package httpcache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory is an in-process Store holding up to a fixed number of entries,
+// evicting the least recently used. It suits single-replica services; with
+// several replicas, invalidations only reach the replica handling the
+// mutation.
+type Memory struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	lru        *list.List
+	tags       map[string]generation
+	clock      uint64
+	now        func() time.Time
+}
+
+// memoryEntry is an entry in the LRU list.
+type memoryEntry struct {
+	key     string
+	entry   *Entry
+	expires time.Time
+}
+
+// generation is the state of an invalidated tag.
+type generation struct {
+	value  uint64
+	bumped time.Time
+}
+
+// pruneAge is how long an invalidated tag is remembered at least, which
+// must exceed the duration of any request: a request that read the tag
+// before its invalidation must not see it reset once it stores its
+// response.
+const pruneAge = 10 * time.Minute
+
+// NewMemory constructs a Memory store holding up to maxEntries entries
+// (10000 when not positive).
+func NewMemory(maxEntries int) *Memory {
+	if maxEntries <= 0 {
+		maxEntries = 10000
+	}
+	return &Memory{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		tags:       make(map[string]generation),
+		now:        time.Now,
+	}
+}
+
+// Get implements Store.
+func (m *Memory) Get(_ context.Context, key string) (*Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	me := el.Value.(*memoryEntry)
+	if !m.now().Before(me.expires) {
+		m.lru.Remove(el)
+		delete(m.entries, key)
+		return nil, nil
+	}
+	m.lru.MoveToFront(el)
+	return me.entry, nil
+}
+
+// Set implements Store.
+func (m *Memory) Set(_ context.Context, key string, e *Entry, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	me := &memoryEntry{key: key, entry: e, expires: m.now().Add(ttl)}
+	if el, ok := m.entries[key]; ok {
+		el.Value = me
+		m.lru.MoveToFront(el)
+		return nil
+	}
+
+	m.entries[key] = m.lru.PushFront(me)
+	for m.lru.Len() > m.maxEntries {
+		oldest := m.lru.Back()
+		m.lru.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryEntry).key)
+	}
+	return nil
+}
+
+// Generations implements Store.
+func (m *Memory) Generations(_ context.Context, tags []string) ([]uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	gens := make([]uint64, len(tags))
+	for i, tag := range tags {
+		gens[i] = m.tags[tag].value
+	}
+	return gens, nil
+}
+
+// Bump implements Store. Generations are drawn from a single clock, so a
+// tag forgotten and bumped again never returns to an earlier value.
+func (m *Memory) Bump(_ context.Context, tags []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for _, tag := range tags {
+		m.clock++
+		m.tags[tag] = generation{value: m.clock, bumped: now}
+	}
+
+	if len(m.tags) > 4*m.maxEntries {
+		m.pruneTags(now)
+	}
+	return nil
+}
+
+// pruneTags forgets tags invalidated long ago that no live entry
+// references; they read as generation 0 again.
+func (m *Memory) pruneTags(now time.Time) {
+	live := make(map[string]bool)
+	for el := m.lru.Front(); el != nil; el = el.Next() {
+		me := el.Value.(*memoryEntry)
+		if now.Before(me.expires) {
+			for _, tag := range me.entry.Tags {
+				live[tag] = true
+			}
+		}
+	}
+	for tag, gen := range m.tags {
+		if !live[tag] && now.Sub(gen.bumped) > pruneAge {
+			delete(m.tags, tag)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package httpclient
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// Alert levels.
+const (
+	AlertWarning  = "warning"  // spend reached the AlertAt fraction of the budget
+	AlertExceeded = "exceeded" // spend reached the budget
+)
+
+// Alert reports that a downstream's spend crossed a budget threshold. Each
+// level is raised at most once per budget period.
+type Alert struct {
+	Downstream  string    `json:"downstream"`
+	Level       string    `json:"level"`
+	Spend       float64   `json:"spend"`
+	Budget      float64   `json:"budget"`
+	PeriodStart time.Time `json:"periodStart"`
+}
+
+// Usage is a downstream's consumption in the current budget period.
+type Usage struct {
+	Downstream  string    `json:"downstream"`
+	Calls       int64     `json:"calls"`
+	BytesOut    int64     `json:"bytesOut"`
+	BytesIn     int64     `json:"bytesIn"`
+	Tokens      int64     `json:"tokens"`
+	Spend       float64   `json:"spend"`
+	Budget      float64   `json:"budget,omitempty"`
+	PeriodStart time.Time `json:"periodStart"`
+}
+
+// downstream is the tracked state of one downstream.
+type downstream struct {
+	conf   *config.Downstream
+	period time.Duration
+	alert  float64
+	usage  Usage
+	warned bool
+	over   bool
+}
+
+// Costs tracks calls, bytes, tokens, and estimated spend per configured
+// downstream, raising alerts when a budget is running out. Calls to hosts
+// that belong to no downstream are not tracked.
+type Costs struct {
+	mu          sync.Mutex
+	downstreams map[string]*downstream
+	hosts       map[string]string
+	onAlert     func(Alert)
+	now         func() time.Time
+
+	calls  *prometheus.CounterVec
+	bytes  *prometheus.CounterVec
+	tokens *prometheus.CounterVec
+	spend  *prometheus.CounterVec
+	ratio  *prometheus.GaugeVec
+}
+
+// NewCosts tracks the downstreams in conf, registering
+// downstream_calls_total, downstream_bytes_total (by direction),
+// downstream_tokens_total, downstream_cost_total, and
+// downstream_budget_used_ratio with reg. onAlert, which may be nil, is
+// called outside any lock when a budget threshold is crossed.
+func NewCosts(conf map[string]*config.Downstream, reg prometheus.Registerer, onAlert func(Alert)) (*Costs, error) {
+	c := &Costs{
+		downstreams: make(map[string]*downstream, len(conf)),
+		hosts:       make(map[string]string),
+		onAlert:     onAlert,
+		now:         time.Now,
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "downstream_calls_total",
+			Help: "Calls made to paid downstreams.",
+		}, []string{"downstream"}),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "downstream_bytes_total",
+			Help: "Bytes exchanged with paid downstreams, by direction (in or out).",
+		}, []string{"downstream", "direction"}),
+		tokens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "downstream_tokens_total",
+			Help: "Tokens consumed from paid downstreams.",
+		}, []string{"downstream"}),
+		spend: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "downstream_cost_total",
+			Help: "Estimated spend on paid downstreams.",
+		}, []string{"downstream"}),
+		ratio: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "downstream_budget_used_ratio",
+			Help: "Fraction of the current period's budget spent, by downstream.",
+		}, []string{"downstream"}),
+	}
+
+	for name, d := range conf {
+		state := &downstream{conf: d, period: d.BudgetPeriod, alert: d.AlertAt}
+		if state.period <= 0 {
+			state.period = 24 * time.Hour
+		}
+		if state.alert <= 0 {
+			state.alert = 0.8
+		}
+		state.usage = Usage{Downstream: name, Budget: d.Budget, PeriodStart: c.now().Truncate(state.period).UTC()}
+		c.downstreams[name] = state
+
+		for _, host := range d.Hosts {
+			host = strings.ToLower(host)
+			if other, ok := c.hosts[host]; ok {
+				return nil, fmt.Errorf("httpclient: host %q belongs to downstreams %q and %q", host, other, name)
+			}
+			c.hosts[host] = name
+		}
+	}
+
+	for _, collector := range []prometheus.Collector{c.calls, c.bytes, c.tokens, c.spend, c.ratio} {
+		if err := reg.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// RecordTokens adds n tokens, e.g. read from an LLM provider's usage
+// report, to the named downstream's spend.
+func (c *Costs) RecordTokens(name string, n int64) {
+	c.record(name, func(d *downstream) float64 {
+		d.usage.Tokens += n
+		c.tokens.WithLabelValues(name).Add(float64(n))
+		return float64(n) * d.conf.CostPerToken
+	})
+}
+
+// Usage returns the consumption of every downstream in its current period,
+// sorted by name.
+func (c *Costs) Usage() []Usage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := make([]Usage, 0, len(c.downstreams))
+	for _, d := range c.downstreams {
+		c.roll(d)
+		out = append(out, d.usage)
+	}
+	slices.SortFunc(out, func(a, b Usage) int { return strings.Compare(a.Downstream, b.Downstream) })
+	return out
+}
+
+// Transport counts calls and bytes sent and received per downstream.
+// Response bytes are counted as the body is read.
+func (c *Costs) Transport(next http.RoundTripper) http.RoundTripper {
+	return costTransport{next: next, costs: c}
+}
+
+// costTransport implements Costs.Transport.
+type costTransport struct {
+	next  http.RoundTripper
+	costs *Costs
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t costTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	name, ok := t.costs.hosts[strings.ToLower(req.URL.Hostname())]
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+
+	out := max(req.ContentLength, 0)
+	t.costs.record(name, func(d *downstream) float64 {
+		d.usage.Calls++
+		d.usage.BytesOut += out
+		t.costs.calls.WithLabelValues(name).Inc()
+		t.costs.bytes.WithLabelValues(name, "out").Add(float64(out))
+		return d.conf.CostPerCall
+	})
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &countingBody{ReadCloser: resp.Body, costs: t.costs, name: name}
+	return resp, nil
+}
+
+// countingBody counts the bytes read from a response body.
+type countingBody struct {
+	io.ReadCloser
+	costs *Costs
+	name  string
+}
+
+// Read implements io.Reader.
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.costs.record(b.name, func(d *downstream) float64 {
+			d.usage.BytesIn += int64(n)
+			b.costs.bytes.WithLabelValues(b.name, "in").Add(float64(n))
+			return 0
+		})
+	}
+	return n, err
+}
+
+// record applies update to the named downstream, adds the cost it
+// returns to the period's spend, and raises any alert it triggers.
+func (c *Costs) record(name string, update func(*downstream) float64) {
+	c.mu.Lock()
+	d, ok := c.downstreams[name]
+	if !ok {
+		c.mu.Unlock()
+		return
+	}
+
+	c.roll(d)
+	cost := update(d)
+	if cost > 0 {
+		d.usage.Spend += cost
+		c.spend.WithLabelValues(name).Add(cost)
+	}
+
+	var alerts []Alert
+	if budget := d.conf.Budget; budget > 0 {
+		c.ratio.WithLabelValues(name).Set(d.usage.Spend / budget)
+		if !d.warned && d.usage.Spend >= budget*d.alert {
+			d.warned = true
+			alerts = append(alerts, Alert{name, AlertWarning, d.usage.Spend, budget, d.usage.PeriodStart})
+		}
+		if !d.over && d.usage.Spend >= budget {
+			d.over = true
+			alerts = append(alerts, Alert{name, AlertExceeded, d.usage.Spend, budget, d.usage.PeriodStart})
+		}
+	}
+	c.mu.Unlock()
+
+	if c.onAlert != nil {
+		for _, a := range alerts {
+			c.onAlert(a)
+		}
+	}
+}
+
+// roll starts a new budget period when the current one is over. The
+// caller holds c.mu.
+func (c *Costs) roll(d *downstream) {
+	start := c.now().Truncate(d.period).UTC()
+	if !start.After(d.usage.PeriodStart) {
+		return
+	}
+	d.usage = Usage{Downstream: d.usage.Downstream, Budget: d.usage.Budget, PeriodStart: start}
+	d.warned, d.over = false, false
+	if d.conf.Budget > 0 {
+		c.ratio.WithLabelValues(d.usage.Downstream).Set(0)
+	}
+}
@@ -0,0 +1,167 @@
+// Package httpclient builds outbound HTTP clients with tuned connection
+// pooling and tracks how often pooled connections are reused, so
+// exhaustion of ephemeral ports towards a busy downstream shows up as a low
+// reuse rate before it becomes an outage. Calls to paid downstreams can be
+// costed against a budget (see Costs).
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/requestid"
+)
+
+// Config tunes the client's connection pool. Zero values use the defaults
+// from DefaultConfig.
+type Config struct {
+	// Timeout bounds the whole request, including reading the body.
+	Timeout time.Duration
+
+	// DialTimeout bounds establishing a TCP connection.
+	DialTimeout time.Duration
+
+	// KeepAlive is the TCP keep-alive probe interval.
+	KeepAlive time.Duration
+
+	// TLSHandshakeTimeout bounds the TLS handshake.
+	TLSHandshakeTimeout time.Duration
+
+	// ResponseHeaderTimeout bounds waiting for response headers.
+	ResponseHeaderTimeout time.Duration
+
+	// IdleConnTimeout is how long an idle pooled connection is kept.
+	IdleConnTimeout time.Duration
+
+	// MaxIdleConns caps idle connections across all hosts.
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost caps idle connections kept per host. The net/http
+	// default of 2 forces new connections (and ephemeral ports) under load.
+	MaxIdleConnsPerHost int
+
+	// MaxConnsPerHost caps total connections per host (0 is unlimited).
+	MaxConnsPerHost int
+
+	// Costs tracks calls and spend per paid downstream; nil disables it.
+	Costs *Costs
+}
+
+// DefaultConfig returns production-ready pool settings.
+func DefaultConfig() Config {
+	return Config{
+		Timeout:               30 * time.Second,
+		DialTimeout:           5 * time.Second,
+		KeepAlive:             30 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: 15 * time.Second,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConns:          200,
+		MaxIdleConnsPerHost:   50,
+	}
+}
+
+// withDefaults fills zero fields from DefaultConfig.
+func (c Config) withDefaults() Config {
+	d := DefaultConfig()
+	pick := func(v, def time.Duration) time.Duration {
+		if v == 0 {
+			return def
+		}
+		return v
+	}
+
+	c.Timeout = pick(c.Timeout, d.Timeout)
+	c.DialTimeout = pick(c.DialTimeout, d.DialTimeout)
+	c.KeepAlive = pick(c.KeepAlive, d.KeepAlive)
+	c.TLSHandshakeTimeout = pick(c.TLSHandshakeTimeout, d.TLSHandshakeTimeout)
+	c.ResponseHeaderTimeout = pick(c.ResponseHeaderTimeout, d.ResponseHeaderTimeout)
+	c.IdleConnTimeout = pick(c.IdleConnTimeout, d.IdleConnTimeout)
+	if c.MaxIdleConns == 0 {
+		c.MaxIdleConns = d.MaxIdleConns
+	}
+	if c.MaxIdleConnsPerHost == 0 {
+		c.MaxIdleConnsPerHost = d.MaxIdleConnsPerHost
+	}
+	return c
+}
+
+// Stats counts the connections used by a client.
+type Stats struct {
+	newConns    atomic.Int64
+	reusedConns atomic.Int64
+}
+
+// StatsSnapshot is a point-in-time copy of Stats.
+type StatsSnapshot struct {
+	NewConns    int64   `json:"newConns"`
+	ReusedConns int64   `json:"reusedConns"`
+	ReuseRate   float64 `json:"reuseRate"`
+}
+
+// Snapshot returns the current counters and the ratio of requests served
+// on a reused connection.
+func (s *Stats) Snapshot() StatsSnapshot {
+	snap := StatsSnapshot{NewConns: s.newConns.Load(), ReusedConns: s.reusedConns.Load()}
+	if total := snap.NewConns + snap.ReusedConns; total > 0 {
+		snap.ReuseRate = float64(snap.ReusedConns) / float64(total)
+	}
+	return snap
+}
+
+// New builds an HTTP client from cfg and returns it with its connection
+// stats. The client forwards the request ID of the request context (see
+// requestid.Transport).
+func New(cfg Config) (*http.Client, *Stats) {
+	cfg = cfg.withDefaults()
+
+	dialer := &net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: cfg.KeepAlive}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		ExpectContinueTimeout: time.Second,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+	}
+
+	var rt http.RoundTripper = transport
+	if cfg.Costs != nil {
+		rt = cfg.Costs.Transport(rt)
+	}
+
+	stats := &Stats{}
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: &trackingTransport{next: requestid.Transport(rt), stats: stats},
+	}, stats
+}
+
+// trackingTransport records whether each request reused a pooled connection.
+type trackingTransport struct {
+	next  http.RoundTripper
+	stats *Stats
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *trackingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				t.stats.reusedConns.Add(1)
+			} else {
+				t.stats.newConns.Add(1)
+			}
+		},
+	}
+
+	ctx := httptrace.WithClientTrace(req.Context(), trace)
+	return t.next.RoundTrip(req.WithContext(ctx))
+}
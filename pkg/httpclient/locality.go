@@ -0,0 +1,154 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNoEndpoints is returned when discovery yields no endpoints.
+var ErrNoEndpoints = errors.New("httpclient: no endpoints available")
+
+// Endpoint is a discovered instance of a downstream service.
+type Endpoint struct {
+	// URL is the endpoint's base URL (scheme and host).
+	URL *url.URL
+
+	// Region and Zone locate the endpoint (e.g., "eu-west-1", "eu-west-1a").
+	Region string
+	Zone   string
+}
+
+// Locality is the region and zone of the calling instance.
+type Locality struct {
+	Region string
+	Zone   string
+}
+
+// rank orders an endpoint: same zone first, then same region, then others.
+func (l Locality) rank(e Endpoint) int {
+	switch {
+	case l.Zone != "" && e.Zone == l.Zone:
+		return 0
+	case l.Region != "" && e.Region == l.Region:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Order returns endpoints sorted by proximity to l, keeping the discovery
+// order within each group.
+func (l Locality) Order(endpoints []Endpoint) []Endpoint {
+	ordered := append([]Endpoint(nil), endpoints...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return l.rank(ordered[i]) < l.rank(ordered[j])
+	})
+	return ordered
+}
+
+// LocalityTransport sends requests to the closest healthy endpoint returned
+// by discovery, failing over to farther ones (eventually cross-region) when
+// a connection fails. Failed endpoints are skipped for Cooldown. Requests
+// with a body are only retried when it can be replayed (GetBody is set).
+type LocalityTransport struct {
+	// Base performs the requests (http.DefaultTransport when nil).
+	Base http.RoundTripper
+
+	// Locality is where the calling instance runs.
+	Locality Locality
+
+	// Discover returns the current endpoints of the downstream service.
+	Discover func() []Endpoint
+
+	// Cooldown is how long a failed endpoint is skipped (defaults to 10s).
+	Cooldown time.Duration
+
+	mu     sync.Mutex
+	failed map[string]time.Time
+}
+
+// RoundTrip sends r to the closest healthy endpoint, failing over on
+// transport errors.
+func (t *LocalityTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	endpoints := t.candidates()
+	if len(endpoints) == 0 {
+		return nil, ErrNoEndpoints
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	var lastErr error
+	for i, e := range endpoints {
+		if i > 0 && r.Body != nil && r.Body != http.NoBody && r.GetBody == nil {
+			break
+		}
+
+		req := r.Clone(r.Context())
+		req.URL.Scheme = e.URL.Scheme
+		req.URL.Host = e.URL.Host
+		req.Host = ""
+		if i > 0 && r.GetBody != nil {
+			body, err := r.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		resp, err := base.RoundTrip(req)
+		if err == nil {
+			return resp, nil
+		}
+		if r.Context().Err() != nil {
+			return nil, err
+		}
+
+		t.markFailed(e)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// candidates returns the endpoints in locality order, healthy ones first.
+func (t *LocalityTransport) candidates() []Endpoint {
+	ordered := t.Locality.Order(t.Discover())
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	healthy := make([]Endpoint, 0, len(ordered))
+	var cooling []Endpoint
+	for _, e := range ordered {
+		if until, ok := t.failed[e.URL.Host]; ok && now.Before(until) {
+			cooling = append(cooling, e)
+			continue
+		}
+		delete(t.failed, e.URL.Host)
+		healthy = append(healthy, e)
+	}
+	return append(healthy, cooling...)
+}
+
+// markFailed skips e for the cooldown period.
+func (t *LocalityTransport) markFailed(e Endpoint) {
+	cooldown := t.Cooldown
+	if cooldown <= 0 {
+		cooldown = 10 * time.Second
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.failed == nil {
+		t.failed = make(map[string]time.Time)
+	}
+	t.failed[e.URL.Host] = time.Now().Add(cooldown)
+}
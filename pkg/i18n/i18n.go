@@ -0,0 +1,290 @@
+// Package i18n localizes the messages the API shows its users. A Bundle
+// holds message catalogs per locale, the embedded ones (see locales/)
+// overlaid with files such as translations/fr.json, and Middleware picks
+// the locale of each request from its Accept-Language header, so handlers
+// translate with the request context:
+//
+//	msg := i18n.T(r.Context(), "orders.limit_reached", "max", 10)
+//
+// Catalogs are JSON objects whose nested keys are joined with dots
+// ({"orders": {"limit_reached": "..."}} is "orders.limit_reached"), and
+// messages name their arguments in braces ("at most {max} orders"). Keys
+// under "validation" localize the messages of the validation package,
+// under "errors" those of the errors package, by error code, and under
+// "status" the titles of problem responses, by status code.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/validation"
+	"github.com/iamBelugaa/go-boilerplate/pkg/web/negotiate"
+)
+
+// NameI18n names Middleware in route group chains.
+const NameI18n = "i18n"
+
+// DefaultLocale is the locale of requests matching no catalog.
+const DefaultLocale = "en"
+
+// embedded holds the catalogs shipped with the package.
+//
+//go:embed locales/*.json
+var embedded embed.FS
+
+// Bundle holds the message catalogs of every locale. It is safe for
+// concurrent use.
+type Bundle struct {
+	mu       sync.RWMutex
+	fallback string
+	catalogs map[string]map[string]string
+}
+
+// NewBundle returns a Bundle with the embedded catalogs, answering
+// requests matching none of its locales in fallback (defaults to
+// DefaultLocale).
+func NewBundle(fallback string) (*Bundle, error) {
+	if fallback == "" {
+		fallback = DefaultLocale
+	}
+	b := &Bundle{fallback: normalize(fallback), catalogs: make(map[string]map[string]string)}
+	if err := b.LoadFS(embedded, "locales"); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Add merges messages, keyed with dots, into the catalog of locale (e.g.,
+// "de" or "pt-BR").
+func (b *Bundle) Add(locale string, messages map[string]string) {
+	locale = normalize(locale)
+
+	b.mu.Lock()
+	if b.catalogs[locale] == nil {
+		b.catalogs[locale] = make(map[string]string, len(messages))
+	}
+	for key, msg := range messages {
+		b.catalogs[locale][key] = msg
+	}
+	b.mu.Unlock()
+}
+
+// LoadFS adds the catalogs of dir in fsys, one JSON file per locale named
+// after it (e.g., "de.json").
+func (b *Bundle) LoadFS(fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("i18n: list %q: %w", dir, err)
+	}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return fmt.Errorf("i18n: read %q: %w", file, err)
+		}
+		var tree map[string]any
+		if err := json.Unmarshal(data, &tree); err != nil {
+			return fmt.Errorf("i18n: parse %q: %w", file, err)
+		}
+		messages := make(map[string]string)
+		if err := flatten("", tree, messages); err != nil {
+			return fmt.Errorf("i18n: parse %q: %w", file, err)
+		}
+		b.Add(strings.TrimSuffix(path.Base(file), ".json"), messages)
+	}
+	return nil
+}
+
+// LoadDir adds the catalogs of a directory, overriding the messages
+// already loaded.
+func (b *Bundle) LoadDir(dir string) error {
+	return b.LoadFS(os.DirFS(dir), ".")
+}
+
+// flatten adds the messages of tree to out, their keys prefixed.
+func flatten(prefix string, tree map[string]any, out map[string]string) error {
+	for key, v := range tree {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := v.(type) {
+		case string:
+			out[key] = v
+		case map[string]any:
+			if err := flatten(key, v, out); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("message %q is neither a string nor an object", key)
+		}
+	}
+	return nil
+}
+
+// Locales returns the locales with a catalog, sorted.
+func (b *Bundle) Locales() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	locales := make([]string, 0, len(b.catalogs))
+	for locale := range b.catalogs {
+		locales = append(locales, locale)
+	}
+	slices.Sort(locales)
+	return locales
+}
+
+// Fallback returns the locale of requests matching no catalog.
+func (b *Bundle) Fallback() string {
+	return b.fallback
+}
+
+// Match returns the locale of the bundle best matching an Accept-Language
+// header value (e.g., "de-CH,de;q=0.9,en;q=0.8"): the first language, by
+// quality, with a catalog for it or for its base language, or the
+// fallback.
+func (b *Bundle) Match(acceptLanguage string) string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, tag := range negotiate.Languages(acceptLanguage) {
+		lang := normalize(tag)
+		if _, ok := b.catalogs[lang]; ok {
+			return lang
+		}
+		if base, _, ok := strings.Cut(lang, "-"); ok {
+			if _, ok := b.catalogs[base]; ok {
+				return base
+			}
+		}
+	}
+	return b.fallback
+}
+
+// Lookup returns the message of key in locale, or in the fallback locale,
+// with its {name} placeholders replaced by args, given as name/value
+// pairs. It reports whether the key was found.
+func (b *Bundle) Lookup(locale, key string, args ...any) (string, bool) {
+	return b.lookup(locale, key, true, args)
+}
+
+// lookup returns the message of key in locale, or in the fallback locale
+// when fallback is set.
+func (b *Bundle) lookup(locale, key string, fallback bool, args []any) (string, bool) {
+	b.mu.RLock()
+	msg, ok := b.catalogs[normalize(locale)][key]
+	if !ok && fallback {
+		msg, ok = b.catalogs[b.fallback][key]
+	}
+	b.mu.RUnlock()
+	if !ok {
+		return "", false
+	}
+	return format(msg, args), true
+}
+
+// format replaces the {name} placeholders of msg with args.
+func format(msg string, args []any) string {
+	if len(args) == 0 || !strings.Contains(msg, "{") {
+		return msg
+	}
+	pairs := make([]string, 0, len(args))
+	for i := 0; i+1 < len(args); i += 2 {
+		pairs = append(pairs, "{"+fmt.Sprint(args[i])+"}", fmt.Sprint(args[i+1]))
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
+
+// normalize returns locale as "language-REGION" (e.g., "pt_br" is
+// "pt-BR").
+func normalize(locale string) string {
+	lang, region, ok := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	if !ok {
+		return strings.ToLower(lang)
+	}
+	return strings.ToLower(lang) + "-" + strings.ToUpper(region)
+}
+
+// localeKey is the context key of the request's locale.
+type localeKey struct{}
+
+// requestLocale is the locale of a request and the bundle it comes from.
+type requestLocale struct {
+	bundle *Bundle
+	locale string
+}
+
+// WithLocale returns a copy of ctx translating into locale with b. The
+// validation messages of the request are rendered with the "validation."
+// messages of locale, falling back to those of the validation package.
+func WithLocale(ctx context.Context, b *Bundle, locale string) context.Context {
+	ctx = validation.WithLanguage(ctx, locale)
+	ctx = validation.WithCatalog(ctx, func(tag string) (string, bool) {
+		return b.lookup(locale, "validation."+tag, false, nil)
+	})
+	return context.WithValue(ctx, localeKey{}, requestLocale{bundle: b, locale: normalize(locale)})
+}
+
+// Locale returns the locale of ctx, or "" outside Middleware.
+func Locale(ctx context.Context) string {
+	rl, _ := ctx.Value(localeKey{}).(requestLocale)
+	return rl.locale
+}
+
+// IsFallback reports whether ctx translates into its bundle's fallback
+// locale, or is outside Middleware.
+func IsFallback(ctx context.Context) bool {
+	rl, ok := ctx.Value(localeKey{}).(requestLocale)
+	return !ok || rl.locale == rl.bundle.fallback
+}
+
+// Lookup returns the message of key in the locale of ctx, like
+// Bundle.Lookup. It reports false outside Middleware.
+func Lookup(ctx context.Context, key string, args ...any) (string, bool) {
+	rl, ok := ctx.Value(localeKey{}).(requestLocale)
+	if !ok {
+		return "", false
+	}
+	return rl.bundle.Lookup(rl.locale, key, args...)
+}
+
+// LookupStrict is Lookup without falling back to the bundle's fallback
+// locale, for callers having a message of their own in that language.
+func LookupStrict(ctx context.Context, key string, args ...any) (string, bool) {
+	rl, ok := ctx.Value(localeKey{}).(requestLocale)
+	if !ok {
+		return "", false
+	}
+	return rl.bundle.lookup(rl.locale, key, false, args)
+}
+
+// T returns the message of key in the locale of ctx, with its
+// placeholders replaced by args, given as name/value pairs. Missing keys
+// are returned as they are, so they stand out without failing requests.
+func T(ctx context.Context, key string, args ...any) string {
+	if msg, ok := Lookup(ctx, key, args...); ok {
+		return msg
+	}
+	return key
+}
+
+// Middleware translates each request into the locale of b best matching
+// its Accept-Language header, announced in the Content-Language response
+// header.
+func Middleware(b *Bundle) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			locale := b.Match(r.Header.Get("Accept-Language"))
+			w.Header().Set("Content-Language", locale)
+			w.Header().Add("Vary", "Accept-Language")
+			next.ServeHTTP(w, r.WithContext(WithLocale(r.Context(), b, locale)))
+		})
+	}
+}
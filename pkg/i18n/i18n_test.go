@@ -0,0 +1,66 @@
+package i18n
+
+import (
+	"context"
+	"testing"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/validation"
+)
+
+func TestMatch(t *testing.T) {
+	b, err := NewBundle("")
+	if err != nil {
+		t.Fatalf("NewBundle: %v", err)
+	}
+	b.Add("pt-BR", map[string]string{"greeting": "olá"})
+
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{accept: "", want: "en"},
+		{accept: "de-CH,en;q=0.5", want: "de"},
+		{accept: "pt_br", want: "pt-BR"},
+		{accept: "fr, en;q=0.1", want: "en"},
+		{accept: "fr", want: "en"},
+	}
+	for _, tt := range tests {
+		if got := b.Match(tt.accept); got != tt.want {
+			t.Errorf("Match(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+// TestValidationMessagesFollowTheBundle checks validation messages come
+// from the request's bundle, without one bundle's messages leaking into
+// requests of another.
+func TestValidationMessagesFollowTheBundle(t *testing.T) {
+	custom, err := NewBundle("")
+	if err != nil {
+		t.Fatalf("NewBundle: %v", err)
+	}
+	custom.Add("de", map[string]string{"validation.required": "Pflichtfeld"})
+	stock, err := NewBundle("")
+	if err != nil {
+		t.Fatalf("NewBundle: %v", err)
+	}
+
+	type input struct {
+		Name string `json:"name" validate:"required"`
+	}
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{name: "custom bundle", ctx: WithLocale(context.Background(), custom, "de"), want: "Pflichtfeld"},
+		{name: "stock bundle", ctx: WithLocale(context.Background(), stock, "de"), want: "ist erforderlich"},
+		{name: "no bundle", ctx: validation.WithLanguage(context.Background(), "de"), want: "is required"},
+	}
+	for _, tt := range tests {
+		fields := validation.AsFieldErrors(validation.CheckCtx(tt.ctx, &input{}))
+		if len(fields) != 1 || fields[0].Err != tt.want {
+			t.Errorf("%s: errors = %v, want %q", tt.name, fields, tt.want)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+// Package idempotency makes unsafe requests safe to retry: the response to
+// the first request carrying an Idempotency-Key header is stored and
+// replayed to retries with the same key, so a client that timed out can
+// resend a payment or an order without it being processed twice.
+//
+// Keys are scoped to the authenticated principal. A retry whose method,
+// path, query, or body differ from the original is rejected 422
+// Unprocessable Entity, and one arriving while the original is in flight
+// 409 Conflict. Server errors and panics are forgotten, so a later retry
+// runs again.
+//
+// Records are kept in a Store: Memory for a single replica, or Redis,
+// shared by every replica:
+//
+//	store, err := idempotency.NewStore(conf.Server.Idempotency)
+//	group.Use(middleware.Named{Name: idempotency.NameIdempotency,
+//		Wrap: idempotency.Middleware(conf.Server.Idempotency, store, onError)})
+//
+// It must run after authentication.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+	"github.com/iamBelugaa/go-boilerplate/pkg/auth"
+)
+
+// NameIdempotency names Middleware in route group chains.
+const NameIdempotency = "idempotency"
+
+// Headers read and written by Middleware.
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+)
+
+// Defaults applied when the configuration leaves a value unset.
+const (
+	DefaultTTL          = 24 * time.Hour
+	DefaultLockTimeout  = time.Minute
+	DefaultMaxBodyBytes = 1 << 20
+)
+
+// maxKeyLength bounds the Idempotency-Key header.
+const maxKeyLength = 255
+
+// maxReplayBytes caps the response body stored for replay; retries of
+// larger responses are answered 409 Conflict instead.
+const maxReplayBytes = 1 << 20
+
+// Record is the state of a key: in flight until Completed, then the
+// response to replay.
+type Record struct {
+	Fingerprint string      `json:"fingerprint"`
+	Completed   bool        `json:"completed"`
+	Oversized   bool        `json:"oversized,omitempty"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+// Store persists records. Implementations must be safe for concurrent use.
+type Store interface {
+	// Lock stores rec under key for ttl unless a record is already stored
+	// there, which it returns instead; it returns nil when rec was stored.
+	Lock(ctx context.Context, key string, rec *Record, ttl time.Duration) (*Record, error)
+
+	// Save replaces the record stored under key with rec, for ttl.
+	Save(ctx context.Context, key string, rec *Record, ttl time.Duration) error
+
+	// Delete forgets the record stored under key.
+	Delete(ctx context.Context, key string) error
+}
+
+// NewStore returns the Store selected by conf (nil uses Memory).
+func NewStore(conf *config.Idempotency) (Store, error) {
+	if conf != nil && conf.Store == "redis" {
+		return NewRedis(conf.RedisURL)
+	}
+	return NewMemory(), nil
+}
+
+// keys is the state of a Middleware.
+type keys struct {
+	store       Store
+	onError     func(error)
+	ttl         time.Duration
+	lockTimeout time.Duration
+	maxBody     int64
+	required    bool
+}
+
+// Middleware stores the responses to POST, PUT, PATCH, and DELETE requests
+// carrying an Idempotency-Key header in store and replays them to retries,
+// marked with "Idempotent-Replayed: true". Requests without the header
+// pass through, unless conf requires it. Store errors are reported to
+// onError, which may be nil, and answered 503 Service Unavailable rather
+// than risking processing a request twice. A nil conf uses the defaults.
+func Middleware(conf *config.Idempotency, store Store, onError func(error)) func(http.Handler) http.Handler {
+	k := &keys{
+		store:       store,
+		onError:     onError,
+		ttl:         DefaultTTL,
+		lockTimeout: DefaultLockTimeout,
+		maxBody:     DefaultMaxBodyBytes,
+	}
+	if conf != nil {
+		if conf.TTL > 0 {
+			k.ttl = conf.TTL
+		}
+		if conf.LockTimeout > 0 {
+			k.lockTimeout = conf.LockTimeout
+		}
+		if conf.MaxBodyBytes > 0 {
+			k.maxBody = conf.MaxBodyBytes
+		}
+		k.required = conf.Required
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isUnsafe(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := r.Header.Get(HeaderKey)
+			switch {
+			case key == "" && k.required:
+				writeError(w, http.StatusBadRequest, "missing "+HeaderKey+" header")
+				return
+			case key == "":
+				next.ServeHTTP(w, r)
+				return
+			case len(key) > maxKeyLength:
+				writeError(w, http.StatusBadRequest, "invalid "+HeaderKey+" header")
+				return
+			}
+
+			fingerprint, ok := k.fingerprint(r)
+			if !ok {
+				writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+				return
+			}
+
+			ctx := context.WithoutCancel(r.Context())
+			storeKey := scopedKey(r, key)
+			existing, err := k.store.Lock(ctx, storeKey, &Record{Fingerprint: fingerprint}, k.lockTimeout)
+			if err != nil {
+				k.report(err)
+				writeError(w, http.StatusServiceUnavailable, "idempotency store unavailable")
+				return
+			}
+			if existing != nil {
+				replay(w, r, existing, fingerprint)
+				return
+			}
+
+			rec := &recorder{ResponseWriter: w}
+			completed := false
+			defer func() { k.finish(ctx, storeKey, fingerprint, rec, completed) }()
+
+			next.ServeHTTP(rec, r)
+			completed = true
+		})
+	}
+}
+
+// isUnsafe reports whether method changes server state.
+func isUnsafe(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// fingerprint hashes the request's method, path, query, and body,
+// restoring the body for the handler. It fails for bodies over the limit.
+func (k *keys) fingerprint(r *http.Request) (string, bool) {
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		buf, err := io.ReadAll(io.LimitReader(r.Body, k.maxBody+1))
+		if err != nil || int64(len(buf)) > k.maxBody {
+			return "", false
+		}
+		r.Body = io.NopCloser(bytes.NewReader(buf))
+		body = buf
+	}
+	return hash(r.Method, r.URL.Path, r.URL.Query().Encode(), string(body)), true
+}
+
+// scopedKey returns the store key of the Idempotency-Key header value,
+// scoped to the principal so clients cannot read each other's responses.
+func scopedKey(r *http.Request, key string) string {
+	parts := []string{key}
+	if p := auth.PrincipalFromContext(r.Context()); p != nil {
+		parts = append(parts, p.Subject, p.ClientID, p.ImpersonatedBy)
+	}
+	return hash(parts...)
+}
+
+// hash returns the hex SHA-256 of parts.
+func hash(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		// Length prefixes keep adjacent parts from running into each other.
+		_ = binary.Write(h, binary.BigEndian, uint64(len(part)))
+		h.Write([]byte(part))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// finish stores the recorded response for replay, or forgets the key
+// after a panic or server error so a retry runs again.
+func (k *keys) finish(ctx context.Context, key, fingerprint string, rec *recorder, completed bool) {
+	status := rec.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if !completed || status >= http.StatusInternalServerError {
+		if err := k.store.Delete(ctx, key); err != nil {
+			k.report(err)
+		}
+		return
+	}
+
+	saved := &Record{Fingerprint: fingerprint, Completed: true, Status: status}
+	if rec.overflow {
+		saved.Oversized = true
+	} else {
+		saved.Header = rec.header
+		if saved.Header == nil {
+			saved.Header = rec.Header().Clone()
+		}
+		saved.Body = rec.body
+	}
+	if err := k.store.Save(ctx, key, saved, k.ttl); err != nil {
+		k.report(err)
+	}
+}
+
+// replay answers a retry with the stored record.
+func replay(w http.ResponseWriter, r *http.Request, rec *Record, fingerprint string) {
+	switch {
+	case rec.Fingerprint != fingerprint:
+		writeError(w, http.StatusUnprocessableEntity, HeaderKey+" was used for a different request")
+		return
+	case !rec.Completed:
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusConflict, "a request with this "+HeaderKey+" is in progress")
+		return
+	case rec.Oversized:
+		writeError(w, http.StatusConflict, "the response to this "+HeaderKey+" is too large to replay")
+		return
+	}
+
+	// Headers already set by outer middleware, such as the request ID,
+	// belong to this request.
+	h := w.Header()
+	for k, v := range rec.Header {
+		if _, ok := h[k]; !ok {
+			h[k] = v
+		}
+	}
+	h.Set(HeaderReplayed, "true")
+	h.Set("Content-Length", strconv.Itoa(len(rec.Body)))
+	w.WriteHeader(rec.Status)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(rec.Body)
+	}
+}
+
+// report passes err to onError.
+func (k *keys) report(err error) {
+	if k.onError != nil {
+		k.onError(err)
+	}
+}
+
+// writeError answers status with a JSON error.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+// recorder passes the response through while keeping a copy for replay.
+type recorder struct {
+	http.ResponseWriter
+	status   int
+	header   http.Header
+	body     []byte
+	overflow bool
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *recorder) WriteHeader(status int) {
+	if w.status == 0 && status >= http.StatusOK {
+		w.status = status
+		w.header = w.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter.
+func (w *recorder) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.overflow {
+		if len(w.body)+len(p) > maxReplayBytes {
+			w.overflow, w.body = true, nil
+		} else {
+			w.body = append(w.body, p...)
+		}
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush marks the response as streamed, which is never replayed, and
+// flushes it.
+func (w *recorder) Flush() {
+	w.overflow, w.body = true, nil
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *recorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,68 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often Memory drops expired records.
+const sweepInterval = time.Minute
+
+// Memory is an in-process Store. It suits single-replica services; with
+// several replicas, a retry reaching another replica runs again.
+type Memory struct {
+	mu        sync.Mutex
+	records   map[string]memoryRecord
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// memoryRecord is a stored record with its expiry.
+type memoryRecord struct {
+	rec     *Record
+	expires time.Time
+}
+
+// NewMemory constructs an empty Memory store.
+func NewMemory() *Memory {
+	return &Memory{records: make(map[string]memoryRecord), now: time.Now}
+}
+
+// Lock implements Store.
+func (m *Memory) Lock(_ context.Context, key string, rec *Record, ttl time.Duration) (*Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if now.Sub(m.lastSweep) >= sweepInterval {
+		for k, mr := range m.records {
+			if !now.Before(mr.expires) {
+				delete(m.records, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	if mr, ok := m.records[key]; ok && now.Before(mr.expires) {
+		return mr.rec, nil
+	}
+	m.records[key] = memoryRecord{rec: rec, expires: now.Add(ttl)}
+	return nil, nil
+}
+
+// Save implements Store.
+func (m *Memory) Save(_ context.Context, key string, rec *Record, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[key] = memoryRecord{rec: rec, expires: m.now().Add(ttl)}
+	return nil
+}
+
+// Delete implements Store.
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, key)
+	return nil
+}
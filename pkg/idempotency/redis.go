@@ -0,0 +1,271 @@
+package idempotency
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redisKeyPrefix namespaces the keys of a Redis store.
+const redisKeyPrefix = "idempotency:"
+
+// redisTimeout bounds commands whose context has no deadline.
+const redisTimeout = 5 * time.Second
+
+// redisMaxIdle is the number of idle connections kept for reuse.
+const redisMaxIdle = 8
+
+// errRedisNil is the reply to a command finding nothing.
+var errRedisNil = errors.New("idempotency: redis: nil reply")
+
+// Redis is a Store kept in Redis, shared by every replica. It speaks the
+// RESP protocol itself and needs Redis 2.6.12 or later (SET with NX and PX).
+type Redis struct {
+	addr     string
+	username string
+	password string
+	db       int
+	tls      *tls.Config
+	idle     chan *redisConn
+}
+
+// NewRedis constructs a Redis store for rawURL, of the form
+// "redis://[user:password@]host:port[/db]", or "rediss://" for TLS.
+func NewRedis(rawURL string) (*Redis, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("idempotency: redis url: %w", err)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("idempotency: redis url: unsupported scheme %q", u.Scheme)
+	}
+
+	r := &Redis{addr: u.Host, idle: make(chan *redisConn, redisMaxIdle)}
+	if u.Port() == "" {
+		r.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		r.username = u.User.Username()
+		r.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if r.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("idempotency: redis url: invalid database %q", db)
+		}
+	}
+	if u.Scheme == "rediss" {
+		r.tls = &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+	}
+	return r, nil
+}
+
+// Lock implements Store with SET NX, reading the existing record when the
+// key is taken.
+func (r *Redis) Lock(ctx context.Context, key string, rec *Record, ttl time.Duration) (*Record, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+
+	// The existing record may expire between SET and GET; try again then.
+	for range 3 {
+		_, err := r.do(ctx, "SET", redisKeyPrefix+key, string(data), "NX", "PX", milliseconds(ttl))
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, errRedisNil) {
+			return nil, err
+		}
+
+		reply, err := r.do(ctx, "GET", redisKeyPrefix+key)
+		if errors.Is(err, errRedisNil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var existing Record
+		if err := json.Unmarshal([]byte(reply), &existing); err != nil {
+			return nil, fmt.Errorf("idempotency: redis: decode record: %w", err)
+		}
+		return &existing, nil
+	}
+	return nil, errors.New("idempotency: redis: key keeps expiring")
+}
+
+// Save implements Store.
+func (r *Redis) Save(ctx context.Context, key string, rec *Record, ttl time.Duration) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	_, err = r.do(ctx, "SET", redisKeyPrefix+key, string(data), "PX", milliseconds(ttl))
+	return err
+}
+
+// Delete implements Store.
+func (r *Redis) Delete(ctx context.Context, key string) error {
+	_, err := r.do(ctx, "DEL", redisKeyPrefix+key)
+	return err
+}
+
+// Close closes the idle connections.
+func (r *Redis) Close() error {
+	for {
+		select {
+		case c := <-r.idle:
+			_ = c.conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// milliseconds formats d for the PX option.
+func milliseconds(d time.Duration) string {
+	return strconv.FormatInt(max(d.Milliseconds(), 1), 10)
+}
+
+// redisConn is a connection with its reply reader.
+type redisConn struct {
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// do runs a command and returns its reply, or errRedisNil for a nil reply.
+// Connections are reused unless the command failed on the network.
+func (r *Redis) do(ctx context.Context, args ...string) (string, error) {
+	c, err := r.conn(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(redisTimeout)
+	}
+	_ = c.conn.SetDeadline(deadline)
+
+	reply, err := c.roundTrip(args)
+	if err != nil && !errors.Is(err, errRedisNil) {
+		var replyErr redisError
+		if !errors.As(err, &replyErr) {
+			_ = c.conn.Close()
+			return "", fmt.Errorf("idempotency: redis: %w", err)
+		}
+		err = fmt.Errorf("idempotency: redis: %w", err)
+	}
+
+	select {
+	case r.idle <- c:
+	default:
+		_ = c.conn.Close()
+	}
+	return reply, err
+}
+
+// conn returns an idle connection, or dials, authenticates, and selects
+// the database of a new one.
+func (r *Redis) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case c := <-r.idle:
+		return c, nil
+	default:
+	}
+
+	dialer := &net.Dialer{Timeout: redisTimeout}
+	var conn net.Conn
+	var err error
+	if r.tls != nil {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: r.tls}).DialContext(ctx, "tcp", r.addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", r.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("idempotency: redis: %w", err)
+	}
+
+	c := &redisConn{conn: conn, rd: bufio.NewReader(conn)}
+	_ = conn.SetDeadline(time.Now().Add(redisTimeout))
+	var setup [][]string
+	switch {
+	case r.username != "":
+		setup = append(setup, []string{"AUTH", r.username, r.password})
+	case r.password != "":
+		setup = append(setup, []string{"AUTH", r.password})
+	}
+	if r.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(r.db)})
+	}
+	for _, args := range setup {
+		if _, err := c.roundTrip(args); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("idempotency: redis: %s: %w", args[0], err)
+		}
+	}
+	return c, nil
+}
+
+// redisError is an error reply.
+type redisError string
+
+// Error implements error.
+func (e redisError) Error() string {
+	return string(e)
+}
+
+// roundTrip writes a command and reads its reply.
+func (c *redisConn) roundTrip(args []string) (string, error) {
+	var b strings.Builder
+	b.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		b.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n")
+	}
+	if _, err := io.WriteString(c.conn, b.String()); err != nil {
+		return "", err
+	}
+	return c.readReply()
+}
+
+// readReply reads a simple string, error, integer, or bulk string reply.
+func (c *redisConn) readReply() (string, error) {
+	line, err := c.rd.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return "", errors.New("malformed reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", redisError(line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", errors.New("malformed bulk reply")
+		}
+		if n < 0 {
+			return "", errRedisNil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.rd, buf); err != nil {
+			return "", err
+		}
+		return string(buf[:n]), nil
+	default:
+		return "", fmt.Errorf("unexpected reply %q", line[0])
+	}
+}
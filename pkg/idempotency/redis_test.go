@@ -0,0 +1,298 @@
+package idempotency
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is a RESP server answering each command with the raw reply
+// returned by its script, or closing the connection when the reply is "".
+type fakeRedis struct {
+	ln     net.Listener
+	script func(args []string) string
+
+	mu       sync.Mutex
+	conns    int
+	commands []string
+}
+
+func newFakeRedis(t *testing.T, script func(args []string) string) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	f := &fakeRedis{ln: ln, script: script}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.conns++
+			f.mu.Unlock()
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+// url returns the URL of the server, with userinfo and path appended.
+func (f *fakeRedis) url(userinfo, path string) string {
+	return "redis://" + userinfo + f.ln.Addr().String() + path
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	rd := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(rd)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.commands = append(f.commands, strings.Join(args, " "))
+		f.mu.Unlock()
+
+		reply := f.script(args)
+		if reply == "" {
+			return
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// readCommand reads a command sent as an array of bulk strings.
+func readCommand(rd *bufio.Reader) ([]string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+	if err != nil || line[0] != '*' {
+		return nil, errors.New("not an array")
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+		if err != nil || line[0] != '$' {
+			return nil, errors.New("not a bulk string")
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func (f *fakeRedis) stats() (conns int, commands []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.conns, append([]string(nil), f.commands...)
+}
+
+// bulk encodes s as a bulk string reply.
+func bulk(s string) string {
+	return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n"
+}
+
+func newTestRedis(t *testing.T, rawURL string) *Redis {
+	t.Helper()
+	r, err := NewRedis(rawURL)
+	if err != nil {
+		t.Fatalf("NewRedis: %v", err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+	return r
+}
+
+func TestNewRedis(t *testing.T) {
+	tests := []struct {
+		url     string
+		addr    string
+		db      int
+		tls     bool
+		wantErr bool
+	}{
+		{url: "redis://cache", addr: "cache:6379"},
+		{url: "redis://:secret@cache:6380/3", addr: "cache:6380", db: 3},
+		{url: "rediss://cache", addr: "cache:6379", tls: true},
+		{url: "http://cache", wantErr: true},
+		{url: "redis://cache/one", wantErr: true},
+	}
+	for _, tt := range tests {
+		r, err := NewRedis(tt.url)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("NewRedis(%q) succeeded, want an error", tt.url)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("NewRedis(%q): %v", tt.url, err)
+			continue
+		}
+		if r.addr != tt.addr || r.db != tt.db || (r.tls != nil) != tt.tls {
+			t.Errorf("NewRedis(%q) = addr %q, db %d, tls %v; want %q, %d, %v", tt.url, r.addr, r.db, r.tls != nil, tt.addr, tt.db, tt.tls)
+		}
+	}
+}
+
+func TestRedisLockStoresRecord(t *testing.T) {
+	f := newFakeRedis(t, func([]string) string { return "+OK\r\n" })
+	r := newTestRedis(t, f.url("", ""))
+
+	rec := &Record{Fingerprint: "abc"}
+	existing, err := r.Lock(context.Background(), "k", rec, 1500*time.Millisecond)
+	if err != nil || existing != nil {
+		t.Fatalf("Lock = %v, %v; want nil, nil", existing, err)
+	}
+
+	data, _ := json.Marshal(rec)
+	_, commands := f.stats()
+	if want := "SET idempotency:k " + string(data) + " NX PX 1500"; len(commands) != 1 || commands[0] != want {
+		t.Fatalf("commands = %q, want [%q]", commands, want)
+	}
+}
+
+func TestRedisLockReturnsExistingRecord(t *testing.T) {
+	stored, _ := json.Marshal(&Record{Fingerprint: "abc", Completed: true, Status: 201})
+	f := newFakeRedis(t, func(args []string) string {
+		if args[0] == "SET" {
+			return "$-1\r\n" // NX found the key taken
+		}
+		return bulk(string(stored))
+	})
+	r := newTestRedis(t, f.url("", ""))
+
+	existing, err := r.Lock(context.Background(), "k", &Record{Fingerprint: "abc"}, time.Minute)
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	if existing == nil || !existing.Completed || existing.Status != 201 {
+		t.Fatalf("Lock returned %+v, want the stored record", existing)
+	}
+}
+
+func TestRedisLockGivesUpWhenKeyKeepsExpiring(t *testing.T) {
+	// SET finds the key taken, and it expires before every GET.
+	f := newFakeRedis(t, func([]string) string { return "$-1\r\n" })
+	r := newTestRedis(t, f.url("", ""))
+
+	_, err := r.Lock(context.Background(), "k", &Record{}, time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "keeps expiring") {
+		t.Fatalf("Lock error = %v, want the key to keep expiring", err)
+	}
+
+	conns, commands := f.stats()
+	var kinds []string
+	for _, c := range commands {
+		kinds = append(kinds, strings.Fields(c)[0])
+	}
+	if got, want := strings.Join(kinds, " "), "SET GET SET GET SET GET"; got != want {
+		t.Errorf("commands = %s, want %s", got, want)
+	}
+	if conns != 1 {
+		t.Errorf("connections = %d, want nil replies to keep the connection", conns)
+	}
+}
+
+func TestRedisErrorReplyKeepsConnection(t *testing.T) {
+	f := newFakeRedis(t, func(args []string) string {
+		if args[0] == "SET" {
+			return "-OOM command not allowed when used memory > 'maxmemory'\r\n"
+		}
+		return ":1\r\n"
+	})
+	r := newTestRedis(t, f.url("", ""))
+
+	err := r.Save(context.Background(), "k", &Record{}, time.Minute)
+	var replyErr redisError
+	if !errors.As(err, &replyErr) || !strings.HasPrefix(string(replyErr), "OOM") {
+		t.Fatalf("Save error = %v, want the OOM error reply", err)
+	}
+
+	if err := r.Delete(context.Background(), "k"); err != nil {
+		t.Fatalf("Delete after an error reply: %v", err)
+	}
+	if conns, _ := f.stats(); conns != 1 {
+		t.Errorf("connections = %d, want the connection reused after an error reply", conns)
+	}
+}
+
+func TestRedisNetworkErrorDropsConnection(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	f := newFakeRedis(t, func([]string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			return "" // hang up without replying
+		}
+		return ":1\r\n"
+	})
+	r := newTestRedis(t, f.url("", ""))
+
+	if err := r.Delete(context.Background(), "k"); err == nil {
+		t.Fatal("Delete succeeded on a closed connection")
+	}
+	if err := r.Delete(context.Background(), "k"); err != nil {
+		t.Fatalf("Delete on a new connection: %v", err)
+	}
+	if conns, _ := f.stats(); conns != 2 {
+		t.Errorf("connections = %d, want a new one after the network error", conns)
+	}
+}
+
+func TestRedisAuthenticatesAndSelects(t *testing.T) {
+	f := newFakeRedis(t, func(args []string) string {
+		if args[0] == "DEL" {
+			return ":0\r\n"
+		}
+		return "+OK\r\n"
+	})
+	r := newTestRedis(t, f.url("app:s3cret@", "/2"))
+
+	for range 2 {
+		if err := r.Delete(context.Background(), "k"); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+	}
+
+	_, commands := f.stats()
+	want := []string{"AUTH app s3cret", "SELECT 2", "DEL idempotency:k", "DEL idempotency:k"}
+	if strings.Join(commands, "; ") != strings.Join(want, "; ") {
+		t.Fatalf("commands = %q, want %q", commands, want)
+	}
+}
+
+func TestRedisAuthFailure(t *testing.T) {
+	f := newFakeRedis(t, func([]string) string { return "-WRONGPASS invalid username-password pair\r\n" })
+	r := newTestRedis(t, f.url(":wrong@", ""))
+
+	err := r.Delete(context.Background(), "k")
+	if err == nil || !strings.Contains(err.Error(), "AUTH: WRONGPASS") {
+		t.Fatalf("Delete error = %v, want the AUTH failure", err)
+	}
+}
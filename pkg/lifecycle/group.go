@@ -0,0 +1,268 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultStopTimeout is the stop budget of components that set none.
+const DefaultStopTimeout = 10 * time.Second
+
+// Component is a subsystem run by a Group, such as the HTTP server, a
+// database pool, a worker pool, a consumer, or a scheduler. Every hook is
+// optional.
+type Component struct {
+	// Name identifies the component in dependencies and results.
+	Name string
+
+	// DependsOn names the components started before this one and stopped
+	// after it.
+	DependsOn []string
+
+	// Start prepares the component (e.g., opens the pool) before the
+	// components depending on it start.
+	Start HookFunc
+
+	// Run runs the component until its context is cancelled (e.g., a
+	// server or a consume loop). An error before shutdown stops the group.
+	Run HookFunc
+
+	// Stop releases the component (e.g., closes the pool) once Run has
+	// returned.
+	Stop HookFunc
+
+	// StopTimeout is the budget for Run to return and Stop to complete
+	// (defaults to DefaultStopTimeout).
+	StopTimeout time.Duration
+}
+
+// Group starts components in dependency order and, once the process
+// receives SIGINT or SIGTERM or a component fails, stops them in reverse
+// order, each within its own budget, so a server stops taking requests
+// before the pool its handlers use is closed.
+type Group struct {
+	mu         sync.Mutex
+	components []Component
+	results    []Result
+}
+
+// NewGroup constructs an empty Group.
+func NewGroup() *Group {
+	return &Group{}
+}
+
+// Add registers c. Components without dependencies between them start in
+// registration order.
+func (g *Group) Add(c Component) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.components = append(g.components, c)
+}
+
+// running is a started component.
+type running struct {
+	Component
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+// Run starts the components and blocks until ctx is cancelled, the process
+// receives SIGINT or SIGTERM, or a component's Run fails, then stops every
+// started component. Components exceeding their stop budget are abandoned
+// and reported with an error wrapping context.DeadlineExceeded. Start,
+// run, and stop errors are returned together.
+func (g *Group) Run(ctx context.Context) error {
+	order, err := g.order()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var errs []error
+	exited := make(chan *running, len(order))
+	started := make([]*running, 0, len(order))
+	for _, c := range order {
+		if ctx.Err() != nil {
+			break
+		}
+		if c.Start != nil {
+			if err := c.Start(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("lifecycle: start %q: %w", c.Name, err))
+				break
+			}
+		}
+
+		rc := &running{Component: c, done: make(chan struct{})}
+		started = append(started, rc)
+		if c.Run == nil {
+			close(rc.done)
+			continue
+		}
+
+		// Each component is cancelled in turn during shutdown, not all
+		// at once by the signal.
+		var runCtx context.Context
+		runCtx, rc.cancel = context.WithCancel(context.WithoutCancel(ctx))
+		go func() {
+			defer close(rc.done)
+			rc.err = c.Run(runCtx)
+			exited <- rc
+		}()
+	}
+
+	if len(errs) == 0 {
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				break wait
+			case rc := <-exited:
+				if rc.err != nil {
+					errs = append(errs, fmt.Errorf("lifecycle: run %q: %w", rc.Name, rc.err))
+					rc.err = nil
+					break wait
+				}
+			}
+		}
+	}
+	stop()
+
+	results := make([]Result, 0, len(started))
+	for _, rc := range slices.Backward(started) {
+		res, err := stopComponent(rc)
+		results = append(results, res)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	g.mu.Lock()
+	g.results = results
+	g.mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// StopResults returns the results of stopping each component in the last
+// run, in the order they were stopped.
+func (g *Group) StopResults() []Result {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return slices.Clone(g.results)
+}
+
+// stopComponent cancels the component's Run, waits for it to return, and
+// calls Stop, within the stop budget.
+func stopComponent(rc *running) (Result, error) {
+	budget := rc.StopTimeout
+	if budget <= 0 {
+		budget = DefaultStopTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), budget)
+	defer cancel()
+
+	start := time.Now()
+	finished := make(chan error, 1)
+	go func() {
+		if rc.cancel != nil {
+			rc.cancel()
+			select {
+			case <-rc.done:
+			case <-ctx.Done():
+				finished <- ctx.Err()
+				return
+			}
+			if rc.err != nil {
+				finished <- rc.err
+				return
+			}
+		}
+		if rc.Stop != nil {
+			finished <- rc.Stop(ctx)
+			return
+		}
+		finished <- nil
+	}()
+
+	var err error
+	select {
+	case err = <-finished:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := Result{Name: rc.Name, Duration: time.Since(start)}
+	switch {
+	case err == nil:
+		return res, nil
+	case errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil:
+		res.TimedOut = true
+		err = fmt.Errorf("lifecycle: stop %q: exceeded its %s budget: %w", rc.Name, budget, err)
+	default:
+		err = fmt.Errorf("lifecycle: stop %q: %w", rc.Name, err)
+	}
+	res.Error = err.Error()
+	return res, err
+}
+
+// order sorts the components so each comes after its dependencies,
+// keeping registration order otherwise.
+func (g *Group) order() ([]Component, error) {
+	g.mu.Lock()
+	components := slices.Clone(g.components)
+	g.mu.Unlock()
+
+	byName := make(map[string]bool, len(components))
+	for _, c := range components {
+		if byName[c.Name] {
+			return nil, fmt.Errorf("lifecycle: component %q registered twice", c.Name)
+		}
+		byName[c.Name] = true
+	}
+	for _, c := range components {
+		for _, dep := range c.DependsOn {
+			if !byName[dep] {
+				return nil, fmt.Errorf("lifecycle: component %q depends on unknown %q", c.Name, dep)
+			}
+		}
+	}
+
+	placed := make(map[string]bool, len(components))
+	order := make([]Component, 0, len(components))
+	for len(order) < len(components) {
+		// Place the first component whose dependencies are placed.
+		i := slices.IndexFunc(components, func(c Component) bool {
+			return !placed[c.Name] && allPlaced(placed, c.DependsOn)
+		})
+		if i < 0 {
+			var cycle []string
+			for _, c := range components {
+				if !placed[c.Name] {
+					cycle = append(cycle, c.Name)
+				}
+			}
+			return nil, fmt.Errorf("lifecycle: dependency cycle among %q", cycle)
+		}
+		placed[components[i].Name] = true
+		order = append(order, components[i])
+	}
+	return order, nil
+}
+
+// allPlaced reports whether every name is placed.
+func allPlaced(placed map[string]bool, names []string) bool {
+	for _, name := range names {
+		if !placed[name] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,169 @@
+// Package lifecycle runs deployment hooks around the period an instance
+// serves traffic. Pre-traffic hooks (e.g., migrations applied, caches warmed)
+// must all succeed before the instance reports ready; post-traffic hooks
+// (e.g., flushing buffers, emitting a deployment marker) run once shutdown
+// begins, after the instance has stopped reporting ready.
+//
+// A Group runs the process's subsystems, starting them in dependency order
+// and stopping them in reverse on SIGTERM, each within its own budget:
+//
+//	group := lifecycle.NewGroup()
+//	group.Add(lifecycle.Component{Name: "db", Start: pool.Ping, Stop: pool.Close})
+//	group.Add(lifecycle.Component{Name: "worker", DependsOn: []string{"db"}, Run: worker.Run})
+//	group.Add(lifecycle.Component{Name: "http", DependsOn: []string{"db"}, Run: srv.Run})
+//	err := group.Run(ctx)
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNotReady is returned by pre-traffic hooks that cannot succeed yet.
+var ErrNotReady = errors.New("lifecycle: not ready")
+
+// HookFunc is a deployment hook.
+type HookFunc func(ctx context.Context) error
+
+// hook is a registered, named deployment hook.
+type hook struct {
+	name    string
+	fn      HookFunc
+	timeout time.Duration
+}
+
+// Result reports the outcome of a single hook.
+type Result struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+	TimedOut bool          `json:"timedOut,omitempty"`
+}
+
+// Hooks holds the registered deployment hooks and the readiness they gate.
+type Hooks struct {
+	mu      sync.Mutex
+	pre     []hook
+	post    []hook
+	results []Result
+	ready   atomic.Bool
+}
+
+// New constructs an empty set of hooks. The instance is not ready until
+// RunPreTraffic succeeds.
+func New() *Hooks {
+	return &Hooks{}
+}
+
+// PreTraffic registers a hook that must succeed, within timeout (zero for
+// no limit), before the instance reports ready. Hooks run in registration
+// order.
+func (h *Hooks) PreTraffic(name string, timeout time.Duration, fn HookFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pre = append(h.pre, hook{name: name, fn: fn, timeout: timeout})
+}
+
+// PostTraffic registers a hook run, within timeout (zero for no limit),
+// after shutdown begins. Hooks run in registration order.
+func (h *Hooks) PostTraffic(name string, timeout time.Duration, fn HookFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.post = append(h.post, hook{name: name, fn: fn, timeout: timeout})
+}
+
+// RunPreTraffic runs the pre-traffic hooks in order, stopping at the first
+// failure, and marks the instance ready once all of them succeed.
+func (h *Hooks) RunPreTraffic(ctx context.Context) error {
+	h.mu.Lock()
+	hooks := append([]hook(nil), h.pre...)
+	h.mu.Unlock()
+
+	results := make([]Result, 0, len(hooks))
+	defer func() { h.setResults(results) }()
+
+	for _, hk := range hooks {
+		res, err := run(ctx, hk)
+		results = append(results, res)
+		if err != nil {
+			return fmt.Errorf("lifecycle: pre-traffic hook %q: %w", hk.name, err)
+		}
+	}
+
+	h.ready.Store(true)
+	return nil
+}
+
+// RunPostTraffic marks the instance not ready and runs every post-traffic
+// hook, even when earlier ones fail, returning their joined errors.
+func (h *Hooks) RunPostTraffic(ctx context.Context) error {
+	h.ready.Store(false)
+
+	h.mu.Lock()
+	hooks := append([]hook(nil), h.post...)
+	h.mu.Unlock()
+
+	var errs []error
+	for _, hk := range hooks {
+		if _, err := run(ctx, hk); err != nil {
+			errs = append(errs, fmt.Errorf("lifecycle: post-traffic hook %q: %w", hk.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Ready reports whether every pre-traffic hook has succeeded and shutdown
+// has not begun.
+func (h *Hooks) Ready() bool {
+	return h.ready.Load()
+}
+
+// ReadyHandler serves the readiness probe: 200 OK when ready and 503
+// Service Unavailable otherwise, with the last pre-traffic results.
+func (h *Hooks) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := http.StatusOK
+		if !h.Ready() {
+			status = http.StatusServiceUnavailable
+		}
+
+		h.mu.Lock()
+		results := h.results
+		h.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]any{"ready": status == http.StatusOK, "hooks": results})
+	})
+}
+
+// setResults records the results of the latest pre-traffic run.
+func (h *Hooks) setResults(results []Result) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.results = results
+}
+
+// run executes a single hook within its timeout.
+func run(ctx context.Context, hk hook) (Result, error) {
+	if hk.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, hk.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := hk.fn(ctx)
+
+	res := Result{Name: hk.name, Duration: time.Since(start)}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res, err
+}
@@ -0,0 +1,355 @@
+// Package listquery parses the filter and sort query parameters of list
+// endpoints against a whitelist of fields and turns them into SQL WHERE
+// and ORDER BY fragments whose values are bound parameters:
+//
+//	var orders = listquery.Schema{
+//		Fields: map[string]listquery.Field{
+//			"status":    {Column: "status", Ops: []listquery.Op{listquery.OpEq, listquery.OpIn}},
+//			"createdAt": {Column: "created_at", Type: listquery.TypeTime, Ops: []listquery.Op{listquery.OpGte, listquery.OpLt}, Sortable: true},
+//			"total":     {Column: "total_cents", Type: listquery.TypeInt, Sortable: true},
+//		},
+//		DefaultSort: "-createdAt",
+//	}
+//
+//	// GET /orders?filter[status][in]=open,paid&filter[createdAt][gte]=2024-01-01T00:00:00Z&sort=-total
+//	q, err := listquery.Parse(r.URL.Query(), orders)
+//	where, args := q.Where(1)
+//	rows := query(`SELECT ... FROM orders WHERE `+where+` ORDER BY `+q.OrderBy("id"), args...)
+//
+// Client input never reaches the SQL text: field names are only looked up
+// in the schema, whose columns are trusted, and values are only bound.
+package listquery
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/validation"
+)
+
+// Query parameters read by Parse.
+const (
+	ParamSort   = "sort"
+	ParamFilter = "filter"
+)
+
+// MaxInValues is the largest number of values an "in" filter may list.
+const MaxInValues = 100
+
+// Op is a filter operator, written after the field name
+// (e.g., filter[createdAt][gte]=...); a missing one is OpEq.
+type Op string
+
+// Supported operators.
+const (
+	OpEq       Op = "eq"
+	OpNe       Op = "ne"
+	OpLt       Op = "lt"
+	OpLte      Op = "lte"
+	OpGt       Op = "gt"
+	OpGte      Op = "gte"
+	OpIn       Op = "in"       // comma-separated values
+	OpContains Op = "contains" // case-insensitive substring, for strings
+	OpNull     Op = "null"     // "true" for IS NULL, "false" for IS NOT NULL
+)
+
+// comparisons maps the operators comparing a single value to SQL.
+var comparisons = map[Op]string{
+	OpEq:  "=",
+	OpNe:  "<>",
+	OpLt:  "<",
+	OpLte: "<=",
+	OpGt:  ">",
+	OpGte: ">=",
+}
+
+// Type is the type filter values of a field are parsed as.
+type Type string
+
+// Supported types.
+const (
+	TypeString Type = "string"
+	TypeInt    Type = "int"
+	TypeFloat  Type = "float"
+	TypeBool   Type = "bool"
+	TypeTime   Type = "time" // RFC 3339
+)
+
+// Field is a field clients may filter or sort by.
+type Field struct {
+	// Column is the SQL expression of the field (e.g., "o.created_at").
+	Column string
+
+	// Type is the type of filter values (defaults to TypeString).
+	Type Type
+
+	// Ops are the operators clients may filter with; none disables
+	// filtering by the field.
+	Ops []Op
+
+	// Sortable allows sorting by the field.
+	Sortable bool
+}
+
+// Schema is the whitelist of a list endpoint, keyed by the names clients
+// use.
+type Schema struct {
+	Fields map[string]Field
+
+	// DefaultSort is the sort used when the request sets none, in the
+	// syntax of the sort parameter (e.g., "-createdAt").
+	DefaultSort string
+}
+
+// Filter is a parsed filter.
+type Filter struct {
+	Field  string
+	Column string
+	Op     Op
+	Values []any
+}
+
+// Sort is a parsed sort key.
+type Sort struct {
+	Field  string
+	Column string
+	Desc   bool
+}
+
+// Query is the parsed filters and sort of a request.
+type Query struct {
+	Filters []Filter
+	Sort    []Sort
+}
+
+// Parse reads the filter[field] or filter[field][op] parameters and the
+// comma-separated sort parameter, whose fields are descending when
+// prefixed with "-", from values. Fields, operators, and values not allowed
+// by schema are returned as *validation.RequestError with an error for
+// each parameter.
+func Parse(values url.Values, schema Schema) (Query, error) {
+	var q Query
+	var errs validation.FieldErrors
+
+	params := make([]string, 0, len(values))
+	for param := range values {
+		params = append(params, param)
+	}
+	slices.Sort(params)
+
+	for _, param := range params {
+		name, op, ok := parseFilterParam(param)
+		if !ok {
+			continue
+		}
+		f, err := parseFilter(schema, name, op, values.Get(param))
+		if err != nil {
+			errs = append(errs, validation.FieldError{Field: param, Err: err.Error()})
+			continue
+		}
+		q.Filters = append(q.Filters, f)
+	}
+
+	sort := values.Get(ParamSort)
+	if sort == "" {
+		sort = schema.DefaultSort
+	}
+	if sort != "" {
+		s, err := parseSort(schema, sort)
+		if err != nil {
+			errs = append(errs, validation.FieldError{Field: ParamSort, Err: err.Error()})
+		}
+		q.Sort = s
+	}
+
+	if len(errs) > 0 {
+		return Query{}, &validation.RequestError{Status: http.StatusBadRequest, Message: "request is invalid", Fields: errs}
+	}
+	return q, nil
+}
+
+// parseFilterParam splits a filter[name] or filter[name][op] parameter.
+func parseFilterParam(param string) (name string, op Op, ok bool) {
+	rest, ok := strings.CutPrefix(param, ParamFilter+"[")
+	if !ok {
+		return "", "", false
+	}
+	name, rest, ok = strings.Cut(rest, "]")
+	if !ok || name == "" {
+		return "", "", false
+	}
+	if rest == "" {
+		return name, OpEq, true
+	}
+	opName, ok := strings.CutPrefix(rest, "[")
+	if !ok || !strings.HasSuffix(opName, "]") {
+		return "", "", false
+	}
+	return name, Op(strings.TrimSuffix(opName, "]")), true
+}
+
+// parseFilter checks a filter against schema and parses its value.
+func parseFilter(schema Schema, name string, op Op, raw string) (Filter, error) {
+	field, ok := schema.Fields[name]
+	if !ok || len(field.Ops) == 0 {
+		return Filter{}, errors.New("is not a filterable field")
+	}
+	if !slices.Contains(field.Ops, op) {
+		return Filter{}, fmt.Errorf("does not support the %q operator", op)
+	}
+	f := Filter{Field: name, Column: field.Column, Op: op}
+
+	switch op {
+	case OpNull:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return Filter{}, errors.New("must be true or false")
+		}
+		f.Values = []any{b}
+
+	case OpIn:
+		parts := strings.Split(raw, ",")
+		if len(parts) > MaxInValues {
+			return Filter{}, fmt.Errorf("must list at most %d values", MaxInValues)
+		}
+		for _, part := range parts {
+			v, err := parseValue(field.Type, strings.TrimSpace(part))
+			if err != nil {
+				return Filter{}, err
+			}
+			f.Values = append(f.Values, v)
+		}
+
+	case OpContains:
+		if field.Type != "" && field.Type != TypeString {
+			return Filter{}, fmt.Errorf("does not support the %q operator", op)
+		}
+		f.Values = []any{"%" + escapeLike(raw) + "%"}
+
+	default:
+		v, err := parseValue(field.Type, raw)
+		if err != nil {
+			return Filter{}, err
+		}
+		f.Values = []any{v}
+	}
+	return f, nil
+}
+
+// parseValue parses a filter value of type typ.
+func parseValue(typ Type, raw string) (any, error) {
+	switch typ {
+	case TypeInt:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, errors.New("must be an integer")
+		}
+		return n, nil
+	case TypeFloat:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, errors.New("must be a number")
+		}
+		return n, nil
+	case TypeBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, errors.New("must be a boolean")
+		}
+		return b, nil
+	case TypeTime:
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, errors.New("must be an RFC 3339 time (e.g., 2006-01-02T15:04:05Z)")
+		}
+		return t, nil
+	default:
+		return raw, nil
+	}
+}
+
+// escapeLike escapes the LIKE wildcards in s.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// parseSort parses a comma-separated sort parameter against schema.
+func parseSort(schema Schema, raw string) ([]Sort, error) {
+	var sorts []Sort
+	for part := range strings.SplitSeq(raw, ",") {
+		name, desc := strings.CutPrefix(strings.TrimSpace(part), "-")
+		field, ok := schema.Fields[name]
+		if !ok || !field.Sortable {
+			return nil, fmt.Errorf("cannot sort by %q", name)
+		}
+		if slices.ContainsFunc(sorts, func(s Sort) bool { return s.Field == name }) {
+			return nil, fmt.Errorf("sorts by %q twice", name)
+		}
+		sorts = append(sorts, Sort{Field: name, Column: field.Column, Desc: desc})
+	}
+	return sorts, nil
+}
+
+// Where returns the filters joined with AND, or "TRUE" without filters,
+// and their arguments, numbered from the placeholder $argN.
+func (q Query) Where(argN int) (string, []any) {
+	if len(q.Filters) == 0 {
+		return "TRUE", nil
+	}
+
+	var args []any
+	placeholder := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(argN+len(args)-1)
+	}
+
+	conds := make([]string, 0, len(q.Filters))
+	for _, f := range q.Filters {
+		switch f.Op {
+		case OpNull:
+			if f.Values[0].(bool) {
+				conds = append(conds, f.Column+" IS NULL")
+			} else {
+				conds = append(conds, f.Column+" IS NOT NULL")
+			}
+		case OpIn:
+			placeholders := make([]string, len(f.Values))
+			for i, v := range f.Values {
+				placeholders[i] = placeholder(v)
+			}
+			conds = append(conds, f.Column+" IN ("+strings.Join(placeholders, ", ")+")")
+		case OpContains:
+			conds = append(conds, f.Column+" ILIKE "+placeholder(f.Values[0]))
+		default:
+			conds = append(conds, f.Column+" "+comparisons[f.Op]+" "+placeholder(f.Values[0]))
+		}
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// OrderBy returns the ORDER BY list of the sort, ending with the tiebreak
+// columns (e.g., the primary key), ascending, so the order is total and
+// pages do not overlap. Without sort or tiebreak it is "" and the query
+// should have no ORDER BY.
+func (q Query) OrderBy(tiebreak ...string) string {
+	terms := make([]string, 0, len(q.Sort)+len(tiebreak))
+	for _, s := range q.Sort {
+		dir := "ASC"
+		if s.Desc {
+			dir = "DESC"
+		}
+		terms = append(terms, s.Column+" "+dir)
+	}
+	for _, col := range tiebreak {
+		if !slices.ContainsFunc(q.Sort, func(s Sort) bool { return s.Column == col }) {
+			terms = append(terms, col+" ASC")
+		}
+	}
+	return strings.Join(terms, ", ")
+}
@@ -0,0 +1,118 @@
+package listquery
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/validation"
+)
+
+var orders = Schema{
+	Fields: map[string]Field{
+		"status":    {Column: "status", Ops: []Op{OpEq, OpIn, OpContains}},
+		"createdAt": {Column: "created_at", Type: TypeTime, Ops: []Op{OpGte, OpLt}, Sortable: true},
+		"total":     {Column: "total_cents", Type: TypeInt, Ops: []Op{OpGt, OpContains}, Sortable: true},
+		"shippedAt": {Column: "shipped_at", Type: TypeTime, Ops: []Op{OpNull}},
+		"id":        {Column: "id", Sortable: true},
+	},
+	DefaultSort: "-createdAt",
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantWhere string
+		wantArgs  []any
+		wantOrder string
+	}{
+		{name: "none", query: "", wantWhere: "TRUE", wantOrder: "created_at DESC, id ASC"},
+		{name: "eq", query: "filter[status]=open", wantWhere: "status = $1", wantArgs: []any{"open"}, wantOrder: "created_at DESC, id ASC"},
+		{name: "in", query: "filter[status][in]=open, paid", wantWhere: "status IN ($1, $2)", wantArgs: []any{"open", "paid"}, wantOrder: "created_at DESC, id ASC"},
+		{name: "typed", query: "filter[total][gt]=100", wantWhere: "total_cents > $1", wantArgs: []any{int64(100)}, wantOrder: "created_at DESC, id ASC"},
+		{name: "time", query: "filter[createdAt][gte]=2024-01-01T00:00:00Z", wantWhere: "created_at >= $1", wantArgs: []any{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}, wantOrder: "created_at DESC, id ASC"},
+		{name: "contains escapes wildcards", query: "filter[status][contains]=50%25_off", wantWhere: "status ILIKE $1", wantArgs: []any{`%50\%\_off%`}, wantOrder: "created_at DESC, id ASC"},
+		{name: "null", query: "filter[shippedAt][null]=false", wantWhere: "shipped_at IS NOT NULL", wantOrder: "created_at DESC, id ASC"},
+		{name: "several filters in parameter order", query: "filter[total][gt]=1&filter[status]=open", wantWhere: "status = $1 AND total_cents > $2", wantArgs: []any{"open", int64(1)}, wantOrder: "created_at DESC, id ASC"},
+		{name: "sort", query: "sort=total,-id", wantWhere: "TRUE", wantOrder: "total_cents ASC, id DESC"},
+		{name: "other parameters ignored", query: "page=2&filter=x&filter[status", wantWhere: "TRUE", wantOrder: "created_at DESC, id ASC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("ParseQuery: %v", err)
+			}
+			q, err := Parse(values, orders)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			where, args := q.Where(1)
+			if where != tt.wantWhere || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Where = %q %v, want %q %v", where, args, tt.wantWhere, tt.wantArgs)
+			}
+			if order := q.OrderBy("id"); order != tt.wantOrder {
+				t.Errorf("OrderBy = %q, want %q", order, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantField string
+		wantErr   string
+	}{
+		{name: "unknown field", query: "filter[secret]=x", wantField: "filter[secret]", wantErr: "is not a filterable field"},
+		{name: "unfilterable field", query: "filter[id]=1", wantField: "filter[id]", wantErr: "is not a filterable field"},
+		{name: "operator not allowed", query: "filter[status][gt]=a", wantField: "filter[status][gt]", wantErr: `does not support the "gt" operator`},
+		{name: "contains on a number", query: "filter[total][contains]=1", wantField: "filter[total][contains]", wantErr: `does not support the "contains" operator`},
+		{name: "bad value", query: "filter[total][gt]=lots", wantField: "filter[total][gt]", wantErr: "must be an integer"},
+		{name: "bad time", query: "filter[createdAt][lt]=yesterday", wantField: "filter[createdAt][lt]", wantErr: "must be an RFC 3339 time"},
+		{name: "bad null", query: "filter[shippedAt][null]=maybe", wantField: "filter[shippedAt][null]", wantErr: "must be true or false"},
+		{name: "too many in values", query: "filter[status][in]=" + strings.Repeat("a,", MaxInValues) + "a", wantField: "filter[status][in]", wantErr: "must list at most"},
+		{name: "unsortable field", query: "sort=status", wantField: "sort", wantErr: `cannot sort by "status"`},
+		{name: "sorted twice", query: "sort=total,-total", wantField: "sort", wantErr: `sorts by "total" twice`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("ParseQuery: %v", err)
+			}
+			_, err = Parse(values, orders)
+			var reqErr *validation.RequestError
+			if !errors.As(err, &reqErr) || len(reqErr.Fields) != 1 {
+				t.Fatalf("Parse = %v, want a RequestError with one field", err)
+			}
+			if f := reqErr.Fields[0]; f.Field != tt.wantField || !strings.Contains(f.Err, tt.wantErr) {
+				t.Errorf("field error = %+v, want %s %q", f, tt.wantField, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWhereNumbersFromArgN(t *testing.T) {
+	q, err := Parse(url.Values{"filter[status][in]": {"a,b"}, "filter[total][gt]": {"5"}}, orders)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if where, _ := q.Where(3); where != "status IN ($3, $4) AND total_cents > $5" {
+		t.Errorf("Where(3) = %q", where)
+	}
+}
+
+func TestOrderByWithoutSort(t *testing.T) {
+	if order := (Query{}).OrderBy(); order != "" {
+		t.Errorf("OrderBy without sort or tiebreak = %q, want empty", order)
+	}
+	if order := (Query{}).OrderBy("id"); order != "id ASC" {
+		t.Errorf("OrderBy with only a tiebreak = %q, want id ASC", order)
+	}
+}
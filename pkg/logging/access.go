@@ -0,0 +1,181 @@
+package logging
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// defaultMaxBodyBytes caps logged bodies when AccessLog.MaxBodyBytes is unset.
+const defaultMaxBodyBytes = 4096
+
+// AccessLog writes one entry per request to logger with the method, path,
+// status, response size, latency, remote IP, user agent, request ID, and
+// trace ID. Server errors are logged at warn level, everything else at info.
+// cfg may be nil.
+//
+// When cfg.Bodies is set and logger is at debug level, the request and
+// response bodies, truncated to cfg.MaxBodyBytes, are logged as well.
+func AccessLog(logger *zap.Logger, cfg *config.AccessLog) func(http.Handler) http.Handler {
+	if cfg == nil {
+		cfg = &config.AccessLog{}
+	}
+
+	maxBody := cfg.MaxBodyBytes
+	if maxBody <= 0 {
+		maxBody = defaultMaxBodyBytes
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if skipPath(cfg.SkipPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			bodies := cfg.Bodies && logger.Core().Enabled(zapcore.DebugLevel)
+
+			var reqBody *cappedBuffer
+			if bodies && r.Body != nil && r.Body != http.NoBody {
+				reqBody = &cappedBuffer{max: maxBody}
+				r.Body = readCloser{io.TeeReader(r.Body, reqBody), r.Body}
+			}
+
+			rw := &accessWriter{ResponseWriter: w, status: http.StatusOK}
+			if bodies {
+				rw.body = &cappedBuffer{max: maxBody}
+			}
+
+			next.ServeHTTP(rw, r)
+
+			fields := []zap.Field{
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.Int("status", rw.status),
+				zap.Int64("bytes", rw.bytes),
+				zap.Duration("latency", time.Since(start)),
+				zap.String("remote_ip", remoteIP(r)),
+				zap.String("user_agent", r.UserAgent()),
+			}
+
+			requestID := r.Header.Get(HeaderRequestID)
+			if requestID == "" {
+				requestID = w.Header().Get(HeaderRequestID)
+			}
+			if requestID != "" {
+				fields = append(fields, zap.String("request_id", requestID))
+			}
+			if traceID := traceID(r.Header.Get("Traceparent")); traceID != "" {
+				fields = append(fields, zap.String("trace_id", traceID))
+			}
+
+			if reqBody != nil {
+				fields = append(fields, zap.String("request_body", reqBody.String()))
+			}
+			if rw.body != nil {
+				fields = append(fields, zap.String("response_body", rw.body.String()))
+			}
+
+			level := zapcore.InfoLevel
+			if rw.status >= http.StatusInternalServerError {
+				level = zapcore.WarnLevel
+			}
+			logger.Log(level, "request", fields...)
+		})
+	}
+}
+
+// skipPath reports whether path is excluded from the access log. Entries
+// ending in "/" match every path below them.
+func skipPath(skip []string, path string) bool {
+	for _, s := range skip {
+		if path == s || (strings.HasSuffix(s, "/") && strings.HasPrefix(path, s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteIP returns the host part of the request's remote address.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// accessWriter records the status code and size of a response, and a
+// prefix of its body when body is set.
+type accessWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+	body        *cappedBuffer
+}
+
+// WriteHeader records the status code.
+func (w *accessWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the number of bytes written.
+func (w *accessWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	if w.body != nil {
+		w.body.Write(p[:n])
+	}
+	return n, err
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *accessWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// cappedBuffer keeps the first max bytes written to it and discards the rest.
+type cappedBuffer struct {
+	bytes.Buffer
+	max       int
+	truncated bool
+}
+
+// Write implements io.Writer, never failing.
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); room < len(p) {
+		b.truncated = true
+		b.Buffer.Write(p[:max(room, 0)])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// String returns the captured bytes, marked when they were truncated.
+func (b *cappedBuffer) String() string {
+	if b.truncated {
+		return b.Buffer.String() + "...(truncated)"
+	}
+	return b.Buffer.String()
+}
+
+// readCloser pairs a reader with the closer of the body it reads from.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
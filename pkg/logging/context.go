@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/auth"
+	"github.com/iamBelugaa/go-boilerplate/pkg/requestid"
+)
+
+// HeaderRequestID is the header carrying the request's correlation ID.
+const HeaderRequestID = requestid.Header
+
+// loggerKey is the context key for the request-scoped logger.
+type loggerKey struct{}
+
+// NewContext returns a copy of ctx carrying logger.
+func NewContext(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger attached to ctx, or the global zap logger
+// (see zap.ReplaceGlobals) when there is none.
+func FromContext(ctx context.Context) *zap.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*zap.Logger); ok {
+		return logger
+	}
+	return zap.L()
+}
+
+// With returns a copy of ctx whose logger carries the additional fields,
+// for middleware that learns more about a request (e.g., after
+// authentication).
+func With(ctx context.Context, fields ...zap.Field) context.Context {
+	return NewContext(ctx, FromContext(ctx).With(fields...))
+}
+
+// Middleware attaches a child of base to every request context, carrying
+// the request ID, trace ID, route, user ID, and impersonating staff member
+// when they are known, so all logs written while serving the request can
+// be correlated.
+func Middleware(base *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fields := make([]zap.Field, 0, 4)
+
+			if id := r.Header.Get(HeaderRequestID); id != "" {
+				fields = append(fields, zap.String("request_id", id))
+			}
+			if traceID := traceID(r.Header.Get("Traceparent")); traceID != "" {
+				fields = append(fields, zap.String("trace_id", traceID))
+			}
+
+			route := r.Pattern
+			if route == "" {
+				route = r.URL.Path
+			}
+			fields = append(fields, zap.String("route", route))
+
+			if p := auth.PrincipalFromContext(r.Context()); p != nil {
+				fields = append(fields, zap.String("user_id", p.Subject))
+				if p.ImpersonatedBy != "" {
+					fields = append(fields, zap.String("impersonated_by", p.ImpersonatedBy))
+				}
+			}
+
+			ctx := NewContext(r.Context(), base.With(fields...))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// traceID extracts the trace ID from a W3C traceparent header
+// ("00-<trace-id>-<span-id>-<flags>").
+func traceID(traceparent string) string {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) < 4 || len(parts[1]) != 32 {
+		return ""
+	}
+	return parts[1]
+}
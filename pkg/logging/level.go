@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"maps"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// Raise lowers the minimum enabled level by one step (e.g., info to debug),
+// making the logger more verbose, and returns the new level.
+func Raise(level zap.AtomicLevel) zapcore.Level {
+	if l := level.Level(); l > zapcore.DebugLevel {
+		level.SetLevel(l - 1)
+	}
+	return level.Level()
+}
+
+// Lower raises the minimum enabled level by one step (e.g., info to warn),
+// making the logger less verbose, and returns the new level.
+func Lower(level zap.AtomicLevel) zapcore.Level {
+	if l := level.Level(); l < zapcore.FatalLevel {
+		level.SetLevel(l + 1)
+	}
+	return level.Level()
+}
+
+// FollowConfig applies Logging.Level changes from configuration reloads to
+// level. Reloads leaving the configured level unchanged keep any level set
+// at runtime through signals or the admin endpoint.
+func FollowConfig(r *config.Reloader, level zap.AtomicLevel, onError func(error)) {
+	r.Subscribe(func(prev, next *config.Config, _ config.Snapshot) {
+		if next.Logging == nil || prev.Logging != nil && prev.Logging.Level == next.Logging.Level {
+			return
+		}
+
+		l, err := zapcore.ParseLevel(next.Logging.Level)
+		if err != nil {
+			if onError != nil {
+				onError(err)
+			}
+			return
+		}
+		level.SetLevel(l)
+	})
+}
+
+// FollowConfigModules applies Logging.Levels changes from configuration
+// reloads to levels, replacing every module override. Reloads leaving the
+// module levels unchanged keep overrides set at runtime.
+func FollowConfigModules(r *config.Reloader, levels *Levels, onError func(error)) {
+	r.Subscribe(func(prev, next *config.Config, _ config.Snapshot) {
+		if next.Logging == nil || prev.Logging != nil && maps.Equal(prev.Logging.Levels, next.Logging.Levels) {
+			return
+		}
+
+		modules, err := parseLevels(next.Logging.Levels)
+		if err != nil {
+			if onError != nil {
+				onError(err)
+			}
+			return
+		}
+		levels.Replace(modules)
+	})
+}
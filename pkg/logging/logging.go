@@ -0,0 +1,155 @@
+// Package logging builds the application's structured logger from the
+// logging and service configuration.
+package logging
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+	"github.com/iamBelugaa/go-boilerplate/pkg/errreport"
+)
+
+// Option customizes the logger built by New.
+type Option func(*options)
+
+// options holds the settings applied by Options.
+type options struct {
+	level  *zap.AtomicLevel
+	levels *Levels
+	tees   []zapcore.Core
+}
+
+// WithAtomicLevel makes the logger use level, set to the configured level,
+// so verbosity can be changed at runtime (see WatchSignals and
+// FollowConfig).
+func WithAtomicLevel(level zap.AtomicLevel) Option {
+	return func(o *options) {
+		o.level = &level
+	}
+}
+
+// WithLevels makes the logger use levels, set to the configured root and
+// module levels, so module verbosity can be changed at runtime. Its root
+// level takes precedence over WithAtomicLevel.
+func WithLevels(levels *Levels) Option {
+	return func(o *options) {
+		o.levels = levels
+	}
+}
+
+// WithErrorReporter sends entries at error level and above, scrubbed of
+// secrets, to reporter.
+func WithErrorReporter(reporter errreport.Reporter) Option {
+	return func(o *options) {
+		o.tees = append(o.tees, errreport.NewCore(reporter, zapcore.ErrorLevel))
+	}
+}
+
+// New constructs a zap logger writing to cfg.OutputPaths at cfg.Level.
+// Production and staging use JSON encoding for log shippers; development
+// uses the human-friendly console encoding. Every entry carries the
+// service name, version, environment, and locality, and secrets are
+// scrubbed from messages and fields.
+func New(cfg *config.Logging, svc *config.Service, opts ...Option) (*zap.Logger, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	level, err := zapcore.ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, fmt.Errorf("logging: %w", err)
+	}
+
+	modules, err := parseLevels(cfg.Levels)
+	if err != nil {
+		return nil, err
+	}
+
+	levels := o.levels
+	if levels == nil {
+		root := zap.NewAtomicLevel()
+		if o.level != nil {
+			root = *o.level
+		}
+		levels = NewLevels(root)
+	}
+	levels.Root().SetLevel(level)
+	levels.Replace(modules)
+
+	zapCfg := zap.NewProductionConfig()
+	if svc.Environment == config.EnvironmentDevelopment {
+		zapCfg = zap.NewDevelopmentConfig()
+		zapCfg.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	}
+
+	// Levels are enforced per logger by the level core; the output core
+	// accepts everything that gets past it.
+	zapCfg.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	zapCfg.EncoderConfig.TimeKey = "timestamp"
+	zapCfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	if len(cfg.OutputPaths) > 0 {
+		zapCfg.OutputPaths = cfg.OutputPaths
+	}
+	if cfg.Rotation != nil {
+		if zapCfg.OutputPaths, err = rotatedPaths(zapCfg.OutputPaths, cfg.Rotation); err != nil {
+			return nil, err
+		}
+	}
+	zapCfg.InitialFields = map[string]any{
+		"service":     svc.Name,
+		"version":     svc.Version,
+		"environment": svc.Environment.String(),
+	}
+	if svc.Region != "" {
+		zapCfg.InitialFields["region"] = svc.Region
+	}
+	if svc.Zone != "" {
+		zapCfg.InitialFields["zone"] = svc.Zone
+	}
+
+	// Error reporting and OTLP export are teed with the output core first,
+	// so redaction, which must wrap them directly, also scrubs the entries
+	// they receive.
+	var zapOpts []zap.Option
+	if len(o.tees) > 0 {
+		zapOpts = append(zapOpts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return tee(core, o.tees)
+		}))
+	}
+	if cfg.Redaction == nil || !cfg.Redaction.Disabled {
+		r, err := newRedactor(cfg.Redaction)
+		if err != nil {
+			return nil, err
+		}
+		zapOpts = append(zapOpts, redactionOption(r))
+	}
+	if cfg.Sampling != nil {
+		zapCfg.Sampling = nil
+		zapOpts = append(zapOpts, samplingOption(cfg.Sampling))
+	}
+
+	zapOpts = append(zapOpts, levelsOption(levels))
+
+	logger, err := zapCfg.Build(zapOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("logging: build logger: %w", err)
+	}
+	return logger, nil
+}
+
+// parseLevels parses the configured module levels.
+func parseLevels(levels map[string]string) (map[string]zapcore.Level, error) {
+	modules := make(map[string]zapcore.Level, len(levels))
+	for module, name := range levels {
+		level, err := zapcore.ParseLevel(name)
+		if err != nil {
+			return nil, fmt.Errorf("logging: module %q: %w", module, err)
+		}
+		modules[module] = level
+	}
+	return modules, nil
+}
@@ -0,0 +1,123 @@
+package logging
+
+import (
+	"maps"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Levels holds the root log level and per-module overrides. Modules are
+// loggers named with zap's Named (e.g., logger.Named("http")); a module's
+// level also applies to its children ("http.client") unless they have
+// their own. It is safe for concurrent use.
+type Levels struct {
+	root    zap.AtomicLevel
+	mu      sync.Mutex
+	modules atomic.Pointer[map[string]zapcore.Level]
+}
+
+// NewLevels constructs Levels with root as the level of unnamed loggers and
+// modules without an override.
+func NewLevels(root zap.AtomicLevel) *Levels {
+	l := &Levels{root: root}
+	l.modules.Store(&map[string]zapcore.Level{})
+	return l
+}
+
+// Root returns the root level.
+func (l *Levels) Root() zap.AtomicLevel {
+	return l.root
+}
+
+// Set overrides the level of module.
+func (l *Levels) Set(module string, level zapcore.Level) {
+	l.update(func(m map[string]zapcore.Level) { m[module] = level })
+}
+
+// Reset removes the override of module, which then follows its parent.
+func (l *Levels) Reset(module string) {
+	l.update(func(m map[string]zapcore.Level) { delete(m, module) })
+}
+
+// Replace swaps every module override for modules.
+func (l *Levels) Replace(modules map[string]zapcore.Level) {
+	l.update(func(m map[string]zapcore.Level) {
+		clear(m)
+		maps.Copy(m, modules)
+	})
+}
+
+// Modules returns a copy of the module overrides.
+func (l *Levels) Modules() map[string]zapcore.Level {
+	return maps.Clone(*l.modules.Load())
+}
+
+// For returns the effective level of the logger with the given name.
+func (l *Levels) For(name string) zapcore.Level {
+	modules := *l.modules.Load()
+	for name != "" {
+		if level, ok := modules[name]; ok {
+			return level
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return l.root.Level()
+}
+
+// update applies fn to a copy of the overrides and publishes it.
+func (l *Levels) update(fn func(map[string]zapcore.Level)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	next := maps.Clone(*l.modules.Load())
+	fn(next)
+	l.modules.Store(&next)
+}
+
+// levelCore filters entries by the level of the logger that wrote them.
+type levelCore struct {
+	zapcore.Core
+	levels *Levels
+}
+
+// Enabled reports whether any logger may write entries at level, so the
+// per-logger decision can be made in Check.
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	if c.levels.root.Enabled(level) {
+		return true
+	}
+	for _, l := range *c.levels.modules.Load() {
+		if l.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+// With adds structured context.
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), levels: c.levels}
+}
+
+// Check passes the entry on when its logger's level enables it.
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.levels.For(ent.LoggerName).Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// levelsOption returns the zap option filtering entries by module level.
+func levelsOption(levels *Levels) zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &levelCore{Core: core, levels: levels}
+	})
+}
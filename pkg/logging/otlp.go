@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelzap"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// NewOTLPProvider builds an OpenTelemetry logger provider exporting logs
+// over OTLP/HTTP to tel.Endpoint, in batches and with retries. It returns
+// nil when OTLP log export is disabled. The provider must be shut down
+// before the process exits so buffered logs are flushed.
+func NewOTLPProvider(ctx context.Context, tel *config.Telemetry, svc *config.Service) (*sdklog.LoggerProvider, error) {
+	if tel == nil || tel.Logs == nil || !tel.Logs.Enabled {
+		return nil, nil
+	}
+	logs := tel.Logs
+
+	exporter, err := otlploghttp.New(ctx,
+		otlploghttp.WithEndpointURL(tel.Endpoint+"/v1/logs"),
+		otlploghttp.WithHeaders(tel.Headers),
+		otlploghttp.WithRetry(otlploghttp.RetryConfig{
+			Enabled:         true,
+			InitialInterval: time.Second,
+			MaxInterval:     30 * time.Second,
+			MaxElapsedTime:  orDefault(logs.MaxRetryElapsed, time.Minute),
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("logging: otlp exporter: %w", err)
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("service.name", svc.Name),
+		attribute.String("service.version", svc.Version),
+		attribute.String("deployment.environment.name", svc.Environment.String()),
+	}
+	if svc.Region != "" {
+		attrs = append(attrs, attribute.String("cloud.region", svc.Region))
+	}
+	if svc.Zone != "" {
+		attrs = append(attrs, attribute.String("cloud.availability_zone", svc.Zone))
+	}
+
+	var batchOpts []sdklog.BatchProcessorOption
+	if logs.BatchSize > 0 {
+		batchOpts = append(batchOpts, sdklog.WithExportMaxBatchSize(logs.BatchSize))
+	}
+	if logs.MaxQueueSize > 0 {
+		batchOpts = append(batchOpts, sdklog.WithMaxQueueSize(logs.MaxQueueSize))
+	}
+	if logs.ExportInterval > 0 {
+		batchOpts = append(batchOpts, sdklog.WithExportInterval(logs.ExportInterval))
+	}
+
+	return sdklog.NewLoggerProvider(
+		sdklog.WithResource(resource.NewSchemaless(attrs...)),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter, batchOpts...)),
+	), nil
+}
+
+// WithOTLP ships every entry written to the local output paths, scrubbed
+// of secrets, to provider as well. A nil provider is ignored.
+func WithOTLP(provider *sdklog.LoggerProvider) Option {
+	return func(o *options) {
+		if provider != nil {
+			o.tees = append(o.tees, otelzap.NewCore(instrumentationName, otelzap.WithLoggerProvider(provider)))
+		}
+	}
+}
+
+// instrumentationName identifies this package as the OTLP log source.
+const instrumentationName = "github.com/iamBelugaa/go-boilerplate/pkg/logging"
+
+// orDefault returns d, or def when d is zero.
+func orDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
+
+// tee returns core teed with the additional cores.
+func tee(core zapcore.Core, cores []zapcore.Core) zapcore.Core {
+	return zapcore.NewTee(append([]zapcore.Core{core}, cores...)...)
+}
@@ -0,0 +1,228 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// redactedValue replaces scrubbed secrets.
+const redactedValue = "[REDACTED]"
+
+// defaultSensitiveKeys are field and object key fragments whose values are
+// always redacted. Keys are compared lowercase with "-" and "_" removed.
+var defaultSensitiveKeys = []string{
+	"password", "passwd", "secret", "token", "authorization",
+	"apikey", "cookie", "cardnumber", "creditcard", "cvv",
+}
+
+// defaultPatterns match secrets embedded in free text. The first submatch,
+// when present, is kept so the redaction stays readable.
+var defaultPatterns = []string{
+	`(?i)(bearer\s+)[a-z0-9._~+/=-]+`,
+	`(?i)((?:password|passwd|secret|token|api[_-]?key)["']?\s*[:=]\s*["']?)[^\s"'&,;]+`,
+	`(://[^:/@\s]+:)[^@/\s]+`,
+}
+
+// cardPattern matches candidate payment card numbers, confirmed with the
+// Luhn checksum before redaction.
+var cardPattern = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+
+// redactor scrubs secrets from log messages and fields.
+type redactor struct {
+	keys     []string
+	patterns []*regexp.Regexp
+}
+
+// newRedactor builds a redactor from the default rules extended with cfg,
+// which may be nil.
+func newRedactor(cfg *config.LogRedaction) (*redactor, error) {
+	keys, patterns := defaultSensitiveKeys, defaultPatterns
+	if cfg != nil {
+		keys = append(append([]string(nil), keys...), cfg.Keys...)
+		patterns = append(append([]string(nil), patterns...), cfg.Patterns...)
+	}
+
+	r := &redactor{}
+	for _, key := range keys {
+		r.keys = append(r.keys, normalizeKey(key))
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("logging: invalid redaction pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// normalizeKey lowercases key and removes separators.
+func normalizeKey(key string) string {
+	return strings.NewReplacer("-", "", "_", "", ".", "").Replace(strings.ToLower(key))
+}
+
+// sensitive reports whether values under key must be redacted.
+func (r *redactor) sensitive(key string) bool {
+	key = normalizeKey(key)
+	for _, k := range r.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// text scrubs secrets from free text.
+func (r *redactor) text(s string) string {
+	for _, re := range r.patterns {
+		if re.NumSubexp() > 0 {
+			s = re.ReplaceAllString(s, "${1}"+redactedValue)
+		} else {
+			s = re.ReplaceAllString(s, redactedValue)
+		}
+	}
+	return cardPattern.ReplaceAllStringFunc(s, func(m string) string {
+		if luhn(m) {
+			return redactedValue
+		}
+		return m
+	})
+}
+
+// field returns f with its secrets scrubbed. Structured values are
+// flattened to plain maps and slices so nested keys can be inspected.
+func (r *redactor) field(f zapcore.Field) zapcore.Field {
+	if r.sensitive(f.Key) {
+		return zap.String(f.Key, redactedValue)
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		return zap.String(f.Key, r.text(f.String))
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok {
+			return zap.String(f.Key, r.text(err.Error()))
+		}
+	case zapcore.StringerType:
+		if s, ok := f.Interface.(fmt.Stringer); ok {
+			return zap.String(f.Key, r.text(s.String()))
+		}
+	case zapcore.ReflectType, zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType:
+		enc := zapcore.NewMapObjectEncoder()
+		f.AddTo(enc)
+		return zap.Any(f.Key, r.value(normalize(enc.Fields[f.Key])))
+	}
+	return f
+}
+
+// normalize converts v to plain JSON values (maps, slices, scalars).
+func normalize(v any) any {
+	switch v.(type) {
+	case map[string]any, []any, string, bool, float64, nil:
+		return v
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return string(data)
+	}
+	return out
+}
+
+// value scrubs a normalized value recursively.
+func (r *redactor) value(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			if r.sensitive(k) {
+				out[k] = redactedValue
+				continue
+			}
+			out[k] = r.value(normalize(item))
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = r.value(normalize(item))
+		}
+		return out
+	case string:
+		return r.text(v)
+	}
+	return v
+}
+
+// luhn reports whether the digits in s pass the Luhn checksum.
+func luhn(s string) bool {
+	var sum, n int
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// redactCore scrubs entries before handing them to the wrapped core.
+type redactCore struct {
+	zapcore.Core
+	r *redactor
+}
+
+// With adds scrubbed structured context.
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(c.scrub(fields)), r: c.r}
+}
+
+// Check adds the core to ce when the entry's level is enabled.
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write scrubs the message and fields and writes the entry.
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.r.text(ent.Message)
+	return c.Core.Write(ent, c.scrub(fields))
+}
+
+// scrub returns scrubbed copies of fields.
+func (c *redactCore) scrub(fields []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		out[i] = c.r.field(f)
+	}
+	return out
+}
+
+// redactionOption returns the zap option scrubbing secrets from every entry.
+func redactionOption(r *redactor) zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &redactCore{Core: core, r: r}
+	})
+}
@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// rotateScheme is the zap sink scheme for rotated log files.
+const rotateScheme = "rotate"
+
+// registerRotateSink registers the rotated file sink with zap once.
+var registerRotateSink = sync.OnceValue(func() error {
+	return zap.RegisterSink(rotateScheme, newRotateSink)
+})
+
+// rotateSink adapts a lumberjack logger to zap.Sink.
+type rotateSink struct {
+	*lumberjack.Logger
+}
+
+// Sync is a no-op; lumberjack writes directly to the file.
+func (rotateSink) Sync() error {
+	return nil
+}
+
+// newRotateSink builds a rotated file sink from a "rotate:" URL carrying the
+// file path and the rotation settings as query parameters.
+func newRotateSink(u *url.URL) (zap.Sink, error) {
+	path := u.Path
+	if u.Opaque != "" {
+		path = u.Opaque
+	}
+
+	q := u.Query()
+	maxSize, _ := strconv.Atoi(q.Get("max_size"))
+	maxBackups, _ := strconv.Atoi(q.Get("max_backups"))
+	maxAge, _ := strconv.Atoi(q.Get("max_age"))
+
+	return rotateSink{&lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    maxSize,
+		MaxBackups: maxBackups,
+		MaxAge:     maxAge,
+		Compress:   q.Get("compress") == "true",
+	}}, nil
+}
+
+// rotatedPaths rewrites the file paths in paths to rotated file sinks.
+// Standard streams and URLs with another scheme are kept as they are.
+func rotatedPaths(paths []string, rotation *config.LogRotation) ([]string, error) {
+	if err := registerRotateSink(); err != nil {
+		return nil, fmt.Errorf("logging: register rotation sink: %w", err)
+	}
+
+	q := url.Values{}
+	q.Set("max_size", strconv.Itoa(rotation.MaxSizeMB))
+	q.Set("max_backups", strconv.Itoa(rotation.MaxBackups))
+	q.Set("max_age", strconv.Itoa(rotation.MaxAgeDays))
+	q.Set("compress", strconv.FormatBool(rotation.Compress))
+
+	rotated := make([]string, len(paths))
+	for i, path := range paths {
+		if path == "stdout" || path == "stderr" || strings.Contains(path, "://") {
+			rotated[i] = path
+			continue
+		}
+
+		u := url.URL{Scheme: rotateScheme, Opaque: path, RawQuery: q.Encode()}
+		if strings.HasPrefix(path, "/") {
+			u = url.URL{Scheme: rotateScheme, Path: path, RawQuery: q.Encode()}
+		}
+		rotated[i] = u.String()
+	}
+	return rotated, nil
+}
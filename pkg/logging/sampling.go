@@ -0,0 +1,161 @@
+package logging
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// keyField is the field naming the rate limiting key of an entry.
+const keyField = "log_key"
+
+// Key returns a field grouping entries for rate limiting, for messages that
+// vary (e.g., carry an ID) but belong to the same storm. Entries without a
+// key are limited by message.
+func Key(key string) zap.Field {
+	return zap.String(keyField, key)
+}
+
+// rateLimitCore drops entries sharing a key beyond a per-interval limit and
+// reports how many were suppressed on the next entry let through.
+type rateLimitCore struct {
+	zapcore.Core
+	limiter *keyLimiter
+	key     string
+}
+
+// newRateLimitCore wraps core, allowing limit entries per key per interval.
+func newRateLimitCore(core zapcore.Core, limit int, interval time.Duration) zapcore.Core {
+	return &rateLimitCore{
+		Core:    core,
+		limiter: &keyLimiter{limit: limit, interval: interval, windows: make(map[string]*window), now: time.Now},
+	}
+}
+
+// With adds structured context, keeping the key if fields set one.
+func (c *rateLimitCore) With(fields []zapcore.Field) zapcore.Core {
+	key := c.key
+	for _, f := range fields {
+		if f.Key == keyField {
+			key = f.String
+		}
+	}
+	return &rateLimitCore{Core: c.Core.With(fields), limiter: c.limiter, key: key}
+}
+
+// Check adds the core to ce when the entry's level is enabled.
+func (c *rateLimitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write writes the entry unless its key exceeded the limit.
+func (c *rateLimitCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	key := c.key
+	for _, f := range fields {
+		if f.Key == keyField {
+			key = f.String
+		}
+	}
+	if key == "" {
+		key = ent.Message
+	}
+
+	allowed, suppressed := c.limiter.allow(ent.Level.String() + "|" + key)
+	if !allowed {
+		return nil
+	}
+	if suppressed > 0 {
+		fields = append(fields, zap.Int("suppressed", suppressed))
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// maxWindows bounds the number of tracked keys before expired ones are
+// evicted.
+const maxWindows = 10_000
+
+// window counts the entries of one key in the current interval.
+type window struct {
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+// keyLimiter is a fixed-window limiter keyed by entry key.
+type keyLimiter struct {
+	mu       sync.Mutex
+	limit    int
+	interval time.Duration
+	windows  map[string]*window
+	now      func() time.Time
+}
+
+// allow reports whether an entry with key may be written and how many
+// entries of the key were suppressed in the previous window.
+func (l *keyLimiter) allow(key string) (bool, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	w, ok := l.windows[key]
+	if !ok {
+		if len(l.windows) >= maxWindows {
+			l.evict(now)
+		}
+		w = &window{start: now}
+		l.windows[key] = w
+	}
+
+	var suppressed int
+	if now.Sub(w.start) >= l.interval {
+		suppressed = w.suppressed
+		*w = window{start: now}
+	}
+
+	if w.count >= l.limit {
+		w.suppressed++
+		return false, 0
+	}
+	w.count++
+	return true, suppressed
+}
+
+// evict removes windows that have expired.
+func (l *keyLimiter) evict(now time.Time) {
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= l.interval {
+			delete(l.windows, key)
+		}
+	}
+}
+
+// samplingOption returns the zap option applying s's sampling and rate
+// limiting, with one-second ticks and intervals by default. Sampling
+// happens first, so the rate limiter only sees sampled entries.
+func samplingOption(s *config.LogSampling) zap.Option {
+	tick := s.Tick
+	if tick <= 0 {
+		tick = time.Second
+	}
+	interval := s.RateInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		if s.RateLimit > 0 {
+			core = newRateLimitCore(core, s.RateLimit, interval)
+		}
+		if s.Initial > 0 {
+			core = zapcore.NewSamplerWithOptions(core, tick, s.Initial, s.Thereafter)
+		}
+		return core
+	})
+}
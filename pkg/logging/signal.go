@@ -0,0 +1,35 @@
+//go:build !windows
+
+package logging
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+// WatchSignals makes the logger more verbose on SIGUSR1 and less verbose
+// on SIGUSR2, one level per signal, until ctx is cancelled. Every change is
+// logged to logger.
+func WatchSignals(ctx context.Context, level zap.AtomicLevel, logger *zap.Logger) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGUSR1, syscall.SIGUSR2)
+	defer signal.Stop(sigCh)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-sigCh:
+			if sig == syscall.SIGUSR1 {
+				Raise(level)
+			} else {
+				Lower(level)
+			}
+			logger.Warn("log level changed", zap.String("signal", sig.String()), zap.Stringer("level", level.Level()))
+		}
+	}
+}
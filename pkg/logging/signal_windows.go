@@ -0,0 +1,13 @@
+package logging
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// WatchSignals blocks until ctx is cancelled; Windows has no SIGUSR1 or
+// SIGUSR2, so the level can only be changed through the admin endpoint.
+func WatchSignals(ctx context.Context, _ zap.AtomicLevel, _ *zap.Logger) {
+	<-ctx.Done()
+}
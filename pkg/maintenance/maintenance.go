@@ -0,0 +1,148 @@
+// Package maintenance provides a runtime maintenance switch for deploy
+// windows and incident response. While it is on, application routes answer
+// 503 Service Unavailable with Retry-After, and probes, metrics, and the
+// admin endpoints keep working.
+package maintenance
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// DefaultRetryAfter is sent to clients when the end of the window is
+// unknown and no other value is configured.
+const DefaultRetryAfter = time.Minute
+
+// alwaysExempt are the paths kept available so orchestrators do not restart
+// or drain instances that are only in maintenance.
+var alwaysExempt = []string{"/healthz", "/readyz", "/metrics"}
+
+// State describes the maintenance switch.
+type State struct {
+	Enabled bool      `json:"enabled"`
+	Reason  string    `json:"reason,omitempty"`
+	Since   time.Time `json:"since,omitzero"`
+	Until   time.Time `json:"until,omitzero"`
+}
+
+// Switch is the maintenance switch. It is safe for concurrent use.
+type Switch struct {
+	mu         sync.Mutex
+	state      State
+	retryAfter time.Duration
+}
+
+// New constructs a Switch with maintenance mode off. retryAfter is sent to
+// clients when the end of the window is unknown; 0 means DefaultRetryAfter.
+func New(retryAfter time.Duration) *Switch {
+	if retryAfter <= 0 {
+		retryAfter = DefaultRetryAfter
+	}
+	return &Switch{retryAfter: retryAfter}
+}
+
+// Enable turns maintenance mode on, recording why and, when known, until
+// when (the zero time if not).
+func (s *Switch) Enable(reason string, until time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	since := s.state.Since
+	if !s.state.Enabled {
+		since = time.Now().UTC()
+	}
+	s.state = State{Enabled: true, Reason: reason, Since: since, Until: until.UTC()}
+}
+
+// Disable turns maintenance mode off.
+func (s *Switch) Disable() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = State{}
+}
+
+// State returns the current state of the switch.
+func (s *Switch) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// Enabled reports whether maintenance mode is on.
+func (s *Switch) Enabled() bool {
+	return s.State().Enabled
+}
+
+// RetryAfter returns how long clients should wait before retrying: until
+// the end of the window when it is known and ahead, otherwise the
+// configured default.
+func (s *Switch) RetryAfter() time.Duration {
+	if until := s.State().Until; !until.IsZero() {
+		if d := time.Until(until); d > 0 {
+			return d
+		}
+	}
+	return s.retryAfter
+}
+
+// FollowConfig applies Server.Maintenance.Enabled changes from
+// configuration reloads. Reloads leaving the setting unchanged keep the
+// state set at runtime.
+func (s *Switch) FollowConfig(r *config.Reloader) {
+	r.Subscribe(func(prev, next *config.Config, _ config.Snapshot) {
+		if next.Server == nil || prev.Server != nil && enabled(prev.Server) == enabled(next.Server) {
+			return
+		}
+		if enabled(next.Server) {
+			s.Enable("config", time.Time{})
+		} else {
+			s.Disable()
+		}
+	})
+}
+
+// enabled reports whether conf turns maintenance mode on.
+func enabled(conf *config.Server) bool {
+	return conf.Maintenance != nil && conf.Maintenance.Enabled
+}
+
+// Middleware answers requests with 503 Service Unavailable and a
+// Retry-After header while maintenance mode is on. The probes and metrics
+// endpoints and the exempt paths pass through; a path ending in "/" covers
+// every path below it.
+func Middleware(s *Switch, exempt []string) func(http.Handler) http.Handler {
+	exempt = slices.Concat(exempt, alwaysExempt)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !s.Enabled() || isExempt(exempt, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			seconds := int(math.Ceil(s.RetryAfter().Seconds()))
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "service is under maintenance"})
+		})
+	}
+}
+
+// isExempt reports whether path is one of exempt, or below one ending in "/".
+func isExempt(exempt []string, path string) bool {
+	for _, e := range exempt {
+		if path == e || (strings.HasSuffix(e, "/") && strings.HasPrefix(path, e)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,222 @@
+// Package mask anonymizes personal data copied out of production, e.g.
+// when seeding staging or restoring a backup into it.
+//
+// Masking is deterministic: a value always maps to the same pseudonym
+// under a given key, so a user's email still matches across every table it
+// appears in and joins keep working. Keys must never be shared with the
+// environments receiving masked data.
+//
+// Struct fields are masked by tagging them with a Kind:
+//
+//	type User struct {
+//		ID    string
+//		Email string `pii:"email"`
+//		Name  string `pii:"name"`
+//	}
+package mask
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// Kind selects how a value is pseudonymized.
+type Kind string
+
+// Supported kinds.
+const (
+	// KindEmail yields an address at example.com.
+	KindEmail Kind = "email"
+
+	// KindName yields a plausible full name.
+	KindName Kind = "name"
+
+	// KindPhone yields a +1 555 number, a range reserved for fiction.
+	KindPhone Kind = "phone"
+
+	// KindToken yields an opaque hex token, for identifiers and free text.
+	KindToken Kind = "token"
+
+	// KindNull drops the value altogether.
+	KindNull Kind = "null"
+)
+
+// Errors returned by the package.
+var (
+	ErrKey        = errors.New("mask: key must be at least 16 bytes")
+	ErrKind       = errors.New("mask: unknown kind")
+	ErrProduction = errors.New("mask: refusing to write masked data into production")
+)
+
+var (
+	firstNames = []string{
+		"Alex", "Blair", "Casey", "Dana", "Eden", "Finley", "Gray", "Harper",
+		"Indy", "Jordan", "Kai", "Logan", "Morgan", "Noel", "Parker", "Quinn",
+		"Riley", "Sage", "Taylor", "Robin",
+	}
+	lastNames = []string{
+		"Adler", "Brooks", "Carter", "Doyle", "Ellis", "Fischer", "Grant", "Hayes",
+		"Ingram", "Jensen", "Keller", "Lambert", "Meyer", "Nash", "Olsen", "Porter",
+		"Reyes", "Sutton", "Tanaka", "Weber",
+	}
+)
+
+// Masker pseudonymizes values with a secret key.
+type Masker struct {
+	key []byte
+}
+
+// New constructs a Masker. The key is what makes pseudonyms irreversible,
+// so it must be random and at least 16 bytes long.
+func New(key []byte) (*Masker, error) {
+	if len(key) < 16 {
+		return nil, ErrKey
+	}
+	return &Masker{key: key}, nil
+}
+
+// Guard returns ErrProduction when target, the environment receiving the
+// masked data, is production. Tools copying data call it before writing.
+func Guard(target config.Environment) error {
+	if target == config.EnvironmentProduction {
+		return ErrProduction
+	}
+	return nil
+}
+
+// String returns the pseudonym of v. Empty strings are kept as they are,
+// so optional fields stay empty.
+func (m *Masker) String(kind Kind, v string) (string, error) {
+	if v == "" {
+		return "", nil
+	}
+
+	sum := m.sum(kind, v)
+	switch kind {
+	case KindEmail:
+		return "user-" + hex.EncodeToString(sum[:6]) + "@example.com", nil
+	case KindName:
+		return firstNames[int(sum[0])%len(firstNames)] + " " + lastNames[int(sum[1])%len(lastNames)], nil
+	case KindPhone:
+		return fmt.Sprintf("+1555%07d", binary.BigEndian.Uint32(sum[:4])%10_000_000), nil
+	case KindToken:
+		return hex.EncodeToString(sum[:16]), nil
+	case KindNull:
+		return "", nil
+	default:
+		return "", fmt.Errorf("%w %q", ErrKind, kind)
+	}
+}
+
+// sum derives the pseudonym material for v. The kind is mixed in so the
+// same value masks differently as, say, an email and a token.
+func (m *Masker) sum(kind Kind, v string) [sha256.Size]byte {
+	mac := hmac.New(sha256.New, m.key)
+	mac.Write([]byte(kind))
+	mac.Write([]byte{0})
+	mac.Write([]byte(v))
+
+	var sum [sha256.Size]byte
+	copy(sum[:], mac.Sum(nil))
+	return sum
+}
+
+// Struct masks, in place, every string field of the struct v points to
+// that carries a pii tag, descending into nested structs, pointers,
+// slices, and maps of structs.
+func (m *Masker) Struct(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New("mask: Struct requires a non-nil pointer")
+	}
+	return m.walk(rv.Elem())
+}
+
+// walk masks the tagged fields reachable from v.
+func (m *Masker) walk(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return m.walk(v.Elem())
+
+	case reflect.Interface:
+		// Only reference types stored in interfaces can be set in place.
+		switch e := v.Elem(); e.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map:
+			return m.walk(e)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := m.walk(v.Index(i)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.New(iter.Value().Type()).Elem()
+			elem.Set(iter.Value())
+			if err := m.walk(elem); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), elem)
+		}
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field, fv := t.Field(i), v.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			kind := Kind(field.Tag.Get("pii"))
+			if kind == "" {
+				if err := m.walk(fv); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := m.field(fv, kind); err != nil {
+				return fmt.Errorf("%w (field %s.%s)", err, t.Name(), field.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// field masks a tagged string or *string field.
+func (m *Masker) field(v reflect.Value, kind Kind) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		if kind == KindNull {
+			v.SetZero()
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.String {
+		return fmt.Errorf("mask: pii tag on non-string field of type %s", v.Type())
+	}
+
+	masked, err := m.String(kind, v.String())
+	if err != nil {
+		return err
+	}
+	v.SetString(masked)
+	return nil
+}
@@ -0,0 +1,129 @@
+package mask
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Columns maps the PII columns of a table to how they are masked. Columns
+// not listed are copied as they are.
+type Columns map[string]Kind
+
+// Copy copies every row of table from src into the same table in dst,
+// masking the listed columns, and returns the number of rows copied. The
+// insert runs in a single transaction on dst. Both databases are
+// PostgreSQL; callers check the destination with Guard first.
+//
+// Listing a column the table lacks is an error rather than being ignored,
+// so a renamed column cannot silently leak into staging.
+func (m *Masker) Copy(ctx context.Context, src, dst *sql.DB, table string, columns Columns) (int64, error) {
+	rows, err := src.QueryContext(ctx, "SELECT * FROM "+quoteTable(table))
+	if err != nil {
+		return 0, fmt.Errorf("mask: read %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return 0, fmt.Errorf("mask: read %s: %w", table, err)
+	}
+	for col, kind := range columns {
+		if !slices.Contains(names, col) {
+			return 0, fmt.Errorf("mask: table %s has no column %q", table, col)
+		}
+		if _, err := m.String(kind, "x"); err != nil {
+			return 0, fmt.Errorf("%w (column %s.%s)", err, table, col)
+		}
+	}
+
+	quoted := make([]string, len(names))
+	params := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdent(name)
+		params[i] = fmt.Sprintf("$%d", i+1)
+	}
+
+	tx, err := dst.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		quoteTable(table), strings.Join(quoted, ", "), strings.Join(params, ", ")))
+	if err != nil {
+		return 0, fmt.Errorf("mask: write %s: %w", table, err)
+	}
+	defer stmt.Close()
+
+	values := make([]any, len(names))
+	ptrs := make([]any, len(names))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	var n int64
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return n, fmt.Errorf("mask: read %s: %w", table, err)
+		}
+		for i, name := range names {
+			kind, ok := columns[name]
+			if !ok {
+				continue
+			}
+			if values[i], err = m.value(kind, values[i]); err != nil {
+				return n, fmt.Errorf("mask: column %s.%s: %w", table, name, err)
+			}
+		}
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			return n, fmt.Errorf("mask: write %s: %w", table, err)
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return n, fmt.Errorf("mask: read %s: %w", table, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// value masks a scanned column value. NULLs stay NULL.
+func (m *Masker) value(kind Kind, v any) (any, error) {
+	var s string
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return nil, fmt.Errorf("cannot mask value of type %T", v)
+	}
+
+	if kind == KindNull {
+		return nil, nil
+	}
+	return m.String(kind, s)
+}
+
+// quoteTable quotes a table name, optionally qualified by its schema.
+func quoteTable(name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = quoteIdent(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// quoteIdent quotes a PostgreSQL identifier.
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,36 @@
+package metering
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// ExportHandler serves rolled-up usage as JSON. It accepts the query
+// parameters "from" and "to" (RFC 3339, required) and "subject" (optional).
+func ExportHandler(store Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		from, err := time.Parse(time.RFC3339, q.Get("from"))
+		if err != nil {
+			http.Error(w, "invalid or missing 'from' parameter", http.StatusBadRequest)
+			return
+		}
+
+		to, err := time.Parse(time.RFC3339, q.Get("to"))
+		if err != nil || !to.After(from) {
+			http.Error(w, "invalid or missing 'to' parameter", http.StatusBadRequest)
+			return
+		}
+
+		usage, err := store.Usage(r.Context(), q.Get("subject"), from, to)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"usage": usage})
+	})
+}
@@ -0,0 +1,96 @@
+package metering
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// usageKey identifies a rolled-up usage row.
+type usageKey struct {
+	subject string
+	metric  Metric
+	period  time.Time
+}
+
+// MemoryStore is an in-process Store for development and tests.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	events map[string]Event
+	usage  map[usageKey]float64
+}
+
+// NewMemoryStore constructs an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{events: make(map[string]Event), usage: make(map[usageKey]float64)}
+}
+
+// Insert implements Store.
+func (s *MemoryStore) Insert(_ context.Context, events []Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range events {
+		if _, ok := s.events[e.ID]; !ok {
+			s.events[e.ID] = e
+		}
+	}
+	return nil
+}
+
+// Rollup implements Store.
+func (s *MemoryStore) Rollup(_ context.Context, from, to time.Time, period time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from, to = from.Truncate(period), to.Truncate(period)
+	for key := range s.usage {
+		if !key.period.Before(from) && key.period.Before(to) {
+			delete(s.usage, key)
+		}
+	}
+
+	for _, e := range s.events {
+		start := e.OccurredAt.Truncate(period)
+		if start.Before(from) || !start.Before(to) {
+			continue
+		}
+		s.usage[usageKey{subject: e.Subject, metric: e.Metric, period: start}] += e.Quantity
+	}
+	return nil
+}
+
+// Usage implements Store.
+func (s *MemoryStore) Usage(_ context.Context, subject string, from, to time.Time) ([]Usage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var usage []Usage
+	for key, qty := range s.usage {
+		if subject != "" && key.subject != subject {
+			continue
+		}
+		if key.period.Before(from) || !key.period.Before(to) {
+			continue
+		}
+		usage = append(usage, Usage{Subject: key.subject, Metric: key.metric, PeriodStart: key.period, Quantity: qty})
+	}
+
+	sortUsage(usage)
+	return usage, nil
+}
+
+// sortUsage orders usage by period, subject, then metric.
+func sortUsage(usage []Usage) {
+	sort.Slice(usage, func(i, j int) bool {
+		a, b := usage[i], usage[j]
+		if !a.PeriodStart.Equal(b.PeriodStart) {
+			return a.PeriodStart.Before(b.PeriodStart)
+		}
+		if a.Subject != b.Subject {
+			return a.Subject < b.Subject
+		}
+		return a.Metric < b.Metric
+	})
+}
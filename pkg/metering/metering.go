@@ -0,0 +1,166 @@
+// Package metering records billable usage events (API calls, storage bytes,
+// job minutes) and aggregates them into per-period usage totals for export
+// to billing.
+//
+// Every event carries an idempotency ID so retried recordings are counted
+// once, and rollups recompute totals from raw events so running them more
+// than once for the same period is safe.
+package metering
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Metric identifies the unit being metered.
+type Metric string
+
+// Common metrics recorded by services.
+const (
+	MetricAPICalls     Metric = "api_calls"
+	MetricStorageBytes Metric = "storage_bytes"
+	MetricJobMinutes   Metric = "job_minutes"
+)
+
+// Event is a single billable usage occurrence.
+type Event struct {
+	// ID is the idempotency key; events with the same ID are counted once.
+	ID string `json:"id"`
+
+	// Subject is the billed party (customer, tenant, or account ID).
+	Subject string `json:"subject"`
+
+	// Metric is the unit being metered.
+	Metric Metric `json:"metric"`
+
+	// Quantity is the amount consumed.
+	Quantity float64 `json:"quantity"`
+
+	// OccurredAt is when the usage happened.
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+// Validate checks that the event can be recorded.
+func (e Event) Validate() error {
+	switch {
+	case e.ID == "":
+		return errors.New("metering: event id is required")
+	case e.Subject == "":
+		return errors.New("metering: event subject is required")
+	case e.Metric == "":
+		return errors.New("metering: event metric is required")
+	case e.Quantity < 0:
+		return errors.New("metering: event quantity must not be negative")
+	case e.OccurredAt.IsZero():
+		return errors.New("metering: event occurredAt is required")
+	}
+	return nil
+}
+
+// Usage is the aggregated quantity of a metric for a subject in a period.
+type Usage struct {
+	Subject     string    `json:"subject"`
+	Metric      Metric    `json:"metric"`
+	PeriodStart time.Time `json:"periodStart"`
+	Quantity    float64   `json:"quantity"`
+}
+
+// Store persists events and rollups.
+type Store interface {
+	// Insert stores events, ignoring any whose ID was already stored.
+	Insert(ctx context.Context, events []Event) error
+
+	// Rollup recomputes usage totals for every period of the given
+	// granularity overlapping [from, to).
+	Rollup(ctx context.Context, from, to time.Time, period time.Duration) error
+
+	// Usage returns rolled-up usage in [from, to), optionally for one subject.
+	Usage(ctx context.Context, subject string, from, to time.Time) ([]Usage, error)
+}
+
+// Recorder buffers events in memory and flushes them to a Store in batches.
+type Recorder struct {
+	store     Store
+	batchSize int
+	mu        sync.Mutex
+	buffer    []Event
+}
+
+// NewRecorder constructs a Recorder that flushes once batchSize events are
+// buffered, in addition to the periodic flushes performed by Run.
+func NewRecorder(store Store, batchSize int) *Recorder {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	return &Recorder{store: store, batchSize: batchSize}
+}
+
+// Record buffers a usage event, flushing the buffer when it is full.
+func (r *Recorder) Record(ctx context.Context, e Event) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.buffer = append(r.buffer, e)
+	full := len(r.buffer) >= r.batchSize
+	r.mu.Unlock()
+
+	if full {
+		return r.Flush(ctx)
+	}
+	return nil
+}
+
+// Flush writes all buffered events to the store. Events are returned to the
+// buffer if the write fails so they are retried on the next flush.
+func (r *Recorder) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	batch := r.buffer
+	r.buffer = nil
+	r.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	if err := r.store.Insert(ctx, batch); err != nil {
+		r.mu.Lock()
+		r.buffer = append(batch, r.buffer...)
+		r.mu.Unlock()
+		return fmt.Errorf("metering: flush: %w", err)
+	}
+
+	return nil
+}
+
+// Run flushes buffered events and rolls up the current and previous period
+// every interval until ctx is cancelled, then performs a final flush.
+// It is intended to be driven by the service's scheduler or lifecycle.
+func (r *Recorder) Run(ctx context.Context, interval, period time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := r.Flush(context.WithoutCancel(ctx)); err != nil && onError != nil {
+				onError(err)
+			}
+			return
+		case now := <-ticker.C:
+			if err := r.Flush(ctx); err != nil && onError != nil {
+				onError(err)
+				continue
+			}
+
+			to := now.Truncate(period).Add(period)
+			if err := r.store.Rollup(ctx, to.Add(-2*period), to, period); err != nil && onError != nil {
+				onError(fmt.Errorf("metering: rollup: %w", err))
+			}
+		}
+	}
+}
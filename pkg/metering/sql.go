@@ -0,0 +1,125 @@
+package metering
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Schema creates the PostgreSQL tables used by SQLStore.
+const Schema = `
+CREATE TABLE IF NOT EXISTS metering_events (
+	id          TEXT PRIMARY KEY,
+	subject     TEXT NOT NULL,
+	metric      TEXT NOT NULL,
+	quantity    DOUBLE PRECISION NOT NULL,
+	occurred_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS metering_events_occurred_at_idx ON metering_events (occurred_at);
+
+CREATE TABLE IF NOT EXISTS metering_usage (
+	subject      TEXT NOT NULL,
+	metric       TEXT NOT NULL,
+	period_start TIMESTAMPTZ NOT NULL,
+	quantity     DOUBLE PRECISION NOT NULL,
+	PRIMARY KEY (subject, metric, period_start)
+);
+`
+
+// SQLStore is a PostgreSQL-backed Store.
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore constructs a SQLStore. The tables in Schema must exist.
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: db}
+}
+
+// Insert implements Store.
+func (s *SQLStore) Insert(ctx context.Context, events []Event) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO metering_events (id, subject, metric, quantity, occurred_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO NOTHING`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, e := range events {
+		if _, err := stmt.ExecContext(ctx, e.ID, e.Subject, string(e.Metric), e.Quantity, e.OccurredAt.UTC()); err != nil {
+			return fmt.Errorf("insert event %q: %w", e.ID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Rollup implements Store.
+func (s *SQLStore) Rollup(ctx context.Context, from, to time.Time, period time.Duration) error {
+	from, to = from.Truncate(period).UTC(), to.Truncate(period).UTC()
+	seconds := int64(period / time.Second)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM metering_usage WHERE period_start >= $1 AND period_start < $2`,
+		from, to,
+	); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO metering_usage (subject, metric, period_start, quantity)
+		SELECT subject, metric,
+		       to_timestamp(floor(extract(epoch FROM occurred_at) / $3) * $3) AS period_start,
+		       sum(quantity)
+		FROM metering_events
+		WHERE occurred_at >= $1 AND occurred_at < $2
+		GROUP BY subject, metric, period_start`,
+		from, to, seconds,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Usage implements Store.
+func (s *SQLStore) Usage(ctx context.Context, subject string, from, to time.Time) ([]Usage, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT subject, metric, period_start, quantity
+		FROM metering_usage
+		WHERE period_start >= $1 AND period_start < $2 AND ($3 = '' OR subject = $3)
+		ORDER BY period_start, subject, metric`,
+		from.UTC(), to.UTC(), subject,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usage []Usage
+	for rows.Next() {
+		var u Usage
+		if err := rows.Scan(&u.Subject, &u.Metric, &u.PeriodStart, &u.Quantity); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+
+	return usage, rows.Err()
+}
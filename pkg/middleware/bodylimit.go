@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	apperrors "github.com/iamBelugaa/go-boilerplate/pkg/errors"
+)
+
+// BodyLimit caps request bodies at limit bytes and answers 413 Content Too
+// Large, written by apperrors.Write, beyond it. Requests declaring a larger
+// Content-Length are refused before the handler runs; for others, the
+// handler's reads fail once the limit is crossed and whatever response it
+// then writes is replaced by the 413. The connection is closed afterwards
+// rather than reading the rest of the body.
+func BodyLimit(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				w.Header().Set("Connection", "close")
+				writeTooLarge(w, r, limit)
+				return
+			}
+			if r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body := &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, limit)}
+			r.Body = body
+
+			lw := &limitWriter{ResponseWriter: w, r: r, body: body, limit: limit}
+			next.ServeHTTP(lw, r)
+
+			if !lw.wroteHeader && body.exceeded.Load() {
+				writeTooLarge(w, r, limit)
+			}
+		})
+	}
+}
+
+// limitedBody records whether reads hit the limit.
+type limitedBody struct {
+	io.ReadCloser
+	exceeded atomic.Bool
+}
+
+// Read implements io.Reader.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		b.exceeded.Store(true)
+	}
+	return n, err
+}
+
+// limitWriter replaces the handler's response with a 413 once its body
+// exceeded the limit.
+type limitWriter struct {
+	http.ResponseWriter
+	r           *http.Request
+	body        *limitedBody
+	limit       int64
+	wroteHeader bool
+	replaced    bool
+}
+
+// WriteHeader writes status, or the 413 when the body was too large.
+func (w *limitWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	if w.body.exceeded.Load() {
+		w.replaced = true
+		writeTooLarge(w.ResponseWriter, w.r, w.limit)
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write writes p unless the response was replaced.
+func (w *limitWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.replaced {
+		return len(p), nil
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *limitWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// writeTooLarge writes the 413 response.
+func writeTooLarge(w http.ResponseWriter, r *http.Request, limit int64) {
+	h := w.Header()
+	for _, k := range []string{"Content-Type", "Content-Encoding", "Content-Length", "Content-Disposition", "Etag", "Last-Modified"} {
+		h.Del(k)
+	}
+	apperrors.Write(w, r, apperrors.TooLarge("request body exceeds "+strconv.FormatInt(limit, 10)+" bytes").
+		With("limit", limit))
+}
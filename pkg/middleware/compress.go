@@ -0,0 +1,292 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// defaultMinSize is the smallest body compressed when none is configured;
+// below it the gzip framing outweighs the savings.
+const defaultMinSize = 1024
+
+// defaultContentTypes are the media types compressed when none are
+// configured.
+var defaultContentTypes = []string{
+	"text/",
+	"application/json",
+	"application/problem+json",
+	"application/javascript",
+	"application/xml",
+	"application/wasm",
+	"image/svg+xml",
+}
+
+// Compress gzips responses for clients accepting it when their content
+// type is allowed and their body reaches the minimum size, unless the
+// handler already encoded the response. Strong ETags of compressed
+// responses are weakened. A nil conf uses the defaults; a disabled one only
+// sets "Vary: Accept-Encoding".
+func Compress(conf *config.Compression) func(http.Handler) http.Handler {
+	enabled, level, minSize, types := true, gzip.DefaultCompression, defaultMinSize, defaultContentTypes
+	if conf != nil {
+		enabled = conf.Enabled
+		if conf.Level != 0 {
+			level = conf.Level
+		}
+		if conf.MinSize != 0 {
+			minSize = conf.MinSize
+		}
+		if len(conf.ContentTypes) > 0 {
+			types = conf.ContentTypes
+		}
+	}
+
+	// Compressors are expensive to allocate, so they are pooled.
+	pool := &sync.Pool{New: func() any {
+		gz, _ := gzip.NewWriterLevel(io.Discard, level)
+		return gz
+	}}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			if !enabled || r.Method == http.MethodHead || !accepts(r.Header.Get("Accept-Encoding"), "gzip") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, pool: pool, minSize: minSize, types: types}
+			defer cw.close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// accepts reports whether an Accept-Encoding header allows coding.
+func accepts(header, coding string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), coding) {
+			continue
+		}
+		q := strings.ReplaceAll(params, " ", "")
+		return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+	return false
+}
+
+// compressWriter holds back the response headers until it knows whether
+// to compress: when the headers rule it out, when the body reaches the
+// minimum size, or when the handler returns or flushes.
+type compressWriter struct {
+	http.ResponseWriter
+	pool    *sync.Pool
+	minSize int
+	types   []string
+
+	status    int
+	pending   bool // the handler wrote the header; the decision is open
+	committed bool // the header was passed on
+	buf       []byte
+	gz        *gzip.Writer
+}
+
+// WriteHeader records the status and decides at once when the headers
+// allow it.
+func (w *compressWriter) WriteHeader(status int) {
+	if w.committed || w.pending {
+		return
+	}
+	if status < http.StatusOK {
+		w.ResponseWriter.WriteHeader(status) // informational, e.g. 103 Early Hints
+		return
+	}
+
+	w.status, w.pending = status, true
+
+	h := w.Header()
+	if !compressible(status, h, w.types) {
+		w.commit(false)
+		return
+	}
+	if n, err := strconv.Atoi(h.Get("Content-Length")); err == nil && n < w.minSize {
+		w.commit(false)
+	}
+}
+
+// Write buffers p until the minimum size is reached, then compresses.
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if !w.pending && !w.committed {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(p))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.committed {
+		if w.gz != nil {
+			return w.gz.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.minSize {
+		w.commit(true)
+		if err := w.drain(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush commits to compression, since the handler is streaming, and
+// flushes compressed data to the client.
+func (w *compressWriter) Flush() {
+	if w.pending && !w.committed {
+		w.commit(true)
+		_ = w.drain()
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// commit writes the header, compressed or not.
+func (w *compressWriter) commit(compress bool) {
+	w.committed = true
+	if compress {
+		h := w.Header()
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Accept-Ranges") // ranges would address the encoded bytes
+		if tag := h.Get("ETag"); strings.HasPrefix(tag, `"`) {
+			h.Set("ETag", "W/"+tag) // the encoded bytes differ from the tagged ones
+		}
+		w.gz = w.pool.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// drain writes the buffered body through the chosen writer.
+func (w *compressWriter) drain() error {
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if w.gz != nil {
+		_, err := w.gz.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// close sends a body too small to compress as is, finishes the compressed
+// stream, and returns the compressor to the pool.
+func (w *compressWriter) close() {
+	if w.pending && !w.committed {
+		if w.Header().Get("Content-Length") == "" {
+			w.Header().Set("Content-Length", strconv.Itoa(len(w.buf)))
+		}
+		w.commit(false)
+		_ = w.drain()
+	}
+
+	if w.gz == nil {
+		return
+	}
+	_ = w.gz.Close()
+	w.gz.Reset(io.Discard)
+	w.pool.Put(w.gz)
+	w.gz = nil
+}
+
+// compressible reports whether a response with the given status and
+// headers should be compressed. Partial content is not: its range
+// addresses the identity bytes, which compressing would garble.
+func compressible(status int, h http.Header, types []string) bool {
+	switch status {
+	case http.StatusNoContent, http.StatusNotModified, http.StatusPartialContent:
+		return false
+	}
+	if h.Get("Content-Range") != "" {
+		return false
+	}
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	ct, _, _ := strings.Cut(h.Get("Content-Type"), ";")
+	ct = strings.ToLower(strings.TrimSpace(ct))
+	for _, t := range types {
+		if ct == t || (strings.HasSuffix(t, "/") && strings.HasPrefix(ct, t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Precompressed serves the files of root like http.FileServerFS, but
+// serves "name.br" or "name.gz" instead of "name" when the client accepts
+// that encoding and the file exists, so static assets are compressed once
+// at build time, with Brotli when available, rather than on every request.
+func Precompressed(root fs.FS) http.Handler {
+	files := http.FileServerFS(root)
+	encodings := []struct{ coding, ext string }{{"br", ".br"}, {"gzip", ".gz"}}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		ctype := mime.TypeByExtension(path.Ext(name))
+		if name == "" || ctype == "" {
+			files.ServeHTTP(w, r)
+			return
+		}
+
+		for _, enc := range encodings {
+			if !accepts(r.Header.Get("Accept-Encoding"), enc.coding) {
+				continue
+			}
+
+			f, err := root.Open(name + enc.ext)
+			if err != nil {
+				continue
+			}
+			info, err := f.Stat()
+			content, ok := f.(io.ReadSeeker)
+			if err != nil || info.IsDir() || !ok {
+				f.Close()
+				continue
+			}
+
+			w.Header().Set("Content-Type", ctype)
+			w.Header().Set("Content-Encoding", enc.coding)
+			http.ServeContent(w, r, name, info.ModTime(), content)
+			f.Close()
+			return
+		}
+
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+func TestCompress(t *testing.T) {
+	body := strings.Repeat("compressible text ", 200)
+	const minSize = 1024
+	tests := []struct {
+		name     string
+		body     string
+		handle   func(w http.ResponseWriter)
+		wantGzip bool
+	}{
+		{name: "large text", wantGzip: true, handle: func(w http.ResponseWriter) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Accept-Ranges", "bytes")
+		}},
+		{name: "small body", body: body[:minSize-1], handle: func(w http.ResponseWriter) {
+			w.Header().Set("Content-Type", "text/plain")
+		}},
+		{name: "disallowed type", handle: func(w http.ResponseWriter) { w.Header().Set("Content-Type", "image/png") }},
+		{name: "already encoded", handle: func(w http.ResponseWriter) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Content-Encoding", "br")
+		}},
+		{name: "partial content", handle: func(w http.ResponseWriter) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Content-Range", "bytes 0-3599/7200")
+			w.WriteHeader(http.StatusPartialContent)
+		}},
+		{name: "content range without 206", handle: func(w http.ResponseWriter) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Content-Range", "bytes */7200")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.body == "" {
+				tt.body = body
+			}
+			h := Compress(&config.Compression{Enabled: true, MinSize: minSize})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tt.handle(w)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Encoding", "gzip")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+
+			gzipped := rec.Header().Get("Content-Encoding") == "gzip"
+			if gzipped != tt.wantGzip {
+				t.Fatalf("Content-Encoding = %q, want gzip %v", rec.Header().Get("Content-Encoding"), tt.wantGzip)
+			}
+			if !gzipped {
+				if rec.Body.String() != tt.body {
+					t.Errorf("body was altered")
+				}
+				return
+			}
+			if rec.Header().Get("Accept-Ranges") != "" {
+				t.Error("a compressed response kept Accept-Ranges")
+			}
+			zr, err := gzip.NewReader(rec.Body)
+			if err != nil {
+				t.Fatalf("gzip: %v", err)
+			}
+			if got, _ := io.ReadAll(zr); string(got) != tt.body {
+				t.Errorf("decompressed body differs")
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// defaultCORSMethods are allowed when CORS.AllowedMethods is empty.
+var defaultCORSMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+
+// CORS answers preflight requests and adds CORS headers to requests from
+// allowed origins. Requests from other origins are served without CORS
+// headers, so browsers withhold the response from the calling page.
+func CORS(conf *config.CORS) func(http.Handler) http.Handler {
+	methods := conf.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(conf.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(conf.ExposedHeaders, ", ")
+	wildcard := slices.Contains(conf.AllowedOrigins, "*")
+
+	allowed := func(origin string) bool {
+		return wildcard || slices.ContainsFunc(conf.AllowedOrigins, func(o string) bool {
+			return strings.EqualFold(o, origin)
+		})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			w.Header().Add("Vary", "Origin")
+
+			if origin == "" || !allowed(origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			if wildcard && !conf.AllowCredentials {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if conf.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if !preflight {
+				if exposeHeaders != "" {
+					h.Set("Access-Control-Expose-Headers", exposeHeaders)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", allowMethods)
+			if allowHeaders != "" {
+				h.Set("Access-Control-Allow-Headers", allowHeaders)
+			}
+			if conf.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(conf.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+	"github.com/iamBelugaa/go-boilerplate/pkg/auth"
+	apperrors "github.com/iamBelugaa/go-boilerplate/pkg/errors"
+)
+
+// NameDedup names Dedup in route group chains. It is not part of the
+// default chain, as it must run after authentication.
+const NameDedup = "dedup"
+
+// HeaderDuplicate marks a response replayed to a duplicate submission.
+const HeaderDuplicate = "X-Duplicate-Submission"
+
+// maxReplayBytes caps the response body kept for replay; duplicates of
+// larger responses are rejected instead.
+const maxReplayBytes = 1 << 20
+
+// Dedup absorbs double submissions: a mutating request whose method, path,
+// query, and normalized body match one from the same principal that is in
+// flight or completed within the window is a duplicate. JSON bodies are
+// compared by value, ignoring whitespace and key order, and form bodies by
+// their sorted fields. In "coalesce" mode, the default, duplicates wait for
+// the first request and receive a copy of its response; in "reject" mode
+// they are answered 409 Conflict. Server errors are replayed to waiting
+// duplicates but not remembered, so a later retry runs again.
+//
+// Requests carrying an Idempotency-Key, anonymous requests, and bodies over
+// MaxBodyBytes pass through untouched. A nil conf uses the defaults.
+func Dedup(conf *config.Dedup) func(http.Handler) http.Handler {
+	d := &dedup{
+		window:  2 * time.Second,
+		maxBody: 1 << 20,
+		entries: make(map[[sha256.Size]byte]*submission),
+		now:     time.Now,
+	}
+	if conf != nil {
+		if conf.Window > 0 {
+			d.window = conf.Window
+		}
+		if conf.MaxBodyBytes > 0 {
+			d.maxBody = conf.MaxBodyBytes
+		}
+		d.reject = conf.Mode == "reject"
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p := auth.PrincipalFromContext(r.Context())
+			if !isMutating(r.Method) || p == nil || r.Header.Get("Idempotency-Key") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key, ok := d.fingerprint(r, p)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			sub, first := d.begin(key)
+			if !first {
+				d.duplicate(w, r, sub)
+				return
+			}
+
+			rec := NewRecorder(w, maxReplayBytes)
+			completed := false
+			defer func() { d.finish(key, sub, rec, completed) }()
+
+			next.ServeHTTP(rec, r)
+			completed = true
+		})
+	}
+}
+
+// isMutating reports whether method changes server state.
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// dedup is the state of a Dedup middleware.
+type dedup struct {
+	window  time.Duration
+	maxBody int64
+	reject  bool
+	now     func() time.Time
+
+	mu        sync.Mutex
+	entries   map[[sha256.Size]byte]*submission
+	lastSweep time.Time
+}
+
+// submission is a fingerprinted request, in flight until done is closed.
+type submission struct {
+	done    chan struct{}
+	expires time.Time
+
+	// Set before done is closed.
+	status     int
+	header     http.Header
+	body       []byte
+	replayable bool
+}
+
+// fingerprint hashes the principal and the normalized request, restoring
+// the body for the handler. It fails for bodies over the limit.
+func (d *dedup) fingerprint(r *http.Request, p *auth.Principal) ([sha256.Size]byte, bool) {
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		buf, err := io.ReadAll(io.LimitReader(r.Body, d.maxBody+1))
+		rest := r.Body
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(buf), rest), rest}
+		if err != nil || int64(len(buf)) > d.maxBody {
+			return [sha256.Size]byte{}, false
+		}
+		body = normalizeBody(r.Header.Get("Content-Type"), buf)
+	}
+
+	h := sha256.New()
+	for _, part := range []string{p.Subject, p.ClientID, p.ImpersonatedBy, r.Method, r.URL.Path, r.URL.Query().Encode(), string(body)} {
+		// Length prefixes keep adjacent parts from running into each other.
+		_ = binary.Write(h, binary.BigEndian, uint64(len(part)))
+		h.Write([]byte(part))
+	}
+
+	var key [sha256.Size]byte
+	h.Sum(key[:0])
+	return key, true
+}
+
+// normalizeBody returns body in a canonical form for its content type, or
+// as is when it cannot be parsed.
+func normalizeBody(contentType string, body []byte) []byte {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		var v any
+		if dec.Decode(&v) != nil || dec.More() {
+			return body
+		}
+		// Maps are marshaled with sorted keys.
+		if out, err := json.Marshal(v); err == nil {
+			return out
+		}
+	case mediaType == "application/x-www-form-urlencoded":
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			return []byte(values.Encode())
+		}
+	}
+	return body
+}
+
+// begin returns the live submission for key, or registers a new one and
+// reports that the caller is first.
+func (d *dedup) begin(key [sha256.Size]byte) (*submission, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	if now.Sub(d.lastSweep) >= d.window {
+		for k, sub := range d.entries {
+			if !sub.expires.IsZero() && !now.Before(sub.expires) {
+				delete(d.entries, k)
+			}
+		}
+		d.lastSweep = now
+	}
+
+	if sub, ok := d.entries[key]; ok && (sub.expires.IsZero() || now.Before(sub.expires)) {
+		return sub, false
+	}
+
+	sub := &submission{done: make(chan struct{})}
+	d.entries[key] = sub
+	return sub, true
+}
+
+// finish records the first request's response for its duplicates and
+// releases those waiting. Panics and server errors are forgotten at once.
+func (d *dedup) finish(key [sha256.Size]byte, sub *submission, rec *Recorder, completed bool) {
+	var whole bool
+	sub.status, sub.header, sub.body, whole = rec.Result()
+	sub.replayable = completed && whole
+
+	d.mu.Lock()
+	if completed && sub.status < http.StatusInternalServerError {
+		sub.expires = d.now().Add(d.window)
+	} else if d.entries[key] == sub {
+		delete(d.entries, key)
+	}
+	d.mu.Unlock()
+
+	close(sub.done)
+}
+
+// duplicate answers a duplicate of sub.
+func (d *dedup) duplicate(w http.ResponseWriter, r *http.Request, sub *submission) {
+	if d.reject {
+		writeDuplicate(w, r, d.window)
+		return
+	}
+
+	select {
+	case <-sub.done:
+	case <-r.Context().Done():
+		return
+	}
+	if !sub.replayable {
+		writeDuplicate(w, r, d.window)
+		return
+	}
+
+	h := w.Header()
+	for k, v := range sub.header {
+		h[k] = v
+	}
+	h.Set(HeaderDuplicate, "true")
+	w.WriteHeader(sub.status)
+	_, _ = w.Write(sub.body)
+}
+
+// writeDuplicate answers 409 Conflict through apperrors.Write.
+func writeDuplicate(w http.ResponseWriter, r *http.Request, window time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(window.Seconds())+1))
+	w.Header().Set(HeaderDuplicate, "true")
+	apperrors.Write(w, r, apperrors.Conflict("duplicate submission").WithCode("duplicate_submission"))
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+	"github.com/iamBelugaa/go-boilerplate/pkg/auth"
+	apperrors "github.com/iamBelugaa/go-boilerplate/pkg/errors"
+)
+
+// submit sends a request through h as subject, returning the response.
+func submit(h http.Handler, subject, method, body string, header map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/orders", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	if subject != "" {
+		r = r.WithContext(auth.WithPrincipal(r.Context(), &auth.Principal{Subject: subject}))
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestDedupMatching(t *testing.T) {
+	tests := []struct {
+		name       string
+		subject    string
+		method     string
+		first      string
+		second     string
+		header     map[string]string
+		wantCalled int32
+	}{
+		{name: "same body", subject: "u1", first: `{"a":1}`, second: `{"a":1}`, wantCalled: 1},
+		{name: "reordered JSON", subject: "u1", first: `{"a":1,"b":[1,2]}`, second: "{ \"b\": [1, 2],\n \"a\": 1 }", wantCalled: 1},
+		{name: "different body", subject: "u1", first: `{"a":1}`, second: `{"a":2}`, wantCalled: 2},
+		{name: "anonymous", first: `{"a":1}`, second: `{"a":1}`, wantCalled: 2},
+		{name: "idempotency key", subject: "u1", first: `{"a":1}`, second: `{"a":1}`, header: map[string]string{"Idempotency-Key": "k1"}, wantCalled: 2},
+		{name: "safe method", subject: "u1", method: http.MethodGet, wantCalled: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called atomic.Int32
+			h := Dedup(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called.Add(1)
+				w.Header().Set("Location", "/orders/1")
+				w.WriteHeader(http.StatusCreated)
+			}))
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			submit(h, tt.subject, method, tt.first, tt.header)
+			rec := submit(h, tt.subject, method, tt.second, tt.header)
+
+			if got := called.Load(); got != tt.wantCalled {
+				t.Fatalf("handler ran %d times, want %d", got, tt.wantCalled)
+			}
+			if rec.Code != http.StatusCreated || rec.Header().Get("Location") != "/orders/1" {
+				t.Errorf("second response = %d, Location %q; want the first's", rec.Code, rec.Header().Get("Location"))
+			}
+			if replayed := rec.Header().Get(HeaderDuplicate) == "true"; replayed != (tt.wantCalled == 1) {
+				t.Errorf("%s = %v, want %v", HeaderDuplicate, replayed, tt.wantCalled == 1)
+			}
+		})
+	}
+}
+
+func TestDedupCoalescesInFlight(t *testing.T) {
+	release := make(chan struct{})
+	var called atomic.Int32
+	h := Dedup(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called.Add(1)
+		<-release
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- submit(h, "u1", http.MethodPost, `{}`, nil) }()
+	for called.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	second := make(chan *httptest.ResponseRecorder)
+	go func() { second <- submit(h, "u1", http.MethodPost, `{}`, nil) }()
+
+	close(release)
+	for _, rec := range []*httptest.ResponseRecorder{<-first, <-second} {
+		if rec.Body.String() != `{"id":1}` {
+			t.Errorf("body = %q, want the first response", rec.Body.String())
+		}
+	}
+	if called.Load() != 1 {
+		t.Errorf("handler ran %d times, want once", called.Load())
+	}
+}
+
+func TestDedupReject(t *testing.T) {
+	h := Dedup(&config.Dedup{Mode: "reject"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	submit(h, "u1", http.MethodPost, `{}`, nil)
+	rec := submit(h, "u1", http.MethodPost, `{}`, nil)
+
+	var p apperrors.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rec.Code != http.StatusConflict || p.Code != "duplicate_submission" || rec.Header().Get("Retry-After") != "3" {
+		t.Fatalf("got %d %+v, Retry-After %q; want 409 duplicate_submission after 3s", rec.Code, p, rec.Header().Get("Retry-After"))
+	}
+}
+
+func TestDedupForgets(t *testing.T) {
+	tests := []struct {
+		name   string
+		window time.Duration
+		status int
+		wait   time.Duration
+	}{
+		{name: "server error", window: time.Minute, status: http.StatusInternalServerError},
+		{name: "window elapsed", window: 10 * time.Millisecond, status: http.StatusOK, wait: 20 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called atomic.Int32
+			h := Dedup(&config.Dedup{Window: tt.window})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called.Add(1)
+				w.WriteHeader(tt.status)
+			}))
+			submit(h, "u1", http.MethodPost, `{}`, nil)
+			time.Sleep(tt.wait)
+			submit(h, "u1", http.MethodPost, `{}`, nil)
+			if called.Load() != 2 {
+				t.Errorf("handler ran %d times, want the retry to run", called.Load())
+			}
+		})
+	}
+}
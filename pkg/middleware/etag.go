@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// NameETag names ETag in route group chains. It is not part of the
+// default chain, as it buffers responses; read-heavy routes opt in.
+const NameETag = "etag"
+
+// maxETagBytes caps the response body buffered for hashing; larger
+// responses are sent untagged.
+const maxETagBytes = 4 << 20
+
+// ETag tags successful GET and HEAD responses with a JSON body and answers
+// conditional requests with 304 Not Modified: when If-None-Match lists
+// the tag (compared weakly, so "*" and tags weakened by compression
+// match), or, without If-None-Match, when the response's Last-Modified is
+// not after If-Modified-Since. The tag is a hash of the body, strong
+// unless weak is set, for handlers whose output is equivalent but not
+// byte-for-byte stable. A tag the handler set itself, such as a row
+// version, is kept. Responses are buffered until the handler returns,
+// except when it flushes or the body exceeds 4 MiB, which are sent
+// untagged.
+func ETag(weak bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ew := &etagWriter{ResponseWriter: w}
+			next.ServeHTTP(ew, r)
+			if ew.passthrough {
+				return
+			}
+
+			status := ew.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			h := w.Header()
+			if status != http.StatusOK || !isJSON(h.Get("Content-Type")) {
+				ew.release()
+				return
+			}
+
+			tag := h.Get("ETag")
+			if tag == "" {
+				sum := sha256.Sum256(ew.buf)
+				tag = `"` + hex.EncodeToString(sum[:16]) + `"`
+				if weak {
+					tag = "W/" + tag
+				}
+				h.Set("ETag", tag)
+			}
+
+			if notModified(r, h, tag) {
+				h.Del("Content-Type")
+				h.Del("Content-Length")
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			h.Set("Content-Length", strconv.Itoa(len(ew.buf)))
+			ew.release()
+		})
+	}
+}
+
+// notModified evaluates the request's preconditions against the response
+// headers (RFC 9110 section 13.2.2).
+func notModified(r *http.Request, h http.Header, tag string) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(tag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(h.Get("Last-Modified"))
+	return err == nil && !modified.After(ims)
+}
+
+// isJSON reports whether the media type is JSON, including "+json"
+// suffixed types such as application/problem+json.
+func isJSON(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mt == "application/json" || strings.HasSuffix(mt, "+json"))
+}
+
+// etagWriter buffers the response so it can be hashed, and switches to
+// passing it through when the handler streams or the body grows too big.
+type etagWriter struct {
+	http.ResponseWriter
+	status      int
+	buf         []byte
+	passthrough bool
+}
+
+// WriteHeader records the status; informational responses go out at once.
+func (w *etagWriter) WriteHeader(status int) {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if status < http.StatusOK {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// Write buffers p, or passes it through once the response is untagged.
+func (w *etagWriter) Write(p []byte) (int, error) {
+	if w.passthrough {
+		return w.ResponseWriter.Write(p)
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if len(w.buf)+len(p) > maxETagBytes {
+		if err := w.release(); err != nil {
+			return 0, err
+		}
+		return w.ResponseWriter.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	return len(p), nil
+}
+
+// Flush sends the response untagged, since the handler is streaming.
+func (w *etagWriter) Flush() {
+	if !w.passthrough {
+		_ = w.release()
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *etagWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// release writes the recorded status and buffered body and passes the
+// rest of the response through.
+func (w *etagWriter) release() error {
+	w.passthrough = true
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
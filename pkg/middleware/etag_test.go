@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestETag(t *testing.T) {
+	json := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Last-Modified", "Mon, 01 Jan 2024 00:00:00 GMT")
+			_, _ = w.Write([]byte(body))
+		}
+	}
+	tag := func(h http.Handler) string {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec.Header().Get("ETag")
+	}
+
+	strong := ETag(false)(json(`{"a":1}`))
+	current := tag(strong)
+	if !strings.HasPrefix(current, `"`) {
+		t.Fatalf("ETag = %q, want a strong tag", current)
+	}
+
+	tests := []struct {
+		name       string
+		handler    http.Handler
+		method     string
+		header     map[string]string
+		wantStatus int
+		wantTag    bool
+	}{
+		{name: "tagged", handler: strong, wantStatus: http.StatusOK, wantTag: true},
+		{name: "matching tag", handler: strong, header: map[string]string{"If-None-Match": current}, wantStatus: http.StatusNotModified, wantTag: true},
+		{name: "weakened tag", handler: strong, header: map[string]string{"If-None-Match": `"other", W/` + current}, wantStatus: http.StatusNotModified, wantTag: true},
+		{name: "star", handler: strong, header: map[string]string{"If-None-Match": "*"}, wantStatus: http.StatusNotModified, wantTag: true},
+		{name: "stale tag", handler: strong, header: map[string]string{"If-None-Match": `"other"`}, wantStatus: http.StatusOK, wantTag: true},
+		{name: "not modified since", handler: strong, header: map[string]string{"If-Modified-Since": "Tue, 02 Jan 2024 00:00:00 GMT"}, wantStatus: http.StatusNotModified, wantTag: true},
+		{name: "modified since", handler: strong, header: map[string]string{"If-Modified-Since": "Sun, 31 Dec 2023 00:00:00 GMT"}, wantStatus: http.StatusOK, wantTag: true},
+		{name: "post", handler: strong, method: http.MethodPost, wantStatus: http.StatusOK},
+		{
+			name: "not JSON", wantStatus: http.StatusOK,
+			handler: ETag(false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("text")) })),
+		},
+		{
+			name: "error status", wantStatus: http.StatusNotFound,
+			handler: ETag(false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotFound)
+			})),
+		},
+		{
+			name: "streamed", wantStatus: http.StatusOK,
+			handler: ETag(false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json("[")(w, r)
+				_ = http.NewResponseController(w).Flush()
+				_, _ = w.Write([]byte("]"))
+			})),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, "/", nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("ETag"); (got != "") != tt.wantTag || (tt.wantTag && got != current) {
+				t.Errorf("ETag = %q, want tagged %v", got, tt.wantTag)
+			}
+			if rec.Code == http.StatusNotModified && rec.Body.Len() != 0 {
+				t.Errorf("304 carried a body")
+			}
+		})
+	}
+
+	if weak := tag(ETag(true)(json(`{"a":1}`))); weak != "W/"+current {
+		t.Errorf("weak ETag = %q, want W/%s", weak, current)
+	}
+	if kept := tag(ETag(false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v7"`)
+		json(`{}`)(w, r)
+	}))); kept != `"v7"` {
+		t.Errorf("ETag = %q, want the handler's own", kept)
+	}
+}
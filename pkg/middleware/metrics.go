@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// routeKey is the context key for the slot the matched route is stored in.
+type routeKey struct{}
+
+// Metrics records Prometheus metrics for every request, registered with
+// reg: http_requests_total and http_request_duration_seconds, labelled by
+// method, route pattern, and status, and http_requests_in_flight. Routes
+// are the patterns matched by http.ServeMux, keeping label cardinality
+// bounded; unmatched requests are labelled "unmatched".
+func Metrics(reg prometheus.Registerer) (func(http.Handler) http.Handler, error) {
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests served, by method, route, and status.",
+	}, []string{"method", "route", "status"})
+
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time spent serving HTTP requests, by method, route, and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	inFlight := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "HTTP requests currently being served.",
+	})
+
+	for _, c := range []prometheus.Collector{requests, duration, inFlight} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			inFlight.Inc()
+			defer inFlight.Dec()
+
+			route := new(atomic.Pointer[string])
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+
+			defer func() {
+				pattern := "unmatched"
+				if p := route.Load(); p != nil && *p != "" {
+					pattern = *p
+				}
+				labels := prometheus.Labels{
+					"method": r.Method,
+					"route":  pattern,
+					"status": strconv.Itoa(sw.status),
+				}
+				requests.With(labels).Inc()
+				duration.With(labels).Observe(time.Since(start).Seconds())
+			}()
+
+			next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), routeKey{}, route)))
+		})
+	}, nil
+}
+
+// captureRoute stores the pattern http.ServeMux (or a router wrapping one)
+// matches for the request in the slot placed in the context by Metrics. It
+// wraps the handler directly so the mux sets the pattern on the very
+// request it sees. The slot may be read concurrently when the request
+// times out, so a mux is asked for the pattern before serving.
+func captureRoute(next http.Handler) http.Handler {
+	mux, _ := next.(interface {
+		Handler(r *http.Request) (http.Handler, string)
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, ok := r.Context().Value(routeKey{}).(*atomic.Pointer[string])
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if mux != nil {
+			_, pattern := mux.Handler(r)
+			route.Store(&pattern)
+		}
+		defer func() {
+			if r.Pattern != "" {
+				route.Store(&r.Pattern)
+			}
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// statusWriter records the status code of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+// WriteHeader records the status code.
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write marks the header as written.
+func (w *statusWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
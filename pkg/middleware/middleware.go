@@ -0,0 +1,152 @@
+// Package middleware provides the HTTP middleware every service needs and
+// a Chain composing them, so services start from a production-ready stack
+// and reorder or remove pieces by name.
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+	"github.com/iamBelugaa/go-boilerplate/pkg/errreport"
+	"github.com/iamBelugaa/go-boilerplate/pkg/logging"
+)
+
+// Names of the middleware in the default chain.
+const (
+	NameRequestID       = "request_id"
+	NameRealIP          = "real_ip"
+	NameLogger          = "logger"
+	NameAccessLog       = "access_log"
+	NameMetrics         = "metrics"
+	NameRecover         = "recover"
+	NameSecurityHeaders = "security_headers"
+	NameBodyLimit       = "body_limit"
+	NameCORS            = "cors"
+	NameCompress        = "compress"
+	NameTimeout         = "timeout"
+)
+
+// Named is a middleware identified by name within a Chain.
+type Named struct {
+	Name string
+	Wrap func(http.Handler) http.Handler
+}
+
+// Chain is an ordered list of middleware, outermost first. Its methods
+// return modified copies, so a shared default chain is never altered.
+type Chain []Named
+
+// Then wraps h in every middleware of the chain.
+func (c Chain) Then(h http.Handler) http.Handler {
+	h = captureRoute(h)
+	for i := len(c) - 1; i >= 0; i-- {
+		h = c[i].Wrap(h)
+	}
+	return h
+}
+
+// Without returns the chain minus the named middleware.
+func (c Chain) Without(names ...string) Chain {
+	return slices.DeleteFunc(slices.Clone(c), func(n Named) bool {
+		return slices.Contains(names, n.Name)
+	})
+}
+
+// Append returns the chain with m added innermost.
+func (c Chain) Append(m ...Named) Chain {
+	return append(slices.Clone(c), m...)
+}
+
+// Before returns the chain with m inserted just outside the named
+// middleware, or innermost when it is not in the chain.
+func (c Chain) Before(name string, m Named) Chain {
+	i := c.index(name)
+	if i < 0 {
+		return c.Append(m)
+	}
+	return slices.Insert(slices.Clone(c), i, m)
+}
+
+// After returns the chain with m inserted just inside the named
+// middleware, or innermost when it is not in the chain.
+func (c Chain) After(name string, m Named) Chain {
+	i := c.index(name)
+	if i < 0 {
+		return c.Append(m)
+	}
+	return slices.Insert(slices.Clone(c), i+1, m)
+}
+
+// Replace returns the chain with the named middleware swapped for wrap.
+func (c Chain) Replace(name string, wrap func(http.Handler) http.Handler) Chain {
+	out := slices.Clone(c)
+	if i := c.index(name); i >= 0 {
+		out[i].Wrap = wrap
+	}
+	return out
+}
+
+// index returns the position of the named middleware, or -1.
+func (c Chain) index(name string) int {
+	return slices.IndexFunc(c, func(n Named) bool { return n.Name == name })
+}
+
+// Defaults returns the recommended chain, outermost first: request IDs,
+// client IPs from trusted proxies, request-scoped loggers, access logs,
+// metrics registered with reg, panic recovery reporting to reporter (may
+// be nil), security headers (unless disabled), request body limits and
+// CORS (when configured), response compression, and per-request timeouts
+// (when configured).
+func Defaults(conf *config.Config, logger *zap.Logger, reg prometheus.Registerer, reporter errreport.Reporter) (Chain, error) {
+	realIP, err := RealIP(conf.Server.TrustedProxies, conf.Server.ClientIPHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, err := Metrics(reg)
+	if err != nil {
+		return nil, err
+	}
+
+	recoverer, err := Recover(reg, reporter)
+	if err != nil {
+		return nil, err
+	}
+
+	var accessLog *config.AccessLog
+	if conf.Logging != nil {
+		accessLog = conf.Logging.AccessLog
+	}
+
+	chain := Chain{
+		{NameRequestID, RequestID},
+		{NameRealIP, realIP},
+		{NameLogger, logging.Middleware(logger)},
+		{NameAccessLog, logging.AccessLog(logger, accessLog)},
+		{NameMetrics, metrics},
+		{NameRecover, recoverer},
+	}
+	if security := conf.Server.SecurityHeaders; security == nil || !security.Disabled {
+		var env config.Environment
+		if conf.Service != nil {
+			env = conf.Service.Environment
+		}
+		chain = append(chain, Named{NameSecurityHeaders, SecurityHeaders(security, env)})
+	}
+	if conf.Server.MaxBodyBytes > 0 {
+		chain = append(chain, Named{NameBodyLimit, BodyLimit(conf.Server.MaxBodyBytes)})
+	}
+	if conf.Server.CORS != nil {
+		chain = append(chain, Named{NameCORS, CORS(conf.Server.CORS)})
+	}
+	chain = append(chain, Named{NameCompress, Compress(conf.Server.Compression)})
+	if conf.Server.RequestTimeout > 0 || len(conf.Server.RouteTimeouts) > 0 {
+		chain = append(chain, Named{NameTimeout, Timeout(conf.Server.RequestTimeout, conf.Server.RouteTimeouts)})
+	}
+
+	return chain, nil
+}
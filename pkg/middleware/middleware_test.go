@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// tracing returns a middleware appending name to trace on the way in.
+func tracing(name string, trace *[]string) Named {
+	return Named{Name: name, Wrap: func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*trace = append(*trace, name)
+			next.ServeHTTP(w, r)
+		})
+	}}
+}
+
+func TestChain(t *testing.T) {
+	var trace []string
+	base := Chain{tracing("a", &trace), tracing("b", &trace), tracing("c", &trace)}
+
+	tests := []struct {
+		name  string
+		chain Chain
+		want  string
+	}{
+		{name: "as is", chain: base, want: "a b c"},
+		{name: "without", chain: base.Without("b", "missing"), want: "a c"},
+		{name: "append", chain: base.Append(tracing("d", &trace)), want: "a b c d"},
+		{name: "before", chain: base.Before("b", tracing("x", &trace)), want: "a x b c"},
+		{name: "after", chain: base.After("b", tracing("x", &trace)), want: "a b x c"},
+		{name: "before missing", chain: base.Before("missing", tracing("x", &trace)), want: "a b c x"},
+		{name: "replace", chain: base.Replace("b", tracing("B", &trace).Wrap), want: "a B c"},
+		{name: "replace missing", chain: base.Replace("missing", tracing("B", &trace).Wrap), want: "a b c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trace = nil
+			tt.chain.Then(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			if got := strings.Join(trace, " "); got != tt.want {
+				t.Errorf("ran %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// The methods return copies, leaving a shared chain untouched.
+	var names []string
+	for _, m := range base {
+		names = append(names, m.Name)
+	}
+	if !slices.Equal(names, []string{"a", "b", "c"}) {
+		t.Errorf("base chain changed to %q", names)
+	}
+}
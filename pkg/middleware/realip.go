@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Forwarding headers RealIP reads the client address from.
+const (
+	HeaderXForwardedFor = "x-forwarded-for"
+	HeaderForwarded     = "forwarded"
+	HeaderXRealIP       = "x-real-ip"
+)
+
+// RealIP rewrites the request's RemoteAddr to the client address reported
+// by the forwarding header when the connection comes from one of the
+// trusted proxies (IPs or CIDRs), so access logs, rate limits, ACLs, and
+// audit events see the client rather than the proxy. header selects
+// X-Forwarded-For (the default, falling back to X-Real-IP when absent),
+// Forwarded (RFC 7239), or X-Real-IP; proxies must overwrite or append to
+// the header chosen. Lists are read right to left, skipping trusted
+// proxies, so clients cannot spoof their address by sending the header
+// themselves. With no trusted proxies it does nothing.
+func RealIP(trusted []string, header string) (func(http.Handler) http.Handler, error) {
+	prefixes := make([]netip.Prefix, 0, len(trusted))
+	for _, entry := range trusted {
+		p, err := netip.ParsePrefix(entry)
+		if err != nil {
+			addr, aerr := netip.ParseAddr(entry)
+			if aerr != nil {
+				return nil, fmt.Errorf("middleware: invalid trusted proxy %q", entry)
+			}
+			p = netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+
+	var hops func(r *http.Request) []string
+	switch strings.ToLower(header) {
+	case "", HeaderXForwardedFor:
+		hops = func(r *http.Request) []string {
+			if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+				return strings.Split(strings.Join(xff, ","), ",")
+			}
+			return realIPHops(r)
+		}
+	case HeaderForwarded:
+		hops = forwardedHops
+	case HeaderXRealIP:
+		hops = realIPHops
+	default:
+		return nil, fmt.Errorf("middleware: unknown client IP header %q", header)
+	}
+
+	isTrusted := func(addr netip.Addr) bool {
+		addr = addr.Unmap()
+		for _, p := range prefixes {
+			if p.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		if len(prefixes) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, port, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			peer, err := netip.ParseAddr(host)
+			if err != nil || !isTrusted(peer) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			client := peer
+			list := hops(r)
+			for i := len(list) - 1; i >= 0; i-- {
+				addr, ok := parseHop(list[i])
+				if !ok {
+					break
+				}
+				client = addr
+				if !isTrusted(addr) {
+					break
+				}
+			}
+
+			r2 := r.Clone(r.Context())
+			r2.RemoteAddr = net.JoinHostPort(client.Unmap().String(), port)
+			next.ServeHTTP(w, r2)
+		})
+	}, nil
+}
+
+// realIPHops returns the X-Real-IP address as a single hop.
+func realIPHops(r *http.Request) []string {
+	if v := r.Header.Get("X-Real-IP"); v != "" {
+		return []string{v}
+	}
+	return nil
+}
+
+// forwardedHops returns the "for" parameters of the Forwarded elements,
+// in order. Elements without one are kept empty, so they stop the walk.
+func forwardedHops(r *http.Request) []string {
+	var hops []string
+	for _, v := range r.Header.Values("Forwarded") {
+		for _, elem := range strings.Split(v, ",") {
+			hop := ""
+			for _, pair := range strings.Split(elem, ";") {
+				key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(key, "for") {
+					hop = strings.Trim(value, `"`)
+				}
+			}
+			hops = append(hops, hop)
+		}
+	}
+	return hops
+}
+
+// parseHop parses a forwarded address: a bare IP, an IP and port, or a
+// bracketed IPv6 address with an optional port. Obfuscated identifiers
+// and "unknown" are rejected.
+func parseHop(hop string) (netip.Addr, bool) {
+	hop = strings.TrimSpace(hop)
+	if addr, err := netip.ParseAddr(hop); err == nil {
+		return addr, true
+	}
+	if host, _, err := net.SplitHostPort(hop); err == nil {
+		hop = host
+	} else {
+		hop = strings.TrimSuffix(strings.TrimPrefix(hop, "["), "]")
+	}
+	addr, err := netip.ParseAddr(hop)
+	return addr, err == nil
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	trusted := []string{"10.0.0.0/8", "192.0.2.1"}
+	tests := []struct {
+		name    string
+		header  string // the configured client IP header
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{name: "untrusted peer", remote: "203.0.113.9:1234", headers: map[string]string{"X-Forwarded-For": "198.51.100.1"}, want: "203.0.113.9:1234"},
+		{name: "one proxy", remote: "10.0.0.1:1234", headers: map[string]string{"X-Forwarded-For": "198.51.100.1"}, want: "198.51.100.1:1234"},
+		{
+			name: "skips trusted hops right to left", remote: "10.0.0.1:1234",
+			headers: map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.7, 10.0.0.2, 192.0.2.1"},
+			want:    "203.0.113.7:1234",
+		},
+		{
+			name: "spoofed leftmost entry", remote: "10.0.0.1:1234",
+			headers: map[string]string{"X-Forwarded-For": "127.0.0.1, 198.51.100.1"},
+			want:    "198.51.100.1:1234",
+		},
+		{name: "all hops trusted", remote: "10.0.0.1:1234", headers: map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"}, want: "10.0.0.3:1234"},
+		{name: "garbage hop stops the walk", remote: "10.0.0.1:1234", headers: map[string]string{"X-Forwarded-For": "198.51.100.1, unknown"}, want: "10.0.0.1:1234"},
+		{name: "X-Real-IP fallback", remote: "10.0.0.1:1234", headers: map[string]string{"X-Real-IP": "198.51.100.1"}, want: "198.51.100.1:1234"},
+		{
+			name: "forwarded", header: HeaderForwarded, remote: "10.0.0.1:1234",
+			headers: map[string]string{"Forwarded": `for="[2001:db8::1]:4711";proto=https, for=10.0.0.2`},
+			want:    "[2001:db8::1]:1234",
+		},
+		{
+			name: "forwarded element without for", header: HeaderForwarded, remote: "10.0.0.1:1234",
+			headers: map[string]string{"Forwarded": "for=198.51.100.1, proto=https"},
+			want:    "10.0.0.1:1234",
+		},
+		{name: "mapped IPv4 peer", remote: "[::ffff:10.0.0.1]:1234", headers: map[string]string{"X-Forwarded-For": "198.51.100.1"}, want: "198.51.100.1:1234"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			realIP, err := RealIP(trusted, tt.header)
+			if err != nil {
+				t.Fatalf("RealIP: %v", err)
+			}
+			var got string
+			h := realIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { got = r.RemoteAddr }))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remote
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), r)
+			if got != tt.want {
+				t.Errorf("RemoteAddr = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRealIPRejectsConfig(t *testing.T) {
+	if _, err := RealIP([]string{"not-an-ip"}, ""); err == nil {
+		t.Error("RealIP accepted an invalid trusted proxy")
+	}
+	if _, err := RealIP(nil, "x-client-ip"); err == nil {
+		t.Error("RealIP accepted an unknown header")
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import "net/http"
+
+// Recorder passes a response through while keeping a copy of its status,
+// header, and body, for middleware caching or replaying responses. Bodies
+// larger than its limit, and flushed (streamed) responses, are not kept.
+type Recorder struct {
+	http.ResponseWriter
+	limit    int
+	status   int
+	header   http.Header
+	body     []byte
+	overflow bool
+}
+
+// NewRecorder returns a Recorder writing through to w and keeping bodies
+// of up to limit bytes.
+func NewRecorder(w http.ResponseWriter, limit int) *Recorder {
+	return &Recorder{ResponseWriter: w, limit: limit}
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *Recorder) WriteHeader(status int) {
+	if w.status == 0 && status >= http.StatusOK {
+		w.status = status
+		w.header = w.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter.
+func (w *Recorder) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.overflow {
+		if len(w.body)+len(p) > w.limit {
+			w.overflow, w.body = true, nil
+		} else {
+			w.body = append(w.body, p...)
+		}
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush marks the response as streamed, which is not kept, and flushes it.
+func (w *Recorder) Flush() {
+	w.overflow, w.body = true, nil
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *Recorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Result returns the recorded response: a handler writing nothing sent an
+// empty 200. complete is false when the body was not kept whole.
+func (w *Recorder) Result() (status int, header http.Header, body []byte, complete bool) {
+	if w.status == 0 {
+		w.status, w.header = http.StatusOK, w.Header().Clone()
+	}
+	return w.status, w.header, w.body, !w.overflow
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	tests := []struct {
+		name         string
+		handle       func(w http.ResponseWriter)
+		wantStatus   int
+		wantBody     string
+		wantComplete bool
+	}{
+		{name: "nothing written", handle: func(http.ResponseWriter) {}, wantStatus: http.StatusOK, wantComplete: true},
+		{
+			name: "status and body", wantStatus: http.StatusCreated, wantBody: "abcd", wantComplete: true,
+			handle: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusEarlyHints)
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte("ab"))
+				_, _ = w.Write([]byte("cd"))
+			},
+		},
+		{name: "over the limit", wantStatus: http.StatusOK, handle: func(w http.ResponseWriter) { _, _ = w.Write([]byte("too long")) }},
+		{
+			name: "flushed", wantStatus: http.StatusOK,
+			handle: func(w http.ResponseWriter) {
+				_, _ = w.Write([]byte("ab"))
+				_ = http.NewResponseController(w).Flush()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := httptest.NewRecorder()
+			rec := NewRecorder(out, 4)
+			rec.Header().Set("X-Test", "1")
+			tt.handle(rec)
+
+			status, header, body, complete := rec.Result()
+			if status != tt.wantStatus || string(body) != tt.wantBody || complete != tt.wantComplete {
+				t.Fatalf("Result = %d %q %v, want %d %q %v", status, body, complete, tt.wantStatus, tt.wantBody, tt.wantComplete)
+			}
+			if header.Get("X-Test") != "1" {
+				t.Errorf("header not recorded")
+			}
+			if tt.wantComplete && out.Body.String() != tt.wantBody {
+				t.Errorf("passed through %q, want %q", out.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	apperrors "github.com/iamBelugaa/go-boilerplate/pkg/errors"
+	"github.com/iamBelugaa/go-boilerplate/pkg/errreport"
+	"github.com/iamBelugaa/go-boilerplate/pkg/logging"
+	"github.com/iamBelugaa/go-boilerplate/pkg/requestid"
+)
+
+// Recover turns a panicking handler into a 500 Internal Server Error,
+// written by apperrors.Write and carrying the request ID, so callers can
+// quote it to support, instead of letting net/http drop the connection.
+// Each panic is logged with its stack trace, counted in http_panics_total
+// (registered with reg), and sent with the request to reporter, which may
+// be nil. http.ErrAbortHandler panics are re-raised, as they deliberately
+// abort the response.
+func Recover(reg prometheus.Registerer, reporter errreport.Reporter) (func(http.Handler) http.Handler, error) {
+	panics := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "http_panics_total",
+		Help: "Panics recovered while serving HTTP requests.",
+	})
+	if err := reg.Register(panics); err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				panics.Inc()
+
+				requestID := r.Header.Get(logging.HeaderRequestID)
+				if requestID == "" {
+					requestID = requestid.New()
+				}
+
+				fields := []zap.Field{
+					zap.Any("panic", rec),
+					zap.String("request_id", requestID),
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+					zap.Stack("stack"),
+				}
+				if reporter != nil {
+					errreport.Panic(r.Context(), reporter, rec, r)
+					fields = append(fields, errreport.Reported)
+				}
+				logging.FromContext(r.Context()).Error("panic serving request", fields...)
+
+				w.Header().Set(logging.HeaderRequestID, requestID)
+				apperrors.Write(w, r.WithContext(requestid.NewContext(r.Context(), requestID)),
+					apperrors.Logged(apperrors.Internal(fmt.Errorf("panic: %v", rec))))
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
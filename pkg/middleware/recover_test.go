@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	apperrors "github.com/iamBelugaa/go-boilerplate/pkg/errors"
+	"github.com/iamBelugaa/go-boilerplate/pkg/requestid"
+)
+
+func TestRecover(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	recoverer, err := Recover(reg, nil)
+	if err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+	h := recoverer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+
+	tests := []struct {
+		name      string
+		requestID string
+	}{
+		{name: "request ID set", requestID: "req-1"},
+		{name: "no request ID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.requestID != "" {
+				r.Header.Set(requestid.Header, tt.requestID)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+
+			var p apperrors.Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if rec.Code != http.StatusInternalServerError || p.Code != "internal" || p.Detail != "" {
+				t.Fatalf("got %d %+v, want a bare 500", rec.Code, p)
+			}
+			id := rec.Header().Get(requestid.Header)
+			if id == "" || p.RequestID != id || (tt.requestID != "" && id != tt.requestID) {
+				t.Errorf("request ID header %q, body %q; want them equal to the request's", id, p.RequestID)
+			}
+		})
+	}
+
+	want := `
+# HELP http_panics_total Panics recovered while serving HTTP requests.
+# TYPE http_panics_total counter
+http_panics_total 2
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "http_panics_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRecoverReraisesAbort(t *testing.T) {
+	recoverer, err := Recover(prometheus.NewRegistry(), nil)
+	if err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Fatalf("recovered %v, want http.ErrAbortHandler re-raised", p)
+		}
+	}()
+	recoverer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
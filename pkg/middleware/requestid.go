@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/audit"
+	"github.com/iamBelugaa/go-boilerplate/pkg/requestid"
+)
+
+// RequestID ensures every request carries an X-Request-ID, accepting the
+// caller's when it is well-formed and generating a UUIDv7 otherwise. The
+// ID is echoed in the response, stored in the request context for loggers,
+// audit events, and outbound calls (see requestid.Transport), and left on
+// the request header for middleware further in.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+			r.Header.Set(requestid.Header, id)
+		}
+
+		ctx := requestid.NewContext(r.Context(), id)
+		ctx = audit.WithRequestID(ctx, id)
+
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
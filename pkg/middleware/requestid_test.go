@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/requestid"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "caller's ID", incoming: "abc-123", keep: true},
+		{name: "none"},
+		{name: "with spaces", incoming: "abc 123"},
+		{name: "too long", incoming: strings.Repeat("a", 129)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inContext, inHeader string
+			h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				inContext, inHeader = requestid.FromContext(r.Context()), r.Header.Get(requestid.Header)
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				r.Header.Set(requestid.Header, tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+
+			echoed := rec.Header().Get(requestid.Header)
+			if !requestid.Valid(echoed) || inContext != echoed || inHeader != echoed {
+				t.Fatalf("response %q, context %q, request header %q; want one valid ID", echoed, inContext, inHeader)
+			}
+			if (echoed == tt.incoming) != tt.keep {
+				t.Errorf("ID = %q for incoming %q, want kept %v", echoed, tt.incoming, tt.keep)
+			}
+		})
+	}
+}
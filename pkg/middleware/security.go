@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// defaultHSTSMaxAge is the Strict-Transport-Security max age outside
+// development.
+const defaultHSTSMaxAge = 365 * 24 * time.Hour
+
+// CSP builds a Content-Security-Policy header value. Directives keep the
+// order they were first set in.
+type CSP struct {
+	names   []string
+	sources map[string][]string
+}
+
+// NewCSP returns an empty policy.
+func NewCSP() *CSP {
+	return &CSP{sources: make(map[string][]string)}
+}
+
+// DefaultCSP returns the default policy: same-origin resources only, no
+// plugins, and no framing. It suits APIs and frontends served by the
+// service itself.
+func DefaultCSP() *CSP {
+	return NewCSP().
+		Set("default-src", "'self'").
+		Set("base-uri", "'self'").
+		Set("form-action", "'self'").
+		Set("frame-ancestors", "'none'").
+		Set("object-src", "'none'")
+}
+
+// Set replaces the sources of directive; none makes it a bare directive
+// such as upgrade-insecure-requests.
+func (c *CSP) Set(directive string, sources ...string) *CSP {
+	directive = strings.ToLower(directive)
+	if _, ok := c.sources[directive]; !ok {
+		c.names = append(c.names, directive)
+	}
+	c.sources[directive] = slices.Clone(sources)
+	return c
+}
+
+// Add appends sources to directive, skipping those it already has.
+func (c *CSP) Add(directive string, sources ...string) *CSP {
+	directive = strings.ToLower(directive)
+	current := c.sources[directive]
+	for _, src := range sources {
+		if !slices.Contains(current, src) {
+			current = append(current, src)
+		}
+	}
+	return c.Set(directive, current...)
+}
+
+// Remove deletes directive.
+func (c *CSP) Remove(directive string) *CSP {
+	directive = strings.ToLower(directive)
+	delete(c.sources, directive)
+	c.names = slices.DeleteFunc(c.names, func(n string) bool { return n == directive })
+	return c
+}
+
+// String returns the header value.
+func (c *CSP) String() string {
+	parts := make([]string, 0, len(c.names))
+	for _, name := range c.names {
+		parts = append(parts, strings.Join(append([]string{name}, c.sources[name]...), " "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// SecurityHeaders sets X-Content-Type-Options, X-Frame-Options,
+// Referrer-Policy, Strict-Transport-Security, and Content-Security-Policy
+// on every response, before the handler runs, so handlers may override
+// them (e.g., a page embedding a third-party widget). Unset values follow
+// env: strict in production and staging; relaxed in development, where
+// HSTS is not sent, lest it stick to localhost, and the CSP only reports
+// violations. A nil conf uses the defaults of env.
+func SecurityHeaders(conf *config.SecurityHeaders, env config.Environment) func(http.Handler) http.Handler {
+	if conf == nil {
+		conf = &config.SecurityHeaders{}
+	}
+	dev := env != config.EnvironmentProduction && env != config.EnvironmentStaging
+
+	headers := http.Header{}
+	headers.Set("X-Content-Type-Options", "nosniff")
+
+	frameOptions, referrerPolicy, cspMode := "DENY", "no-referrer", "enforce"
+	if dev {
+		frameOptions, referrerPolicy, cspMode = "SAMEORIGIN", "strict-origin-when-cross-origin", "report-only"
+	}
+	if conf.FrameOptions != "" {
+		frameOptions = conf.FrameOptions
+	}
+	if conf.ReferrerPolicy != "" {
+		referrerPolicy = conf.ReferrerPolicy
+	}
+	if conf.CSPMode != "" {
+		cspMode = conf.CSPMode
+	}
+	headers.Set("X-Frame-Options", frameOptions)
+	headers.Set("Referrer-Policy", referrerPolicy)
+
+	maxAge := conf.HSTSMaxAge
+	if maxAge == 0 && !dev {
+		maxAge = defaultHSTSMaxAge
+	}
+	if maxAge > 0 {
+		hsts := "max-age=" + strconv.Itoa(int(maxAge.Seconds())) + "; includeSubDomains"
+		if conf.HSTSPreload {
+			hsts += "; preload"
+		}
+		headers.Set("Strict-Transport-Security", hsts)
+	}
+
+	if cspMode != "off" {
+		csp := DefaultCSP()
+		names := make([]string, 0, len(conf.CSP))
+		for name := range conf.CSP {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			csp.Set(name, conf.CSP[name]...)
+		}
+		if conf.CSPReportURI != "" {
+			csp.Set("report-uri", conf.CSPReportURI)
+		}
+
+		header := "Content-Security-Policy"
+		if cspMode == "report-only" {
+			header = "Content-Security-Policy-Report-Only"
+		}
+		headers.Set(header, csp.String())
+	}
+
+	return func(next http.Handler) http.Handler {
+		if conf.Disabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			for k, v := range headers {
+				h[k] = v
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
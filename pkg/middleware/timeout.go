@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	apperrors "github.com/iamBelugaa/go-boilerplate/pkg/errors"
+)
+
+// Timeout cancels the request context after d and answers 504 Gateway
+// Timeout, written by apperrors.Write, if the handler has not responded by
+// then. Handlers must respect context cancellation to stop
+// working on abandoned requests.
+//
+// overrides replaces d for some routes, keyed by "[METHOD ]path" where a
+// path ending in "/" covers every path below it (e.g., "POST /exports/");
+// the longest match wins. An override of 0 disables the deadline, for
+// streaming and other long-running endpoints. Responses are buffered
+// until the handler returns, except on routes without a deadline. The
+// server's write timeout still applies on top.
+//
+// Buffered responses cannot be flushed or hijacked, so connection
+// upgrades (Connection: Upgrade, as websockets send) and Server-Sent
+// Events requests (Accept: text/event-stream) are never given a deadline,
+// whatever the overrides say.
+func Timeout(d time.Duration, overrides map[string]time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := routeTimeout(d, overrides, r)
+			if timeout <= 0 || streaming(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			tw := &timeoutWriter{header: w.Header().Clone()}
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+
+				dst := w.Header()
+				clear(dst)
+				maps.Copy(dst, tw.header)
+				if tw.status == 0 {
+					tw.status = http.StatusOK
+				}
+				w.WriteHeader(tw.status)
+				_, _ = w.Write(tw.body.Bytes())
+
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+
+				tw.timedOut = true
+				if ctx.Err() != context.DeadlineExceeded {
+					return // the client went away
+				}
+
+				apperrors.Write(w, r, apperrors.Timeout("request timed out"))
+			}
+		})
+	}
+}
+
+// routeTimeout returns the deadline for r: the longest matching override,
+// or d.
+func routeTimeout(d time.Duration, overrides map[string]time.Duration, r *http.Request) time.Duration {
+	best := -1
+	for key, timeout := range overrides {
+		method, path, found := strings.Cut(key, " ")
+		if !found {
+			method, path = "", key
+		}
+		if method != "" && method != r.Method {
+			continue
+		}
+		if r.URL.Path != path && !(strings.HasSuffix(path, "/") && strings.HasPrefix(r.URL.Path, path)) {
+			continue
+		}
+
+		// Longer paths win; a method-specific key beats a bare one.
+		score := len(path) * 2
+		if method != "" {
+			score++
+		}
+		if score > best {
+			best, d = score, timeout
+		}
+	}
+	return d
+}
+
+// streaming reports whether r asks for a connection upgrade or an event
+// stream, which must be written through as they are produced.
+func streaming(r *http.Request) bool {
+	return hasToken(r.Header, "Connection", "upgrade") || hasToken(r.Header, "Accept", "text/event-stream")
+}
+
+// hasToken reports whether the comma-separated values of the header name
+// include token, ignoring case and parameters.
+func hasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			t, _, _ = strings.Cut(t, ";")
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// timeoutWriter buffers a response until the handler returns, and
+// discards it once the request has timed out.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	body     bytes.Buffer
+	status   int
+	timedOut bool
+}
+
+// Header returns the buffered header.
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader records the status code.
+func (w *timeoutWriter) WriteHeader(status int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.status == 0 && !w.timedOut {
+		w.status = status
+	}
+}
+
+// Write buffers p, failing with http.ErrHandlerTimeout after the timeout.
+func (w *timeoutWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(p)
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeoutPassesStreamsThrough(t *testing.T) {
+	tests := []struct {
+		name         string
+		header       map[string]string
+		wantDeadline bool
+	}{
+		{name: "plain", wantDeadline: true},
+		{name: "websocket", header: map[string]string{"Connection": "keep-alive, Upgrade", "Upgrade": "websocket"}},
+		{name: "event stream", header: map[string]string{"Accept": "text/event-stream"}},
+		{name: "event stream with params", header: map[string]string{"Accept": "application/json, Text/Event-Stream;q=0.9"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Timeout(time.Minute, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, hasDeadline := r.Context().Deadline()
+				if hasDeadline != tt.wantDeadline {
+					t.Errorf("deadline set = %v, want %v", hasDeadline, tt.wantDeadline)
+				}
+				flushErr := http.NewResponseController(w).Flush()
+				if (flushErr == nil) == tt.wantDeadline {
+					t.Errorf("Flush = %v, want it supported only without a deadline", flushErr)
+				}
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/events", nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), r)
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		handle     func(w http.ResponseWriter, r *http.Request)
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name: "in time", wantStatus: http.StatusAccepted, wantBody: "done",
+			handle: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Handler", "1")
+				w.WriteHeader(http.StatusAccepted)
+				_, _ = w.Write([]byte("done"))
+			},
+		},
+		{name: "nothing written", wantStatus: http.StatusOK, handle: func(http.ResponseWriter, *http.Request) {}},
+		{
+			name: "too slow", wantStatus: http.StatusGatewayTimeout,
+			handle: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Handler", "1")
+				<-r.Context().Done()
+				// The middleware marks the writer timed out once it sees
+				// the deadline too; from then on writes fail.
+				for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+					if _, err := w.Write([]byte("late")); err == http.ErrHandlerTimeout {
+						return
+					}
+					if time.Now().After(deadline) {
+						t.Error("writes after the deadline kept succeeding")
+						return
+					}
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Timeout(20*time.Millisecond, nil)(http.HandlerFunc(tt.handle)).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+			}
+			if rec.Code == http.StatusGatewayTimeout {
+				if rec.Header().Get("X-Handler") != "" || !strings.Contains(rec.Body.String(), `"timeout"`) {
+					t.Errorf("504 carried %v %q, want only the timeout problem", rec.Header(), rec.Body.String())
+				}
+			} else if tt.wantBody != "" && rec.Header().Get("X-Handler") != "1" {
+				t.Errorf("handler header lost")
+			}
+		})
+	}
+}
+
+func TestTimeoutPropagatesPanics(t *testing.T) {
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Fatalf("recovered %v, want the handler's panic", p)
+		}
+	}()
+	Timeout(time.Second, nil)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestRouteTimeout(t *testing.T) {
+	overrides := map[string]time.Duration{
+		"/exports/":        time.Minute,
+		"POST /exports/":   time.Hour,
+		"/exports/big":     2 * time.Hour,
+		"GET /events":      0,
+		"/reports/monthly": 3 * time.Minute,
+	}
+	tests := []struct {
+		method, path string
+		want         time.Duration
+	}{
+		{method: http.MethodGet, path: "/users", want: time.Second},
+		{method: http.MethodGet, path: "/exports/1", want: time.Minute},
+		{method: http.MethodPost, path: "/exports/1", want: time.Hour},
+		{method: http.MethodPost, path: "/exports/big", want: 2 * time.Hour},
+		{method: http.MethodGet, path: "/events", want: 0},
+		{method: http.MethodPost, path: "/events", want: time.Second},
+		{method: http.MethodGet, path: "/reports/monthly/2024", want: time.Second},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		if got := routeTimeout(time.Second, overrides, r); got != tt.want {
+			t.Errorf("%s %s: timeout = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
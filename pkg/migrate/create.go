@@ -0,0 +1,61 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Create writes an empty migration named name into dir, numbered after
+// the latest one there, and returns its path.
+func Create(dir, name string) (string, error) {
+	name = slug(name)
+	if name == "" {
+		return "", errors.New("migrate: migration name is empty")
+	}
+
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil {
+		return "", err
+	}
+	var version int64 = 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	file := filepath.Join(dir, fmt.Sprintf("%03d_%s.sql", version, name))
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("migrate: create %q: %w", file, err)
+	}
+	if _, err := fmt.Fprintf(f, "\n\n%s\n", Separator); err != nil {
+		_ = f.Close()
+		return "", fmt.Errorf("migrate: create %q: %w", file, err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("migrate: create %q: %w", file, err)
+	}
+	return file, nil
+}
+
+// slug returns name in lowercase with runs of other characters than
+// letters and digits replaced by underscores (e.g., "Add orders.status"
+// is "add_orders_status").
+func slug(name string) string {
+	var b strings.Builder
+	pending := false
+	for _, c := range strings.ToLower(name) {
+		if 'a' <= c && c <= 'z' || '0' <= c && c <= '9' {
+			if pending && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(c)
+			pending = false
+		} else {
+			pending = true
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,359 @@
+// Package migrate applies versioned SQL migrations to a PostgreSQL
+// database. Migrations are files named after their version and purpose,
+// such as "003_add_orders_status.sql", holding the change above a
+// separator line and its reversal below it, in the format tern uses:
+//
+//	ALTER TABLE orders ADD COLUMN status TEXT NOT NULL DEFAULT 'new';
+//	---- create above / drop below ----
+//	ALTER TABLE orders DROP COLUMN status;
+//
+// Each migration runs in a transaction and is recorded in a table, so it
+// is applied once. Migrators hold a PostgreSQL advisory lock while they
+// run, so replicas started together, or a deployment job racing a
+// developer, apply every migration exactly once.
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// DefaultTable is the table recording applied migrations.
+const DefaultTable = "schema_migrations"
+
+// Separator divides a migration file into its up and down sections.
+const Separator = "---- create above / drop below ----"
+
+// ErrLocked is returned when another migrator holds the lock past the
+// configured lock timeout.
+var ErrLocked = errors.New("migrate: another migrator holds the lock")
+
+// fileName matches migration file names, capturing the version and name.
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.sql$`)
+
+// Migration is a versioned schema change.
+type Migration struct {
+	// Version orders the migrations; each is unique.
+	Version int64 `json:"version"`
+
+	// Name describes the change (e.g., "add_orders_status").
+	Name string `json:"name"`
+
+	// Up applies the change and Down reverts it; either may be blank.
+	Up   string `json:"-"`
+	Down string `json:"-"`
+}
+
+// Load reads the migrations in the root of fsys, sorted by version. Files
+// not ending in ".sql" are ignored.
+func Load(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("migrate: list migrations: %w", err)
+	}
+
+	migrations := make([]Migration, 0, len(files))
+	for _, file := range files {
+		m := fileName.FindStringSubmatch(path.Base(file))
+		if m == nil {
+			return nil, fmt.Errorf("migrate: %q is not named VERSION_name.sql", file)
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migrate: %q has an invalid version", file)
+		}
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("migrate: read %q: %w", file, err)
+		}
+		up, down, ok := strings.Cut(string(data), Separator)
+		if !ok {
+			return nil, fmt.Errorf("migrate: %q lacks the %q line", file, Separator)
+		}
+		migrations = append(migrations, Migration{Version: version, Name: m[2], Up: up, Down: down})
+	}
+
+	slices.SortFunc(migrations, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("migrate: version %d is used by %q and %q",
+				migrations[i].Version, migrations[i-1].Name, migrations[i].Name)
+		}
+	}
+	return migrations, nil
+}
+
+// Status is a migration and whether it is applied.
+type Status struct {
+	Migration
+
+	// AppliedAt is when the migration was applied, or nil if it is pending.
+	AppliedAt *time.Time `json:"appliedAt"`
+
+	// Missing reports an applied migration absent from the sources, such as
+	// one applied by a newer release.
+	Missing bool `json:"missing,omitempty"`
+}
+
+// Migrator applies migrations to a database.
+type Migrator struct {
+	db          *sql.DB
+	migrations  []Migration
+	table       string
+	lockTimeout time.Duration
+	log         *zap.Logger
+}
+
+// New constructs a Migrator applying the migrations of fsys (see Load) to
+// db, logging the migrations it runs to log. A nil conf uses the defaults.
+func New(db *sql.DB, fsys fs.FS, conf *config.Migrations, log *zap.Logger) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		conf = &config.Migrations{}
+	}
+	m := &Migrator{
+		db:          db,
+		migrations:  migrations,
+		table:       conf.Table,
+		lockTimeout: conf.LockTimeout,
+		log:         log,
+	}
+	if m.table == "" {
+		m.table = DefaultTable
+	}
+	if m.log == nil {
+		m.log = zap.NewNop()
+	}
+	return m, nil
+}
+
+// Migrations returns the migrations of the sources, sorted by version.
+func (m *Migrator) Migrations() []Migration {
+	return slices.Clone(m.migrations)
+}
+
+// Up applies the pending migrations in version order, including any older
+// than the latest applied one (e.g., merged from another branch), and
+// returns those it applied. It stops at the first failure, whose changes
+// are rolled back.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			err := m.run(ctx, conn, mig.Up,
+				`INSERT INTO `+m.quotedTable()+` (version, name) VALUES ($1, $2)`, mig.Version, mig.Name)
+			if err != nil {
+				return fmt.Errorf("migrate: apply %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			m.log.Info("migration applied", zap.Int64("version", mig.Version), zap.String("name", mig.Name))
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the latest steps applied migrations, newest first, and
+// returns those it reverted. Applied migrations absent from the sources
+// cannot be reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		versions := make([]int64, 0, len(applied))
+		for v := range applied {
+			versions = append(versions, v)
+		}
+		slices.SortFunc(versions, func(a, b int64) int { return cmp.Compare(b, a) })
+
+		for _, v := range versions[:min(steps, len(versions))] {
+			i := slices.IndexFunc(m.migrations, func(mig Migration) bool { return mig.Version == v })
+			if i < 0 {
+				return fmt.Errorf("migrate: applied migration %d has no source to revert it with", v)
+			}
+			mig := m.migrations[i]
+			err := m.run(ctx, conn, mig.Down,
+				`DELETE FROM `+m.quotedTable()+` WHERE version = $1`, mig.Version)
+			if err != nil {
+				return fmt.Errorf("migrate: revert %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			m.log.Info("migration reverted", zap.Int64("version", mig.Version), zap.String("name", mig.Name))
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status returns every migration, of the sources or applied, sorted by
+// version.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: connect: %w", err)
+	}
+	defer conn.Close()
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := Status{Migration: mig}
+		if a, ok := applied[mig.Version]; ok {
+			s.AppliedAt = &a.at
+			delete(applied, mig.Version)
+		}
+		statuses = append(statuses, s)
+	}
+	for v, a := range applied {
+		statuses = append(statuses, Status{Migration: Migration{Version: v, Name: a.name}, AppliedAt: &a.at, Missing: true})
+	}
+	slices.SortFunc(statuses, func(a, b Status) int { return cmp.Compare(a.Version, b.Version) })
+	return statuses, nil
+}
+
+// appliedMigration is a row of the migrations table.
+type appliedMigration struct {
+	name string
+	at   time.Time
+}
+
+// applied creates the migrations table if needed and returns its rows by
+// version.
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	_, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS `+m.quotedTable()+` (
+			version    BIGINT PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: create %s: %w", m.table, err)
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, applied_at FROM `+m.quotedTable())
+	if err != nil {
+		return nil, fmt.Errorf("migrate: read %s: %w", m.table, err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]appliedMigration)
+	for rows.Next() {
+		var (
+			version int64
+			a       appliedMigration
+		)
+		if err := rows.Scan(&version, &a.name, &a.at); err != nil {
+			return nil, fmt.Errorf("migrate: read %s: %w", m.table, err)
+		}
+		applied[version] = a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("migrate: read %s: %w", m.table, err)
+	}
+	return applied, nil
+}
+
+// run executes script and records the change with query in a transaction.
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, script, query string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if !blank(script) {
+		if _, err := tx.ExecContext(ctx, script); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// locked runs fn on a connection holding the advisory lock of the
+// migrations table, waiting for it up to the lock timeout.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("migrate: connect: %w", err)
+	}
+	defer conn.Close()
+
+	lockCtx := ctx
+	if m.lockTimeout > 0 {
+		var cancel context.CancelFunc
+		lockCtx, cancel = context.WithTimeout(ctx, m.lockTimeout)
+		defer cancel()
+	}
+	key := m.lockKey()
+	if _, err := conn.ExecContext(lockCtx, `SELECT pg_advisory_lock($1)`, key); err != nil {
+		if ctx.Err() == nil && lockCtx.Err() != nil {
+			return ErrLocked
+		}
+		return fmt.Errorf("migrate: lock: %w", err)
+	}
+	defer func() {
+		// The lock is released with the session if unlocking fails.
+		_, _ = conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, key)
+	}()
+
+	return fn(conn)
+}
+
+// lockKey returns the advisory lock key of the migrations table, so
+// migrators of different tables in one database do not wait on each other.
+func (m *Migrator) lockKey() int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte("migrate:" + m.table))
+	return int64(h.Sum64())
+}
+
+// quotedTable returns the migrations table as a quoted identifier.
+func (m *Migrator) quotedTable() string {
+	return `"` + strings.ReplaceAll(m.table, `"`, `""`) + `"`
+}
+
+// blank reports whether script holds nothing but whitespace and comments.
+func blank(script string) bool {
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
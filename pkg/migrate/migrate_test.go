@@ -0,0 +1,324 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// fakeDriver is a database/sql driver keeping the migrations table in
+// memory and recording the other statements it runs. Scripts containing
+// "FAIL" fail.
+type fakeDriver struct {
+	mu      sync.Mutex
+	applied map[int64]string
+	log     []string
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{d: d}, nil }
+
+func (d *fakeDriver) statements() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.log...)
+}
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{d: c.d, query: strings.TrimSpace(query)}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE IF NOT EXISTS"), strings.HasPrefix(s.query, "SELECT pg_advisory"):
+	case strings.HasPrefix(s.query, "INSERT INTO"):
+		s.d.applied[args[0].(int64)] = args[1].(string)
+	case strings.HasPrefix(s.query, "DELETE FROM"):
+		delete(s.d.applied, args[0].(int64))
+	case strings.Contains(s.query, "FAIL"):
+		return nil, errors.New("syntax error")
+	default:
+		s.d.log = append(s.d.log, s.query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	if !strings.HasPrefix(s.query, "SELECT version, name, applied_at FROM") {
+		return nil, errors.New("fake driver: unexpected query")
+	}
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	rows := &fakeRows{}
+	for v, name := range s.d.applied {
+		rows.rows = append(rows.rows, []driver.Value{v, name, time.Unix(0, 0)})
+	}
+	return rows, nil
+}
+
+type fakeRows struct{ rows [][]driver.Value }
+
+func (r *fakeRows) Columns() []string { return []string{"version", "name", "applied_at"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// newTestMigrator returns a Migrator of fsys on a fresh fakeDriver whose
+// table holds the applied versions.
+func newTestMigrator(t *testing.T, fsys fstest.MapFS, applied map[int64]string) (*Migrator, *fakeDriver) {
+	t.Helper()
+	if applied == nil {
+		applied = map[int64]string{}
+	}
+	d := &fakeDriver{applied: applied}
+	name := "fake-" + strings.ReplaceAll(t.Name(), "/", "-")
+	sql.Register(name, d)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	m, err := New(db, fsys, nil, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return m, d
+}
+
+func file(up, down string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(up + "\n" + Separator + "\n" + down)}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		want    []string // VERSION_name
+		wantErr string
+	}{
+		{
+			name: "sorted by version",
+			fsys: fstest.MapFS{
+				"010_add_index.sql":    file("up", "down"),
+				"002_create_users.sql": file("up", "down"),
+				"README.md":            {Data: []byte("ignored")},
+			},
+			want: []string{"2_create_users", "10_add_index"},
+		},
+		{name: "empty", fsys: fstest.MapFS{}},
+		{name: "uppercase name", fsys: fstest.MapFS{"001_AddUsers.sql": file("", "")}, wantErr: "is not named"},
+		{name: "dashed name", fsys: fstest.MapFS{"001_add-users.sql": file("", "")}, wantErr: "is not named"},
+		{name: "timestamp prefix of another tool", fsys: fstest.MapFS{"20240101_init.up.sql": file("", "")}, wantErr: "is not named"},
+		{name: "version zero", fsys: fstest.MapFS{"000_init.sql": file("", "")}, wantErr: "invalid version"},
+		{name: "no separator", fsys: fstest.MapFS{"001_init.sql": {Data: []byte("CREATE TABLE t ();")}}, wantErr: "lacks the"},
+		{
+			name:    "duplicate version",
+			fsys:    fstest.MapFS{"001_a.sql": file("", ""), "01_b.sql": file("", "")},
+			wantErr: "version 1 is used by",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := Load(tt.fsys)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			var got []string
+			for _, m := range migrations {
+				got = append(got, fmt.Sprintf("%d_%s", m.Version, m.Name))
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Fatalf("Load = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSplitsSections(t *testing.T) {
+	migrations, err := Load(fstest.MapFS{"001_init.sql": file("CREATE TABLE t ();", "DROP TABLE t;")})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	m := migrations[0]
+	if strings.TrimSpace(m.Up) != "CREATE TABLE t ();" || strings.TrimSpace(m.Down) != "DROP TABLE t;" {
+		t.Fatalf("Up = %q, Down = %q", m.Up, m.Down)
+	}
+}
+
+func TestBlank(t *testing.T) {
+	tests := []struct {
+		script string
+		want   bool
+	}{
+		{script: "", want: true},
+		{script: " \n\t\n", want: true},
+		{script: "-- nothing to revert\n  -- really\n", want: true},
+		{script: "DROP TABLE t; -- trailing comment", want: false},
+		{script: "-- comment\nSELECT 1;", want: false},
+		{script: "/* block */", want: false},
+	}
+	for _, tt := range tests {
+		if got := blank(tt.script); got != tt.want {
+			t.Errorf("blank(%q) = %v, want %v", tt.script, got, tt.want)
+		}
+	}
+}
+
+func TestUpAppliesPendingInOrder(t *testing.T) {
+	fsys := fstest.MapFS{
+		"001_one.sql":   file("UP 1", "DOWN 1"),
+		"002_two.sql":   file("UP 2", "DOWN 2"),
+		"003_three.sql": file("-- nothing yet", "DOWN 3"),
+		"004_four.sql":  file("UP 4", "DOWN 4"),
+	}
+	// 2 was merged from another branch after 4 was applied.
+	m, d := newTestMigrator(t, fsys, map[int64]string{1: "one", 4: "four"})
+
+	done, err := m.Up(context.Background())
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if len(done) != 2 || done[0].Version != 2 || done[1].Version != 3 {
+		t.Fatalf("Up applied %+v, want 2 and 3", done)
+	}
+	if got := strings.Join(d.statements(), "; "); got != "UP 2" {
+		t.Errorf("scripts run = %q, want only UP 2 (3 is blank)", got)
+	}
+	if len(d.applied) != 4 || d.applied[3] != "three" {
+		t.Errorf("table = %v, want every version recorded", d.applied)
+	}
+
+	if done, err := m.Up(context.Background()); err != nil || len(done) != 0 {
+		t.Errorf("second Up = %+v, %v; want nothing applied", done, err)
+	}
+}
+
+func TestUpStopsAtFailure(t *testing.T) {
+	fsys := fstest.MapFS{
+		"001_one.sql": file("UP 1", ""),
+		"002_bad.sql": file("FAIL", ""),
+		"003_tre.sql": file("UP 3", ""),
+	}
+	m, d := newTestMigrator(t, fsys, nil)
+
+	done, err := m.Up(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "apply 2_bad") {
+		t.Fatalf("Up error = %v, want the failure of 2_bad", err)
+	}
+	if len(done) != 1 || done[0].Version != 1 {
+		t.Errorf("Up applied %+v, want 1", done)
+	}
+	if _, ok := d.applied[2]; ok || len(d.applied) != 1 {
+		t.Errorf("table = %v, want only 1 recorded", d.applied)
+	}
+}
+
+func TestDownRevertsNewestFirst(t *testing.T) {
+	fsys := fstest.MapFS{
+		"001_one.sql": file("UP 1", "DOWN 1"),
+		"002_two.sql": file("UP 2", "DOWN 2"),
+		"003_tre.sql": file("UP 3", "DOWN 3"),
+	}
+	m, d := newTestMigrator(t, fsys, map[int64]string{1: "one", 2: "two", 3: "tre"})
+
+	done, err := m.Down(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if len(done) != 2 || done[0].Version != 3 || done[1].Version != 2 {
+		t.Fatalf("Down reverted %+v, want 3 then 2", done)
+	}
+	if got := strings.Join(d.statements(), "; "); got != "DOWN 3; DOWN 2" {
+		t.Errorf("scripts run = %q", got)
+	}
+	if len(d.applied) != 1 {
+		t.Errorf("table = %v, want only 1 left", d.applied)
+	}
+
+	if done, err := m.Down(context.Background(), 5); err != nil || len(done) != 1 {
+		t.Errorf("Down past the first = %+v, %v; want 1 reverted", done, err)
+	}
+}
+
+func TestDownRefusesMissingSource(t *testing.T) {
+	m, d := newTestMigrator(t, fstest.MapFS{"001_one.sql": file("", "")}, map[int64]string{1: "one", 7: "newer"})
+
+	if _, err := m.Down(context.Background(), 1); err == nil || !strings.Contains(err.Error(), "no source") {
+		t.Fatalf("Down error = %v, want no source to revert 7 with", err)
+	}
+	if len(d.applied) != 2 {
+		t.Errorf("table = %v, want it unchanged", d.applied)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	fsys := fstest.MapFS{
+		"001_one.sql": file("", ""),
+		"002_two.sql": file("", ""),
+	}
+	m, _ := newTestMigrator(t, fsys, map[int64]string{1: "one", 9: "newer"})
+
+	statuses, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	type row struct {
+		version          int64
+		applied, missing bool
+	}
+	var got []row
+	for _, s := range statuses {
+		got = append(got, row{s.Version, s.AppliedAt != nil, s.Missing})
+	}
+	want := []row{{1, true, false}, {2, false, false}, {9, true, true}}
+	if len(got) != len(want) {
+		t.Fatalf("Status = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Status = %+v, want %+v", got, want)
+		}
+	}
+	if statuses[2].Name != "newer" {
+		t.Errorf("missing migration name = %q, want newer", statuses[2].Name)
+	}
+}
@@ -0,0 +1,146 @@
+package pagination
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Meta describes a page to clients, typically as the meta of the response
+// envelope.
+type Meta struct {
+	// Limit is the page size.
+	Limit int `json:"limit"`
+
+	// Offset is the number of items skipped, for offset pagination.
+	Offset int `json:"offset,omitempty"`
+
+	// Total is the number of items in the list, when counted.
+	Total *int64 `json:"total,omitempty"`
+
+	// HasMore reports whether a page follows this one.
+	HasMore bool `json:"hasMore"`
+
+	// NextCursor and PrevCursor continue keyset pagination after and
+	// before this page.
+	NextCursor string `json:"nextCursor,omitempty"`
+	PrevCursor string `json:"prevCursor,omitempty"`
+
+	// keyset reports whether the page was paginated by cursor.
+	keyset bool
+}
+
+// Page is one page of a list.
+type Page[T any] struct {
+	Items []T
+	Meta  Meta
+}
+
+// SetTotal records the number of items in the list.
+func (p *Page[T]) SetTotal(total int64) {
+	p.Meta.Total = &total
+}
+
+// NewPage builds the page of req from rows, queried with req.FetchLimit.
+// With a nil key, the page is paginated by offset; otherwise key returns
+// the sort key values of an item, from which the page's cursors are made,
+// and rows queried backward (with Keyset.OrderBy) are put back in order.
+func NewPage[T any](req Request, rows []T, key func(T) []string) Page[T] {
+	more := len(rows) > req.Limit
+	if more {
+		rows = rows[:req.Limit]
+	}
+	meta := Meta{Limit: req.Limit, keyset: key != nil}
+
+	if key == nil {
+		meta.Offset, meta.HasMore = req.Offset, more
+		return Page[T]{Items: rows, Meta: meta}
+	}
+
+	backward := req.Cursor != nil && req.Cursor.Backward
+	if backward {
+		slices.Reverse(rows)
+	}
+
+	// Paging backward, the extra row tells whether a previous page exists;
+	// the page the cursor came from always follows.
+	hasNext, hasPrev := more, req.Cursor != nil
+	if backward {
+		hasNext, hasPrev = true, more
+	}
+	if len(rows) > 0 {
+		if hasNext {
+			meta.NextCursor = Cursor{Values: key(rows[len(rows)-1])}.Encode()
+		}
+		if hasPrev {
+			meta.PrevCursor = Cursor{Values: key(rows[0]), Backward: true}.Encode()
+		}
+	}
+	meta.HasMore = meta.NextCursor != ""
+	return Page[T]{Items: rows, Meta: meta}
+}
+
+// Links returns the RFC 8288 Link header value pointing to the pages
+// around m: next and prev, plus first, and last when the total is known,
+// for offset pagination. They keep the other query parameters of u.
+func Links(u *url.URL, m Meta) string {
+	var links []string
+	add := func(rel string, set func(q url.Values)) {
+		q := u.Query()
+		q.Del(ParamOffset)
+		q.Del(ParamCursor)
+		q.Set(ParamLimit, strconv.Itoa(m.Limit))
+		set(q)
+
+		link := *u
+		link.RawQuery = q.Encode()
+		links = append(links, "<"+link.String()+`>; rel="`+rel+`"`)
+	}
+	cursor := func(c string) func(url.Values) {
+		return func(q url.Values) { q.Set(ParamCursor, c) }
+	}
+	offset := func(n int) func(url.Values) {
+		return func(q url.Values) {
+			if n > 0 {
+				q.Set(ParamOffset, strconv.Itoa(n))
+			}
+		}
+	}
+
+	if m.keyset {
+		if m.NextCursor != "" {
+			add("next", cursor(m.NextCursor))
+		}
+		if m.PrevCursor != "" {
+			add("prev", cursor(m.PrevCursor))
+		}
+		return strings.Join(links, ", ")
+	}
+
+	if m.HasMore {
+		add("next", offset(m.Offset+m.Limit))
+	}
+	if m.Offset > 0 {
+		add("prev", offset(max(0, m.Offset-m.Limit)))
+	}
+	add("first", offset(0))
+	if m.Total != nil {
+		last := 0
+		if *m.Total > 0 {
+			last = int((*m.Total-1)/int64(m.Limit)) * m.Limit
+		}
+		add("last", offset(last))
+	}
+	return strings.Join(links, ", ")
+}
+
+// SetLinks sets the Link header of w to the pages around m, relative to
+// the URL of r.
+func SetLinks(w http.ResponseWriter, r *http.Request, m Meta) {
+	u := &url.URL{Path: r.URL.Path, RawQuery: r.URL.RawQuery}
+	if links := Links(u, m); links != "" {
+		w.Header().Set("Link", links)
+	}
+}
@@ -0,0 +1,161 @@
+// Package pagination gives list endpoints one way to page through results,
+// by offset or by opaque keyset cursor:
+//
+//	req, err := pagination.Parse(r, pagination.Options{})
+//	if err != nil {
+//		respond.Error(w, r, err)
+//		return
+//	}
+//
+//	keyset := pagination.Keyset{Columns: []string{"created_at", "id"}, Desc: true}
+//	where, args, err := keyset.Where(req, 1)
+//	if err != nil {
+//		respond.Error(w, r, err)
+//		return
+//	}
+//	limit, limitArgs := keyset.Limit(req, len(args)+1)
+//	rows := query(`SELECT ... FROM orders WHERE `+where+` ORDER BY `+keyset.OrderBy(req)+` `+limit, append(args, limitArgs...)...)
+//
+//	page := pagination.NewPage(req, rows, func(o Order) []string {
+//		return []string{o.CreatedAt.Format(time.RFC3339Nano), o.ID}
+//	})
+//	pagination.SetLinks(w, r, page.Meta)
+//	respond.JSONWithMeta(w, http.StatusOK, page.Items, page.Meta)
+//
+// Queries fetch one row more than the limit, which tells NewPage whether
+// another page follows without counting the rows.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/validation"
+)
+
+// Query parameters read by Parse and written by SetLinks.
+const (
+	ParamLimit  = "limit"
+	ParamOffset = "offset"
+	ParamCursor = "cursor"
+)
+
+// Default limits.
+const (
+	DefaultLimit    = 20
+	DefaultMaxLimit = 100
+)
+
+// Options configures Parse.
+type Options struct {
+	// DefaultLimit is the page size when the request sets none (defaults to
+	// DefaultLimit).
+	DefaultLimit int
+
+	// MaxLimit is the largest page size a request may ask for (defaults to
+	// DefaultMaxLimit).
+	MaxLimit int
+}
+
+// Cursor is a position in a keyset-paginated list: the sort key values of
+// the item it follows, or precedes when Backward.
+type Cursor struct {
+	Values   []string `json:"v"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+// Encode returns the cursor as an opaque, URL-safe string. Cursors are not
+// signed: their values reach queries only as bound parameters.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor returned by Encode.
+func DecodeCursor(s string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("pagination: decode cursor: %w", err)
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Cursor{}, fmt.Errorf("pagination: decode cursor: %w", err)
+	}
+	if len(c.Values) == 0 {
+		return Cursor{}, errors.New("pagination: decode cursor: no values")
+	}
+	return c, nil
+}
+
+// Request is the page a client asked for.
+type Request struct {
+	// Limit is the page size.
+	Limit int
+
+	// Offset is the number of items skipped, for offset pagination.
+	Offset int
+
+	// Cursor is the position to continue from, for keyset pagination; nil
+	// asks for the first page.
+	Cursor *Cursor
+}
+
+// FetchLimit returns the number of rows to query: one more than the limit,
+// so NewPage can tell whether another page follows.
+func (p Request) FetchLimit() int {
+	return p.Limit + 1
+}
+
+// Parse reads the limit, offset, and cursor query parameters of r. Invalid
+// values, or an offset combined with a cursor, are returned as
+// *validation.RequestError with field-level errors.
+func Parse(r *http.Request, opts Options) (Request, error) {
+	if opts.DefaultLimit <= 0 {
+		opts.DefaultLimit = DefaultLimit
+	}
+	if opts.MaxLimit <= 0 {
+		opts.MaxLimit = DefaultMaxLimit
+	}
+
+	q := r.URL.Query()
+	req := Request{Limit: min(opts.DefaultLimit, opts.MaxLimit)}
+	var errs validation.FieldErrors
+
+	if v := q.Get(ParamLimit); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > opts.MaxLimit {
+			errs = append(errs, validation.FieldError{Field: ParamLimit, Err: fmt.Sprintf("must be an integer between 1 and %d", opts.MaxLimit)})
+		} else {
+			req.Limit = limit
+		}
+	}
+
+	if v := q.Get(ParamOffset); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			errs = append(errs, validation.FieldError{Field: ParamOffset, Err: "must be a non-negative integer"})
+		} else {
+			req.Offset = offset
+		}
+	}
+
+	if v := q.Get(ParamCursor); v != "" {
+		switch c, err := DecodeCursor(v); {
+		case err != nil:
+			errs = append(errs, validation.FieldError{Field: ParamCursor, Err: "is invalid"})
+		case q.Has(ParamOffset):
+			errs = append(errs, validation.FieldError{Field: ParamCursor, Err: "cannot be combined with offset"})
+		default:
+			req.Cursor = &c
+		}
+	}
+
+	if len(errs) > 0 {
+		return Request{}, &validation.RequestError{Status: http.StatusBadRequest, Message: "request is invalid", Fields: errs}
+	}
+	return req, nil
+}
@@ -0,0 +1,75 @@
+package pagination
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/validation"
+)
+
+// LimitOffset returns the LIMIT and OFFSET clause of an offset-paginated
+// query and its arguments, numbered from the placeholder $argN.
+func LimitOffset(req Request, argN int) (string, []any) {
+	return "LIMIT $" + strconv.Itoa(argN) + " OFFSET $" + strconv.Itoa(argN+1), []any{req.FetchLimit(), req.Offset}
+}
+
+// Keyset describes the sort order of a keyset-paginated query. Columns
+// must identify a row together, usually by ending with the primary key,
+// and are trusted: they are written into the query as they are.
+type Keyset struct {
+	Columns []string
+	Desc    bool
+}
+
+// Where returns the condition selecting the rows after the request's
+// cursor, or before it when paging backward, and its arguments, numbered
+// from the placeholder $argN. Without a cursor it is "TRUE". A cursor
+// with a value count other than the number of columns, issued for another
+// sort order or forged, is returned as *validation.RequestError.
+func (k Keyset) Where(req Request, argN int) (string, []any, error) {
+	if req.Cursor == nil {
+		return "TRUE", nil, nil
+	}
+	if len(req.Cursor.Values) != len(k.Columns) {
+		return "", nil, &validation.RequestError{
+			Status:  http.StatusBadRequest,
+			Message: "request is invalid",
+			Fields:  validation.FieldErrors{{Field: ParamCursor, Err: "does not match the sort order"}},
+		}
+	}
+
+	placeholders := make([]string, len(k.Columns))
+	args := make([]any, len(k.Columns))
+	for i, v := range req.Cursor.Values {
+		placeholders[i] = "$" + strconv.Itoa(argN+i)
+		args[i] = v
+	}
+
+	op := ">"
+	if k.Desc != req.Cursor.Backward {
+		op = "<"
+	}
+	return "(" + strings.Join(k.Columns, ", ") + ") " + op + " (" + strings.Join(placeholders, ", ") + ")", args, nil
+}
+
+// OrderBy returns the ORDER BY list of the query, reversed when paging
+// backward so the rows nearest the cursor come first; NewPage restores
+// their order.
+func (k Keyset) OrderBy(req Request) string {
+	dir := "ASC"
+	if k.Desc != (req.Cursor != nil && req.Cursor.Backward) {
+		dir = "DESC"
+	}
+	terms := make([]string, len(k.Columns))
+	for i, col := range k.Columns {
+		terms[i] = col + " " + dir
+	}
+	return strings.Join(terms, ", ")
+}
+
+// Limit returns the LIMIT clause of the query and its argument, numbered
+// as the placeholder $argN.
+func (k Keyset) Limit(req Request, argN int) (string, []any) {
+	return "LIMIT $" + strconv.Itoa(argN), []any{req.FetchLimit()}
+}
@@ -0,0 +1,40 @@
+package pagination
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/validation"
+)
+
+func TestKeysetWhere(t *testing.T) {
+	keyset := Keyset{Columns: []string{"created_at", "id"}, Desc: true}
+	tests := []struct {
+		name     string
+		cursor   *Cursor
+		want     string
+		wantArgs []any
+		wantErr  bool
+	}{
+		{name: "first page", want: "TRUE"},
+		{name: "forward", cursor: &Cursor{Values: []string{"2024-01-01", "42"}}, want: "(created_at, id) < ($3, $4)", wantArgs: []any{"2024-01-01", "42"}},
+		{name: "backward", cursor: &Cursor{Values: []string{"2024-01-01", "42"}, Backward: true}, want: "(created_at, id) > ($3, $4)", wantArgs: []any{"2024-01-01", "42"}},
+		{name: "too few values", cursor: &Cursor{Values: []string{"42"}}, wantErr: true},
+		{name: "too many values", cursor: &Cursor{Values: []string{"2024-01-01", "42", "x"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args, err := keyset.Where(Request{Limit: 10, Cursor: tt.cursor}, 3)
+			if tt.wantErr {
+				if re := validation.AsRequestError(err); re == nil || re.Status != http.StatusBadRequest || re.Fields[0].Field != ParamCursor {
+					t.Fatalf("Where error = %v, want a 400 on the cursor", err)
+				}
+				return
+			}
+			if err != nil || where != tt.want || !slices.Equal(args, tt.wantArgs) {
+				t.Fatalf("Where = %q, %v, %v; want %q, %v", where, args, err, tt.want, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package probabilistic
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+)
+
+// Bloom is a Bloom filter. Items cannot be removed; use a Cuckoo filter
+// when they must be.
+type Bloom struct {
+	bits []uint64
+	m    uint64 // number of bits
+	k    uint32 // number of hash functions
+}
+
+// NewBloom returns a filter sized to hold n items with a false positive
+// rate of about p (e.g., 0.01).
+func NewBloom(n uint64, p float64) *Bloom {
+	if n == 0 {
+		n = 1
+	}
+	if p <= 0 || p >= 1 {
+		p = 0.01
+	}
+
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	m = (m + 63) &^ 63
+	k := uint32(math.Max(1, math.Round(float64(m)/float64(n)*math.Ln2)))
+
+	return &Bloom{bits: make([]uint64, m/64), m: m, k: k}
+}
+
+// Add inserts item.
+func (b *Bloom) Add(item []byte) {
+	h1, h2 := split(item)
+	for i := range uint64(b.k) {
+		pos := (h1 + i*h2) % b.m
+		b.bits[pos/64] |= 1 << (pos % 64)
+	}
+}
+
+// Has reports whether item may have been added. False means it certainly
+// was not.
+func (b *Bloom) Has(item []byte) bool {
+	h1, h2 := split(item)
+	for i := range uint64(b.k) {
+		pos := (h1 + i*h2) % b.m
+		if b.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Merge adds every item of other, which must have been created with the
+// same parameters.
+func (b *Bloom) Merge(other *Bloom) error {
+	if b.m != other.m || b.k != other.k {
+		return ErrIncompatible
+	}
+	for i := range b.bits {
+		b.bits[i] |= other.bits[i]
+	}
+	return nil
+}
+
+// FillRatio returns the fraction of bits set; the false positive rate
+// climbs quickly once it passes one half.
+func (b *Bloom) FillRatio() float64 {
+	var set int
+	for _, w := range b.bits {
+		set += bits.OnesCount64(w)
+	}
+	return float64(set) / float64(b.m)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (b *Bloom) MarshalBinary() ([]byte, error) {
+	out := make([]byte, 13, 13+len(b.bits)*8)
+	out[0] = formatBloom
+	binary.BigEndian.PutUint64(out[1:], b.m)
+	binary.BigEndian.PutUint32(out[9:], b.k)
+	for _, w := range b.bits {
+		out = binary.BigEndian.AppendUint64(out, w)
+	}
+	return out, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (b *Bloom) UnmarshalBinary(data []byte) error {
+	if len(data) < 13 || data[0] != formatBloom {
+		return ErrFormat
+	}
+	m := binary.BigEndian.Uint64(data[1:])
+	k := binary.BigEndian.Uint32(data[9:])
+	if m == 0 || m%64 != 0 || k == 0 || uint64(len(data)-13) != m/8 {
+		return ErrFormat
+	}
+
+	b.m, b.k = m, k
+	b.bits = make([]uint64, m/64)
+	for i := range b.bits {
+		b.bits[i] = binary.BigEndian.Uint64(data[13+i*8:])
+	}
+	return nil
+}
+
+// split derives the two hashes combined by double hashing
+// (Kirsch-Mitzenmacher) to simulate k independent hash functions.
+func split(item []byte) (uint64, uint64) {
+	h := hash(item)
+	return h, mix(h^0x9e3779b97f4a7c15) | 1
+}
@@ -0,0 +1,214 @@
+package probabilistic
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	// bucketSize is the number of fingerprints per cuckoo bucket.
+	bucketSize = 4
+
+	// maxKicks bounds relocations before an insert gives up.
+	maxKicks = 500
+
+	// cuckooHeader is the size of the encoding's header: format, bucket
+	// count, item count, victim, and victim bucket.
+	cuckooHeader = 1 + 8 + 8 + 2 + 8
+)
+
+// Cuckoo is a cuckoo filter with 16-bit fingerprints, giving a false
+// positive rate of about 0.01%. Unlike a Bloom filter it supports Delete,
+// but inserts fail once it is about 95% full.
+type Cuckoo struct {
+	buckets [][bucketSize]uint16
+	mask    uint64
+	count   uint64
+	rng     uint64
+
+	// victim holds the fingerprint (and one of its buckets) left over
+	// when relocation failed; the filter is full while it is set.
+	victim      uint16
+	victimIndex uint64
+}
+
+// NewCuckoo returns a filter able to hold about capacity items.
+func NewCuckoo(capacity uint64) *Cuckoo {
+	n := max(capacity/bucketSize*100/95, 1)
+	n = 1 << bits.Len64(n-1) // round up to a power of two
+	return &Cuckoo{buckets: make([][bucketSize]uint16, n), mask: n - 1, rng: 0x9e3779b97f4a7c15}
+}
+
+// Insert adds item, reporting false when the filter is full. Adding
+// the same item twice stores it twice, so it must be deleted twice.
+func (c *Cuckoo) Insert(item []byte) bool {
+	if c.victim != 0 {
+		return false
+	}
+
+	fp, i1, i2 := c.locate(item)
+	if c.put(i1, fp) || c.put(i2, fp) {
+		c.count++
+		return true
+	}
+
+	// Evict fingerprints to their alternate bucket until one fits. When
+	// none does, the last one evicted is kept aside as the victim, so no
+	// inserted item is lost.
+	i := i1
+	if c.random()&1 == 1 {
+		i = i2
+	}
+	for range maxKicks {
+		slot := c.random() % bucketSize
+		fp, c.buckets[i][slot] = c.buckets[i][slot], fp
+		i = c.alt(i, fp)
+		if c.put(i, fp) {
+			c.count++
+			return true
+		}
+	}
+
+	c.victim, c.victimIndex = fp, i
+	c.count++
+	return true
+}
+
+// Has reports whether item may have been inserted. False means it
+// certainly was not.
+func (c *Cuckoo) Has(item []byte) bool {
+	fp, i1, i2 := c.locate(item)
+	if c.victim == fp && (c.victimIndex == i1 || c.victimIndex == i2) {
+		return true
+	}
+	return c.find(i1, fp) >= 0 || c.find(i2, fp) >= 0
+}
+
+// Delete removes one copy of item, reporting whether one was found. Only
+// delete items that were inserted, or another item sharing the
+// fingerprint may be removed instead.
+func (c *Cuckoo) Delete(item []byte) bool {
+	fp, i1, i2 := c.locate(item)
+	if c.victim == fp && (c.victimIndex == i1 || c.victimIndex == i2) {
+		c.victim = 0
+		c.count--
+		return true
+	}
+
+	for _, i := range [2]uint64{i1, i2} {
+		if slot := c.find(i, fp); slot >= 0 {
+			c.buckets[i][slot] = 0
+			c.count--
+			c.rehouseVictim()
+			return true
+		}
+	}
+	return false
+}
+
+// rehouseVictim moves the victim back into a bucket once there is room.
+func (c *Cuckoo) rehouseVictim() {
+	if c.victim == 0 {
+		return
+	}
+	if c.put(c.victimIndex, c.victim) || c.put(c.alt(c.victimIndex, c.victim), c.victim) {
+		c.victim = 0
+	}
+}
+
+// Len returns the number of items held.
+func (c *Cuckoo) Len() uint64 {
+	return c.count
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (c *Cuckoo) MarshalBinary() ([]byte, error) {
+	out := make([]byte, cuckooHeader, cuckooHeader+len(c.buckets)*bucketSize*2)
+	out[0] = formatCuckoo
+	binary.BigEndian.PutUint64(out[1:], uint64(len(c.buckets)))
+	binary.BigEndian.PutUint64(out[9:], c.count)
+	binary.BigEndian.PutUint16(out[17:], c.victim)
+	binary.BigEndian.PutUint64(out[19:], c.victimIndex)
+	for _, b := range c.buckets {
+		for _, fp := range b {
+			out = binary.BigEndian.AppendUint16(out, fp)
+		}
+	}
+	return out, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (c *Cuckoo) UnmarshalBinary(data []byte) error {
+	if len(data) < cuckooHeader || data[0] != formatCuckoo {
+		return ErrFormat
+	}
+	n := binary.BigEndian.Uint64(data[1:])
+	count := binary.BigEndian.Uint64(data[9:])
+	victim := binary.BigEndian.Uint16(data[17:])
+	victimIndex := binary.BigEndian.Uint64(data[19:])
+	if n == 0 || n&(n-1) != 0 || uint64(len(data)-cuckooHeader) != n*bucketSize*2 ||
+		count > n*bucketSize+1 || victimIndex >= n {
+		return ErrFormat
+	}
+
+	c.buckets = make([][bucketSize]uint16, n)
+	c.mask, c.count = n-1, count
+	c.victim, c.victimIndex = victim, victimIndex
+	if c.rng == 0 {
+		c.rng = 0x9e3779b97f4a7c15
+	}
+	off := cuckooHeader
+	for i := range c.buckets {
+		for j := range bucketSize {
+			c.buckets[i][j] = binary.BigEndian.Uint16(data[off:])
+			off += 2
+		}
+	}
+	return nil
+}
+
+// locate returns the fingerprint of item and its two candidate buckets.
+// Fingerprints are never 0, which marks empty slots.
+func (c *Cuckoo) locate(item []byte) (uint16, uint64, uint64) {
+	h := hash(item)
+	fp := uint16(h >> 48)
+	if fp == 0 {
+		fp = 1
+	}
+	i1 := h & c.mask
+	return fp, i1, c.alt(i1, fp)
+}
+
+// alt returns the other candidate bucket of a fingerprint in bucket i.
+func (c *Cuckoo) alt(i uint64, fp uint16) uint64 {
+	return (i ^ mix(uint64(fp))) & c.mask
+}
+
+// put stores fp in a free slot of bucket i, if any.
+func (c *Cuckoo) put(i uint64, fp uint16) bool {
+	for j, v := range c.buckets[i] {
+		if v == 0 {
+			c.buckets[i][j] = fp
+			return true
+		}
+	}
+	return false
+}
+
+// find returns the slot of bucket i holding fp, or -1.
+func (c *Cuckoo) find(i uint64, fp uint16) int {
+	for j, v := range c.buckets[i] {
+		if v == fp {
+			return j
+		}
+	}
+	return -1
+}
+
+// random returns the next xorshift value, used to pick victims.
+func (c *Cuckoo) random() uint64 {
+	c.rng ^= c.rng << 13
+	c.rng ^= c.rng >> 7
+	c.rng ^= c.rng << 17
+	return c.rng
+}
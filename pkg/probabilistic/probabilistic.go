@@ -0,0 +1,47 @@
+// Package probabilistic provides compact, approximate data structures for
+// large keyspaces: Bloom and cuckoo filters answer "have I seen this?"
+// with no false negatives and a tunable false positive rate, and
+// HyperLogLog estimates how many distinct items were seen.
+//
+// They trade exactness for memory: a Bloom filter holding a million keys at
+// a 1% false positive rate takes about 1.2 MB, and a HyperLogLog counts
+// billions of distinct items in 16 KB within 1%. Each implements
+// encoding.BinaryMarshaler so it can be stored in a cache or database and
+// shared between instances. None is safe for concurrent use.
+package probabilistic
+
+import (
+	"errors"
+	"hash/fnv"
+)
+
+// Errors returned by the package.
+var (
+	ErrFormat       = errors.New("probabilistic: invalid encoding")
+	ErrIncompatible = errors.New("probabilistic: structures have different parameters")
+)
+
+// Encoding format identifiers, the first byte of every encoding.
+const (
+	formatBloom byte = iota + 1
+	formatCuckoo
+	formatHLL
+)
+
+// hash returns a 64-bit hash of item. It is stable across processes and
+// releases, so encoded structures remain valid.
+func hash(item []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(item)
+	return mix(h.Sum64())
+}
+
+// mix is the SplitMix64 finalizer, which spreads FNV's weak low bits.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,254 @@
+package probabilistic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// key returns the i-th test item of a set.
+func key(set string, i int) []byte {
+	return fmt.Appendf(nil, "%s-%d", set, i)
+}
+
+// falsePositiveRate returns the fraction of n items never added that has
+// reports as present.
+func falsePositiveRate(n int, has func([]byte) bool) float64 {
+	var hits int
+	for i := range n {
+		if has(key("absent", i)) {
+			hits++
+		}
+	}
+	return float64(hits) / float64(n)
+}
+
+func TestBloomFalsePositiveRate(t *testing.T) {
+	for _, p := range []float64{0.01, 0.001} {
+		const n = 20_000
+		b := NewBloom(n, p)
+		for i := range n {
+			b.Add(key("present", i))
+		}
+		for i := range n {
+			if !b.Has(key("present", i)) {
+				t.Fatalf("p=%v: false negative for item %d", p, i)
+			}
+		}
+
+		// At capacity, the rate stays near the target: allow for the
+		// rounding of k and the sampling noise of 200k lookups.
+		if rate := falsePositiveRate(200_000, b.Has); rate > 1.5*p {
+			t.Errorf("p=%v: false positive rate %.5f, want at most %.5f", p, rate, 1.5*p)
+		}
+		if fill := b.FillRatio(); fill < 0.4 || fill > 0.6 {
+			t.Errorf("p=%v: fill ratio at capacity %.2f, want about one half", p, fill)
+		}
+	}
+}
+
+func TestBloomMergeAndEncoding(t *testing.T) {
+	a, b := NewBloom(1000, 0.01), NewBloom(1000, 0.01)
+	a.Add([]byte("a"))
+	b.Add([]byte("b"))
+	if err := a.Merge(b); err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	if !a.Has([]byte("a")) || !a.Has([]byte("b")) {
+		t.Fatal("merged filter lost an item")
+	}
+	if err := a.Merge(NewBloom(10, 0.01)); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("Merge of a different size = %v, want ErrIncompatible", err)
+	}
+
+	data, _ := a.MarshalBinary()
+	var decoded Bloom
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary: %v", err)
+	}
+	if !decoded.Has([]byte("a")) || !decoded.Has([]byte("b")) || decoded.FillRatio() != a.FillRatio() {
+		t.Fatal("decoded filter differs")
+	}
+	if err := decoded.UnmarshalBinary(data[:len(data)-1]); !errors.Is(err, ErrFormat) {
+		t.Errorf("UnmarshalBinary of a truncated encoding = %v, want ErrFormat", err)
+	}
+}
+
+func TestHyperLogLogErrorBound(t *testing.T) {
+	for _, precision := range []uint8{10, 14} {
+		h, err := NewHyperLogLog(precision)
+		if err != nil {
+			t.Fatalf("NewHyperLogLog: %v", err)
+		}
+		stdErr := 1.04 / math.Sqrt(float64(uint64(1)<<precision))
+
+		added := 0
+		for _, n := range []int{100, 1_000, 10_000, 100_000, 500_000} {
+			for ; added < n; added++ {
+				h.Add(key("distinct", added))
+			}
+			// Three standard errors hold for all but 0.3% of estimates.
+			got := float64(h.Count())
+			if relErr := math.Abs(got-float64(n)) / float64(n); relErr > 3*stdErr {
+				t.Errorf("p=%d: Count after %d items = %.0f, error %.4f over the bound %.4f", precision, n, got, relErr, 3*stdErr)
+			}
+		}
+	}
+}
+
+func TestHyperLogLogSmallAndDuplicate(t *testing.T) {
+	h, _ := NewHyperLogLog(14)
+	if got := h.Count(); got != 0 {
+		t.Fatalf("Count of an empty estimator = %d, want 0", got)
+	}
+	for range 3 {
+		for i := range 10 {
+			h.Add(key("small", i))
+		}
+	}
+	if got := h.Count(); got != 10 {
+		t.Errorf("Count of 10 items added thrice = %d, want 10", got)
+	}
+}
+
+func TestHyperLogLogMergeAndEncoding(t *testing.T) {
+	a, _ := NewHyperLogLog(12)
+	b, _ := NewHyperLogLog(12)
+	for i := range 20_000 {
+		a.Add(key("a", i))
+		b.Add(key("b", i))
+		b.Add(key("a", i)) // overlaps with a
+	}
+	if err := a.Merge(b); err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	if got := float64(a.Count()); math.Abs(got-40_000)/40_000 > 3*1.04/64 {
+		t.Errorf("Count of the union = %.0f, want about 40000", got)
+	}
+	other, _ := NewHyperLogLog(13)
+	if err := a.Merge(other); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("Merge of a different precision = %v, want ErrIncompatible", err)
+	}
+
+	data, _ := a.MarshalBinary()
+	var decoded HyperLogLog
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary: %v", err)
+	}
+	if decoded.Count() != a.Count() {
+		t.Errorf("decoded Count = %d, want %d", decoded.Count(), a.Count())
+	}
+	for _, p := range []uint8{3, 19} {
+		if _, err := NewHyperLogLog(p); err == nil {
+			t.Errorf("NewHyperLogLog(%d) succeeded, want an error", p)
+		}
+	}
+}
+
+func TestCuckooInsertUntilFull(t *testing.T) {
+	c := NewCuckoo(1000)
+	slots := len(c.buckets) * bucketSize
+
+	inserted := 0
+	for c.Insert(key("present", inserted)) {
+		inserted++
+		if inserted > slots+1 {
+			t.Fatal("Insert never reported the filter full")
+		}
+	}
+	if load := float64(inserted) / float64(slots); load < 0.9 {
+		t.Errorf("filter full at %.0f%% load, want at least 90%%", 100*load)
+	}
+	if c.Len() != uint64(inserted) {
+		t.Errorf("Len = %d, want %d", c.Len(), inserted)
+	}
+	for i := range inserted {
+		if !c.Has(key("present", i)) {
+			t.Fatalf("false negative for item %d of a full filter", i)
+		}
+	}
+
+	// 16-bit fingerprints in two buckets of four: about 8/65536.
+	if rate := falsePositiveRate(200_000, c.Has); rate > 0.0005 {
+		t.Errorf("false positive rate %.5f of a full filter, want at most 0.0005", rate)
+	}
+
+	// Deletes make room: once one frees a slot of the victim's buckets,
+	// the victim moves in and inserts succeed again.
+	deleted := 0
+	for !c.Insert(key("extra", 0)) {
+		if deleted == inserted {
+			t.Fatalf("filter still full after %d deletes", deleted)
+		}
+		if !c.Delete(key("present", deleted)) {
+			t.Fatalf("Delete of item %d reported it missing", deleted)
+		}
+		deleted++
+	}
+	if c.Len() != uint64(inserted-deleted+1) {
+		t.Errorf("Len = %d, want %d", c.Len(), inserted-deleted+1)
+	}
+	for i := deleted; i < inserted; i++ {
+		if !c.Has(key("present", i)) {
+			t.Fatalf("false negative for item %d after deletes", i)
+		}
+	}
+}
+
+func TestCuckooDelete(t *testing.T) {
+	c := NewCuckoo(1000)
+	for i := range 800 {
+		if !c.Insert(key("present", i)) {
+			t.Fatalf("Insert %d failed below capacity", i)
+		}
+	}
+	if !c.Insert(key("present", 0)) {
+		t.Fatal("Insert of a duplicate failed")
+	}
+
+	for i := range 800 {
+		if !c.Delete(key("present", i)) {
+			t.Fatalf("Delete of item %d reported it missing", i)
+		}
+	}
+	if !c.Has(key("present", 0)) {
+		t.Error("the second copy of a duplicate was deleted too")
+	}
+	if !c.Delete(key("present", 0)) || c.Len() != 0 {
+		t.Fatalf("Len after deleting everything = %d, want 0", c.Len())
+	}
+	for i := range 800 {
+		if c.Has(key("present", i)) {
+			t.Fatalf("empty filter has item %d", i)
+		}
+	}
+	if c.Delete(key("present", 0)) {
+		t.Error("Delete from an empty filter reported success")
+	}
+}
+
+func TestCuckooEncoding(t *testing.T) {
+	c := NewCuckoo(64)
+	n := 0
+	for c.Insert(key("present", n)) {
+		n++
+	}
+
+	data, _ := c.MarshalBinary()
+	var decoded Cuckoo
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary: %v", err)
+	}
+	if decoded.Len() != c.Len() || decoded.victim != c.victim {
+		t.Fatalf("decoded Len %d and victim %d, want %d and %d", decoded.Len(), decoded.victim, c.Len(), c.victim)
+	}
+	for i := range n {
+		if !decoded.Has(key("present", i)) {
+			t.Fatalf("decoded filter lost item %d", i)
+		}
+	}
+	if err := decoded.UnmarshalBinary(data[:cuckooHeader]); !errors.Is(err, ErrFormat) {
+		t.Errorf("UnmarshalBinary of a truncated encoding = %v, want ErrFormat", err)
+	}
+}
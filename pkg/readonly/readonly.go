@@ -0,0 +1,125 @@
+// Package readonly provides a runtime read-only switch for database
+// maintenance windows and region failovers. While it is on, mutating
+// requests are rejected and background workers pause their writes.
+package readonly
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// State describes the read-only switch.
+type State struct {
+	Enabled bool      `json:"enabled"`
+	Reason  string    `json:"reason,omitempty"`
+	Since   time.Time `json:"since,omitzero"`
+}
+
+// Switch is the read-only switch. It is safe for concurrent use.
+type Switch struct {
+	mu       sync.Mutex
+	state    State
+	writable chan struct{}
+}
+
+// New constructs a Switch in read-write mode.
+func New() *Switch {
+	writable := make(chan struct{})
+	close(writable)
+	return &Switch{writable: writable}
+}
+
+// Enable turns read-only mode on, recording why.
+func (s *Switch) Enable(reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state.Enabled {
+		s.state.Reason = reason
+		return
+	}
+	s.state = State{Enabled: true, Reason: reason, Since: time.Now().UTC()}
+	s.writable = make(chan struct{})
+}
+
+// Disable turns read-only mode off, resuming paused writers.
+func (s *Switch) Disable() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.state.Enabled {
+		return
+	}
+	s.state = State{}
+	close(s.writable)
+}
+
+// State returns the current state of the switch.
+func (s *Switch) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// Enabled reports whether read-only mode is on.
+func (s *Switch) Enabled() bool {
+	return s.State().Enabled
+}
+
+// WaitWritable blocks workers until read-only mode is off or ctx is done.
+func (s *Switch) WaitWritable(ctx context.Context) error {
+	s.mu.Lock()
+	writable := s.writable
+	s.mu.Unlock()
+
+	select {
+	case <-writable:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// FollowConfig applies Server.ReadOnly changes from configuration reloads.
+// Reloads leaving the setting unchanged keep the state set at runtime.
+func (s *Switch) FollowConfig(r *config.Reloader) {
+	r.Subscribe(func(prev, next *config.Config, _ config.Snapshot) {
+		if next.Server == nil || prev.Server != nil && prev.Server.ReadOnly == next.Server.ReadOnly {
+			return
+		}
+		if next.Server.ReadOnly {
+			s.Enable("config")
+		} else {
+			s.Disable()
+		}
+	})
+}
+
+// Middleware rejects mutating requests (POST, PUT, PATCH, DELETE) with 503
+// Service Unavailable while read-only mode is on. Safe methods pass through.
+func Middleware(s *Switch) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !s.Enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "service is in read-only mode"})
+		})
+	}
+}
@@ -0,0 +1,188 @@
+// Package refdata keeps static or slow-changing datasets (currencies,
+// country codes, plan catalogs) in memory. Datasets are loaded from a
+// database, file, or URL, refreshed periodically, and swapped atomically so
+// readers never observe a partially loaded dataset.
+package refdata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Source loads the current version of a dataset.
+type Source[T any] interface {
+	Load(ctx context.Context) (T, error)
+}
+
+// SourceFunc adapts a function, such as a database query, to Source.
+type SourceFunc[T any] func(ctx context.Context) (T, error)
+
+// Load calls f.
+func (f SourceFunc[T]) Load(ctx context.Context) (T, error) {
+	return f(ctx)
+}
+
+// File returns a Source decoding the JSON file at path.
+func File[T any](path string) Source[T] {
+	return SourceFunc[T](func(context.Context) (T, error) {
+		var v T
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return v, fmt.Errorf("refdata: read %s: %w", path, err)
+		}
+		if err := json.Unmarshal(data, &v); err != nil {
+			return v, fmt.Errorf("refdata: decode %s: %w", path, err)
+		}
+		return v, nil
+	})
+}
+
+// URL returns a Source decoding the JSON document served at url, fetched
+// with client (http.DefaultClient when nil).
+func URL[T any](client *http.Client, url string) Source[T] {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return SourceFunc[T](func(ctx context.Context) (T, error) {
+		var v T
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return v, fmt.Errorf("refdata: %w", err)
+		}
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return v, fmt.Errorf("refdata: fetch %s: %w", url, err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return v, fmt.Errorf("refdata: fetch %s: unexpected status %d", url, resp.StatusCode)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+			return v, fmt.Errorf("refdata: decode %s: %w", url, err)
+		}
+		return v, nil
+	})
+}
+
+// Stats describes the state of a dataset.
+type Stats struct {
+	Name       string    `json:"name"`
+	Generation uint64    `json:"generation"`
+	LoadedAt   time.Time `json:"loadedAt"`
+	Failures   uint64    `json:"failures"`
+	LastError  string    `json:"lastError,omitempty"`
+}
+
+// snapshot is one loaded version of a dataset.
+type snapshot[T any] struct {
+	value      T
+	generation uint64
+	loadedAt   time.Time
+}
+
+// Dataset is an in-memory dataset of type T. It is safe for concurrent use.
+type Dataset[T any] struct {
+	name     string
+	source   Source[T]
+	current  atomic.Pointer[snapshot[T]]
+	failures atomic.Uint64
+
+	mu      sync.Mutex
+	lastErr error
+}
+
+// New constructs a Dataset loaded from source. It holds the zero value of
+// T until the first successful Refresh.
+func New[T any](name string, source Source[T]) *Dataset[T] {
+	return &Dataset[T]{name: name, source: source}
+}
+
+// Get returns the current version of the dataset.
+func (d *Dataset[T]) Get() T {
+	if s := d.current.Load(); s != nil {
+		return s.value
+	}
+	var zero T
+	return zero
+}
+
+// Loaded reports whether the dataset has been loaded at least once.
+func (d *Dataset[T]) Loaded() bool {
+	return d.current.Load() != nil
+}
+
+// Refresh loads the dataset from its source and swaps it in. On error the
+// previous version is kept. Its signature matches cachewarm.Loader so the
+// initial load can be part of the startup warmup.
+func (d *Dataset[T]) Refresh(ctx context.Context) error {
+	value, err := d.source.Load(ctx)
+
+	d.mu.Lock()
+	d.lastErr = err
+	d.mu.Unlock()
+
+	if err != nil {
+		d.failures.Add(1)
+		return err
+	}
+
+	var generation uint64 = 1
+	if prev := d.current.Load(); prev != nil {
+		generation = prev.generation + 1
+	}
+	d.current.Store(&snapshot[T]{value: value, generation: generation, loadedAt: time.Now().UTC()})
+	return nil
+}
+
+// Run refreshes the dataset every interval, reporting failures to onError,
+// until ctx is cancelled.
+func (d *Dataset[T]) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.Refresh(ctx); err != nil && onError != nil {
+				onError(fmt.Errorf("refdata: refresh %s: %w", d.name, err))
+			}
+		}
+	}
+}
+
+// Stats returns the dataset's generation and refresh metrics.
+func (d *Dataset[T]) Stats() Stats {
+	stats := Stats{Name: d.name, Failures: d.failures.Load()}
+	if s := d.current.Load(); s != nil {
+		stats.Generation = s.generation
+		stats.LoadedAt = s.loadedAt
+	}
+
+	d.mu.Lock()
+	if d.lastErr != nil {
+		stats.LastError = d.lastErr.Error()
+	}
+	d.mu.Unlock()
+
+	return stats
+}
+
+// Lookup returns the entry for key in a map dataset.
+func Lookup[K comparable, V any](d *Dataset[map[K]V], key K) (V, bool) {
+	v, ok := d.Get()[key]
+	return v, ok
+}
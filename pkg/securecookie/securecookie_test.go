@@ -0,0 +1,107 @@
+package securecookie
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type session struct {
+	UserID string `json:"userId"`
+}
+
+var (
+	oldKey = bytes.Repeat([]byte("o"), 32)
+	newKey = bytes.Repeat([]byte("n"), 32)
+)
+
+func mustNew[T any](t *testing.T, name string, maxAge time.Duration, keys ...[]byte) *Codec[T] {
+	t.Helper()
+	c, err := New[T](name, maxAge, keys...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+func TestNewRejectsKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    [][]byte
+		wantErr error
+	}{
+		{name: "none", wantErr: ErrNoKeys},
+		{name: "wrong size", keys: [][]byte{[]byte("short")}, wantErr: ErrInvalidKey},
+		{name: "one bad among good", keys: [][]byte{newKey, make([]byte, 20)}, wantErr: ErrInvalidKey},
+	}
+	for _, tt := range tests {
+		if _, err := New[session]("session", 0, tt.keys...); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: New = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	issuer := mustNew[session](t, "session", time.Hour, oldKey)
+	issuer.now = func() time.Time { return now }
+	value, err := issuer.Encode(session{UserID: "u1"})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	tampered := []byte(value)
+	tampered[len(tampered)-1] ^= 1
+
+	tests := []struct {
+		name    string
+		codec   *Codec[session]
+		value   string
+		age     time.Duration
+		wantErr error
+	}{
+		{name: "same key", codec: issuer, value: value},
+		{name: "rotated key", codec: mustNew[session](t, "session", time.Hour, newKey, oldKey), value: value},
+		{name: "retired key", codec: mustNew[session](t, "session", time.Hour, newKey), value: value, wantErr: ErrInvalid},
+		{name: "other cookie name", codec: mustNew[session](t, "csrf", time.Hour, oldKey), value: value, wantErr: ErrInvalid},
+		{name: "tampered", codec: issuer, value: string(tampered), wantErr: ErrInvalid},
+		{name: "not base64", codec: issuer, value: "!!", wantErr: ErrInvalid},
+		{name: "too short", codec: issuer, value: "AAAA", wantErr: ErrInvalid},
+		{name: "expired", codec: issuer, value: value, age: time.Hour + time.Second, wantErr: ErrExpired},
+		{name: "no max age", codec: mustNew[session](t, "session", 0, oldKey), value: value, age: 365 * 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.codec.now = func() time.Time { return now.Add(tt.age) }
+			got, err := tt.codec.Decode(tt.value)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Decode = %+v, %v; want %v", got, err, tt.wantErr)
+			}
+			if tt.wantErr == nil && got.UserID != "u1" {
+				t.Errorf("Decode = %+v, want u1", got)
+			}
+		})
+	}
+}
+
+func TestCookie(t *testing.T) {
+	c := mustNew[session](t, "session", time.Hour, newKey)
+	cookie, err := c.Cookie(session{UserID: "u1"})
+	if err != nil {
+		t.Fatalf("Cookie: %v", err)
+	}
+	if cookie.Name != "session" || cookie.MaxAge != 3600 || !cookie.HttpOnly || !cookie.Secure || cookie.SameSite != http.SameSiteLaxMode || cookie.Path != "/" {
+		t.Errorf("cookie = %+v, want the secure defaults", cookie)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(cookie)
+	if got, err := c.Read(r); err != nil || got.UserID != "u1" {
+		t.Fatalf("Read = %+v, %v; want u1", got, err)
+	}
+	if _, err := c.Read(httptest.NewRequest(http.MethodGet, "/", nil)); !errors.Is(err, http.ErrNoCookie) {
+		t.Errorf("Read without the cookie = %v, want http.ErrNoCookie", err)
+	}
+}
@@ -0,0 +1,30 @@
+package sharding
+
+import "strings"
+
+// Key joins parts into a shard key, e.g. Key("tenant", "42") is
+// "tenant:42".
+func Key(parts ...string) string {
+	return strings.Join(parts, ":")
+}
+
+// Tagged returns a key whose shard is decided by tag alone, e.g.
+// Tagged(Key("tenant", "42"), "orders") is "{tenant:42}:orders".
+func Tagged(tag string, parts ...string) string {
+	return "{" + tag + "}:" + Key(parts...)
+}
+
+// HashTag returns the part of key between the first "{" and the next "}"
+// when it is non-empty, and key otherwise. Only that part is hashed, so
+// keys sharing a tag share a shard, as with Redis Cluster hash tags.
+func HashTag(key string) string {
+	start := strings.IndexByte(key, '{')
+	if start < 0 {
+		return key
+	}
+	end := strings.IndexByte(key[start+1:], '}')
+	if end <= 0 {
+		return key
+	}
+	return key[start+1 : start+1+end]
+}
@@ -0,0 +1,68 @@
+package sharding
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// Router routes keys to per-shard database handles (typically *sql.DB).
+type Router[T any] struct {
+	picker Picker
+	dbs    map[string]T
+}
+
+// NewRouter builds the picker described by conf.Sharding and opens a
+// handle for every shard with open, which receives the shard's database
+// configuration (see config.Config.DB). Handles opened before a failure
+// are returned to close along with the error.
+func NewRouter[T any](conf *config.Config, open func(name string, db *config.Database) (T, error)) (*Router[T], error) {
+	if conf.Sharding == nil {
+		return nil, ErrNoShards
+	}
+
+	var (
+		picker Picker
+		err    error
+	)
+	switch conf.Sharding.Strategy {
+	case "ring":
+		picker, err = NewRing(conf.Sharding.VirtualNodes, conf.Sharding.Shards...)
+	default:
+		picker, err = NewRendezvous(conf.Sharding.Shards...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Router[T]{picker: picker, dbs: make(map[string]T, len(conf.Sharding.Shards))}
+	for _, name := range picker.Shards() {
+		dbConf, ok := conf.DB(name)
+		if !ok {
+			return r, fmt.Errorf("sharding: shard %q is not a configured database", name)
+		}
+		db, err := open(name, dbConf)
+		if err != nil {
+			return r, fmt.Errorf("sharding: open shard %q: %w", name, err)
+		}
+		r.dbs[name] = db
+	}
+	return r, nil
+}
+
+// Shard returns the name of the shard owning key.
+func (r *Router[T]) Shard(key string) string {
+	return r.picker.Pick(key)
+}
+
+// For returns the handle of the shard owning key.
+func (r *Router[T]) For(key string) T {
+	return r.dbs[r.picker.Pick(key)]
+}
+
+// All returns every shard's handle by name, for fan-out queries and
+// migrations.
+func (r *Router[T]) All() map[string]T {
+	return maps.Clone(r.dbs)
+}
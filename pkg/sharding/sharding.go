@@ -0,0 +1,175 @@
+// Package sharding maps keys to shards for services whose data outgrows a
+// single database.
+//
+// Two pickers are provided. Rendezvous (highest random weight) hashing is
+// exact and needs no tuning, at O(shards) per lookup; it suits the handful
+// of shards most services run. A Ring (consistent hashing with virtual
+// nodes) answers in O(log points) and suits many shards. With either,
+// adding or removing a shard only moves the keys that belong to it.
+//
+// Keys are hashed through HashTag, so "{tenant:42}:orders" and
+// "{tenant:42}:invoices" land on the same shard.
+package sharding
+
+import (
+	"errors"
+	"hash/fnv"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// DefaultVirtualNodes is the number of ring points per shard when none is
+// configured; it keeps shards within about 10% of an even load.
+const DefaultVirtualNodes = 160
+
+// Errors returned by the package.
+var (
+	ErrNoShards  = errors.New("sharding: no shards")
+	ErrDuplicate = errors.New("sharding: duplicate shard")
+)
+
+// Picker assigns keys to shards.
+type Picker interface {
+	// Pick returns the shard owning key.
+	Pick(key string) string
+
+	// Shards returns every shard, in configuration order.
+	Shards() []string
+}
+
+// Rendezvous is a Picker using highest random weight hashing.
+type Rendezvous struct {
+	shards []string
+	seeds  []uint64
+}
+
+// NewRendezvous constructs a Rendezvous picker over shards.
+func NewRendezvous(shards ...string) (*Rendezvous, error) {
+	if err := check(shards); err != nil {
+		return nil, err
+	}
+
+	r := &Rendezvous{shards: slices.Clone(shards), seeds: make([]uint64, len(shards))}
+	for i, s := range shards {
+		r.seeds[i] = hash(s)
+	}
+	return r, nil
+}
+
+// Pick implements Picker. The shard whose combined hash with key is
+// highest wins.
+func (r *Rendezvous) Pick(key string) string {
+	h := hash(HashTag(key))
+
+	best, bestWeight := 0, uint64(0)
+	for i, seed := range r.seeds {
+		if w := mix(seed ^ h); w > bestWeight || i == 0 {
+			best, bestWeight = i, w
+		}
+	}
+	return r.shards[best]
+}
+
+// Shards implements Picker.
+func (r *Rendezvous) Shards() []string {
+	return slices.Clone(r.shards)
+}
+
+// Ring is a Picker using consistent hashing with virtual nodes.
+type Ring struct {
+	shards []string
+	points []uint64
+	owners []int
+}
+
+// NewRing constructs a Ring placing vnodes points per shard (0 uses
+// DefaultVirtualNodes).
+func NewRing(vnodes int, shards ...string) (*Ring, error) {
+	if err := check(shards); err != nil {
+		return nil, err
+	}
+	if vnodes <= 0 {
+		vnodes = DefaultVirtualNodes
+	}
+
+	type point struct {
+		hash  uint64
+		owner int
+	}
+	points := make([]point, 0, vnodes*len(shards))
+	for i, s := range shards {
+		for v := range vnodes {
+			points = append(points, point{hash(s + "#" + strconv.Itoa(v)), i})
+		}
+	}
+	slices.SortFunc(points, func(a, b point) int {
+		if a.hash != b.hash {
+			if a.hash < b.hash {
+				return -1
+			}
+			return 1
+		}
+		return a.owner - b.owner
+	})
+
+	r := &Ring{
+		shards: slices.Clone(shards),
+		points: make([]uint64, len(points)),
+		owners: make([]int, len(points)),
+	}
+	for i, p := range points {
+		r.points[i], r.owners[i] = p.hash, p.owner
+	}
+	return r, nil
+}
+
+// Pick implements Picker. The key belongs to the first point clockwise
+// from its hash.
+func (r *Ring) Pick(key string) string {
+	h := hash(HashTag(key))
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.shards[r.owners[i]]
+}
+
+// Shards implements Picker.
+func (r *Ring) Shards() []string {
+	return slices.Clone(r.shards)
+}
+
+// check rejects empty and duplicate shard lists.
+func check(shards []string) error {
+	if len(shards) == 0 {
+		return ErrNoShards
+	}
+	seen := make(map[string]bool, len(shards))
+	for _, s := range shards {
+		if seen[s] {
+			return ErrDuplicate
+		}
+		seen[s] = true
+	}
+	return nil
+}
+
+// hash returns the 64-bit FNV-1a hash of s, finalized for a uniform spread.
+// It is stable across processes and releases, so keys never move between
+// deploys.
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return mix(h.Sum64())
+}
+
+// mix is the SplitMix64 finalizer, which spreads FNV's weak low bits.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,153 @@
+package signing
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testKeys = StaticKeys(map[string]string{"orders": "s3cret", "disabled": ""})
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tests := []struct {
+		name    string
+		sign    func(r *http.Request)
+		alter   func(r *http.Request)
+		wantErr error
+	}{
+		{name: "valid", sign: func(r *http.Request) { _ = SignRequest(r, "orders", []byte("s3cret"), now) }},
+		{name: "unsigned", sign: func(*http.Request) {}, wantErr: ErrMissingSignature},
+		{name: "unknown key", sign: func(r *http.Request) { _ = SignRequest(r, "billing", []byte("s3cret"), now) }, wantErr: ErrUnknownKey},
+		{name: "empty key", sign: func(r *http.Request) { _ = SignRequest(r, "disabled", nil, now) }, wantErr: ErrUnknownKey},
+		{name: "wrong secret", sign: func(r *http.Request) { _ = SignRequest(r, "orders", []byte("guess"), now) }, wantErr: ErrInvalidSignature},
+		{
+			name: "outside skew",
+			sign: func(r *http.Request) {
+				_ = SignRequest(r, "orders", []byte("s3cret"), now.Add(-DefaultMaxSkew-time.Second))
+			},
+			wantErr: ErrExpired,
+		},
+		{
+			name: "in the future",
+			sign: func(r *http.Request) {
+				_ = SignRequest(r, "orders", []byte("s3cret"), now.Add(DefaultMaxSkew+time.Second))
+			},
+			wantErr: ErrExpired,
+		},
+		{
+			name:    "altered body",
+			sign:    func(r *http.Request) { _ = SignRequest(r, "orders", []byte("s3cret"), now) },
+			alter:   func(r *http.Request) { r.Body = io.NopCloser(strings.NewReader(`{"total":1}`)) },
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "altered query",
+			sign:    func(r *http.Request) { _ = SignRequest(r, "orders", []byte("s3cret"), now) },
+			alter:   func(r *http.Request) { r.URL.RawQuery = "dry_run=false" },
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "replayed with a new timestamp",
+			sign:    func(r *http.Request) { _ = SignRequest(r, "orders", []byte("s3cret"), now.Add(-time.Minute)) },
+			alter:   func(r *http.Request) { r.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10)) },
+			wantErr: ErrInvalidSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/orders?dry_run=true", strings.NewReader(`{"total":100}`))
+			tt.sign(r)
+			if tt.alter != nil {
+				tt.alter(r)
+			}
+
+			v := NewVerifier(testKeys, 0)
+			v.now = func() time.Time { return now }
+			keyID, err := v.Verify(r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify = %q, %v; want %v", keyID, err, tt.wantErr)
+			}
+			if tt.wantErr == nil && keyID != "orders" {
+				t.Errorf("key ID = %q, want orders", keyID)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var gotKeyID, gotBody string
+	h := Middleware(NewVerifier(testKeys, 0))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKeyID, _ = KeyIDFromContext(r.Context())
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("payload"))
+	if err := SignRequest(r, "orders", []byte("s3cret"), time.Now()); err != nil {
+		t.Fatalf("SignRequest: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if rec.Code != http.StatusOK || gotKeyID != "orders" || gotBody != "payload" {
+		t.Fatalf("status %d, key ID %q, body %q; want 200 with the key ID and the body intact", rec.Code, gotKeyID, gotBody)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("unsigned request status = %d, want 401", rec.Code)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	v := NewVerifier(testKeys, 0)
+	srv := httptest.NewServer(Middleware(v)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &Transport{Base: srv.Client().Transport, KeyID: "orders", Key: []byte("s3cret")}}
+	resp, err := client.Post(srv.URL+"/orders?page=2", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("status = %d, want the signed request accepted", resp.StatusCode)
+	}
+}
+
+func TestResponseMiddleware(t *testing.T) {
+	h := ResponseMiddleware("orders", []byte("s3cret"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders", nil))
+
+	if rec.Code != http.StatusCreated || rec.Header().Get("Content-Length") != "8" {
+		t.Fatalf("status %d, Content-Length %q; want the first status and the body length", rec.Code, rec.Header().Get("Content-Length"))
+	}
+	if !strings.HasPrefix(rec.Header().Get(HeaderContentDigest), "sha-256=:") {
+		t.Errorf("Content-Digest = %q", rec.Header().Get(HeaderContentDigest))
+	}
+
+	v := NewVerifier(testKeys, 0)
+	resp := rec.Result()
+	if keyID, err := v.VerifyResponse(resp); err != nil || keyID != "orders" {
+		t.Fatalf("VerifyResponse = %q, %v; want orders", keyID, err)
+	}
+	if body, _ := io.ReadAll(resp.Body); string(body) != `{"id":1}` {
+		t.Errorf("body after verification = %q", body)
+	}
+	if _, err := v.VerifyPayload(rec.Header(), []byte(`{"id":2}`)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyPayload of another body = %v, want ErrInvalidSignature", err)
+	}
+}
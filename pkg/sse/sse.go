@@ -0,0 +1,403 @@
+// Package sse streams Server-Sent Events to browsers and other EventSource
+// clients through a Broker, so handlers publish events without dealing
+// with flushing, heartbeats, or disconnects.
+//
+// Every client has a bounded buffer. A client too slow to keep up is
+// disconnected rather than allowed to hold up publishers; like any client
+// that lost its connection, it reconnects with the Last-Event-ID header
+// and is sent the events it missed from the topic's recent history.
+// Heartbeat comments keep idle connections open through proxies.
+//
+// Broker.Shutdown ends every stream, advising clients to reconnect, which
+// they do to another instance. Register it as a post-traffic hook so
+// streams do not hold up draining:
+//
+//	hooks.PostTraffic("sse", 5*time.Second, broker.Shutdown)
+//
+// Streaming routes must be exempt from the buffering timeout middleware
+// (see middleware.Timeout overrides).
+package sse
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults applied when Options leaves a value unset.
+const (
+	DefaultBufferSize   = 64
+	DefaultHeartbeat    = 15 * time.Second
+	DefaultHistory      = 256
+	DefaultHistoryTTL   = 5 * time.Minute
+	DefaultRetry        = 3 * time.Second
+	DefaultWriteTimeout = 10 * time.Second
+)
+
+// ErrClosed is returned by Serve once the broker is shut down.
+var ErrClosed = errors.New("sse: broker closed")
+
+// Event is a Server-Sent Event.
+type Event struct {
+	// ID identifies the event for reconnection; Publish assigns one when empty.
+	ID string
+
+	// Type is the event name clients listen for; empty means "message".
+	Type string
+
+	// Data is the payload, sent as one "data" line per line.
+	Data []byte
+
+	// at is when the event was published, for history expiry.
+	at time.Time
+}
+
+// Options configures a Broker.
+type Options struct {
+	// BufferSize is the number of events queued per client before it is
+	// disconnected as too slow.
+	BufferSize int
+
+	// Heartbeat is the interval of the comments keeping idle connections open.
+	Heartbeat time.Duration
+
+	// History is the number of recent events kept per topic for clients
+	// reconnecting with Last-Event-ID.
+	History int
+
+	// HistoryTTL is how long events are kept for replay, and topics
+	// without clients are remembered.
+	HistoryTTL time.Duration
+
+	// Retry is the reconnection delay advised to clients.
+	Retry time.Duration
+
+	// WriteTimeout bounds the time to send each event to a client.
+	WriteTimeout time.Duration
+}
+
+// client is a connected stream.
+type client struct {
+	events chan Event
+}
+
+// topic is a named stream with its clients and recent history.
+type topic struct {
+	clients map[*client]struct{}
+	history []Event
+}
+
+// Broker fans events out to the clients of a topic. It is safe for
+// concurrent use.
+type Broker struct {
+	opts Options
+
+	mu        sync.Mutex
+	topics    map[string]*topic
+	seq       uint64
+	closed    bool
+	lastSweep time.Time
+	streams   sync.WaitGroup
+}
+
+// NewBroker returns a Broker configured by opts.
+func NewBroker(opts Options) *Broker {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = DefaultBufferSize
+	}
+	if opts.Heartbeat <= 0 {
+		opts.Heartbeat = DefaultHeartbeat
+	}
+	if opts.History <= 0 {
+		opts.History = DefaultHistory
+	}
+	if opts.HistoryTTL <= 0 {
+		opts.HistoryTTL = DefaultHistoryTTL
+	}
+	if opts.Retry <= 0 {
+		opts.Retry = DefaultRetry
+	}
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = DefaultWriteTimeout
+	}
+	return &Broker{opts: opts, topics: make(map[string]*topic), lastSweep: time.Now()}
+}
+
+// Publish sends e to the clients of the topic and keeps it for replay,
+// returning it with its ID. Clients whose buffer is full are disconnected.
+func (b *Broker) Publish(name string, e Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.seq++
+	if e.ID == "" {
+		e.ID = strconv.FormatUint(b.seq, 10)
+	}
+	e.at = now
+
+	t := b.topic(name)
+	t.history = append(t.history, e)
+	if over := len(t.history) - b.opts.History; over > 0 {
+		t.history = append(t.history[:0], t.history[over:]...)
+	}
+
+	for c := range t.clients {
+		select {
+		case c.events <- e:
+		default:
+			delete(t.clients, c)
+			close(c.events)
+		}
+	}
+
+	if now.Sub(b.lastSweep) >= b.opts.HistoryTTL {
+		b.sweep(now)
+	}
+	return e
+}
+
+// Clients returns the number of connected clients of the topic.
+func (b *Broker) Clients(name string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if t, ok := b.topics[name]; ok {
+		return len(t.clients)
+	}
+	return 0
+}
+
+// Handler returns a handler streaming the topic named by topicFn for the
+// request, such as the authenticated user's.
+func (b *Broker) Handler(topicFn func(r *http.Request) string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := b.Serve(w, r, topicFn(r)); errors.Is(err, ErrClosed) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(b.opts.Retry.Seconds())))
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		}
+	})
+}
+
+// Serve streams the topic to the client until it disconnects, falls
+// behind, or the broker shuts down. Events published after the one named
+// by the Last-Event-ID header (or the lastEventId query parameter, for
+// EventSource polyfills) that are still in the history are sent first.
+// It returns ErrClosed, before writing anything, once the broker is shut
+// down.
+func (b *Broker) Serve(w http.ResponseWriter, r *http.Request, name string) error {
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("lastEventId")
+	}
+
+	c := &client{events: make(chan Event, b.opts.BufferSize)}
+	replay, err := b.subscribe(name, c, lastID)
+	if err != nil {
+		return err
+	}
+	defer b.streams.Done()
+	defer b.unsubscribe(name, c)
+
+	sw := &writer{w: w, rc: http.NewResponseController(w), timeout: b.opts.WriteTimeout}
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no") // stop nginx from buffering the stream
+	h.Del("Content-Length")
+	w.WriteHeader(http.StatusOK)
+
+	if err := sw.retry(b.opts.Retry); err != nil {
+		return nil
+	}
+	for _, e := range replay {
+		if err := sw.event(e); err != nil {
+			return nil
+		}
+	}
+
+	heartbeat := time.NewTicker(b.opts.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case e, ok := <-c.events:
+			if !ok {
+				return nil // too slow, or shutting down
+			}
+			if err := sw.event(e); err != nil {
+				return nil
+			}
+		case <-heartbeat.C:
+			if err := sw.comment("ping"); err != nil {
+				return nil
+			}
+		case <-r.Context().Done():
+			return nil
+		}
+	}
+}
+
+// Shutdown ends every stream and refuses new ones, then waits for the
+// streams to return or ctx to be done. Clients reconnect after the advised
+// retry delay.
+func (b *Broker) Shutdown(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		for _, t := range b.topics {
+			for c := range t.clients {
+				close(c.events)
+			}
+			t.clients = nil
+		}
+	}
+	b.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		b.streams.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// subscribe registers c on the topic and returns the events to replay
+// after lastID, in one step so no event falls in between.
+func (b *Broker) subscribe(name string, c *client, lastID string) ([]Event, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrClosed
+	}
+	b.streams.Add(1)
+
+	t := b.topic(name)
+	t.clients[c] = struct{}{}
+
+	if lastID == "" {
+		return nil, nil
+	}
+	cutoff := time.Now().Add(-b.opts.HistoryTTL)
+	for i := len(t.history) - 1; i >= 0; i-- {
+		if t.history[i].ID != lastID {
+			continue
+		}
+		var replay []Event
+		for _, e := range t.history[i+1:] {
+			if e.at.After(cutoff) {
+				replay = append(replay, e)
+			}
+		}
+		return replay, nil
+	}
+	return nil, nil
+}
+
+// unsubscribe removes c from the topic, unless Publish or Shutdown
+// already did.
+func (b *Broker) unsubscribe(name string, c *client) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if t, ok := b.topics[name]; ok {
+		if _, ok := t.clients[c]; ok {
+			delete(t.clients, c)
+			close(c.events)
+		}
+	}
+}
+
+// topic returns the named topic, creating it. b.mu must be held.
+func (b *Broker) topic(name string) *topic {
+	t, ok := b.topics[name]
+	if !ok {
+		t = &topic{clients: make(map[*client]struct{})}
+		b.topics[name] = t
+	}
+	if t.clients == nil {
+		t.clients = make(map[*client]struct{})
+	}
+	return t
+}
+
+// sweep drops expired history, and topics left without clients or
+// history. b.mu must be held.
+func (b *Broker) sweep(now time.Time) {
+	b.lastSweep = now
+	cutoff := now.Add(-b.opts.HistoryTTL)
+	for name, t := range b.topics {
+		i := 0
+		for i < len(t.history) && !t.history[i].at.After(cutoff) {
+			i++
+		}
+		t.history = append(t.history[:0], t.history[i:]...)
+		if len(t.clients) == 0 && len(t.history) == 0 {
+			delete(b.topics, name)
+		}
+	}
+}
+
+// writer writes the SSE wire format, flushing every write.
+type writer struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	timeout time.Duration
+	buf     bytes.Buffer
+}
+
+// event writes e.
+func (sw *writer) event(e Event) error {
+	sw.buf.Reset()
+	sw.buf.WriteString("id: " + oneLine(e.ID) + "\n")
+	if e.Type != "" {
+		sw.buf.WriteString("event: " + oneLine(e.Type) + "\n")
+	}
+	for _, line := range strings.Split(string(e.Data), "\n") {
+		sw.buf.WriteString("data: " + strings.TrimSuffix(line, "\r") + "\n")
+	}
+	sw.buf.WriteByte('\n')
+	return sw.write()
+}
+
+// retry advises the client's reconnection delay.
+func (sw *writer) retry(d time.Duration) error {
+	sw.buf.Reset()
+	sw.buf.WriteString("retry: " + strconv.FormatInt(d.Milliseconds(), 10) + "\n\n")
+	return sw.write()
+}
+
+// comment writes a comment, ignored by clients.
+func (sw *writer) comment(text string) error {
+	sw.buf.Reset()
+	sw.buf.WriteString(": " + oneLine(text) + "\n\n")
+	return sw.write()
+}
+
+// write sends the buffer within the write timeout and flushes it.
+func (sw *writer) write() error {
+	_ = sw.rc.SetWriteDeadline(time.Now().Add(sw.timeout))
+	if _, err := sw.w.Write(sw.buf.Bytes()); err != nil {
+		return err
+	}
+	if err := sw.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// oneLine strips line breaks, which would end an SSE field early.
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
@@ -0,0 +1,82 @@
+package throttle
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+	"github.com/iamBelugaa/go-boilerplate/pkg/auth"
+	apperrors "github.com/iamBelugaa/go-boilerplate/pkg/errors"
+)
+
+// NameThrottle names Middleware in route group chains.
+const NameThrottle = "throttle"
+
+// KeyFunc resolves the principal and its weight for a request.
+type KeyFunc func(r *http.Request) (key string, weight int)
+
+// Middleware admits requests through t, keyed by keyFn. Requests that cannot
+// be admitted are answered 429 Too Many Requests by apperrors.Write.
+func Middleware(t *Throttle, keyFn KeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, weight := keyFn(r)
+
+			release, err := t.Acquire(r.Context(), key, weight)
+			if err != nil {
+				if r.Context().Err() != nil {
+					return
+				}
+				w.Header().Set("Retry-After", "1")
+				apperrors.Write(w, r, apperrors.TooManyRequests("too many requests in flight").Wrapping(err))
+				return
+			}
+			defer release()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIP keys requests by client IP with a weight of 1, for anonymous
+// traffic. Behind proxies, middleware.RealIP must run first so the key is
+// the client rather than the proxy.
+func ClientIP(r *http.Request) (string, int) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host, 1
+}
+
+// Principal keys requests by authenticated principal, weighted by the
+// weights of conf.TierWeights for the principal's tier (1 for tiers not
+// configured). Anonymous requests fall back to ClientIP, so authentication
+// must run before the throttle for requests to be keyed by principal.
+func Principal(conf *config.Throttle) KeyFunc {
+	var weights map[string]int
+	if conf != nil {
+		weights = conf.TierWeights
+	}
+	return func(r *http.Request) (string, int) {
+		p := auth.PrincipalFromContext(r.Context())
+		switch {
+		case p == nil:
+			return ClientIP(r)
+		case p.Subject != "":
+			return "sub:" + p.Subject, tierWeight(weights, p.Tier)
+		case p.ClientID != "":
+			return "client:" + p.ClientID, tierWeight(weights, p.Tier)
+		default:
+			return ClientIP(r)
+		}
+	}
+}
+
+// tierWeight returns the weight of tier, or 1 when it is not configured.
+func tierWeight(weights map[string]int, tier string) int {
+	if w, ok := weights[tier]; ok && w > 0 {
+		return w
+	}
+	return 1
+}
@@ -0,0 +1,182 @@
+// Package throttle provides a weighted fair-queuing concurrency limiter keyed
+// by principal (user, tenant, API key), so a single heavy consumer cannot
+// monopolize the service's capacity.
+//
+// Requests beyond the configured concurrency wait in per-principal queues and
+// are admitted in order of their virtual finish time, where each principal's
+// share of capacity is proportional to its weight.
+package throttle
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// Set of error variables for acquiring capacity.
+var (
+	ErrQueueFull = errors.New("throttle: queue is full")
+	ErrTimeout   = errors.New("throttle: timed out waiting for capacity")
+)
+
+// waiter is a single request queued for capacity.
+type waiter struct {
+	key    string
+	start  float64
+	finish float64
+	index  int
+	ready  chan struct{}
+}
+
+// waitQueue orders waiters by virtual finish time.
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int { return len(q) }
+func (q waitQueue) Less(i, j int) bool {
+	return q[i].finish < q[j].finish
+}
+func (q waitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+func (q *waitQueue) Push(x any) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+func (q *waitQueue) Pop() any {
+	old := *q
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	w.index = -1
+	*q = old[:len(old)-1]
+	return w
+}
+
+// Throttle is a weighted fair-queuing concurrency limiter.
+type Throttle struct {
+	maxConcurrent  int
+	maxQueuePerKey int
+	maxWait        time.Duration
+
+	mu         sync.Mutex
+	inFlight   int
+	vtime      float64
+	lastFinish map[string]float64
+	queued     map[string]int
+	queue      waitQueue
+}
+
+// New constructs a Throttle enforcing the limits of conf: MaxConcurrent
+// requests at a time (at least 1), MaxQueuePerKey waiting per key (0 is
+// unbounded), each for up to MaxWait (0 waits until the caller's context
+// is done).
+func New(conf *config.Throttle) *Throttle {
+	t := &Throttle{
+		maxConcurrent: 1,
+		lastFinish:    make(map[string]float64),
+		queued:        make(map[string]int),
+	}
+	if conf != nil {
+		t.maxConcurrent = max(conf.MaxConcurrent, 1)
+		t.maxQueuePerKey = conf.MaxQueuePerKey
+		t.maxWait = conf.MaxWait
+	}
+	return t
+}
+
+// Acquire blocks until key is granted a slot and returns the function that
+// releases it. weight is the key's share of capacity relative to other keys
+// (values below 1 are treated as 1).
+func (t *Throttle) Acquire(ctx context.Context, key string, weight int) (func(), error) {
+	if weight < 1 {
+		weight = 1
+	}
+
+	t.mu.Lock()
+	if t.inFlight < t.maxConcurrent && t.queue.Len() == 0 {
+		t.inFlight++
+		t.mu.Unlock()
+		return t.release, nil
+	}
+
+	if t.maxQueuePerKey > 0 && t.queued[key] >= t.maxQueuePerKey {
+		t.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+
+	start := max(t.vtime, t.lastFinish[key])
+	w := &waiter{key: key, start: start, finish: start + 1/float64(weight), ready: make(chan struct{})}
+	t.lastFinish[key] = w.finish
+	t.queued[key]++
+	heap.Push(&t.queue, w)
+	t.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if t.maxWait > 0 {
+		timer := time.NewTimer(t.maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-w.ready:
+		return t.release, nil
+	case <-ctx.Done():
+		return nil, t.abandon(w, ctx.Err())
+	case <-timeout:
+		return nil, t.abandon(w, ErrTimeout)
+	}
+}
+
+// abandon removes w from the queue. If w was granted a slot concurrently,
+// the slot is handed back so it is not leaked.
+func (t *Throttle) abandon(w *waiter, err error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if w.index >= 0 {
+		heap.Remove(&t.queue, w.index)
+		t.dequeued(w.key)
+		return err
+	}
+
+	t.releaseLocked()
+	return err
+}
+
+// release frees a slot and admits the next waiter, if any.
+func (t *Throttle) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.releaseLocked()
+}
+
+func (t *Throttle) releaseLocked() {
+	if t.queue.Len() == 0 {
+		t.inFlight--
+		if t.inFlight == 0 {
+			t.vtime = 0
+			clear(t.lastFinish)
+		}
+		return
+	}
+
+	w := heap.Pop(&t.queue).(*waiter)
+	t.vtime = w.start
+	t.dequeued(w.key)
+	close(w.ready)
+}
+
+// dequeued updates the per-key queue accounting after w leaves the queue.
+func (t *Throttle) dequeued(key string) {
+	t.queued[key]--
+	if t.queued[key] <= 0 {
+		delete(t.queued, key)
+	}
+}
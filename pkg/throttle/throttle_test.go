@@ -0,0 +1,227 @@
+package throttle
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+	"github.com/iamBelugaa/go-boilerplate/pkg/auth"
+)
+
+// waitQueued waits until n requests are queued.
+func waitQueued(t *testing.T, th *Throttle, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		th.mu.Lock()
+		queued := th.queue.Len()
+		th.mu.Unlock()
+		if queued == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d requests queued, want %d", queued, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWeightedAdmissionOrder(t *testing.T) {
+	th := New(&config.Throttle{MaxConcurrent: 1})
+	hold, err := th.Acquire(context.Background(), "holder", 1)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	type admission struct {
+		key     string
+		release func()
+	}
+	admitted := make(chan admission)
+
+	// a (weight 2) finishes at 0.5, 1, 1.5 and b (weight 5) at 0.2, 0.4,
+	// 0.6, 0.8 of virtual time, whatever order they queue in.
+	queue := []struct {
+		key    string
+		weight int
+	}{{"a", 2}, {"a", 2}, {"a", 2}, {"b", 5}, {"b", 5}, {"b", 5}, {"b", 5}}
+	for i, q := range queue {
+		go func() {
+			release, err := th.Acquire(context.Background(), q.key, q.weight)
+			if err != nil {
+				t.Errorf("Acquire %s: %v", q.key, err)
+				return
+			}
+			admitted <- admission{q.key, release}
+		}()
+		waitQueued(t, th, i+1)
+	}
+
+	hold()
+	var order []string
+	for range queue {
+		a := <-admitted
+		order = append(order, a.key)
+		a.release()
+	}
+	if got, want := strings.Join(order, ""), "bbabbaa"; got != want {
+		t.Fatalf("admission order = %s, want %s", got, want)
+	}
+	if th.inFlight != 0 || len(th.lastFinish) != 0 {
+		t.Errorf("idle throttle has %d in flight and %d finish times", th.inFlight, len(th.lastFinish))
+	}
+}
+
+func TestQueueLimits(t *testing.T) {
+	th := New(&config.Throttle{MaxConcurrent: 1, MaxQueuePerKey: 1, MaxWait: 20 * time.Millisecond})
+	hold, _ := th.Acquire(context.Background(), "a", 1)
+	defer hold()
+
+	done := make(chan error)
+	go func() {
+		_, err := th.Acquire(context.Background(), "a", 1)
+		done <- err
+	}()
+	waitQueued(t, th, 1)
+
+	if _, err := th.Acquire(context.Background(), "a", 1); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Acquire past MaxQueuePerKey = %v, want ErrQueueFull", err)
+	}
+	if err := <-done; !errors.Is(err, ErrTimeout) {
+		t.Errorf("Acquire past MaxWait = %v, want ErrTimeout", err)
+	}
+	if th.queue.Len() != 0 || len(th.queued) != 0 {
+		t.Errorf("a timed out request stayed queued")
+	}
+}
+
+// enqueue queues a waiter for key as Acquire does, without waiting on it.
+func enqueue(th *Throttle, key string) *waiter {
+	th.mu.Lock()
+	defer th.mu.Unlock()
+	w := &waiter{key: key, finish: 1, ready: make(chan struct{})}
+	th.queued[key]++
+	heap.Push(&th.queue, w)
+	return w
+}
+
+func TestAbandon(t *testing.T) {
+	t.Run("while queued", func(t *testing.T) {
+		th := New(&config.Throttle{MaxConcurrent: 1})
+		hold, _ := th.Acquire(context.Background(), "holder", 1)
+		w := enqueue(th, "a")
+
+		if err := th.abandon(w, context.Canceled); !errors.Is(err, context.Canceled) {
+			t.Fatalf("abandon = %v, want context.Canceled", err)
+		}
+		if th.queue.Len() != 0 || th.queued["a"] != 0 || th.inFlight != 1 {
+			t.Fatalf("after abandon: %d queued, %d in flight; want 0 and 1", th.queue.Len(), th.inFlight)
+		}
+		hold()
+		if th.inFlight != 0 {
+			t.Fatalf("in flight = %d after release, want 0", th.inFlight)
+		}
+	})
+
+	t.Run("after being granted", func(t *testing.T) {
+		// The waiter's context is cancelled as its slot is granted: the
+		// slot passes to the next waiter rather than leaking.
+		th := New(&config.Throttle{MaxConcurrent: 1})
+		hold, _ := th.Acquire(context.Background(), "holder", 1)
+		w := enqueue(th, "a")
+		next := enqueue(th, "b")
+		next.finish = 2
+
+		hold() // grants w
+		select {
+		case <-w.ready:
+		default:
+			t.Fatal("release did not grant the first waiter")
+		}
+		_ = th.abandon(w, context.Canceled)
+		select {
+		case <-next.ready:
+		default:
+			t.Fatal("abandon did not hand the granted slot on")
+		}
+		th.release()
+		if th.inFlight != 0 {
+			t.Fatalf("in flight = %d, want the slot returned", th.inFlight)
+		}
+	})
+}
+
+// TestAbandonRace cancels waiters as they are granted, checking no slot
+// leaks whichever wins.
+func TestAbandonRace(t *testing.T) {
+	th := New(&config.Throttle{MaxConcurrent: 1})
+	for range 500 {
+		hold, err := th.Acquire(context.Background(), "holder", 1)
+		if err != nil {
+			t.Fatalf("Acquire: %v", err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if release, err := th.Acquire(ctx, "a", 1); err == nil {
+				release()
+			}
+		}()
+		waitQueued(t, th, 1)
+
+		go cancel()
+		hold()
+		<-done
+	}
+	if th.inFlight != 0 || th.queue.Len() != 0 {
+		t.Fatalf("in flight = %d, queued = %d; want both 0", th.inFlight, th.queue.Len())
+	}
+}
+
+func TestPrincipal(t *testing.T) {
+	keyFn := Principal(&config.Throttle{TierWeights: map[string]int{"pro": 4, "free": 1}})
+	tests := []struct {
+		name       string
+		principal  *auth.Principal
+		wantKey    string
+		wantWeight int
+	}{
+		{name: "anonymous", wantKey: "ip:192.0.2.1", wantWeight: 1},
+		{name: "configured tier", principal: &auth.Principal{Subject: "u1", Tier: "pro"}, wantKey: "sub:u1", wantWeight: 4},
+		{name: "unknown tier", principal: &auth.Principal{Subject: "u1", Tier: "gold"}, wantKey: "sub:u1", wantWeight: 1},
+		{name: "no tier", principal: &auth.Principal{Subject: "u1"}, wantKey: "sub:u1", wantWeight: 1},
+		{name: "client credentials", principal: &auth.Principal{ClientID: "svc", Tier: "pro"}, wantKey: "client:svc", wantWeight: 4},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = "192.0.2.1:1234"
+		if tt.principal != nil {
+			r = r.WithContext(auth.WithPrincipal(r.Context(), tt.principal))
+		}
+		if key, weight := keyFn(r); key != tt.wantKey || weight != tt.wantWeight {
+			t.Errorf("%s: key %q weight %d, want %q and %d", tt.name, key, weight, tt.wantKey, tt.wantWeight)
+		}
+	}
+}
+
+func TestMiddlewareRejectsWhenFull(t *testing.T) {
+	th := New(&config.Throttle{MaxConcurrent: 1, MaxWait: time.Millisecond})
+	hold, _ := th.Acquire(context.Background(), "ip:192.0.2.1", 1)
+	defer hold()
+
+	h := Middleware(th, ClientIP)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Error("handler ran without capacity")
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("status %d, Retry-After %q; want 429 with Retry-After", rec.Code, rec.Header().Get("Retry-After"))
+	}
+}
@@ -0,0 +1,291 @@
+package uploads
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gcsEndpoint is the XML API endpoint of Google Cloud Storage, which speaks
+// the S3 protocol to HMAC keys.
+const gcsEndpoint = "https://storage.googleapis.com"
+
+// unsignedPayload is the payload hash of requests whose body is not
+// signed; TLS protects it in transit.
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Options configures an S3 storage.
+type S3Options struct {
+	// Bucket is the bucket files are stored in.
+	Bucket string
+
+	// Region is the bucket's region (defaults to "us-east-1").
+	Region string
+
+	// Endpoint overrides the AWS endpoint, for S3-compatible services.
+	Endpoint string
+
+	// PathStyle addresses the bucket in the URL path rather than the host.
+	PathStyle bool
+
+	// AccessKeyID and SecretAccessKey sign requests.
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// Client sends requests (defaults to a client with a 5 minute timeout).
+	Client *http.Client
+}
+
+// S3 stores files in an Amazon S3 bucket, or one of a service speaking its
+// protocol, signing requests with AWS Signature Version 4.
+type S3 struct {
+	opts S3Options
+	base *url.URL
+	now  func() time.Time
+}
+
+// NewS3 returns an S3 storage.
+func NewS3(opts S3Options) (*S3, error) {
+	if opts.Bucket == "" {
+		return nil, errors.New("uploads: s3: bucket is required")
+	}
+	if opts.AccessKeyID == "" || opts.SecretAccessKey == "" {
+		return nil, errors.New("uploads: s3: credentials are required")
+	}
+	if opts.Region == "" {
+		opts.Region = "us-east-1"
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 5 * time.Minute}
+	}
+
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + opts.Region + ".amazonaws.com"
+	}
+	base, err := url.Parse(endpoint)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("uploads: s3: invalid endpoint %q", endpoint)
+	}
+	if opts.PathStyle {
+		base.Path = "/" + opts.Bucket
+	} else {
+		base.Host = opts.Bucket + "." + base.Host
+		base.Path = ""
+	}
+	return &S3{opts: opts, base: base, now: time.Now}, nil
+}
+
+// NewGCS returns a storage for a Google Cloud Storage bucket, accessed
+// through its S3-compatible XML API with an HMAC key of a service account.
+func NewGCS(bucket, accessKeyID, secret string) (*S3, error) {
+	return NewS3(S3Options{
+		Bucket:          bucket,
+		Region:          "auto",
+		Endpoint:        gcsEndpoint,
+		PathStyle:       true,
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secret,
+	})
+}
+
+// Put implements Storage. The size must be known: S3 rejects the chunked
+// uploads of unknown sizes when the payload is unsigned.
+func (s *S3) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	if size < 0 {
+		return fmt.Errorf("uploads: s3: put %q: the content size is required", key)
+	}
+	body := io.NopCloser(content)
+	if size == 0 {
+		body = http.NoBody // a zero ContentLength with a body would be sent chunked
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return s.do(req)
+}
+
+// Delete implements Storage.
+func (s *S3) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+	return s.do(req)
+}
+
+// PresignPut implements Presigner. The client must send the returned
+// Content-Type header.
+func (s *S3) PresignPut(_ context.Context, key, contentType string, expiry time.Duration) (string, map[string]string, error) {
+	headers := map[string]string{}
+	if contentType != "" {
+		headers["Content-Type"] = contentType
+	}
+	return s.presign(http.MethodPut, key, headers, expiry), headers, nil
+}
+
+// PresignGet implements Presigner.
+func (s *S3) PresignGet(_ context.Context, key string, expiry time.Duration) (string, error) {
+	return s.presign(http.MethodGet, key, nil, expiry), nil
+}
+
+// objectURL returns the URL of the object under key.
+func (s *S3) objectURL(key string) *url.URL {
+	u := *s.base
+	u.Path = s.base.Path + "/" + key
+	u.RawPath = s.base.Path + "/" + uriEncode(key, false)
+	return &u
+}
+
+// do signs and sends req, returning an error for non-2xx statuses other
+// than a 404 to a DELETE.
+func (s *S3) do(req *http.Request) error {
+	now := s.now().UTC()
+	req.Header.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	if req.ContentLength > 0 {
+		headers["content-length"] = strconv.FormatInt(req.ContentLength, 10)
+	}
+	signedHeaders, signature := s.sign(req.Method, req.URL, nil, headers, now)
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.opts.AccessKeyID, s.scope(now), signedHeaders, signature))
+
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 || (req.Method == http.MethodDelete && resp.StatusCode == http.StatusNotFound) {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("uploads: s3: %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// presign returns the URL of a request signed in its query string.
+func (s *S3) presign(method, key string, headers map[string]string, expiry time.Duration) string {
+	now := s.now().UTC()
+	u := s.objectURL(key)
+
+	signed := map[string]string{"host": u.Host}
+	for name, value := range headers {
+		signed[strings.ToLower(name)] = value
+	}
+	names := slices.Sorted(maps.Keys(signed))
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.opts.AccessKeyID+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expiry.Seconds())))
+	query.Set("X-Amz-SignedHeaders", strings.Join(names, ";"))
+
+	_, signature := s.sign(method, u, query, signed, now)
+	query.Set("X-Amz-Signature", signature)
+	u.RawQuery = canonicalQuery(query)
+	return u.String()
+}
+
+// scope returns the credential scope of requests signed at t.
+func (s *S3) scope(t time.Time) string {
+	return t.Format("20060102") + "/" + s.opts.Region + "/s3/aws4_request"
+}
+
+// sign returns the signed header names and the signature of a request.
+func (s *S3) sign(method string, u *url.URL, query url.Values, headers map[string]string, t time.Time) (string, string) {
+	names := slices.Sorted(maps.Keys(headers))
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		canonicalQuery(query),
+		canonicalHeaders.String(),
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		t.Format("20060102T150405Z"),
+		s.scope(t),
+		hexSHA256(canonicalRequest),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.opts.SecretAccessKey), t.Format("20060102"))
+	key = hmacSHA256(key, s.opts.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return signedHeaders, hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// canonicalQuery encodes query sorted by name, and the values of a name
+// by value, as signatures require.
+func canonicalQuery(query url.Values) string {
+	var parts []string
+	for _, name := range slices.Sorted(maps.Keys(query)) {
+		for _, value := range slices.Sorted(slices.Values(query[name])) {
+			parts = append(parts, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes s as AWS signatures require: everything but
+// unreserved characters, and slashes when encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := range len(s) {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// hexSHA256 returns the hex-encoded SHA-256 digest of s.
+func hexSHA256(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data under key.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,26 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrRejected is wrapped by Scanner errors for files that must not be
+// stored, such as infected ones; they are answered 422 Unprocessable
+// Entity. Other scan errors fail the request with a server error.
+var ErrRejected = errors.New("uploads: file rejected by scanner")
+
+// Scanner checks uploaded files before they are stored, for instance by
+// passing them to a ClamAV daemon.
+type Scanner interface {
+	Scan(ctx context.Context, filename string, content io.Reader) error
+}
+
+// ScannerFunc adapts a function to Scanner.
+type ScannerFunc func(ctx context.Context, filename string, content io.Reader) error
+
+// Scan implements Scanner.
+func (f ScannerFunc) Scan(ctx context.Context, filename string, content io.Reader) error {
+	return f(ctx, filename, content)
+}
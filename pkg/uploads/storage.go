@@ -0,0 +1,144 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// DefaultDir is the directory of local storage.
+const DefaultDir = "uploads"
+
+// Storage stores uploaded files by key.
+type Storage interface {
+	// Put stores size bytes of content under key.
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+
+	// Delete removes the file under key; a missing file is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Presigner is implemented by storages that can let clients upload and
+// download files directly.
+type Presigner interface {
+	// PresignPut returns a URL accepting a PUT of a file of contentType
+	// under key until expiry, and the headers to send with it.
+	PresignPut(ctx context.Context, key, contentType string, expiry time.Duration) (string, map[string]string, error)
+
+	// PresignGet returns a URL serving the file under key until expiry.
+	PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// NewStorage returns the storage selected by conf: local disk by default,
+// or S3 or GCS.
+func NewStorage(conf *config.Uploads) (Storage, error) {
+	if conf == nil {
+		conf = &config.Uploads{}
+	}
+	switch conf.Storage {
+	case "s3":
+		return NewS3(S3Options{
+			Bucket:          conf.Bucket,
+			Region:          conf.Region,
+			Endpoint:        conf.Endpoint,
+			PathStyle:       conf.PathStyle,
+			AccessKeyID:     conf.AccessKeyID,
+			SecretAccessKey: conf.SecretAccessKey,
+		})
+	case "gcs":
+		return NewGCS(conf.Bucket, conf.AccessKeyID, conf.SecretAccessKey)
+	default:
+		dir := conf.Dir
+		if dir == "" {
+			dir = DefaultDir
+		}
+		return NewLocal(dir)
+	}
+}
+
+// Local stores files in a directory, keys being relative paths within it.
+type Local struct {
+	root *os.Root
+}
+
+// NewLocal returns a Local storing files in dir, which is created if
+// needed.
+func NewLocal(dir string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("uploads: create %q: %w", dir, err)
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("uploads: open %q: %w", dir, err)
+	}
+	return &Local{root: root}, nil
+}
+
+// Put implements Storage. The file is written under a temporary name and
+// renamed into place, so it is never visible partially written.
+func (l *Local) Put(_ context.Context, key string, content io.Reader, _ int64, _ string) error {
+	if !filepath.IsLocal(key) {
+		return fmt.Errorf("uploads: invalid key %q", key)
+	}
+	if dir := filepath.Dir(key); dir != "." {
+		if err := mkdirAll(l.root, dir); err != nil {
+			return err
+		}
+	}
+
+	tmp := key + ".part"
+	f, err := l.root.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, content); err != nil {
+		_ = f.Close()
+		_ = l.root.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = l.root.Remove(tmp)
+		return err
+	}
+	return rename(l.root, tmp, key)
+}
+
+// Delete implements Storage.
+func (l *Local) Delete(_ context.Context, key string) error {
+	if !filepath.IsLocal(key) {
+		return fmt.Errorf("uploads: invalid key %q", key)
+	}
+	if err := l.root.Remove(key); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Open returns the file under key.
+func (l *Local) Open(key string) (*os.File, error) {
+	return l.root.Open(key)
+}
+
+// mkdirAll creates dir and its parents within root.
+func mkdirAll(root *os.Root, dir string) error {
+	if parent := filepath.Dir(dir); parent != "." {
+		if err := mkdirAll(root, parent); err != nil {
+			return err
+		}
+	}
+	if err := root.Mkdir(dir, 0o750); err != nil && !errors.Is(err, os.ErrExist) {
+		return err
+	}
+	return nil
+}
+
+// rename moves oldname to newname within root.
+func rename(root *os.Root, oldname, newname string) error {
+	return os.Rename(filepath.Join(root.Name(), oldname), filepath.Join(root.Name(), newname))
+}
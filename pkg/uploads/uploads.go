@@ -0,0 +1,364 @@
+// Package uploads receives files from multipart/form-data requests and
+// stores them in a Storage backend: local disk, Amazon S3 or a compatible
+// service, or Google Cloud Storage. Each file is streamed to a temporary
+// file rather than held in memory, checked against the size limit and the
+// content types allowed, detected from its content rather than trusted
+// from the client, passed to an optional Scanner (e.g., a virus scanner),
+// and only then stored:
+//
+//	res, err := uploader.Receive(r)
+//	if err != nil {
+//		respond.Error(w, r, err)
+//		return
+//	}
+//	respond.JSON(w, http.StatusCreated, res.Files)
+//
+// Clients may also upload directly to the bucket with a URL from
+// PresignUpload, sparing the service the traffic.
+package uploads
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+	"github.com/iamBelugaa/go-boilerplate/pkg/validation"
+)
+
+// Default limits.
+const (
+	DefaultMaxFileBytes  int64 = 10 << 20
+	DefaultMaxFiles            = 10
+	DefaultPresignExpiry       = 15 * time.Minute
+)
+
+// maxValueBytes caps the size of each non-file form value.
+const maxValueBytes = 64 << 10
+
+// sniffLen is the number of bytes content types are detected from.
+const sniffLen = 512
+
+// ErrPresignUnsupported is returned by PresignUpload when the storage
+// cannot presign URLs, as local storage cannot.
+var ErrPresignUnsupported = errors.New("uploads: storage does not support presigned URLs")
+
+// File is a stored upload.
+type File struct {
+	// Field is the form field the file was sent in.
+	Field string `json:"field"`
+
+	// Filename is the name the client gave the file, without directories.
+	Filename string `json:"filename"`
+
+	// Key identifies the file in the storage.
+	Key string `json:"key"`
+
+	// ContentType is the type detected from the file's content.
+	ContentType string `json:"contentType"`
+
+	// Size is the file's size in bytes.
+	Size int64 `json:"size"`
+
+	// SHA256 is the hex-encoded SHA-256 digest of the file's content.
+	SHA256 string `json:"sha256"`
+}
+
+// Result is a received multipart request.
+type Result struct {
+	// Files are the stored files, in the order they were sent.
+	Files []File
+
+	// Values are the form's other fields.
+	Values url.Values
+}
+
+// Uploader receives uploads into a Storage.
+type Uploader struct {
+	storage       Storage
+	scanner       Scanner
+	maxFileBytes  int64
+	maxFiles      int
+	allowedTypes  []string
+	keyPrefix     string
+	presignExpiry time.Duration
+}
+
+// New constructs an Uploader storing files in storage. scanner may be nil
+// to store files unscanned. A nil conf uses the defaults.
+func New(conf *config.Uploads, storage Storage, scanner Scanner) *Uploader {
+	if conf == nil {
+		conf = &config.Uploads{}
+	}
+	u := &Uploader{
+		storage:       storage,
+		scanner:       scanner,
+		maxFileBytes:  conf.MaxFileBytes,
+		maxFiles:      conf.MaxFiles,
+		allowedTypes:  conf.AllowedTypes,
+		keyPrefix:     conf.KeyPrefix,
+		presignExpiry: conf.PresignExpiry,
+	}
+	if u.maxFileBytes <= 0 {
+		u.maxFileBytes = DefaultMaxFileBytes
+	}
+	if u.maxFiles <= 0 {
+		u.maxFiles = DefaultMaxFiles
+	}
+	if u.presignExpiry <= 0 {
+		u.presignExpiry = DefaultPresignExpiry
+	}
+	return u
+}
+
+// Receive streams the files of r's multipart/form-data body to the storage
+// and returns them with the form's other values. Requests breaking the
+// limits, files of types not allowed, and files the scanner rejects are
+// returned as *validation.RequestError, keyed by form field; if any file
+// fails, the files already stored are deleted.
+func (u *Uploader) Receive(r *http.Request) (_ *Result, err error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, &validation.RequestError{
+			Status:  http.StatusUnsupportedMediaType,
+			Message: "request body must be multipart/form-data",
+			Err:     err,
+		}
+	}
+	maxBytes := int64(u.maxFiles)*u.maxFileBytes + validation.DefaultMaxBodyBytes
+	r.Body = http.MaxBytesReader(nil, r.Body, maxBytes)
+
+	res := &Result{Values: url.Values{}}
+	defer func() {
+		if err == nil {
+			return
+		}
+		// Deletion is best effort: the request already failed.
+		for _, f := range res.Files {
+			_ = u.storage.Delete(context.WithoutCancel(r.Context()), f.Key)
+		}
+	}()
+
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return res, nil
+		}
+		if err != nil {
+			return nil, bodyError(err, maxBytes)
+		}
+
+		field := part.FormName()
+		if part.FileName() == "" {
+			value, err := io.ReadAll(io.LimitReader(part, maxValueBytes+1))
+			if err != nil {
+				return nil, bodyError(err, maxBytes)
+			}
+			if len(value) > maxValueBytes {
+				return nil, fieldError(http.StatusBadRequest, field, fmt.Sprintf("must not be larger than %d bytes", maxValueBytes))
+			}
+			res.Values.Add(field, string(value))
+			continue
+		}
+
+		if len(res.Files) == u.maxFiles {
+			return nil, fieldError(http.StatusBadRequest, field, fmt.Sprintf("exceeds the limit of %d files per request", u.maxFiles))
+		}
+		f, err := u.receive(r.Context(), field, path.Base(strings.ReplaceAll(part.FileName(), `\`, "/")), part)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return nil, bodyError(err, maxBytes)
+			}
+			return nil, err
+		}
+		res.Files = append(res.Files, f)
+	}
+}
+
+// receive spools, checks, scans, and stores one file.
+func (u *Uploader) receive(ctx context.Context, field, filename string, body io.Reader) (File, error) {
+	tmp, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return File{}, fmt.Errorf("uploads: spool %q: %w", filename, err)
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(body, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return File{}, err
+	}
+	head = head[:n]
+	if n == 0 {
+		return File{}, fieldError(http.StatusBadRequest, field, "must not be empty")
+	}
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !u.allowed(contentType) {
+		return File{}, fieldError(http.StatusUnsupportedMediaType, field, fmt.Sprintf("has content type %q, which is not accepted", contentType))
+	}
+
+	hash := sha256.New()
+	w := io.MultiWriter(tmp, hash)
+	size, err := io.Copy(w, io.LimitReader(io.MultiReader(bytes.NewReader(head), body), u.maxFileBytes+1))
+	if err != nil {
+		return File{}, err
+	}
+	if size > u.maxFileBytes {
+		return File{}, fieldError(http.StatusRequestEntityTooLarge, field, fmt.Sprintf("must not be larger than %d bytes", u.maxFileBytes))
+	}
+
+	if u.scanner != nil {
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return File{}, err
+		}
+		if err := u.scanner.Scan(ctx, filename, tmp); err != nil {
+			if errors.Is(err, ErrRejected) {
+				return File{}, fieldError(http.StatusUnprocessableEntity, field, "was rejected by the content scan")
+			}
+			return File{}, fmt.Errorf("uploads: scan %q: %w", filename, err)
+		}
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return File{}, err
+	}
+	key := u.newKey(filename)
+	if err := u.storage.Put(ctx, key, tmp, size, contentType); err != nil {
+		return File{}, fmt.Errorf("uploads: store %q: %w", key, err)
+	}
+
+	return File{
+		Field:       field,
+		Filename:    filename,
+		Key:         key,
+		ContentType: contentType,
+		Size:        size,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// allowed reports whether files of contentType are accepted.
+func (u *Uploader) allowed(contentType string) bool {
+	if len(u.allowedTypes) == 0 {
+		return true
+	}
+	for _, t := range u.allowedTypes {
+		if prefix, ok := strings.CutSuffix(t, "/*"); ok {
+			if strings.HasPrefix(contentType, prefix+"/") {
+				return true
+			}
+		} else if strings.EqualFold(t, contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// newKey returns a unique storage key for filename: the key prefix, the
+// upload date, and a random name keeping the file's extension.
+func (u *Uploader) newKey(filename string) string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return u.keyPrefix + time.Now().UTC().Format("2006/01/02/") + hex.EncodeToString(b[:]) + extension(filename)
+}
+
+// extension returns the lowercased extension of filename, or "" when it is
+// not plain alphanumerics of reasonable length.
+func extension(filename string) string {
+	ext := strings.ToLower(path.Ext(filename))
+	if len(ext) < 2 || len(ext) > 10 {
+		return ""
+	}
+	for _, c := range ext[1:] {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return ""
+		}
+	}
+	return ext
+}
+
+// PresignedUpload lets a client upload a file directly to the storage.
+type PresignedUpload struct {
+	// Key identifies the file in the storage once uploaded.
+	Key string `json:"key"`
+
+	// Method and URL are the request to send the file with.
+	Method string `json:"method"`
+	URL    string `json:"url"`
+
+	// Headers must be sent with the request as they are.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// ExpiresAt is when the URL stops being valid.
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// PresignUpload returns a presigned request uploading a file named
+// filename of contentType directly to the storage. The content type is
+// checked against the types allowed and bound to the signature, but the
+// storage cannot enforce the size limit or scan the file: verify the
+// object once the client reports the upload complete. It returns
+// ErrPresignUnsupported when the storage cannot presign URLs.
+func (u *Uploader) PresignUpload(ctx context.Context, filename, contentType string) (*PresignedUpload, error) {
+	p, ok := u.storage.(Presigner)
+	if !ok {
+		return nil, ErrPresignUnsupported
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !u.allowed(mediaType) {
+		return nil, fieldError(http.StatusUnsupportedMediaType, "contentType", fmt.Sprintf("%q is not accepted", contentType))
+	}
+
+	key := u.newKey(filename)
+	rawURL, headers, err := p.PresignPut(ctx, key, contentType, u.presignExpiry)
+	if err != nil {
+		return nil, fmt.Errorf("uploads: presign %q: %w", key, err)
+	}
+	return &PresignedUpload{
+		Key:       key,
+		Method:    http.MethodPut,
+		URL:       rawURL,
+		Headers:   headers,
+		ExpiresAt: time.Now().Add(u.presignExpiry),
+	}, nil
+}
+
+// fieldError returns a *validation.RequestError for a form field.
+func fieldError(status int, field, msg string) error {
+	return &validation.RequestError{
+		Status:  status,
+		Message: "upload is invalid",
+		Fields:  validation.FieldErrors{{Field: field, Err: msg}},
+	}
+}
+
+// bodyError converts an error reading the body into a
+// *validation.RequestError.
+func bodyError(err error, maxBytes int64) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return &validation.RequestError{
+			Status:  http.StatusRequestEntityTooLarge,
+			Message: fmt.Sprintf("request body must not be larger than %d bytes", maxBytes),
+			Err:     err,
+		}
+	}
+	return &validation.RequestError{Status: http.StatusBadRequest, Message: "request body contains a malformed multipart form", Err: err}
+}
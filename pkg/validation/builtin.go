@@ -0,0 +1,114 @@
+package validation
+
+import (
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// logLevels are the levels accepted by the "loglevel" tag.
+var logLevels = map[string]bool{
+	"debug": true, "info": true, "warn": true, "error": true,
+	"dpanic": true, "panic": true, "fatal": true,
+}
+
+// registerBuiltins adds the infrastructure validators shipped with this
+// package, complementing the library's own "hostname_port", "semver", and
+// "cron" tags:
+//
+//   - listen_addr:        a host:port or :port address a server can bind
+//   - duration_min=1s:    a time.Duration of at least the parameter
+//   - duration_max=1h:    a time.Duration of at most the parameter
+//   - url_scheme=https:   a URL using one of the space-separated schemes
+//   - loglevel:           a log level name (debug, info, warn, error, ...)
+func registerBuiltins() {
+	validate.RegisterValidation("listen_addr", isListenAddr)
+	validate.RegisterValidation("duration_min", durationBound(func(d, bound time.Duration) bool { return d >= bound }))
+	validate.RegisterValidation("duration_max", durationBound(func(d, bound time.Duration) bool { return d <= bound }))
+	validate.RegisterValidation("url_scheme", hasURLScheme)
+	validate.RegisterValidation("loglevel", isLogLevel)
+
+	RegisterMessage("listen_addr", "must be a valid listen address (host:port or :port)")
+	RegisterMessage("duration_min", "must be at least {param}")
+	RegisterMessage("duration_max", "must be at most {param}")
+	RegisterMessage("url_scheme", "must be a URL with scheme {param}")
+	RegisterMessage("loglevel", "must be one of: debug info warn error dpanic panic fatal")
+	RegisterMessage("semver", "must be a valid semantic version")
+	RegisterMessage("cron", "must be a valid cron expression")
+}
+
+// isListenAddr validates "host:port" or ":port" addresses with a valid port.
+func isListenAddr(fl validator.FieldLevel) bool {
+	host, port, err := net.SplitHostPort(fl.Field().String())
+	if err != nil {
+		return false
+	}
+
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 0 || n > 65535 {
+		return false
+	}
+
+	return host == "" || net.ParseIP(host) != nil || isHostname(host)
+}
+
+// isHostname reports whether s is an RFC 1123 hostname.
+func isHostname(s string) bool {
+	if len(s) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(s, "."), ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// durationBound builds a validator comparing a time.Duration field to the
+// duration given as the tag parameter.
+func durationBound(cmp func(d, bound time.Duration) bool) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		bound, err := time.ParseDuration(fl.Param())
+		if err != nil {
+			panic("validation: invalid duration parameter " + strconv.Quote(fl.Param()))
+		}
+
+		field := fl.Field()
+		if field.Kind() != reflect.Int64 {
+			return false
+		}
+		return cmp(time.Duration(field.Int()), bound)
+	}
+}
+
+// hasURLScheme validates an absolute URL whose scheme is one of the
+// space-separated schemes in the tag parameter.
+func hasURLScheme(fl validator.FieldLevel) bool {
+	u, err := url.Parse(fl.Field().String())
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	for _, scheme := range strings.Fields(fl.Param()) {
+		if strings.EqualFold(u.Scheme, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLogLevel validates a log level name.
+func isLogLevel(fl validator.FieldLevel) bool {
+	return logLevels[strings.ToLower(fl.Field().String())]
+}
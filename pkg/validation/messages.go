@@ -0,0 +1,226 @@
+package validation
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/web/negotiate"
+)
+
+// englishMessages holds the bundled English message templates keyed by
+// validation tag. Keys suffixed with ".string" or ".items" are used for
+// strings and collections respectively. The placeholder {param} is replaced
+// by the tag parameter.
+var englishMessages = map[string]string{
+	"required":               "is required",
+	"required_if":            "is required",
+	"required_unless":        "is required",
+	"required_with":          "is required",
+	"required_with_all":      "is required",
+	"required_without":       "is required",
+	"required_without_all":   "is required",
+	"required_if_production": "is required in production",
+	"excluded_if_production": "must not be set in production",
+	"min":                    "must be at least {param}",
+	"min.string":             "must be at least {param} characters long",
+	"min.items":              "must contain at least {param} items",
+	"max":                    "must be at most {param}",
+	"max.string":             "must be at most {param} characters long",
+	"max.items":              "must contain at most {param} items",
+	"len":                    "must be exactly {param}",
+	"len.string":             "must be exactly {param} characters long",
+	"len.items":              "must contain exactly {param} items",
+	"eq":                     "must be equal to {param}",
+	"ne":                     "must not be equal to {param}",
+	"gt":                     "must be greater than {param}",
+	"gte":                    "must be at least {param}",
+	"lt":                     "must be less than {param}",
+	"lte":                    "must be at most {param}",
+	"oneof":                  "must be one of: {param}",
+	"url":                    "must be a valid URL",
+	"uri":                    "must be a valid URI",
+	"http_url":               "must be a valid HTTP(S) URL",
+	"email":                  "must be a valid email address",
+	"uuid":                   "must be a valid UUID",
+	"hostname":               "must be a valid hostname",
+	"hostname_rfc1123":       "must be a valid hostname",
+	"hostname_port":          "must be a valid host:port address",
+	"ip":                     "must be a valid IP address",
+	"cidr":                   "must be a valid CIDR block",
+	"file":                   "must be an existing file",
+	"dir":                    "must be an existing directory",
+	"boolean":                "must be a boolean",
+	"number":                 "must be a number",
+	"alphanum":               "must contain only letters and digits",
+	"eqfield":                "must be equal to {param}",
+	"nefield":                "must not be equal to {param}",
+	"gtfield":                "must be greater than {param}",
+	"gtefield":               "must be greater than or equal to {param}",
+	"ltfield":                "must be less than {param}",
+	"ltefield":               "must be less than or equal to {param}",
+	"unique":                 "must contain unique values",
+}
+
+// catalogs holds the message templates of every registered locale.
+var (
+	catalogsMu sync.RWMutex
+	catalogs   = map[string]map[string]string{"en": englishMessages}
+)
+
+// RegisterMessage sets the English error message for tag, e.g. "must be a
+// valid slug". The placeholder {param} is replaced by the tag parameter.
+func RegisterMessage(tag, message string) {
+	RegisterLocaleMessage("en", tag, message)
+}
+
+// RegisterLocale makes locale available for error messages, with the given
+// message templates keyed like the English ones (e.g., "required",
+// "min.string"). Tags missing from messages fall back to English.
+//
+//	validation.RegisterLocale(de.New(), map[string]string{
+//		"required": "ist erforderlich",
+//	})
+func RegisterLocale(locale locales.Translator, messages map[string]string) error {
+	if err := universal.AddTranslator(locale, true); err != nil {
+		return err
+	}
+
+	for tag, msg := range messages {
+		RegisterLocaleMessage(locale.Locale(), tag, msg)
+	}
+	return nil
+}
+
+// RegisterLocaleMessage sets the error message for tag in a registered locale.
+func RegisterLocaleMessage(locale, tag, message string) {
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+
+	if catalogs[locale] == nil {
+		catalogs[locale] = make(map[string]string)
+	}
+	catalogs[locale][tag] = message
+}
+
+// languageKey is the context key holding the preferred message languages.
+type languageKey struct{}
+
+// WithLanguage returns a copy of ctx carrying the languages of an
+// Accept-Language header value (e.g., "de-DE,de;q=0.9,en;q=0.8"), used to
+// pick the locale of validation error messages.
+func WithLanguage(ctx context.Context, acceptLanguage string) context.Context {
+	return context.WithValue(ctx, languageKey{}, parseAcceptLanguage(acceptLanguage))
+}
+
+// Catalog returns the message template of a validation tag key (e.g.,
+// "required" or "min.string"), such as the messages of an i18n bundle in
+// the request's locale.
+type Catalog func(key string) (string, bool)
+
+// catalogKey is the context key of the request's Catalog.
+type catalogKey struct{}
+
+// WithCatalog returns a copy of ctx rendering validation error messages
+// with the templates of catalog, before those of the registered locales.
+func WithCatalog(ctx context.Context, catalog Catalog) context.Context {
+	return context.WithValue(ctx, catalogKey{}, catalog)
+}
+
+// translation is how the messages of a validation are rendered: with the
+// request's catalog, then the registered catalog of locale.
+type translation struct {
+	catalog Catalog
+	locale  string
+}
+
+// translationFromContext returns the translation of the catalog and
+// languages stored in ctx.
+func translationFromContext(ctx context.Context) translation {
+	catalog, _ := ctx.Value(catalogKey{}).(Catalog)
+	return translation{catalog: catalog, locale: localeFromContext(ctx)}
+}
+
+// localeFromContext returns the best registered locale for the languages
+// stored in ctx, defaulting to English. Locales with a message catalog
+// need no translator.
+func localeFromContext(ctx context.Context) string {
+	langs, _ := ctx.Value(languageKey{}).([]string)
+	if len(langs) == 0 {
+		return "en"
+	}
+
+	catalogsMu.RLock()
+	for _, lang := range langs {
+		if _, ok := catalogs[lang]; ok {
+			catalogsMu.RUnlock()
+			return lang
+		}
+	}
+	catalogsMu.RUnlock()
+
+	trans, found := universal.FindTranslator(langs...)
+	if !found {
+		return "en"
+	}
+	return trans.Locale()
+}
+
+// parseAcceptLanguage returns the languages of an Accept-Language header in
+// preference order, as locale names ("de_DE" followed by its base "de").
+func parseAcceptLanguage(header string) []string {
+	var langs []string
+	for _, tag := range negotiate.Languages(header) {
+		locale := strings.ReplaceAll(tag, "-", "_")
+		base, _, _ := strings.Cut(locale, "_")
+		langs = append(langs, locale, strings.ToLower(base))
+	}
+	return langs
+}
+
+// message renders the human-readable message for a failed validation with
+// tr, falling back to English and then to the library translation.
+func message(fe validator.FieldError, tr translation) string {
+	keys := []string{fe.Tag()}
+	switch fe.Kind() {
+	case reflect.String:
+		keys = append([]string{fe.Tag() + ".string"}, keys...)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		keys = append([]string{fe.Tag() + ".items"}, keys...)
+	}
+
+	if tr.catalog != nil {
+		for _, key := range keys {
+			if tmpl, ok := tr.catalog(key); ok {
+				return strings.ReplaceAll(tmpl, "{param}", fe.Param())
+			}
+		}
+	}
+
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+
+	for _, catalog := range []map[string]string{catalogs[tr.locale], catalogs["en"]} {
+		for _, key := range keys {
+			if tmpl, ok := catalog[key]; ok {
+				return strings.ReplaceAll(tmpl, "{param}", fe.Param())
+			}
+		}
+	}
+
+	return fe.Translate(translator)
+}
+
+// fieldPath returns the dot-separated JSON path of the failed field relative
+// to the validated value (e.g., "database.maxOpenConns").
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if _, path, ok := strings.Cut(ns, "."); ok {
+		return path
+	}
+	return fe.Field()
+}
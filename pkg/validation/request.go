@@ -0,0 +1,170 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxBodyBytes is the request body limit used by DecodeAndValidate.
+const DefaultMaxBodyBytes int64 = 1 << 20
+
+// RequestError describes a request body that could not be decoded or that
+// failed validation. Status is the HTTP status the error should map to.
+type RequestError struct {
+	Status  int         `json:"-"`
+	Message string      `json:"message"`
+	Fields  FieldErrors `json:"fields,omitempty"`
+	Err     error       `json:"-"`
+}
+
+// Error implements the error interface.
+func (re *RequestError) Error() string {
+	if len(re.Fields) > 0 {
+		return re.Message + ": " + re.Fields.Error()
+	}
+	return re.Message
+}
+
+// Unwrap returns the underlying error.
+func (re *RequestError) Unwrap() error {
+	if re.Err != nil {
+		return re.Err
+	}
+	if len(re.Fields) > 0 {
+		return re.Fields
+	}
+	return nil
+}
+
+// IsRequestError checks if an error of type *RequestError exists.
+func IsRequestError(err error) bool {
+	var re *RequestError
+	return errors.As(err, &re)
+}
+
+// AsRequestError returns the *RequestError in err's chain, or nil.
+func AsRequestError(err error) *RequestError {
+	var re *RequestError
+	if !errors.As(err, &re) {
+		return nil
+	}
+	return re
+}
+
+// DecodeAndValidate decodes the JSON request body into dst, rejecting
+// unknown fields and bodies larger than DefaultMaxBodyBytes, and validates
+// the result with CheckCtx. Failures are returned as *RequestError, with
+// messages in the language of the request's Accept-Language header.
+func DecodeAndValidate(r *http.Request, dst any) error {
+	return DecodeAndValidateLimit(r, dst, DefaultMaxBodyBytes)
+}
+
+// DecodeAndValidateLimit is DecodeAndValidate with a custom body size limit.
+func DecodeAndValidateLimit(r *http.Request, dst any, maxBytes int64) error {
+	if err := DecodeJSON(r, dst, maxBytes); err != nil {
+		return err
+	}
+
+	ctx := WithLanguage(r.Context(), r.Header.Get("Accept-Language"))
+	if err := CheckCtx(ctx, dst); err != nil {
+		fields := AsFieldErrors(err)
+		if fields == nil {
+			return err
+		}
+		return &RequestError{Status: http.StatusBadRequest, Message: "request body is invalid", Fields: fields}
+	}
+
+	return nil
+}
+
+// DecodeJSON decodes the JSON request body into dst like DecodeAndValidateLimit,
+// without validating it, for callers filling dst from other sources first.
+func DecodeJSON(r *http.Request, dst any, maxBytes int64) error {
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+			return &RequestError{
+				Status:  http.StatusUnsupportedMediaType,
+				Message: "request body must be application/json",
+			}
+		}
+	}
+
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxBytes))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err, maxBytes)
+	}
+
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return &RequestError{Status: http.StatusBadRequest, Message: "request body must contain a single JSON value"}
+	}
+
+	return nil
+}
+
+// decodeError converts a JSON decoding error into a *RequestError with a
+// client-facing message.
+func decodeError(err error, maxBytes int64) error {
+	var (
+		syntaxErr    *json.SyntaxError
+		typeErr      *json.UnmarshalTypeError
+		maxBytesErr  *http.MaxBytesError
+		unmarshalErr *json.InvalidUnmarshalError
+	)
+
+	switch {
+	case errors.As(err, &unmarshalErr):
+		return err
+
+	case errors.As(err, &maxBytesErr):
+		return &RequestError{
+			Status:  http.StatusRequestEntityTooLarge,
+			Message: fmt.Sprintf("request body must not be larger than %d bytes", maxBytes),
+			Err:     err,
+		}
+
+	case errors.As(err, &syntaxErr):
+		return &RequestError{
+			Status:  http.StatusBadRequest,
+			Message: fmt.Sprintf("request body contains malformed JSON (at position %d)", syntaxErr.Offset),
+			Err:     err,
+		}
+
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &RequestError{Status: http.StatusBadRequest, Message: "request body contains malformed JSON", Err: err}
+
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			return &RequestError{Status: http.StatusBadRequest, Message: "request body has an invalid type", Err: err}
+		}
+		return &RequestError{
+			Status:  http.StatusBadRequest,
+			Message: "request body is invalid",
+			Fields:  FieldErrors{{Field: field, Err: "must be of type " + typeErr.Type.String()}},
+			Err:     err,
+		}
+
+	case errors.Is(err, io.EOF):
+		return &RequestError{Status: http.StatusBadRequest, Message: "request body must not be empty", Err: err}
+
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &RequestError{
+			Status:  http.StatusBadRequest,
+			Message: "request body is invalid",
+			Fields:  FieldErrors{{Field: field, Err: "is not a recognized field"}},
+			Err:     err,
+		}
+
+	default:
+		return &RequestError{Status: http.StatusBadRequest, Message: "request body could not be decoded", Err: err}
+	}
+}
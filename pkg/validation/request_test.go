@@ -0,0 +1,65 @@
+package validation
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeAndValidate(t *testing.T) {
+	type order struct {
+		Item     string `json:"item" validate:"required"`
+		Quantity int    `json:"quantity" validate:"min=1"`
+	}
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		maxBytes    int64
+		wantStatus  int    // 0 decodes
+		wantField   string // field of the first FieldError
+	}{
+		{name: "valid", body: `{"item": "book", "quantity": 2}`},
+		{name: "json suffix", contentType: "application/merge-patch+json", body: `{"item": "book", "quantity": 2}`},
+		{name: "invalid values", body: `{"quantity": 0}`, wantStatus: http.StatusBadRequest, wantField: "item"},
+		{name: "wrong media type", contentType: "text/plain", body: `{}`, wantStatus: http.StatusUnsupportedMediaType},
+		{name: "malformed", body: `{"item": }`, wantStatus: http.StatusBadRequest},
+		{name: "truncated", body: `{"item": "bo`, wantStatus: http.StatusBadRequest},
+		{name: "empty", body: ``, wantStatus: http.StatusBadRequest},
+		{name: "wrong type", body: `{"quantity": "two"}`, wantStatus: http.StatusBadRequest, wantField: "quantity"},
+		{name: "unknown field", body: `{"item": "book", "quantity": 1, "coupon": "x"}`, wantStatus: http.StatusBadRequest, wantField: "coupon"},
+		{name: "trailing value", body: `{"item": "book", "quantity": 1} {}`, wantStatus: http.StatusBadRequest},
+		{name: "too large", body: `{"item": "` + strings.Repeat("x", 64) + `"}`, maxBytes: 32, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			} else {
+				r.Header.Set("Content-Type", "application/json")
+			}
+			maxBytes := tt.maxBytes
+			if maxBytes == 0 {
+				maxBytes = DefaultMaxBodyBytes
+			}
+
+			var dst order
+			err := DecodeAndValidateLimit(r, &dst, maxBytes)
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("DecodeAndValidateLimit = %v", err)
+				}
+				return
+			}
+			re := AsRequestError(err)
+			if re == nil || re.Status != tt.wantStatus {
+				t.Fatalf("error = %v, want a RequestError with status %d", err, tt.wantStatus)
+			}
+			if tt.wantField != "" && (len(re.Fields) == 0 || re.Fields[0].Field != tt.wantField) {
+				t.Errorf("fields = %v, want %q first", re.Fields, tt.wantField)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package validation
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaDraft is the JSON Schema dialect emitted by JSONSchema.
+const SchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema document or subschema.
+type Schema struct {
+	Draft                string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+
+	// duration marks Go durations, encoded as strings but bounded as
+	// numbers by their `validate` rules, which JSON Schema cannot express.
+	duration bool
+}
+
+// durationPattern matches the Go duration syntax accepted by
+// time.ParseDuration (e.g., "1m30s").
+const durationPattern = `^-?([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$|^0$`
+
+// formats maps format tags to JSON Schema formats.
+var formats = map[string]string{
+	"email":            "email",
+	"url":              "uri",
+	"uri":              "uri",
+	"http_url":         "uri",
+	"url_scheme":       "uri",
+	"uuid":             "uuid",
+	"uuid4":            "uuid",
+	"hostname":         "hostname",
+	"hostname_rfc1123": "hostname",
+	"fqdn":             "hostname",
+	"ipv4":             "ipv4",
+	"ipv6":             "ipv6",
+	"datetime":         "date-time",
+}
+
+var (
+	durationType    = reflect.TypeFor[time.Duration]()
+	timeType        = reflect.TypeFor[time.Time]()
+	textMarshalType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// JSONSchema generates a JSON Schema for v, a struct or pointer to one,
+// from its `json` and `validate` tags, so the same rules can be enforced by
+// clients and infrastructure (e.g., Helm values schemas or gateways).
+// Tags without a JSON Schema equivalent, such as cross-field or
+// context-aware rules, are left out.
+func JSONSchema(v any, title string) *Schema {
+	s := schemaFor(reflect.TypeOf(v), make(map[reflect.Type]bool))
+	s.Draft = SchemaDraft
+	s.Title = title
+	return s
+}
+
+// MarshalJSONSchema returns the indented JSON Schema for v.
+func MarshalJSONSchema(v any, title string) ([]byte, error) {
+	return json.MarshalIndent(JSONSchema(v, title), "", "  ")
+}
+
+// schemaFor returns the schema of t. seen guards against recursive types,
+// which are emitted as unconstrained schemas.
+func schemaFor(t reflect.Type, seen map[reflect.Type]bool) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == durationType:
+		return &Schema{Type: "string", Pattern: durationPattern, duration: true}
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case reflect.PointerTo(t).Implements(textMarshalType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Minimum: ptr(0.0)}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaFor(t.Elem(), seen)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaFor(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return &Schema{}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		return structSchema(t, seen)
+	}
+	return &Schema{}
+}
+
+// structSchema returns the object schema of a struct type.
+func structSchema(t reflect.Type, seen map[reflect.Type]bool) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := schemaFor(field.Type, seen)
+		if applyRules(prop, field.Tag.Get("validate")) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+
+	return s
+}
+
+// applyRules narrows s with the rules of a `validate` tag and reports
+// whether the tag makes the field required. Rules following "dive" apply
+// to the items of arrays and the values of maps.
+func applyRules(s *Schema, tag string) (required bool) {
+	if tag == "" {
+		return false
+	}
+
+	rules := strings.Split(tag, ",")
+	for i, rule := range rules {
+		if rule == "dive" {
+			target := s.Items
+			if target == nil {
+				target = s.AdditionalProperties
+			}
+			if target != nil {
+				applyRules(target, strings.Join(rules[i+1:], ","))
+			}
+			return required
+		}
+
+		if rule == "required" {
+			required = true
+			switch {
+			case s.Type == "string" && s.MinLength == nil:
+				s.MinLength = ptr(1)
+			case s.Type == "array" && s.MinItems == nil:
+				s.MinItems = ptr(1)
+			}
+			continue
+		}
+
+		if alternatives := strings.Split(rule, "|"); len(alternatives) > 1 {
+			for _, alt := range alternatives {
+				sub := &Schema{}
+				applyRule(sub, s.Type, alt)
+				s.AnyOf = append(s.AnyOf, sub)
+			}
+			continue
+		}
+
+		applyRule(s, s.Type, rule)
+	}
+	return required
+}
+
+// applyRule narrows s with a single validation rule for a value of the
+// given JSON type.
+func applyRule(s *Schema, typ, rule string) {
+	name, param, _ := strings.Cut(rule, "=")
+
+	if format, ok := formats[name]; ok {
+		s.Format = format
+		return
+	}
+
+	if s.duration {
+		return
+	}
+
+	switch name {
+	case "ip":
+		s.AnyOf = append(s.AnyOf, &Schema{Format: "ipv4"}, &Schema{Format: "ipv6"})
+	case "oneof":
+		for _, v := range strings.Fields(param) {
+			s.Enum = append(s.Enum, enumValue(typ, v))
+		}
+	case "min", "gte":
+		setBound(s, typ, param, &s.Minimum, &s.MinLength, &s.MinItems)
+	case "max", "lte":
+		setBound(s, typ, param, &s.Maximum, &s.MaxLength, &s.MaxItems)
+	case "len":
+		setBound(s, typ, param, &s.Minimum, &s.MinLength, &s.MinItems)
+		setBound(s, typ, param, &s.Maximum, &s.MaxLength, &s.MaxItems)
+	case "gt":
+		if f, err := strconv.ParseFloat(param, 64); err == nil && isNumeric(typ) {
+			s.ExclusiveMinimum = &f
+		}
+	case "lt":
+		if f, err := strconv.ParseFloat(param, 64); err == nil && isNumeric(typ) {
+			s.ExclusiveMaximum = &f
+		}
+	case "alphanum":
+		s.Pattern = "^[a-zA-Z0-9]+$"
+	case "numeric":
+		s.Pattern = `^[-+]?[0-9]+(\.[0-9]+)?$`
+	case "semver":
+		s.Pattern = `^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`
+	case "loglevel":
+		for _, level := range []string{"debug", "info", "warn", "error", "dpanic", "panic", "fatal"} {
+			s.Enum = append(s.Enum, level)
+		}
+	}
+}
+
+// setBound sets the numeric, length, or item-count bound matching typ.
+func setBound(s *Schema, typ, param string, number **float64, length, items **int) {
+	switch {
+	case isNumeric(typ):
+		if f, err := strconv.ParseFloat(param, 64); err == nil {
+			*number = &f
+		}
+	case typ == "string":
+		if n, err := strconv.Atoi(param); err == nil {
+			*length = &n
+		}
+	case typ == "array":
+		if n, err := strconv.Atoi(param); err == nil {
+			*items = &n
+		}
+	}
+}
+
+// isNumeric reports whether typ is a JSON number type.
+func isNumeric(typ string) bool {
+	return typ == "integer" || typ == "number"
+}
+
+// enumValue converts a oneof value to the JSON type of the field.
+func enumValue(typ, v string) any {
+	switch typ {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+// ptr returns a pointer to v.
+func ptr[T any](v T) *T {
+	return &v
+}
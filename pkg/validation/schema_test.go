@@ -0,0 +1,46 @@
+package validation
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestJSONSchema(t *testing.T) {
+	type limits struct {
+		Burst int `json:"burst" validate:"gte=1,lte=100"`
+	}
+	type settings struct {
+		Name     string            `json:"name" validate:"required,alphanum"`
+		Mode     string            `json:"mode" validate:"oneof=fast safe"`
+		Endpoint string            `json:"endpoint" validate:"omitempty,url"`
+		Brokers  []string          `json:"brokers" validate:"required,dive,hostname_port|url"`
+		Timeout  time.Duration     `json:"timeout" validate:"min=1"`
+		Ports    []uint16          `json:"ports" validate:"max=3"`
+		Limits   map[string]limits `json:"limits"`
+		Internal string            `json:"-"`
+	}
+
+	got, err := json.Marshal(JSONSchema(settings{}, "Settings"))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want := `{"$schema":"https://json-schema.org/draft/2020-12/schema","title":"Settings","type":"object",` +
+		`"properties":{` +
+		`"brokers":{"type":"array","minItems":1,"items":{"type":"string","anyOf":[{},{"format":"uri"}]}},` +
+		`"endpoint":{"type":"string","format":"uri"},` +
+		`"limits":{"type":"object","additionalProperties":{"type":"object","properties":{"burst":{"type":"integer","minimum":1,"maximum":100}}}},` +
+		`"mode":{"type":"string","enum":["fast","safe"]},` +
+		`"name":{"type":"string","pattern":"^[a-zA-Z0-9]+$","minLength":1},` +
+		`"ports":{"type":"array","maxItems":3,"items":{"type":"integer","minimum":0}},` +
+		`"timeout":{"type":"string","pattern":"` + jsonEscape(durationPattern) + `"}` +
+		`},"required":["name","brokers"]}`
+	if string(got) != want {
+		t.Errorf("schema =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func jsonEscape(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b[1 : len(b)-1])
+}
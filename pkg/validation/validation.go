@@ -2,6 +2,7 @@
 package validation
 
 import (
+	"context"
 	"reflect"
 	"strings"
 
@@ -14,6 +15,9 @@ import (
 // Validate holds the settings and caches for validating request struct values.
 var validate *validator.Validate
 
+// Universal holds the locales error messages can be rendered in.
+var universal *ut.UniversalTranslator
+
 // Translator is a cache of locale and translation information.
 var translator ut.Translator
 
@@ -22,32 +26,107 @@ func init() {
 
 	// Create a translator for english so the error messages are
 	// more human readable than technical.
-	translator, _ = ut.New(en.New(), en.New()).GetTranslator("en")
+	universal = ut.New(en.New(), en.New())
+	translator, _ = universal.GetTranslator("en")
 
 	// Register the english error messages for use.
 	en_translations.RegisterDefaultTranslations(validate, translator)
 
-	// Use JSON tag names for errors instead of Go struct names.
+	// Use JSON tag names for errors instead of Go struct names, or the
+	// request binding tags of fields not in the body.
 	validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
 		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
+		if name != "" && name != "-" {
+			return name
+		}
+		for _, key := range []string{"path", "query", "form"} {
+			if source := fld.Tag.Get(key); source != "" && source != "-" {
+				return source
+			}
+		}
 		if name == "-" {
 			return ""
 		}
 		return name
 	})
+
+	// Register tags whose behaviour depends on the deployment environment.
+	validate.RegisterValidationCtx("required_if_production", requiredIfProduction, true)
+	validate.RegisterValidationCtx("excluded_if_production", excludedIfProduction, true)
+
+	// Register the infrastructure validators used by configuration structs.
+	registerBuiltins()
+}
+
+// Register adds a custom validation tag (e.g., "iban", "slug") usable in
+// `validate` struct tags. Like all registration functions in this package it
+// is not safe for concurrent use and should be called during program
+// initialization, before any validation runs.
+func Register(tag string, fn validator.Func) error {
+	return validate.RegisterValidation(tag, fn)
+}
+
+// RegisterStructValidation adds a struct-level validation for the given
+// types, used for rules spanning several fields.
+func RegisterStructValidation(fn validator.StructLevelFunc, types ...any) {
+	validate.RegisterStructValidation(fn, types...)
+}
+
+// environmentKey is the context key holding the deployment environment.
+type environmentKey struct{}
+
+// WithEnvironment returns a copy of ctx carrying the deployment environment
+// (e.g., "production") used by environment-aware tags.
+func WithEnvironment(ctx context.Context, env string) context.Context {
+	return context.WithValue(ctx, environmentKey{}, strings.ToLower(env))
+}
+
+// requiredIfProduction behaves like "required" when the environment stored
+// in the validation context is production and passes otherwise.
+func requiredIfProduction(ctx context.Context, fl validator.FieldLevel) bool {
+	env, _ := ctx.Value(environmentKey{}).(string)
+	if env != "production" {
+		return true
+	}
+
+	field := fl.Field()
+	switch field.Kind() {
+	case reflect.Slice, reflect.Map:
+		return field.Len() > 0
+	default:
+		return !field.IsZero()
+	}
+}
+
+// excludedIfProduction fails when the environment stored in the validation
+// context is production and the field is set, for development aids such as
+// automatic migrations.
+func excludedIfProduction(ctx context.Context, fl validator.FieldLevel) bool {
+	env, _ := ctx.Value(environmentKey{}).(string)
+	return env != "production" || fl.Field().IsZero()
 }
 
 // Check validates the provided model against it's declared tags.
 func Check(val any) error {
-	if err := validate.Struct(val); err != nil {
+	return CheckCtx(context.Background(), val)
+}
+
+// CheckCtx validates the provided model against it's declared tags, making
+// ctx available to context-aware tags such as "required_if_production".
+// Error messages are rendered with the catalog set with WithCatalog, or in
+// the language set with WithLanguage.
+func CheckCtx(ctx context.Context, val any) error {
+	if err := validate.StructCtx(ctx, val); err != nil {
 		vErrors, ok := err.(validator.ValidationErrors)
 		if !ok {
 			return err
 		}
 
+		tr := translationFromContext(ctx)
+
 		fields := make(FieldErrors, len(vErrors))
 		for i, vError := range vErrors {
-			field := FieldError{Field: vError.Field(), Err: vError.Translate(translator)}
+			field := FieldError{Field: fieldPath(vError), Err: message(vError, tr)}
 			fields[i] = field
 		}
 
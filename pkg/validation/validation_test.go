@@ -0,0 +1,163 @@
+package validation
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+type server struct {
+	Listen  string        `json:"listen" validate:"required,listen_addr"`
+	Timeout time.Duration `json:"timeout" validate:"duration_min=1s,duration_max=1m"`
+	Webhook string        `json:"webhook" validate:"omitempty,url_scheme=https"`
+	Level   string        `json:"level" validate:"omitempty,loglevel"`
+	Secret  string        `json:"secret" validate:"required_if_production"`
+	Debug   bool          `json:"debug" validate:"excluded_if_production"`
+	Tags    []string      `json:"tags" validate:"max=2,dive,min=2"`
+	DB      *database     `json:"db" validate:"required"`
+}
+
+type database struct {
+	Host string `json:"host" validate:"required,hostname"`
+}
+
+// valid returns a server passing validation outside production.
+func valid() *server {
+	return &server{Listen: ":8080", Timeout: 5 * time.Second, DB: &database{Host: "db.internal"}}
+}
+
+func TestCheckCtx(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    string
+		modify func(s *server)
+		want   string // field: message; "" passes
+	}{
+		{name: "valid", modify: func(*server) {}},
+		{name: "listen host and port", modify: func(s *server) { s.Listen = "localhost:8080" }},
+		{name: "listen without port", modify: func(s *server) { s.Listen = "localhost" }, want: "listen: must be a valid listen address (host:port or :port)"},
+		{name: "listen bad port", modify: func(s *server) { s.Listen = ":70000" }, want: "listen: must be a valid listen address (host:port or :port)"},
+		{name: "duration too short", modify: func(s *server) { s.Timeout = time.Millisecond }, want: "timeout: must be at least 1s"},
+		{name: "duration too long", modify: func(s *server) { s.Timeout = time.Hour }, want: "timeout: must be at most 1m"},
+		{name: "url scheme", modify: func(s *server) { s.Webhook = "http://example.com/hook" }, want: "webhook: must be a URL with scheme https"},
+		{name: "url without host", modify: func(s *server) { s.Webhook = "https:///hook" }, want: "webhook: must be a URL with scheme https"},
+		{name: "log level", modify: func(s *server) { s.Level = "verbose" }, want: "level: must be one of: debug info warn error dpanic panic fatal"},
+		{name: "log level any case", modify: func(s *server) { s.Level = "WARN" }},
+		{name: "too many items", modify: func(s *server) { s.Tags = []string{"ab", "cd", "ef"} }, want: "tags: must contain at most 2 items"},
+		{name: "item too short", modify: func(s *server) { s.Tags = []string{"ab", "c"} }, want: "tags[1]: must be at least 2 characters long"},
+		{name: "nested", modify: func(s *server) { s.DB.Host = "-bad-" }, want: "db.host: must be a valid hostname"},
+		{name: "missing nested", modify: func(s *server) { s.DB = nil }, want: "db: is required"},
+		{name: "secret in development", modify: func(*server) {}, env: "development"},
+		{name: "secret in production", env: "production", modify: func(*server) {}, want: "secret: is required in production"},
+		{name: "debug in production", env: "production", modify: func(s *server) { s.Secret, s.Debug = "x", true }, want: "debug: must not be set in production"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid()
+			tt.modify(s)
+			ctx := context.Background()
+			if tt.env != "" {
+				ctx = WithEnvironment(ctx, tt.env)
+			}
+
+			err := CheckCtx(ctx, s)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("CheckCtx = %v, want it to pass", err)
+				}
+				return
+			}
+			fields := AsFieldErrors(err)
+			if len(fields) != 1 {
+				t.Fatalf("CheckCtx = %v, want one field error", err)
+			}
+			if got := fields[0].Field + ": " + fields[0].Err; got != tt.want {
+				t.Errorf("error = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageLanguage(t *testing.T) {
+	RegisterLocaleMessage("xx", "required", "xx-required")
+	RegisterLocaleMessage("xx", "min.string", "xx-min {param}")
+
+	type input struct {
+		Name  string `json:"name" validate:"required"`
+		Code  string `json:"code" validate:"min=3"`
+		Email string `json:"email" validate:"omitempty,email"`
+	}
+	in := &input{Code: "a", Email: "nope"}
+	catalog := func(key string) (string, bool) {
+		if key == "min.string" {
+			return "catalog-min {param}", true
+		}
+		return "", false
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{name: "default", ctx: context.Background(), want: "is required|must be at least 3 characters long|must be a valid email address"},
+		{name: "registered locale", ctx: WithLanguage(context.Background(), "xx-YY, en;q=0.5"), want: "xx-required|xx-min 3|must be a valid email address"},
+		{name: "unknown locale", ctx: WithLanguage(context.Background(), "zz"), want: "is required|must be at least 3 characters long|must be a valid email address"},
+		{
+			name: "catalog first",
+			ctx:  WithCatalog(WithLanguage(context.Background(), "xx"), catalog),
+			want: "xx-required|catalog-min 3|must be a valid email address",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, fe := range AsFieldErrors(CheckCtx(tt.ctx, in)) {
+				got = append(got, fe.Err)
+			}
+			if strings.Join(got, "|") != tt.want {
+				t.Errorf("messages = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	got := strings.Join(parseAcceptLanguage("de-CH, en;q=0.5, fr-fr;q=0.8"), " ")
+	if want := "de_CH de fr_fr fr en en"; got != want {
+		t.Errorf("parseAcceptLanguage = %q, want %q", got, want)
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	type pool struct {
+		Size int `json:"size" warn:"max=50" warnmsg:"is unusually large"`
+	}
+	type settings struct {
+		Retries int              `json:"retries" warn:"max=5"`
+		Primary *pool            `json:"primary"`
+		Shards  map[string]*pool `json:"shards"`
+		Ignored *pool            `json:"-"`
+	}
+	s := &settings{
+		Retries: 10,
+		Primary: &pool{Size: 100},
+		Shards:  map[string]*pool{"b": {Size: 60}, "a": {Size: 10}},
+		Ignored: &pool{Size: 100},
+	}
+
+	var got []string
+	for _, w := range Warnings(context.Background(), s) {
+		got = append(got, w.Field+": "+w.Err)
+	}
+	want := "retries: must be at most 5|primary.size: is unusually large|shards.b.size: is unusually large"
+	if strings.Join(got, "|") != want {
+		t.Errorf("Warnings = %q, want %q", got, want)
+	}
+
+	fields, err := CheckWithWarnings(context.Background(), s)
+	if err != nil || len(fields) != 3 {
+		t.Errorf("CheckWithWarnings = %v, %v; want 3 warnings and no error", fields, err)
+	}
+}
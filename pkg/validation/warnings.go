@@ -0,0 +1,100 @@
+package validation
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Warnings evaluates the `warn` struct tags of val, a second severity of
+// checks that flag suspicious but acceptable values. A `warn` tag takes the
+// same rules as `validate` (e.g., `warn:"max=200"`); the optional `warnmsg`
+// tag replaces the generated message (e.g., `warnmsg:"is unusually high"`).
+// Nested structs, pointers, and maps of structs are walked, and fields are
+// named by their JSON path as in Check. Warnings never fail validation; it
+// is up to the caller to log them.
+func Warnings(ctx context.Context, val any) FieldErrors {
+	var warnings FieldErrors
+	collectWarnings(ctx, "", reflect.ValueOf(val), translationFromContext(ctx), &warnings)
+	return warnings
+}
+
+// CheckWithWarnings validates val like CheckCtx and also returns the
+// warnings produced by its `warn` tags, so both can be reported at once.
+func CheckWithWarnings(ctx context.Context, val any) (FieldErrors, error) {
+	return Warnings(ctx, val), CheckCtx(ctx, val)
+}
+
+// collectWarnings walks v, appending a FieldError for every failing `warn`
+// tag found below the JSON path prefix.
+func collectWarnings(ctx context.Context, prefix string, v reflect.Value, tr translation, out *FieldErrors) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			path := joinPath(prefix, name)
+
+			if rule := field.Tag.Get("warn"); rule != "" {
+				if err := validate.VarCtx(ctx, v.Field(i).Interface(), rule); err != nil {
+					*out = append(*out, warning(path, field.Tag.Get("warnmsg"), err, tr))
+				}
+			}
+
+			collectWarnings(ctx, path, v.Field(i), tr, out)
+		}
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			collectWarnings(ctx, joinPath(prefix, key.String()), v.MapIndex(key), tr, out)
+		}
+	}
+}
+
+// warning builds the FieldError for a failed `warn` rule.
+func warning(path, custom string, err error, tr translation) FieldError {
+	if custom != "" {
+		return FieldError{Field: path, Err: custom}
+	}
+
+	vErrors, ok := err.(validator.ValidationErrors)
+	if !ok || len(vErrors) == 0 {
+		return FieldError{Field: path, Err: err.Error()}
+	}
+	return FieldError{Field: path, Err: message(vErrors[0], tr)}
+}
+
+// joinPath joins JSON path segments with ".".
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,212 @@
+// Package versioning routes requests to API versions, by path prefix or
+// by the API-Version header, and announces the retirement of old versions
+// with the Deprecation (RFC 9745) and Sunset (RFC 8594) headers:
+//
+//	versions, err := versioning.NewRegistry(reg,
+//		versioning.Version{Name: "v1", Deprecated: v1Deprecated, Sunset: v1Sunset, Link: "https://docs.example.com/migrate-v2"},
+//		versioning.Version{Name: "v2", Default: true},
+//	)
+//
+//	v1 := router.Group("/api/v1", versions.Named("v1"))
+//	v2 := router.Group("/api/v2", versions.Named("v2"))
+//
+//	// Or one path for every version, chosen by the API-Version header:
+//	router.Handle("GET /api/orders", versions.Dispatch(map[string]http.Handler{"v1": listV1, "v2": listV2}))
+//
+// Requests are counted per version in http_requests_api_version_total, so
+// the traffic left on a deprecated version is known before it is removed.
+package versioning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	apperrors "github.com/iamBelugaa/go-boilerplate/pkg/errors"
+	"github.com/iamBelugaa/go-boilerplate/pkg/middleware"
+)
+
+// NameAPIVersion names the version middleware in route introspection.
+const NameAPIVersion = "api_version"
+
+// HeaderVersion is the request header selecting a version for Dispatch,
+// and the response header naming the version that served the request.
+const HeaderVersion = "API-Version"
+
+// Version is an API version.
+type Version struct {
+	// Name identifies the version (e.g., "v2").
+	Name string
+
+	// Default makes the version serve Dispatch requests without an
+	// API-Version header; otherwise the last registered version does.
+	Default bool
+
+	// Deprecated is when the version was, or will be, deprecated; zero if
+	// it is not.
+	Deprecated time.Time
+
+	// Sunset is when the version stops being served; requests after it
+	// are answered 410 Gone. Zero if it is not scheduled.
+	Sunset time.Time
+
+	// Link points to documentation of the deprecation, such as a
+	// migration guide.
+	Link string
+}
+
+// versionKey is the context key of the version serving a request.
+type versionKey struct{}
+
+// FromContext returns the name of the API version serving the request, or
+// "" outside versioned routes.
+func FromContext(ctx context.Context) string {
+	v, _ := ctx.Value(versionKey{}).(string)
+	return v
+}
+
+// Registry holds the versions of an API.
+type Registry struct {
+	versions map[string]*Version
+	names    []string
+	fallback string
+	requests *prometheus.CounterVec
+	now      func() time.Time
+}
+
+// NewRegistry constructs a Registry of versions, registering its metrics
+// with reg.
+func NewRegistry(reg prometheus.Registerer, versions ...Version) (*Registry, error) {
+	if len(versions) == 0 {
+		return nil, errors.New("versioning: no versions")
+	}
+
+	r := &Registry{
+		versions: make(map[string]*Version, len(versions)),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_api_version_total",
+			Help: "HTTP requests served, by API version and whether it is deprecated.",
+		}, []string{"version", "deprecated"}),
+		now: time.Now,
+	}
+	for _, v := range versions {
+		if v.Name == "" {
+			return nil, errors.New("versioning: version without a name")
+		}
+		if _, ok := r.versions[v.Name]; ok {
+			return nil, fmt.Errorf("versioning: version %q registered twice", v.Name)
+		}
+		if v.Default {
+			if r.fallback != "" {
+				return nil, fmt.Errorf("versioning: versions %q and %q are both the default", r.fallback, v.Name)
+			}
+			r.fallback = v.Name
+		}
+		r.versions[v.Name] = &v
+		r.names = append(r.names, v.Name)
+	}
+	if r.fallback == "" {
+		r.fallback = r.names[len(r.names)-1]
+	}
+
+	if err := reg.Register(r.requests); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Versions returns the registered versions in registration order.
+func (r *Registry) Versions() []Version {
+	out := make([]Version, len(r.names))
+	for i, name := range r.names {
+		out[i] = *r.versions[name]
+	}
+	return out
+}
+
+// Middleware serves requests as version name: it records the version in
+// the context and the API-Version response header, counts the request,
+// announces deprecation and sunset, and answers 410 Gone after the
+// sunset, with errors.Write. It panics on unknown versions, as they are programming errors.
+func (r *Registry) Middleware(name string) func(http.Handler) http.Handler {
+	v := r.version(name)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			r.serve(v, next, w, req)
+		})
+	}
+}
+
+// Named returns Middleware for a router group or route.
+func (r *Registry) Named(name string) middleware.Named {
+	return middleware.Named{Name: NameAPIVersion, Wrap: r.Middleware(name)}
+}
+
+// Dispatch serves requests with the handler of the version named by their
+// API-Version header, or of the default version when they have none, like
+// Middleware. Requests naming a version that is not registered, or has no
+// handler, are answered 400 Bad Request with errors.Write. It panics when handlers names an
+// unknown version.
+func (r *Registry) Dispatch(handlers map[string]http.Handler) http.Handler {
+	for name := range handlers {
+		r.version(name)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		name := strings.TrimSpace(req.Header.Get(HeaderVersion))
+		if name == "" {
+			name = r.fallback
+		}
+		h, ok := handlers[name]
+		if !ok {
+			w.Header().Add("Vary", HeaderVersion)
+			apperrors.Write(w, req, apperrors.Invalid(fmt.Sprintf("unsupported API version %q", name)).
+				WithCode("unsupported_version").With("version", name))
+			return
+		}
+		w.Header().Add("Vary", HeaderVersion)
+		r.serve(r.versions[name], h, w, req)
+	})
+}
+
+// serve serves req with next as version v.
+func (r *Registry) serve(v *Version, next http.Handler, w http.ResponseWriter, req *http.Request) {
+	now := r.now()
+	deprecated := !v.Deprecated.IsZero() && !now.Before(v.Deprecated)
+	r.requests.WithLabelValues(v.Name, strconv.FormatBool(deprecated)).Inc()
+
+	h := w.Header()
+	h.Set(HeaderVersion, v.Name)
+	if !v.Deprecated.IsZero() {
+		h.Set("Deprecation", "@"+strconv.FormatInt(v.Deprecated.Unix(), 10))
+		if v.Link != "" {
+			h.Add("Link", "<"+v.Link+`>; rel="deprecation"; type="text/html"`)
+		}
+	}
+	if !v.Sunset.IsZero() {
+		h.Set("Sunset", v.Sunset.UTC().Format(http.TimeFormat))
+		if !now.Before(v.Sunset) {
+			sunset := v.Sunset.UTC().Format(time.DateOnly)
+			apperrors.Write(w, req, apperrors.Gone(fmt.Sprintf("API version %q was retired on %s", v.Name, sunset)).
+				WithCode("version_retired").With("version", v.Name).With("sunset", sunset))
+			return
+		}
+	}
+
+	next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), versionKey{}, v.Name)))
+}
+
+// version returns the version named name, panicking when it is unknown.
+func (r *Registry) version(name string) *Version {
+	v, ok := r.versions[name]
+	if !ok {
+		panic(fmt.Sprintf("versioning: unknown version %q", name))
+	}
+	return v
+}
@@ -0,0 +1,72 @@
+package versioning
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	apperrors "github.com/iamBelugaa/go-boilerplate/pkg/errors"
+)
+
+func TestDispatch(t *testing.T) {
+	sunset := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	versions, err := NewRegistry(prometheus.NewRegistry(),
+		Version{Name: "v1", Deprecated: sunset.AddDate(0, -6, 0), Sunset: sunset},
+		Version{Name: "v2", Default: true},
+		Version{Name: "v3"},
+	)
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	versions.now = func() time.Time { return sunset.Add(time.Hour) }
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(FromContext(r.Context())))
+	})
+	h := versions.Dispatch(map[string]http.Handler{"v1": ok, "v2": ok})
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantCode   string
+		wantBody   string
+	}{
+		{name: "default", wantStatus: http.StatusOK, wantBody: "v2"},
+		{name: "named", header: "v2", wantStatus: http.StatusOK, wantBody: "v2"},
+		{name: "unknown", header: "v9", wantStatus: http.StatusBadRequest, wantCode: "unsupported_version"},
+		{name: "no handler", header: "v3", wantStatus: http.StatusBadRequest, wantCode: "unsupported_version"},
+		{name: "after sunset", header: "v1", wantStatus: http.StatusGone, wantCode: "version_retired"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			if tt.header != "" {
+				r.Header.Set(HeaderVersion, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantCode == "" {
+				if rec.Body.String() != tt.wantBody {
+					t.Errorf("body = %q, want %q", rec.Body.String(), tt.wantBody)
+				}
+				return
+			}
+			var p apperrors.Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil || p.Code != tt.wantCode {
+				t.Errorf("problem = %s (%v), want code %q", rec.Body.String(), err, tt.wantCode)
+			}
+			if rec.Header().Get("Content-Type") != apperrors.ContentTypeProblem {
+				t.Errorf("Content-Type = %q, want a problem", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
@@ -0,0 +1,408 @@
+// Package bind fills a handler's input struct from every part of the
+// request in one call: path parameters, the query string, and a JSON or
+// form body, then validates it:
+//
+//	type listOrders struct {
+//		TenantID string        `path:"tenant" json:"-" validate:"required,uuid"`
+//		Status   []string      `query:"status" json:"-" validate:"dive,oneof=open paid"`
+//		Limit    int           `query:"limit" json:"-" default:"20" validate:"min=1,max=100"`
+//		Wait     time.Duration `query:"wait" json:"-" default:"0s"`
+//	}
+//
+//	var in listOrders
+//	if err := bind.Bind(r, &in); err != nil {
+//		respond.Error(w, r, err)
+//		return
+//	}
+//
+// Fields tagged path, query, or form take the named values; repeated query
+// or form values fill slices. Other fields are filled from a JSON body by
+// encoding/json, so parameters should be tagged json:"-" to keep clients
+// from setting them in the body too. Nested structs are bound recursively.
+// Failures are returned as *validation.RequestError with field-level errors.
+package bind
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/validation"
+)
+
+// DefaultMaxMemory is the part of a multipart body kept in memory; the rest
+// of its files are stored in temporary files.
+const DefaultMaxMemory int64 = 32 << 20
+
+// Value sources, named after their struct tags.
+const (
+	sourcePath  = "path"
+	sourceQuery = "query"
+	sourceForm  = "form"
+)
+
+// Bind fills dst, a pointer to a struct, from r: first the default tags,
+// then path parameters, the query string, and the body, later sources
+// overriding earlier ones. Bodies are limited to
+// validation.DefaultMaxBodyBytes; an empty body is allowed, required fields
+// being enforced by validation. The result is validated with
+// validation.CheckCtx in the language of the Accept-Language header.
+func Bind(r *http.Request, dst any) error {
+	return BindLimit(r, dst, validation.DefaultMaxBodyBytes)
+}
+
+// BindLimit is Bind with a custom body size limit, such as for endpoints
+// accepting file uploads.
+func BindLimit(r *http.Request, dst any, maxBytes int64) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind: destination must be a non-nil pointer to a struct, got %T", dst)
+	}
+	v := rv.Elem()
+	fields := plan(v.Type())
+
+	if err := applyDefaults(v, fields); err != nil {
+		return err
+	}
+
+	var errs validation.FieldErrors
+	errs = fill(v, fields, sourcePath, func(name string) ([]string, bool) {
+		value := r.PathValue(name)
+		return []string{value}, value != ""
+	}, errs)
+	query := r.URL.Query()
+	errs = fill(v, fields, sourceQuery, valuesOf(query), errs)
+
+	form, err := decodeBody(r, dst, maxBytes)
+	if err != nil {
+		return err
+	}
+	if form != nil {
+		errs = fill(v, fields, sourceForm, valuesOf(form.Value), errs)
+		errs = fillFiles(v, fields, form.File, errs)
+	}
+
+	if len(errs) > 0 {
+		return &validation.RequestError{Status: http.StatusBadRequest, Message: "request is invalid", Fields: errs}
+	}
+
+	ctx := validation.WithLanguage(r.Context(), r.Header.Get("Accept-Language"))
+	if err := validation.CheckCtx(ctx, dst); err != nil {
+		fields := validation.AsFieldErrors(err)
+		if fields == nil {
+			return err
+		}
+		return &validation.RequestError{Status: http.StatusBadRequest, Message: "request is invalid", Fields: fields}
+	}
+	return nil
+}
+
+// decodeBody decodes r's body into dst when it is JSON, or parses it when
+// it is a form, returning the form's values and files.
+func decodeBody(r *http.Request, dst any, maxBytes int64) (*multipart.Form, error) {
+	if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		return nil, nil
+	}
+
+	mediaType := "application/json"
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(ct); err != nil {
+			return nil, unsupportedMediaType()
+		}
+	}
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		err := validation.DecodeJSON(r, dst, maxBytes)
+		if errors.Is(err, io.EOF) {
+			// A body of unknown length turned out empty.
+			return nil, nil
+		}
+		return nil, err
+
+	case mediaType == "application/x-www-form-urlencoded":
+		r.Body = http.MaxBytesReader(nil, r.Body, maxBytes)
+		if err := r.ParseForm(); err != nil {
+			return nil, formError(err, maxBytes)
+		}
+		return &multipart.Form{Value: r.PostForm}, nil
+
+	case mediaType == "multipart/form-data":
+		r.Body = http.MaxBytesReader(nil, r.Body, maxBytes)
+		if err := r.ParseMultipartForm(min(maxBytes, DefaultMaxMemory)); err != nil {
+			return nil, formError(err, maxBytes)
+		}
+		return r.MultipartForm, nil
+
+	default:
+		return nil, unsupportedMediaType()
+	}
+}
+
+// unsupportedMediaType returns the error answering a body of another type.
+func unsupportedMediaType() error {
+	return &validation.RequestError{
+		Status:  http.StatusUnsupportedMediaType,
+		Message: "request body must be application/json, application/x-www-form-urlencoded, or multipart/form-data",
+	}
+}
+
+// formError converts a form parsing error into a *validation.RequestError.
+func formError(err error, maxBytes int64) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return &validation.RequestError{
+			Status:  http.StatusRequestEntityTooLarge,
+			Message: fmt.Sprintf("request body must not be larger than %d bytes", maxBytes),
+			Err:     err,
+		}
+	}
+	return &validation.RequestError{Status: http.StatusBadRequest, Message: "request body contains a malformed form", Err: err}
+}
+
+// valuesOf looks names up in values, reporting absent ones.
+func valuesOf(values url.Values) func(string) ([]string, bool) {
+	return func(name string) ([]string, bool) {
+		vs, ok := values[name]
+		return vs, ok && len(vs) > 0
+	}
+}
+
+// field is a bindable struct field.
+type field struct {
+	index  []int
+	source string
+	name   string
+	def    string
+	hasDef bool
+}
+
+// plans caches the fields of the types bound so far.
+var plans sync.Map // map[reflect.Type][]field
+
+// plan returns the bindable fields of t, including those of nested structs.
+func plan(t reflect.Type) []field {
+	if fields, ok := plans.Load(t); ok {
+		return fields.([]field)
+	}
+	fields := collect(t, nil)
+	plans.Store(t, fields)
+	return fields
+}
+
+// collect returns the bindable fields of t, whose index is prefixed by
+// index.
+func collect(t reflect.Type, index []int) []field {
+	var fields []field
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		idx := append(append([]int(nil), index...), i)
+
+		f := field{index: idx}
+		f.def, f.hasDef = sf.Tag.Lookup("default")
+		for _, source := range []string{sourcePath, sourceQuery, sourceForm} {
+			if name := sf.Tag.Get(source); name != "" && name != "-" {
+				f.source, f.name = source, name
+				break
+			}
+		}
+
+		if f.source == "" && sf.Type.Kind() == reflect.Struct && !isScalar(sf.Type) {
+			fields = append(fields, collect(sf.Type, idx)...)
+			continue
+		}
+		if f.source != "" || f.hasDef {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// applyDefaults sets the fields of v with a default tag that are zero.
+func applyDefaults(v reflect.Value, fields []field) error {
+	for _, f := range fields {
+		if !f.hasDef {
+			continue
+		}
+		fv := v.FieldByIndex(f.index)
+		if !fv.IsZero() {
+			continue
+		}
+		values := []string{f.def}
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+			values = strings.Split(f.def, ",")
+		}
+		if err := set(fv, values); err != nil {
+			return fmt.Errorf("bind: field %s: invalid default %q: %w", v.Type().FieldByIndex(f.index).Name, f.def, err)
+		}
+	}
+	return nil
+}
+
+// fill sets the fields of v bound to source from lookup, appending
+// conversion failures to errs.
+func fill(v reflect.Value, fields []field, source string, lookup func(string) ([]string, bool), errs validation.FieldErrors) validation.FieldErrors {
+	for _, f := range fields {
+		if f.source != source || isFile(v.Type().FieldByIndex(f.index).Type) {
+			continue
+		}
+		values, ok := lookup(f.name)
+		if !ok {
+			continue
+		}
+		if err := set(v.FieldByIndex(f.index), values); err != nil {
+			errs = append(errs, validation.FieldError{Field: f.name, Err: err.Error()})
+		}
+	}
+	return errs
+}
+
+// Types of uploaded file fields.
+var (
+	fileHeaderType  = reflect.TypeFor[*multipart.FileHeader]()
+	fileHeadersType = reflect.TypeFor[[]*multipart.FileHeader]()
+)
+
+// isFile reports whether t holds uploaded files.
+func isFile(t reflect.Type) bool {
+	return t == fileHeaderType || t == fileHeadersType
+}
+
+// fillFiles sets the form fields of v holding uploaded files.
+func fillFiles(v reflect.Value, fields []field, files map[string][]*multipart.FileHeader, errs validation.FieldErrors) validation.FieldErrors {
+	for _, f := range fields {
+		if f.source != sourceForm {
+			continue
+		}
+		fhs := files[f.name]
+		if len(fhs) == 0 {
+			continue
+		}
+		fv := v.FieldByIndex(f.index)
+		switch fv.Type() {
+		case fileHeaderType:
+			if len(fhs) > 1 {
+				errs = append(errs, validation.FieldError{Field: f.name, Err: "must be a single file"})
+				continue
+			}
+			fv.Set(reflect.ValueOf(fhs[0]))
+		case fileHeadersType:
+			fv.Set(reflect.ValueOf(fhs))
+		}
+	}
+	return errs
+}
+
+// Types converted specially.
+var (
+	durationType        = reflect.TypeFor[time.Duration]()
+	timeType            = reflect.TypeFor[time.Time]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// isScalar reports whether t is set from a single value despite being a
+// struct.
+func isScalar(t reflect.Type) bool {
+	return t == timeType || reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// set converts values and stores them in v: all of them for slices, the
+// last one otherwise. Its errors are phrased for clients.
+func set(v reflect.Value, values []string) error {
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 && !isScalar(v.Type()) {
+		s := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setOne(s.Index(i), value); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+	return setOne(v, values[len(values)-1])
+}
+
+// setOne converts value and stores it in v.
+func setOne(v reflect.Value, value string) error {
+	if v.Kind() == reflect.Pointer {
+		elem := reflect.New(v.Type().Elem())
+		if err := setOne(elem.Elem(), value); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := u.UnmarshalText([]byte(value)); err != nil {
+			return errors.New("is invalid")
+		}
+		return nil
+	}
+
+	switch v.Type() {
+	case durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return errors.New("must be a duration (e.g., 1m30s)")
+		}
+		v.SetInt(int64(d))
+		return nil
+	case timeType:
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return errors.New("must be an RFC 3339 time (e.g., 2006-01-02T15:04:05Z)")
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("must be a boolean")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("must be a non-negative integer")
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		// []byte takes the raw value.
+		v.SetBytes([]byte(value))
+	default:
+		return fmt.Errorf("cannot be bound to %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+// Package fields shapes response payloads before they are encoded, so one
+// handler and DTO can serve several audiences.
+//
+// Struct fields may declare the scopes required to see them:
+//
+//	type User struct {
+//		ID    string `json:"id"`
+//		Email string `json:"email" scope:"users:read-pii,admin"`
+//	}
+//
+// A field tagged with scope is only kept when the caller holds at least one
+// of the listed scopes.
+//
+// Clients may also request a subset of fields with "?fields=id,owner.email"
+// to reduce payload sizes; see Selection.
+package fields
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// HasScope reports whether the caller holds scope.
+type HasScope func(scope string) bool
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// Authorize returns a JSON-equivalent copy of v with every field the caller
+// is not allowed to see removed. Values without scoped fields are returned
+// unchanged.
+func Authorize(v any, hasScope HasScope) any {
+	if v == nil || !hasScopedFields(reflect.TypeOf(v), make(map[reflect.Type]bool)) {
+		return v
+	}
+	return walk(reflect.ValueOf(v), hasScope)
+}
+
+// walk converts v into maps, slices, and scalars, dropping unauthorized fields.
+func walk(v reflect.Value, hasScope HasScope) any {
+	if !v.IsValid() {
+		return nil
+	}
+
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return walk(v.Elem(), hasScope)
+
+	case reflect.Struct:
+		out := make(map[string]any)
+		walkStruct(v, hasScope, out)
+		return out
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = walk(v.Index(i), hasScope)
+		}
+		return out
+
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		out := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[mapKey(iter.Key())] = walk(iter.Value(), hasScope)
+		}
+		return out
+
+	default:
+		return v.Interface()
+	}
+}
+
+// walkStruct copies the visible, authorized fields of v into out.
+func walkStruct(v reflect.Value, hasScope HasScope, out map[string]any) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		if scopes := field.Tag.Get("scope"); scopes != "" && !anyScope(scopes, hasScope) {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		fv := v.Field(i)
+		if field.Anonymous && name == "" {
+			inner := fv
+			if inner.Kind() == reflect.Pointer {
+				if inner.IsNil() {
+					continue
+				}
+				inner = inner.Elem()
+			}
+			if inner.Kind() == reflect.Struct {
+				walkStruct(inner, hasScope, out)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(opts, "omitempty") && isEmpty(fv) {
+			continue
+		}
+
+		out[name] = walk(fv, hasScope)
+	}
+}
+
+// hasScopedFields reports whether t, or any type reachable from it, has a
+// field tagged with scope.
+func hasScopedFields(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return hasScopedFields(t.Elem(), seen)
+	case reflect.Interface:
+		return true
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.Tag.Get("scope") != "" || hasScopedFields(f.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// anyScope reports whether the caller holds one of the comma-separated scopes.
+func anyScope(scopes string, hasScope HasScope) bool {
+	if hasScope == nil {
+		return false
+	}
+	for _, s := range strings.Split(scopes, ",") {
+		if hasScope(strings.TrimSpace(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmpty mirrors encoding/json's omitempty semantics.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+// mapKey renders a map key the way encoding/json does.
+func mapKey(k reflect.Value) string {
+	if k.Kind() == reflect.String {
+		return k.String()
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		b, _ := tm.MarshalText()
+		return string(b)
+	}
+	b, _ := json.Marshal(k.Interface())
+	return strings.Trim(string(b), `"`)
+}
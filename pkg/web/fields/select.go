@@ -0,0 +1,110 @@
+package fields
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Selection is a parsed field selection such as "id,name,owner.email".
+// Nested objects are selected with dot-separated paths; selecting a parent
+// (e.g., "owner") keeps the whole object.
+type Selection map[string]Selection
+
+// QueryParam is the query parameter clients use to select response fields.
+const QueryParam = "fields"
+
+// Parse parses a comma-separated list of dot-separated field paths.
+// An empty spec yields a nil Selection, which selects everything.
+func Parse(spec string) (Selection, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	sel := make(Selection)
+	for _, path := range strings.Split(spec, ",") {
+		node := sel
+		parts := strings.Split(strings.TrimSpace(path), ".")
+		for i, part := range parts {
+			if part == "" {
+				return nil, fmt.Errorf("fields: invalid field path %q", path)
+			}
+
+			child, seen := node[part]
+			switch {
+			case i == len(parts)-1:
+				// Selecting a whole object overrides any narrower selection.
+				node[part] = nil
+			case seen && child == nil:
+				// The whole object is already selected.
+				node = nil
+			default:
+				if child == nil {
+					child = make(Selection)
+					node[part] = child
+				}
+				node = child
+			}
+
+			if node == nil {
+				break
+			}
+		}
+	}
+	return sel, nil
+}
+
+// FromRequest parses the selection in the request's "fields" query parameter.
+func FromRequest(r *http.Request) (Selection, error) {
+	return Parse(r.URL.Query().Get(QueryParam))
+}
+
+// Apply returns the JSON representation of v reduced to the selected fields.
+// Arrays are reduced element by element. A nil Selection returns v as is.
+func (s Selection) Apply(v any) (any, error) {
+	if s == nil {
+		return v, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return s.prune(doc), nil
+}
+
+// prune removes every unselected key from doc.
+func (s Selection) prune(doc any) any {
+	if s == nil {
+		return doc
+	}
+
+	switch v := doc.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(s))
+		for key, child := range s {
+			if val, ok := v[key]; ok {
+				out[key] = child.prune(val)
+			}
+		}
+		return out
+	case []any:
+		for i := range v {
+			v[i] = s.prune(v[i])
+		}
+		return v
+	default:
+		return doc
+	}
+}
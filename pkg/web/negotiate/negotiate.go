@@ -0,0 +1,112 @@
+// Package negotiate picks the representation of a response from the
+// request's Accept header (RFC 9110 section 12.5.1), and reads the
+// languages of its Accept-Language header (section 12.5.4).
+package negotiate
+
+import (
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Best returns the offer preferred by the request's Accept header, or
+// "" when none is acceptable, so the handler can answer 406 Not
+// Acceptable. Each offer takes the quality of the most specific range
+// matching it; ties go to the earlier offer. Without an Accept header, the
+// first offer is preferred.
+func Best(r *http.Request, offers ...string) string {
+	accept := strings.Join(r.Header.Values("Accept"), ",")
+	if strings.TrimSpace(accept) == "" {
+		if len(offers) == 0 {
+			return ""
+		}
+		return offers[0]
+	}
+
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, spec := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(spec))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q, specificity := 0.0, -1
+		for _, rng := range ranges {
+			if s := matches(rng.mediaType, offer); s > specificity {
+				q, specificity = rng.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// matches returns how specifically mediaRange matches offer: 2 for the
+// same type, 1 for "type/*", 0 for "*/*", and -1 for no match.
+func matches(mediaRange, offer string) int {
+	offerType, _, _ := strings.Cut(offer, "/")
+	rangeType, rangeSub, _ := strings.Cut(mediaRange, "/")
+	switch {
+	case strings.EqualFold(mediaRange, offer):
+		return 2
+	case rangeSub == "*" && strings.EqualFold(rangeType, offerType):
+		return 1
+	case mediaRange == "*/*":
+		return 0
+	}
+	return -1
+}
+
+// Languages returns the language tags of an Accept-Language header value
+// (e.g., "de-CH,de;q=0.9,en;q=0.8") in preference order, as written. Ties
+// keep their order; the "*" range and languages of quality 0 are left
+// out.
+func Languages(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var prefs []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			prefs = append(prefs, weighted{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+
+	tags := make([]string, len(prefs))
+	for i, p := range prefs {
+		tags[i] = p.tag
+	}
+	return tags
+}
@@ -0,0 +1,51 @@
+package negotiate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestBest(t *testing.T) {
+	offers := []string{"application/problem+json", "application/json"}
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{accept: "", want: "application/problem+json"},
+		{accept: "application/json", want: "application/json"},
+		{accept: "application/*;q=0.5, application/json", want: "application/json"},
+		{accept: "*/*", want: "application/problem+json"},
+		{accept: "text/html", want: ""},
+		{accept: "application/json;q=0, */*", want: "application/problem+json"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.accept != "" {
+			r.Header.Set("Accept", tt.accept)
+		}
+		if got := Best(r, offers...); got != tt.want {
+			t.Errorf("Best(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{header: "", want: []string{}},
+		{header: "de-CH", want: []string{"de-CH"}},
+		{header: "en;q=0.8, de-CH, de;q=0.9", want: []string{"de-CH", "de", "en"}},
+		{header: "fr;q=0.5, it;q=0.5", want: []string{"fr", "it"}},
+		{header: "*, pt-BR;q=0, es ; q=0.3", want: []string{"es"}},
+		{header: "nl;q=bogus", want: []string{"nl"}},
+	}
+	for _, tt := range tests {
+		if got := Languages(tt.header); !slices.Equal(got, tt.want) {
+			t.Errorf("Languages(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
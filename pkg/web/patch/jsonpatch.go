@@ -0,0 +1,303 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Operation is a single RFC 6902 JSON Patch operation.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// JSONPatch applies an RFC 6902 patch to dst, which must be a pointer. The
+// patch is applied atomically: if any operation fails, dst is unchanged.
+func JSONPatch(dst any, body []byte, allowed Allowed) error {
+	var ops []Operation
+	if err := decode(body, &ops); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+	}
+
+	for i, op := range ops {
+		if !strings.HasPrefix(op.Path, "/") {
+			return fmt.Errorf("%w: operation %d: invalid path %q", ErrInvalidPatch, i, op.Path)
+		}
+
+		switch op.Op {
+		case "test":
+			continue
+		case "move":
+			if !allowed.permits(op.From) {
+				return fmt.Errorf("%w: %s", ErrForbiddenPath, op.From)
+			}
+		case "add", "remove", "replace", "copy":
+		default:
+			return fmt.Errorf("%w: operation %d: unknown op %q", ErrInvalidPatch, i, op.Op)
+		}
+
+		if !allowed.permits(op.Path) {
+			return fmt.Errorf("%w: %s", ErrForbiddenPath, op.Path)
+		}
+	}
+
+	doc, err := toDocument(dst)
+	if err != nil {
+		return err
+	}
+
+	for i, op := range ops {
+		if doc, err = applyOp(doc, op); err != nil {
+			return fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+
+	return fromDocument(doc, dst)
+}
+
+// applyOp applies a single operation and returns the updated document.
+func applyOp(doc any, op Operation) (any, error) {
+	value := func() (any, error) {
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: missing value", ErrInvalidPatch)
+		}
+		var v any
+		err := decode(op.Value, &v)
+		return v, err
+	}
+
+	switch op.Op {
+	case "add":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		return set(doc, splitPointer(op.Path), v, true)
+
+	case "replace":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := get(doc, splitPointer(op.Path)); err != nil {
+			return nil, err
+		}
+		return set(doc, splitPointer(op.Path), v, false)
+
+	case "remove":
+		return remove(doc, splitPointer(op.Path))
+
+	case "move", "copy":
+		v, err := get(doc, splitPointer(op.From))
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if strings.HasPrefix(op.Path, op.From+"/") {
+				return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalidPatch)
+			}
+			if doc, err = remove(doc, splitPointer(op.From)); err != nil {
+				return nil, err
+			}
+		} else {
+			v = deepCopy(v)
+		}
+		return set(doc, splitPointer(op.Path), v, true)
+
+	case "test":
+		want, err := value()
+		if err != nil {
+			return nil, err
+		}
+		got, err := get(doc, splitPointer(op.Path))
+		if err != nil || !equal(got, want) {
+			return nil, ErrConflict
+		}
+		return doc, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+}
+
+// get returns the value at path.
+func get(doc any, path []string) (any, error) {
+	cur := doc
+	for _, seg := range path {
+		switch node := cur.(type) {
+		case map[string]any:
+			v, ok := node[seg]
+			if !ok {
+				return nil, fmt.Errorf("%w: path not found", ErrInvalidPatch)
+			}
+			cur = v
+		case []any:
+			idx, err := index(seg, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			cur = node[idx]
+		default:
+			return nil, fmt.Errorf("%w: path not found", ErrInvalidPatch)
+		}
+	}
+	return cur, nil
+}
+
+// set stores v at path. When insert is true, array elements are inserted
+// (with "-" appending) rather than replaced.
+func set(doc any, path []string, v any, insert bool) (any, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+
+	seg, rest := path[0], path[1:]
+	switch node := doc.(type) {
+	case map[string]any:
+		if len(rest) == 0 {
+			node[seg] = v
+			return node, nil
+		}
+		child, ok := node[seg]
+		if !ok {
+			return nil, fmt.Errorf("%w: path not found", ErrInvalidPatch)
+		}
+		updated, err := set(child, rest, v, insert)
+		if err != nil {
+			return nil, err
+		}
+		node[seg] = updated
+		return node, nil
+
+	case []any:
+		if len(rest) == 0 && insert {
+			idx := len(node)
+			if seg != "-" {
+				var err error
+				if idx, err = index(seg, len(node)); err != nil {
+					return nil, err
+				}
+			}
+			node = append(node, nil)
+			copy(node[idx+1:], node[idx:])
+			node[idx] = v
+			return node, nil
+		}
+
+		idx, err := index(seg, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) == 0 {
+			node[idx] = v
+			return node, nil
+		}
+		updated, err := set(node[idx], rest, v, insert)
+		if err != nil {
+			return nil, err
+		}
+		node[idx] = updated
+		return node, nil
+	}
+
+	return nil, fmt.Errorf("%w: path not found", ErrInvalidPatch)
+}
+
+// remove deletes the value at path.
+func remove(doc any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: cannot remove the document root", ErrInvalidPatch)
+	}
+
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	last := path[len(path)-1]
+	switch node := parent.(type) {
+	case map[string]any:
+		if _, ok := node[last]; !ok {
+			return nil, fmt.Errorf("%w: path not found", ErrInvalidPatch)
+		}
+		delete(node, last)
+		return doc, nil
+	case []any:
+		idx, err := index(last, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		return set(doc, path[:len(path)-1], append(node[:idx:idx], node[idx+1:]...), false)
+	}
+
+	return nil, fmt.Errorf("%w: path not found", ErrInvalidPatch)
+}
+
+// index parses an array index segment in the range [0, maxIdx].
+func index(seg string, maxIdx int) (int, error) {
+	idx, err := strconv.Atoi(seg)
+	if err != nil || idx < 0 || idx > maxIdx || (len(seg) > 1 && seg[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, seg)
+	}
+	return idx, nil
+}
+
+// equal compares two decoded JSON values, treating numbers numerically.
+func equal(a, b any) bool {
+	an, aok := a.(json.Number)
+	bn, bok := b.(json.Number)
+	if aok && bok {
+		af, _ := an.Float64()
+		bf, _ := bn.Float64()
+		return af == bf
+	}
+
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			if w, ok := bv[k]; !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		bv, ok := b.([]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !equal(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// deepCopy copies a decoded JSON value.
+func deepCopy(v any) any {
+	switch node := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(node))
+		for k, val := range node {
+			out[k] = deepCopy(val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(node))
+		for i, val := range node {
+			out[i] = deepCopy(val)
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,211 @@
+// Package patch applies partial updates to domain values using RFC 6902
+// JSON Patch and RFC 7386 JSON Merge Patch documents.
+//
+// Every path touched by a patch must be permitted by the caller's Allowed
+// list, so clients can only modify fields that the endpoint exposes for
+// update. Conflicts are detected with JSON Patch "test" operations, which
+// let clients assert the current value of a field before changing it.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"reflect"
+	"strings"
+)
+
+// Supported patch media types.
+const (
+	ContentTypeJSONPatch  = "application/json-patch+json"
+	ContentTypeMergePatch = "application/merge-patch+json"
+)
+
+// Set of error variables for applying patches.
+var (
+	ErrInvalidPatch     = errors.New("patch: invalid patch document")
+	ErrForbiddenPath    = errors.New("patch: path may not be modified")
+	ErrConflict         = errors.New("patch: test operation failed")
+	ErrUnsupportedMedia = errors.New("patch: unsupported content type")
+)
+
+// Allowed lists the JSON Pointer paths a patch may modify. A path is
+// permitted when it equals an entry or lies beneath it; the segment "*"
+// matches any single segment (e.g., "/addresses/*/city").
+type Allowed []string
+
+// permits reports whether pointer may be modified.
+func (a Allowed) permits(pointer string) bool {
+	segs := splitPointer(pointer)
+	for _, pattern := range a {
+		psegs := splitPointer(pattern)
+		if len(psegs) > len(segs) {
+			continue
+		}
+
+		match := true
+		for i, p := range psegs {
+			if p != "*" && p != segs[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply applies body to dst according to contentType, which must be one of
+// the JSON Patch or JSON Merge Patch media types.
+func Apply(dst any, contentType string, body []byte, allowed Allowed) error {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case ContentTypeJSONPatch:
+		return JSONPatch(dst, body, allowed)
+	case ContentTypeMergePatch:
+		return MergePatch(dst, body, allowed)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedMedia, contentType)
+	}
+}
+
+// MergePatch applies an RFC 7386 merge patch to dst, which must be a pointer.
+func MergePatch(dst any, body []byte, allowed Allowed) error {
+	var p any
+	if err := decode(body, &p); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+	}
+
+	for _, pointer := range mergePaths("", p) {
+		if !allowed.permits(pointer) {
+			return fmt.Errorf("%w: %s", ErrForbiddenPath, pointer)
+		}
+	}
+
+	doc, err := toDocument(dst)
+	if err != nil {
+		return err
+	}
+
+	return fromDocument(mergePatch(doc, p), dst)
+}
+
+// mergePatch implements the RFC 7386 MergePatch algorithm.
+func mergePatch(target, p any) any {
+	obj, ok := p.(map[string]any)
+	if !ok {
+		return p
+	}
+
+	tobj, ok := target.(map[string]any)
+	if !ok {
+		tobj = make(map[string]any)
+	}
+
+	for key, val := range obj {
+		if val == nil {
+			delete(tobj, key)
+			continue
+		}
+		tobj[key] = mergePatch(tobj[key], val)
+	}
+	return tobj
+}
+
+// mergePaths lists the JSON Pointers of every leaf a merge patch touches.
+func mergePaths(prefix string, p any) []string {
+	obj, ok := p.(map[string]any)
+	if !ok || len(obj) == 0 {
+		return []string{prefix}
+	}
+
+	var paths []string
+	for key, val := range obj {
+		paths = append(paths, mergePaths(prefix+"/"+escape(key), val)...)
+	}
+	return paths
+}
+
+// toDocument converts v into its generic JSON representation.
+func toDocument(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc any
+	if err := decode(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// fromDocument decodes doc into dst. Fields that disappeared from the
+// document (e.g., removed by a patch) are reset to their zero value, while
+// fields hidden from JSON ("-" or unexported) are preserved.
+func fromDocument(doc any, dst any) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New("patch: destination must be a non-nil pointer")
+	}
+
+	fresh := reflect.New(rv.Elem().Type())
+	if err := json.Unmarshal(data, fresh.Interface()); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+	}
+
+	target, source := rv.Elem(), fresh.Elem()
+	if target.Kind() != reflect.Struct {
+		target.Set(source)
+		return nil
+	}
+
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		target.Field(i).Set(source.Field(i))
+	}
+	return nil
+}
+
+// decode unmarshals JSON preserving number precision.
+func decode(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// escape encodes a key as a JSON Pointer segment.
+func escape(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// unescape decodes a JSON Pointer segment.
+func unescape(seg string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(seg, "~1", "/"), "~0", "~")
+}
+
+// splitPointer splits a JSON Pointer into unescaped segments.
+func splitPointer(pointer string) []string {
+	if pointer == "" || pointer == "/" {
+		return nil
+	}
+
+	segs := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, s := range segs {
+		segs[i] = unescape(s)
+	}
+	return segs
+}
@@ -0,0 +1,188 @@
+package respond
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+
+	apperrors "github.com/iamBelugaa/go-boilerplate/pkg/errors"
+	"github.com/iamBelugaa/go-boilerplate/pkg/requestid"
+	"github.com/iamBelugaa/go-boilerplate/pkg/web/negotiate"
+)
+
+// ErrUnsupported is returned by encoders for envelopes their format cannot
+// represent, such as an error in CSV; Render then answers in JSON.
+var ErrUnsupported = errors.New("respond: envelope not representable in this format")
+
+// Encoder writes envelopes in a media type.
+type Encoder interface {
+	// ContentType is the media type the encoder produces.
+	ContentType() string
+
+	// Encode writes env to w, or returns ErrUnsupported.
+	Encode(w io.Writer, env Envelope) error
+}
+
+// encoderFunc is the Encoder returned by NewEncoder.
+type encoderFunc struct {
+	contentType string
+	encode      func(w io.Writer, env Envelope) error
+}
+
+// NewEncoder returns an Encoder producing contentType with encode.
+func NewEncoder(contentType string, encode func(w io.Writer, env Envelope) error) Encoder {
+	return encoderFunc{contentType: contentType, encode: encode}
+}
+
+// ContentType implements Encoder.
+func (e encoderFunc) ContentType() string { return e.contentType }
+
+// Encode implements Encoder.
+func (e encoderFunc) Encode(w io.Writer, env Envelope) error { return e.encode(w, env) }
+
+// encoders are the formats Render offers, in order of preference; JSON
+// comes first, so it is chosen without an Accept header.
+var encoders = []Encoder{
+	NewEncoder(contentType, func(w io.Writer, env Envelope) error { return json.NewEncoder(w).Encode(env) }),
+	NewEncoder("application/xml", encodeXML),
+	NewEncoder("application/msgpack", encodeMsgpack),
+	NewEncoder("text/csv", encodeCSV),
+}
+
+// RegisterEncoder adds e to the formats Render offers, after those
+// registered before, or replaces the encoder of the same content type
+// (e.g., to change how CSV is written). Like validation.Register it is not
+// safe for concurrent use and should be called during program
+// initialization.
+func RegisterEncoder(e Encoder) {
+	i := slices.IndexFunc(encoders, func(old Encoder) bool { return old.ContentType() == e.ContentType() })
+	if i >= 0 {
+		encoders[i] = e
+		return
+	}
+	encoders = append(encoders, e)
+}
+
+// Render writes data in the envelope with status, in the format the
+// request's Accept header prefers among the registered encoders: JSON,
+// XML, MessagePack, CSV for list data, and any added with
+// RegisterEncoder. Data a format cannot represent is written in JSON;
+// requests accepting none of the formats are answered 406 Not Acceptable.
+func Render(w http.ResponseWriter, r *http.Request, status int, data any) {
+	render(w, r, status, Envelope{Data: data})
+}
+
+// RenderWithMeta is Render with meta, such as pagination.
+func RenderWithMeta(w http.ResponseWriter, r *http.Request, status int, data, meta any) {
+	render(w, r, status, Envelope{Data: data, Meta: meta})
+}
+
+// RenderError is Error.
+//
+// Deprecated: errors are answered in problem+json or the JSON envelope
+// whatever format the request prefers for data; use Error.
+func RenderError(w http.ResponseWriter, r *http.Request, err error) {
+	Error(w, r, err)
+}
+
+// render writes env with status in the format r prefers.
+func render(w http.ResponseWriter, r *http.Request, status int, env Envelope) {
+	w.Header().Add("Vary", "Accept")
+
+	offers := make([]string, len(encoders))
+	for i, e := range encoders {
+		offers[i] = e.ContentType()
+	}
+	best := negotiate.Best(r, offers...)
+	if best == "" {
+		Error(w, r, apperrors.New(apperrors.KindNotAcceptable, fmt.Sprintf("response can only be encoded as one of %q", offers)))
+		return
+	}
+	enc := encoders[slices.Index(offers, best)]
+
+	if !bodyAllowed(status) {
+		w.WriteHeader(status)
+		return
+	}
+
+	env.RequestID = w.Header().Get(requestid.Header)
+	var buf bytes.Buffer
+	switch err := enc.Encode(&buf, env); {
+	case errors.Is(err, ErrUnsupported):
+		write(w, status, env)
+		return
+	case err != nil:
+		Error(w, r, apperrors.Internal(err))
+		return
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", enc.ContentType())
+	h.Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// object is a JSON object with its keys in order.
+type object struct {
+	keys   []string
+	values []any
+}
+
+// generic converts v to the values encoding/json produces for it: objects
+// keeping their key order, arrays as []any, and numbers as json.Number, so
+// every format encodes the same fields, in the same order and under the
+// same names, as JSON.
+func generic(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return decodeValue(dec)
+}
+
+// decodeValue decodes the next value of dec.
+func decodeValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := &object{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.keys = append(obj.keys, key.(string))
+			obj.values = append(obj.values, v)
+		}
+		_, err := dec.Token() // }
+		return obj, err
+	case json.Delim('['):
+		arr := []any{}
+		for dec.More() {
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		_, err := dec.Token() // ]
+		return arr, err
+	default:
+		return tok, nil
+	}
+}
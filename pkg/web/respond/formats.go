@@ -0,0 +1,302 @@
+package respond
+
+import (
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+)
+
+// encodeXML writes env as a <response> element whose children are the
+// envelope's fields; arrays become repeated <item> elements.
+func encodeXML(w io.Writer, env Envelope) error {
+	v, err := generic(env)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	if err := writeXML(enc, "response", v); err != nil {
+		return err
+	}
+	if err := enc.Flush(); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// writeXML writes v as an element named name.
+func writeXML(enc *xml.Encoder, name string, v any) error {
+	start := xml.StartElement{Name: xml.Name{Local: xmlName(name)}}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case *object:
+		for i, key := range v.keys {
+			if err := writeXML(enc, key, v.values[i]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if err := writeXML(enc, "item", item); err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		if err := enc.EncodeToken(xml.CharData(scalarString(v))); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// xmlName turns an object key into a valid XML element name.
+func xmlName(key string) string {
+	var b strings.Builder
+	for i, c := range key {
+		valid := c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' ||
+			i > 0 && (c == '-' || c == '.' || '0' <= c && c <= '9')
+		if valid {
+			b.WriteRune(c)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	if b.Len() == 0 || strings.HasPrefix(strings.ToLower(key), "xml") {
+		return "_" + b.String()
+	}
+	return b.String()
+}
+
+// scalarString formats a JSON scalar as text.
+func scalarString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case nil:
+		return ""
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// encodeMsgpack writes env as a MessagePack map.
+func encodeMsgpack(w io.Writer, env Envelope) error {
+	v, err := generic(env)
+	if err != nil {
+		return err
+	}
+	var buf []byte
+	buf, err = appendMsgpack(buf, v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// appendMsgpack appends the MessagePack encoding of v to buf.
+func appendMsgpack(buf []byte, v any) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, 0xc0), nil
+	case bool:
+		if v {
+			return append(buf, 0xc3), nil
+		}
+		return append(buf, 0xc2), nil
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return appendMsgpackInt(buf, n), nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("respond: msgpack: %w", err)
+		}
+		buf = append(buf, 0xcb)
+		return binary.BigEndian.AppendUint64(buf, math.Float64bits(f)), nil
+	case string:
+		n := len(v)
+		switch {
+		case n < 32:
+			buf = append(buf, 0xa0|byte(n))
+		case n <= math.MaxUint8:
+			buf = append(buf, 0xd9, byte(n))
+		case n <= math.MaxUint16:
+			buf = binary.BigEndian.AppendUint16(append(buf, 0xda), uint16(n))
+		default:
+			buf = binary.BigEndian.AppendUint32(append(buf, 0xdb), uint32(n))
+		}
+		return append(buf, v...), nil
+	case []any:
+		buf = appendMsgpackLen(buf, len(v), 0x90, 0xdc, 0xdd)
+		var err error
+		for _, item := range v {
+			if buf, err = appendMsgpack(buf, item); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case *object:
+		buf = appendMsgpackLen(buf, len(v.keys), 0x80, 0xde, 0xdf)
+		var err error
+		for i, key := range v.keys {
+			if buf, err = appendMsgpack(buf, key); err != nil {
+				return nil, err
+			}
+			if buf, err = appendMsgpack(buf, v.values[i]); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	default:
+		return nil, fmt.Errorf("respond: msgpack: unexpected %T", v)
+	}
+}
+
+// appendMsgpackInt appends n in the smallest MessagePack integer format:
+// the unsigned formats for positive integers, the signed ones otherwise.
+func appendMsgpackInt(buf []byte, n int64) []byte {
+	switch {
+	case n >= 0 && n <= 127, n < 0 && n >= -32:
+		return append(buf, byte(n))
+	case n > 0 && n <= math.MaxUint8:
+		return append(buf, 0xcc, byte(n))
+	case n > 0 && n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xcd), uint16(n))
+	case n > 0 && n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xce), uint32(n))
+	case n > 0:
+		return binary.BigEndian.AppendUint64(append(buf, 0xcf), uint64(n))
+	case n >= math.MinInt8:
+		return append(buf, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xd1), uint16(n))
+	case n >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xd2), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, 0xd3), uint64(n))
+	}
+}
+
+// appendMsgpackLen appends the header of an array or map of n entries:
+// the fix format up to 15 entries, then the 16- and 32-bit ones.
+func appendMsgpackLen(buf []byte, n int, fix, len16, len32 byte) []byte {
+	switch {
+	case n < 16:
+		return append(buf, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, len16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(buf, len32), uint32(n))
+	}
+}
+
+// encodeCSV writes list data as CSV: a header of the fields of the items,
+// in the order they first appear, then a row per item. Nested values are
+// written as JSON. Other envelopes, such as errors, are ErrUnsupported.
+func encodeCSV(w io.Writer, env Envelope) error {
+	if env.Error != nil || env.Data == nil {
+		return ErrUnsupported
+	}
+	v, err := generic(env.Data)
+	if err != nil {
+		return err
+	}
+	items, ok := v.([]any)
+	if !ok {
+		return ErrUnsupported
+	}
+
+	var columns []string
+	for _, item := range items {
+		obj, ok := item.(*object)
+		if !ok {
+			return ErrUnsupported
+		}
+		for _, key := range obj.keys {
+			if !slices.Contains(columns, key) {
+				columns = append(columns, key)
+			}
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	row := make([]string, len(columns))
+	for _, item := range items {
+		obj := item.(*object)
+		clear(row)
+		for i, key := range obj.keys {
+			row[slices.Index(columns, key)] = csvField(obj.values[i])
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvField formats a value as a CSV field.
+func csvField(v any) string {
+	switch v := v.(type) {
+	case *object, []any:
+		data, _ := json.Marshal(jsonValue(v))
+		return string(data)
+	default:
+		return scalarString(v)
+	}
+}
+
+// jsonValue converts a generic value back to one encoding/json writes in
+// the same key order.
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case *object:
+		var b strings.Builder
+		b.WriteByte('{')
+		for i, key := range v.keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			k, _ := json.Marshal(key)
+			val, _ := json.Marshal(jsonValue(v.values[i]))
+			b.Write(k)
+			b.WriteByte(':')
+			b.Write(val)
+		}
+		b.WriteByte('}')
+		return json.RawMessage(b.String())
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = jsonValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,289 @@
+package respond
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMsgpackKnownBytes(t *testing.T) {
+	tests := []struct {
+		name string
+		in   any
+		want string // hex
+	}{
+		{name: "nil", in: nil, want: "c0"},
+		{name: "false", in: false, want: "c2"},
+		{name: "true", in: true, want: "c3"},
+		{name: "positive fixint", in: json.Number("127"), want: "7f"},
+		{name: "uint8", in: json.Number("255"), want: "ccff"},
+		{name: "uint16", in: json.Number("65535"), want: "cdffff"},
+		{name: "uint32", in: json.Number("4294967295"), want: "ceffffffff"},
+		{name: "uint64", in: json.Number("4294967296"), want: "cf0000000100000000"},
+		{name: "negative fixint", in: json.Number("-32"), want: "e0"},
+		{name: "int8", in: json.Number("-128"), want: "d080"},
+		{name: "int16", in: json.Number("-129"), want: "d1ff7f"},
+		{name: "int32", in: json.Number("-2147483648"), want: "d280000000"},
+		{name: "int64", in: json.Number("-9223372036854775808"), want: "d38000000000000000"},
+		{name: "float64", in: json.Number("1.5"), want: "cb3ff8000000000000"},
+		{name: "empty string", in: "", want: "a0"},
+		{name: "fixstr", in: "hi", want: "a26869"},
+		{name: "fixstr 31 bytes", in: strings.Repeat("a", 31), want: "bf" + strings.Repeat("61", 31)},
+		{name: "str8 32 bytes", in: strings.Repeat("a", 32), want: "d920" + strings.Repeat("61", 32)},
+		{name: "str8 255 bytes", in: strings.Repeat("a", 255), want: "d9ff" + strings.Repeat("61", 255)},
+		{name: "str16 256 bytes", in: strings.Repeat("a", 256), want: "da0100" + strings.Repeat("61", 256)},
+		{name: "non-ASCII counts bytes", in: "é", want: "a2c3a9"},
+		{name: "fixarray", in: []any{json.Number("1"), nil}, want: "9201c0"},
+		{name: "array16", in: make([]any, 16), want: "dc0010" + strings.Repeat("c0", 16)},
+		{
+			name: "fixmap keeps key order",
+			in:   &object{keys: []string{"b", "a"}, values: []any{json.Number("1"), "x"}},
+			want: "82a16201a161a178",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := appendMsgpack(nil, tt.in)
+			if err != nil {
+				t.Fatalf("appendMsgpack: %v", err)
+			}
+			if hex.EncodeToString(got) != tt.want {
+				t.Fatalf("appendMsgpack = %x, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMsgpackLongHeaders(t *testing.T) {
+	str32, _ := appendMsgpack(nil, strings.Repeat("a", math.MaxUint16+1))
+	if got := hex.EncodeToString(str32[:5]); got != "db00010000" {
+		t.Errorf("str32 header = %s, want db00010000", got)
+	}
+
+	obj := &object{}
+	for i := range 16 {
+		obj.keys = append(obj.keys, fmt.Sprint(i))
+		obj.values = append(obj.values, nil)
+	}
+	map16, _ := appendMsgpack(nil, obj)
+	if got := hex.EncodeToString(map16[:3]); got != "de0010" {
+		t.Errorf("map16 header = %s, want de0010", got)
+	}
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	env := Envelope{
+		Data: map[string]any{
+			"id":      42,
+			"balance": -1234567890123,
+			"ratio":   0.25,
+			"name":    strings.Repeat("long name ", 30),
+			"tags":    []string{"a", "b"},
+			"owner":   nil,
+			"active":  true,
+			"nested":  map[string]any{"deep": []any{1, "two", 3.5, nil}},
+		},
+		Meta:      map[string]int{"total": 300},
+		RequestID: "req-1",
+	}
+
+	var buf bytes.Buffer
+	if err := encodeMsgpack(&buf, env); err != nil {
+		t.Fatalf("encodeMsgpack: %v", err)
+	}
+	got, rest, err := decodeMsgpack(buf.Bytes())
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(rest) != 0 {
+		t.Fatalf("%d trailing bytes", len(rest))
+	}
+
+	data, _ := json.Marshal(env)
+	var want any
+	_ = json.Unmarshal(data, &want)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip =\n%v\nwant\n%v", got, want)
+	}
+}
+
+// decodeMsgpack decodes the MessagePack value at the start of b into the
+// values encoding/json decodes into an any, returning the bytes after it.
+func decodeMsgpack(b []byte) (any, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, errors.New("unexpected end")
+	}
+	c, b := b[0], b[1:]
+	switch {
+	case c <= 0x7f:
+		return float64(c), b, nil
+	case c >= 0xe0:
+		return float64(int8(c)), b, nil
+	case c&0xe0 == 0xa0:
+		return decodeMsgpackStr(b, int(c&0x1f))
+	case c&0xf0 == 0x90:
+		return decodeMsgpackArray(b, int(c&0x0f))
+	case c&0xf0 == 0x80:
+		return decodeMsgpackMap(b, int(c&0x0f))
+	}
+
+	// size returns the big-endian unsigned integer of n bytes at b.
+	size := func(n int) (uint64, []byte, error) {
+		if len(b) < n {
+			return 0, nil, errors.New("unexpected end")
+		}
+		var v uint64
+		for _, x := range b[:n] {
+			v = v<<8 | uint64(x)
+		}
+		return v, b[n:], nil
+	}
+	widths := map[byte]int{
+		0xcc: 1, 0xcd: 2, 0xce: 4, 0xcf: 8, 0xd0: 1, 0xd1: 2, 0xd2: 4, 0xd3: 8,
+		0xd9: 1, 0xda: 2, 0xdb: 4, 0xdc: 2, 0xdd: 4, 0xde: 2, 0xdf: 4, 0xcb: 8,
+	}
+	switch c {
+	case 0xc0:
+		return nil, b, nil
+	case 0xc2, 0xc3:
+		return c == 0xc3, b, nil
+	}
+	width, ok := widths[c]
+	if !ok {
+		return nil, nil, fmt.Errorf("unexpected type byte %#x", c)
+	}
+	n, rest, err := size(width)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch c {
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return float64(n), rest, nil
+	case 0xd0:
+		return float64(int8(n)), rest, nil
+	case 0xd1:
+		return float64(int16(n)), rest, nil
+	case 0xd2:
+		return float64(int32(n)), rest, nil
+	case 0xd3:
+		return float64(int64(n)), rest, nil
+	case 0xcb:
+		return math.Float64frombits(binary.BigEndian.Uint64(b[:8])), rest, nil
+	case 0xd9, 0xda, 0xdb:
+		return decodeMsgpackStr(rest, int(n))
+	case 0xdc, 0xdd:
+		return decodeMsgpackArray(rest, int(n))
+	default:
+		return decodeMsgpackMap(rest, int(n))
+	}
+}
+
+func decodeMsgpackStr(b []byte, n int) (any, []byte, error) {
+	if len(b) < n {
+		return nil, nil, errors.New("unexpected end")
+	}
+	return string(b[:n]), b[n:], nil
+}
+
+func decodeMsgpackArray(b []byte, n int) (any, []byte, error) {
+	out := make([]any, n)
+	for i := range out {
+		var err error
+		if out[i], b, err = decodeMsgpack(b); err != nil {
+			return nil, nil, err
+		}
+	}
+	return out, b, nil
+}
+
+func decodeMsgpackMap(b []byte, n int) (any, []byte, error) {
+	out := make(map[string]any, n)
+	for range n {
+		key, rest, err := decodeMsgpack(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		k, ok := key.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("map key %v is not a string", key)
+		}
+		if out[k], b, err = decodeMsgpack(rest); err != nil {
+			return nil, nil, err
+		}
+	}
+	return out, b, nil
+}
+
+func TestEncodeXML(t *testing.T) {
+	env := Envelope{
+		Data: map[string]any{
+			"name":   "a <b> & c",
+			"tags":   []string{"x", "y"},
+			"1st":    nil,
+			"xmlns":  true,
+			"nested": map[string]int{"n": 2},
+		},
+		RequestID: "req-1",
+	}
+
+	var buf bytes.Buffer
+	if err := encodeXML(&buf, env); err != nil {
+		t.Fatalf("encodeXML: %v", err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<response><data>` +
+		`<_st></_st>` +
+		`<name>a &lt;b&gt; &amp; c</name>` +
+		`<nested><n>2</n></nested>` +
+		`<tags><item>x</item><item>y</item></tags>` +
+		`<_xmlns>true</_xmlns>` +
+		`</data><requestId>req-1</requestId></response>` + "\n"
+	if buf.String() != want {
+		t.Fatalf("encodeXML =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestEncodeCSV(t *testing.T) {
+	type item struct {
+		ID    int            `json:"id"`
+		Name  string         `json:"name"`
+		Extra map[string]int `json:"extra,omitempty"`
+		Note  string         `json:"note,omitempty"`
+	}
+	env := Envelope{Data: []item{
+		{ID: 1, Name: "plain"},
+		{ID: 2, Name: `comma, "quoted"`, Extra: map[string]int{"b": 2, "a": 1}, Note: "line\nbreak"},
+	}}
+
+	var buf bytes.Buffer
+	if err := encodeCSV(&buf, env); err != nil {
+		t.Fatalf("encodeCSV: %v", err)
+	}
+	want := "id,name,extra,note\n" +
+		"1,plain,,\n" +
+		`2,"comma, ""quoted""","{""a"":1,""b"":2}","line` + "\nbreak\"\n"
+	if buf.String() != want {
+		t.Fatalf("encodeCSV =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestEncodeCSVUnsupported(t *testing.T) {
+	for name, env := range map[string]Envelope{
+		"error":      {Error: &ErrorBody{Code: "not_found", Message: "not found"}},
+		"no data":    {},
+		"object":     {Data: map[string]int{"n": 1}},
+		"scalars":    {Data: []int{1, 2}},
+		"mixed list": {Data: []any{map[string]int{"n": 1}, "x"}},
+	} {
+		if err := encodeCSV(&bytes.Buffer{}, env); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("%s: encodeCSV error = %v, want ErrUnsupported", name, err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Package respond writes JSON responses in the service's envelope, so
+// handlers stop hand-rolling json.NewEncoder calls and every response has
+// the same shape:
+//
+//	{"data": ..., "meta": ..., "requestId": "..."}
+//	{"error": {"code": "not_found", "message": "..."}, "requestId": "..."}
+//
+// Error answers errors through the errors package, in problem+json or this
+// envelope: errors of the errors package take their kind's status, request
+// decoding and validation errors are client errors, and anything else is a
+// 500 whose details stay in the logs. Lines and Array stream large
+// results; negotiate.Best picks between them and JSON. Render and
+// RenderWithMeta write the envelope in the format the Accept header
+// prefers: JSON, XML, MessagePack, CSV for lists, or one added with
+// RegisterEncoder.
+package respond
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	apperrors "github.com/iamBelugaa/go-boilerplate/pkg/errors"
+	"github.com/iamBelugaa/go-boilerplate/pkg/requestid"
+)
+
+// contentType is the media type of enveloped responses.
+const contentType = "application/json"
+
+// Envelope is the body of every JSON response.
+type Envelope struct {
+	Data      any        `json:"data,omitempty"`
+	Error     *ErrorBody `json:"error,omitempty"`
+	Meta      any        `json:"meta,omitempty"`
+	RequestID string     `json:"requestId,omitempty"`
+}
+
+// ErrorBody describes a failed request.
+type ErrorBody struct {
+	// Code is a stable, machine-readable identifier (e.g., "not_found").
+	Code string `json:"code"`
+
+	// Message is a human-readable description.
+	Message string `json:"message"`
+
+	// Fields maps invalid request fields to their errors.
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// JSON writes data in the envelope with status. Statuses without a body,
+// such as 204 No Content, only write the header.
+func JSON(w http.ResponseWriter, status int, data any) {
+	write(w, status, Envelope{Data: data})
+}
+
+// JSONWithMeta writes data and meta, such as pagination, in the envelope
+// with status.
+func JSONWithMeta(w http.ResponseWriter, status int, data, meta any) {
+	write(w, status, Envelope{Data: data, Meta: meta})
+}
+
+// Error answers err with apperrors.Write, so every error response has the
+// status, code, and format the errors package gives it. The message of
+// unexpected errors is never sent; they are logged with the request's
+// logger.
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	apperrors.Write(w, r, err)
+}
+
+// errorBody describes err in the envelope, for responses whose status was
+// already sent, as Error would.
+func errorBody(err error) *ErrorBody {
+	p := apperrors.NewProblem(err, false)
+	message := p.Detail
+	if message == "" {
+		message = strings.ToLower(p.Title)
+	}
+	return &ErrorBody{Code: p.Code, Message: message, Fields: p.Fields}
+}
+
+// write encodes env and writes it with status, answering 500 instead when
+// it cannot be encoded.
+func write(w http.ResponseWriter, status int, env Envelope) {
+	if !bodyAllowed(status) {
+		w.WriteHeader(status)
+		return
+	}
+
+	env.RequestID = w.Header().Get(requestid.Header)
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(env); err != nil {
+		status = http.StatusInternalServerError
+		buf.Reset()
+		_ = json.NewEncoder(&buf).Encode(Envelope{Error: errorBody(err), RequestID: env.RequestID})
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// bodyAllowed reports whether a response with status may have a body.
+func bodyAllowed(status int) bool {
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
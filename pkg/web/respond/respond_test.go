@@ -0,0 +1,43 @@
+package respond
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apperrors "github.com/iamBelugaa/go-boilerplate/pkg/errors"
+	"github.com/iamBelugaa/go-boilerplate/pkg/validation"
+)
+
+// TestErrorMatchesErrorsWrite checks Error answers as errors.Write does,
+// so an error gets the same status and code whichever a handler calls.
+func TestErrorMatchesErrorsWrite(t *testing.T) {
+	tests := []error{
+		validation.NewFieldsError("email", errors.New("is required")),
+		apperrors.NotFound("user not found"),
+		errors.New("connection refused"),
+	}
+	for _, err := range tests {
+		for _, accept := range []string{"application/json", "application/problem+json"} {
+			r := httptest.NewRequest(http.MethodGet, "/users", nil)
+			r.Header.Set("Accept", accept)
+			got, want := httptest.NewRecorder(), httptest.NewRecorder()
+			Error(got, r, err)
+			apperrors.Write(want, r, err)
+
+			if got.Code != want.Code || got.Body.String() != want.Body.String() {
+				t.Errorf("%v (%s): Error wrote %d %s, errors.Write %d %s", err, accept, got.Code, got.Body, want.Code, want.Body)
+			}
+		}
+	}
+}
+
+func TestErrorBody(t *testing.T) {
+	body := errorBody(validation.NewFieldsError("email", errors.New("is required")))
+	b, _ := json.Marshal(body)
+	if body.Code != "invalid" || body.Fields["email"] != "is required" {
+		t.Fatalf("errorBody = %s, want the invalid code and email field", b)
+	}
+}
@@ -0,0 +1,105 @@
+package respond
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"iter"
+	"net/http"
+
+	"github.com/iamBelugaa/go-boilerplate/pkg/requestid"
+)
+
+// linesContentType is the media type of Lines responses.
+const linesContentType = "application/x-ndjson"
+
+// flushEvery is the number of Array items written between flushes.
+const flushEvery = 64
+
+// Lines streams the values of seq as JSON Lines (application/x-ndjson),
+// flushing each one, so clients process results as they are produced. A
+// failure of seq midway ends the stream with an {"error": ...} line, since
+// the status was already sent, and is returned.
+func Lines(w http.ResponseWriter, seq iter.Seq2[any, error]) error {
+	rc := http.NewResponseController(w)
+	h := w.Header()
+	h.Set("Content-Type", linesContentType)
+	h.Set("X-Accel-Buffering", "no") // stop nginx from buffering the stream
+	h.Del("Content-Length")
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	for v, err := range seq {
+		if err != nil {
+			_ = enc.Encode(Envelope{Error: errorBody(err)})
+			return err
+		}
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Array streams the values of seq as the data array of the envelope with
+// status 200, without holding the whole result in memory. A failure of
+// seq midway closes the array and adds the error to the envelope, so the
+// body stays valid JSON but has both data and error; it is returned.
+func Array(w http.ResponseWriter, seq iter.Seq2[any, error]) error {
+	rc := http.NewResponseController(w)
+	h := w.Header()
+	h.Set("Content-Type", contentType)
+	h.Del("Content-Length")
+	w.WriteHeader(http.StatusOK)
+
+	var buf bytes.Buffer
+	buf.WriteString(`{"data":[`)
+	n := 0
+	var seqErr error
+	for v, err := range seq {
+		if err != nil {
+			seqErr = err
+			break
+		}
+		item, err := json.Marshal(v)
+		if err != nil {
+			seqErr = err
+			break
+		}
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(item)
+		n++
+
+		if n%flushEvery == 0 {
+			if _, err := w.Write(buf.Bytes()); err != nil {
+				return err
+			}
+			buf.Reset()
+			if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				return err
+			}
+		}
+	}
+
+	buf.WriteByte(']')
+	if seqErr != nil {
+		errJSON, _ := json.Marshal(errorBody(seqErr))
+		buf.WriteString(`,"error":`)
+		buf.Write(errJSON)
+	}
+	if id := h.Get(requestid.Header); id != "" {
+		idJSON, _ := json.Marshal(id)
+		buf.WriteString(`,"requestId":`)
+		buf.Write(idJSON)
+	}
+	buf.WriteString("}\n")
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return seqErr
+}
@@ -0,0 +1,250 @@
+// Package static serves a frontend's static assets: from a directory, or
+// from an embed.FS compiled into the binary (use fs.Sub to strip the
+// embedded directory's name).
+//
+// Responses carry a strong ETag derived from the content, so browsers
+// revalidate with If-None-Match, and a Cache-Control header: index.html is
+// always revalidated, fingerprinted assets are cached for a year, and the
+// rest for the configured max age. In SPA mode, browsers navigating to a
+// path without a file get index.html, so client-side routing works on
+// reload. Dotfiles are never served.
+package static
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// Defaults applied when the configuration leaves a value unset.
+const (
+	DefaultMaxAge = time.Hour
+	DefaultPrefix = "/"
+)
+
+// indexFile is served for directories and, in SPA mode, unknown paths.
+const indexFile = "index.html"
+
+// immutableCacheControl is sent for fingerprinted assets.
+const immutableCacheControl = "public, max-age=31536000, immutable"
+
+// entry is the cached ETag of a file, valid while its size and
+// modification time are unchanged.
+type entry struct {
+	size    int64
+	modTime time.Time
+	etag    string
+}
+
+// Handler serves static assets. It is safe for concurrent use.
+type Handler struct {
+	fsys      fs.FS
+	prefix    string
+	spa       bool
+	maxAge    string
+	immutable []string
+
+	mu    sync.Mutex
+	etags map[string]entry
+}
+
+// New returns a Handler serving the assets configured by conf from fsys,
+// or from conf.Dir when fsys is nil. It is meant to be mounted at
+// conf.Prefix with the GET method (e.g., "GET /app/").
+func New(conf *config.Static, fsys fs.FS) (*Handler, error) {
+	if fsys == nil {
+		if conf.Dir == "" {
+			return nil, errors.New("static: no directory or embedded file system to serve")
+		}
+		info, err := os.Stat(conf.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("static: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("static: %s is not a directory", conf.Dir)
+		}
+		fsys = os.DirFS(conf.Dir)
+	}
+
+	h := &Handler{
+		fsys:      fsys,
+		prefix:    conf.Prefix,
+		spa:       conf.SPA,
+		maxAge:    "public, max-age=" + strconv.Itoa(int(DefaultMaxAge.Seconds())),
+		immutable: conf.Immutable,
+		etags:     make(map[string]entry),
+	}
+	if h.prefix == "" {
+		h.prefix = DefaultPrefix
+	}
+	if conf.MaxAge > 0 {
+		h.maxAge = "public, max-age=" + strconv.Itoa(int(conf.MaxAge.Seconds()))
+	}
+
+	if h.spa {
+		if _, err := fs.Stat(fsys, indexFile); err != nil {
+			return nil, fmt.Errorf("static: SPA mode needs %s: %w", indexFile, err)
+		}
+	}
+	return h, nil
+}
+
+// Prefix returns the URL path the assets are served under.
+func (h *Handler) Prefix() string {
+	return h.prefix
+}
+
+// ServeHTTP serves the file at the request path, relative to the prefix.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	rel, ok := strings.CutPrefix(path.Clean("/"+r.URL.Path), strings.TrimSuffix(h.prefix, "/"))
+	if !ok || rel != "" && !strings.HasPrefix(rel, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	if rel == "" {
+		rel = "/"
+	}
+
+	name, ok := h.resolve(rel)
+	if !ok && h.spa && path.Ext(rel) == "" && acceptsHTML(r) {
+		name, ok = indexFile, true
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if err := h.serveFile(w, r, name, rel); err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
+// resolve maps the request path to a file name in the file system: the
+// file itself, or the index of a directory.
+func (h *Handler) resolve(rel string) (string, bool) {
+	name := strings.TrimPrefix(rel, "/")
+	if name == "" {
+		name = "."
+	}
+	for _, seg := range strings.Split(name, "/") {
+		if strings.HasPrefix(seg, ".") && seg != "." {
+			return "", false
+		}
+	}
+
+	info, err := fs.Stat(h.fsys, name)
+	if err != nil {
+		return "", false
+	}
+	if info.IsDir() {
+		name = path.Join(name, indexFile)
+		if info, err = fs.Stat(h.fsys, name); err != nil || info.IsDir() {
+			return "", false
+		}
+	}
+	return name, true
+}
+
+// serveFile writes the file with its caching headers. http.ServeContent
+// answers conditional and range requests.
+func (h *Handler) serveFile(w http.ResponseWriter, r *http.Request, name, rel string) error {
+	f, err := h.fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	var content io.ReadSeeker
+	if rs, ok := f.(io.ReadSeeker); ok {
+		content = rs
+	} else {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return err
+		}
+		content = bytes.NewReader(data)
+	}
+
+	etag, err := h.etag(name, info, content)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("ETag", etag)
+	switch {
+	case path.Base(name) == indexFile:
+		w.Header().Set("Cache-Control", "no-cache")
+	case matchesAny(h.immutable, rel):
+		w.Header().Set("Cache-Control", immutableCacheControl)
+	default:
+		w.Header().Set("Cache-Control", h.maxAge)
+	}
+
+	http.ServeContent(w, r, name, info.ModTime(), content)
+	return nil
+}
+
+// etag returns the ETag of the file, hashing its content when it is not
+// cached or has changed since.
+func (h *Handler) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	h.mu.Lock()
+	e, ok := h.etags[name]
+	h.mu.Unlock()
+	if ok && e.size == info.Size() && e.modTime.Equal(info.ModTime()) {
+		return e.etag, nil
+	}
+
+	sum := sha256.New()
+	if _, err := io.Copy(sum, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := `"` + hex.EncodeToString(sum.Sum(nil)[:16]) + `"`
+
+	h.mu.Lock()
+	h.etags[name] = entry{size: info.Size(), modTime: info.ModTime(), etag: etag}
+	h.mu.Unlock()
+	return etag, nil
+}
+
+// acceptsHTML reports whether the request comes from a browser navigating
+// to a page rather than a script fetching data.
+func acceptsHTML(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// matchesAny reports whether p is one of paths, or below one ending in "/".
+func matchesAny(paths []string, p string) bool {
+	for _, s := range paths {
+		if p == s || (strings.HasSuffix(s, "/") && strings.HasPrefix(p, s)) {
+			return true
+		}
+	}
+	return false
+}
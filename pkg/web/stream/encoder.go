@@ -0,0 +1,109 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Encoder writes chunks to the client, flushing each one.
+type Encoder struct {
+	w            http.ResponseWriter
+	rc           *http.ResponseController
+	format       Format
+	writeTimeout time.Duration
+	started      bool
+}
+
+// NewEncoder returns an Encoder writing format to w. When writeTimeout is
+// positive, each chunk must reach the client within it; the deadline is
+// renewed per chunk, so long streams outlive the server's write timeout
+// while stalled clients are still cut off.
+func NewEncoder(w http.ResponseWriter, format Format, writeTimeout time.Duration) *Encoder {
+	return &Encoder{w: w, rc: http.NewResponseController(w), format: format, writeTimeout: writeTimeout}
+}
+
+// Start writes the response headers with status 200 and flushes them.
+// Send calls it when needed.
+func (e *Encoder) Start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+
+	h := e.w.Header()
+	h.Set("Content-Type", e.format.ContentType())
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no") // stop nginx from buffering the stream
+	h.Del("Content-Length")
+	e.w.WriteHeader(http.StatusOK)
+	return e.flush()
+}
+
+// Started reports whether the headers were written, after which errors
+// can only be reported in the stream.
+func (e *Encoder) Started() bool {
+	return e.started
+}
+
+// Send writes c and flushes it to the client. It blocks while the client
+// is slow to read, which is what provides backpressure.
+func (e *Encoder) Send(c Chunk) error {
+	if err := e.Start(); err != nil {
+		return err
+	}
+	if e.writeTimeout > 0 {
+		_ = e.rc.SetWriteDeadline(time.Now().Add(e.writeTimeout))
+	}
+
+	var buf bytes.Buffer
+	if e.format == JSONLines {
+		if err := json.Compact(&buf, c.Data); err != nil {
+			buf.Reset()
+			buf.WriteString(oneLine(string(c.Data)))
+		}
+		buf.WriteByte('\n')
+	} else {
+		if c.ID != "" {
+			buf.WriteString("id: " + oneLine(c.ID) + "\n")
+		}
+		if c.Event != "" {
+			buf.WriteString("event: " + oneLine(c.Event) + "\n")
+		}
+		for _, line := range strings.Split(string(c.Data), "\n") {
+			buf.WriteString("data: " + strings.TrimSuffix(line, "\r") + "\n")
+		}
+		buf.WriteByte('\n')
+	}
+
+	if _, err := e.w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return e.flush()
+}
+
+// Error ends the stream with an error chunk: an "error" event for SSE, or
+// a {"error": ...} line for JSON Lines.
+func (e *Encoder) Error(msg string) error {
+	data, err := json.Marshal(map[string]string{"error": msg})
+	if err != nil {
+		return err
+	}
+	return e.Send(Chunk{Event: "error", Data: data})
+}
+
+// flush pushes buffered data to the client.
+func (e *Encoder) flush() error {
+	err := e.rc.Flush()
+	if err == http.ErrNotSupported {
+		return nil
+	}
+	return err
+}
+
+// oneLine strips line breaks, which would end an SSE field early.
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
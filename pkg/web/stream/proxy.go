@@ -0,0 +1,153 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Results of a proxied stream, as reported in metrics.
+const (
+	ResultComplete = "complete" // the downstream ended the stream
+	ResultPartial  = "partial"  // the downstream failed midway
+	ResultCanceled = "canceled" // the client went away or the request was canceled
+	ResultFailed   = "failed"   // the downstream failed before its first chunk
+)
+
+// Metrics records per-downstream stream metrics.
+type Metrics struct {
+	chunks     *prometheus.CounterVec
+	bytes      *prometheus.CounterVec
+	firstChunk *prometheus.HistogramVec
+	streams    *prometheus.CounterVec
+}
+
+// NewMetrics registers stream_chunks_total, stream_bytes_total,
+// stream_first_chunk_seconds, and streams_total (by result) with reg, each
+// labelled by downstream.
+func NewMetrics(reg prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		chunks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "stream_chunks_total",
+			Help: "Chunks relayed from streaming downstreams.",
+		}, []string{"downstream"}),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "stream_bytes_total",
+			Help: "Chunk payload bytes relayed from streaming downstreams.",
+		}, []string{"downstream"}),
+		firstChunk: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "stream_first_chunk_seconds",
+			Help:    "Time until a streaming downstream produced its first chunk.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"downstream"}),
+		streams: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "streams_total",
+			Help: "Streams relayed, by downstream and result.",
+		}, []string{"downstream", "result"}),
+	}
+
+	for _, c := range []prometheus.Collector{m.chunks, m.bytes, m.firstChunk, m.streams} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Options configures Proxy.
+type Options struct {
+	// Downstream names the downstream in metrics (e.g., "openai").
+	Downstream string
+
+	// In and Out are the downstream's and the client's formats.
+	In, Out Format
+
+	// MaxChunkSize bounds a single chunk (0 means 1 MiB).
+	MaxChunkSize int
+
+	// WriteTimeout bounds writing each chunk to the client (see NewEncoder).
+	WriteTimeout time.Duration
+
+	// Transform rewrites each chunk before it is sent, e.g. to map a
+	// provider's schema to ours; returning false drops the chunk. Errors
+	// end the stream as partial.
+	Transform func(Chunk) (Chunk, bool, error)
+
+	// Metrics records the stream; it may be nil.
+	Metrics *Metrics
+}
+
+// Result summarizes a proxied stream.
+type Result struct {
+	Chunks int
+	Bytes  int64
+	Status string
+}
+
+// Proxy relays the chunks of body to w until the stream ends, then
+// closes body. If ctx is canceled, body is closed to abort the pending
+// read. A downstream failure before the first chunk is returned without
+// writing anything, so the handler can still answer with a regular error;
+// after it, the stream is ended with an error chunk (see Encoder.Error)
+// and the error returned.
+func Proxy(ctx context.Context, w http.ResponseWriter, body io.ReadCloser, opts Options) (Result, error) {
+	defer body.Close()
+	stop := context.AfterFunc(ctx, func() { body.Close() })
+	defer stop()
+
+	start := time.Now()
+	dec := NewDecoder(body, opts.In, opts.MaxChunkSize)
+	enc := NewEncoder(w, opts.Out, opts.WriteTimeout)
+
+	res := Result{Status: ResultComplete}
+	defer func() {
+		if opts.Metrics != nil {
+			opts.Metrics.streams.WithLabelValues(opts.Downstream, res.Status).Inc()
+		}
+	}()
+
+	for {
+		c, err := dec.Next()
+		if errors.Is(err, io.EOF) {
+			return res, enc.Start()
+		}
+		if ctx.Err() != nil {
+			res.Status = ResultCanceled
+			return res, ctx.Err()
+		}
+		if err == nil && opts.Transform != nil {
+			var keep bool
+			if c, keep, err = opts.Transform(c); err == nil && !keep {
+				continue
+			}
+		}
+		if err != nil {
+			if !enc.Started() {
+				res.Status = ResultFailed
+				return res, err
+			}
+			res.Status = ResultPartial
+			_ = enc.Error("stream interrupted")
+			return res, err
+		}
+
+		if res.Chunks == 0 && opts.Metrics != nil {
+			opts.Metrics.firstChunk.WithLabelValues(opts.Downstream).Observe(time.Since(start).Seconds())
+		}
+		if err := enc.Send(c); err != nil {
+			res.Status = ResultCanceled // the client stopped reading
+			return res, err
+		}
+
+		res.Chunks++
+		res.Bytes += int64(len(c.Data))
+		if opts.Metrics != nil {
+			opts.Metrics.chunks.WithLabelValues(opts.Downstream).Inc()
+			opts.Metrics.bytes.WithLabelValues(opts.Downstream).Add(float64(len(c.Data)))
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Package stream relays streaming responses, such as tokens from an LLM
+// provider, from a downstream API to the client as they arrive.
+//
+// Downstream bodies in Server-Sent Events or JSON Lines format are read
+// chunk by chunk with a Decoder and written with an Encoder, which flushes
+// every chunk. Proxy ties the two together: it only reads the next chunk
+// once the client has taken the previous one (backpressure), stops reading
+// when the request is canceled, and ends the stream with an error chunk
+// when the downstream fails midway, so clients can tell a partial result
+// from a complete one.
+//
+// Streaming routes must be exempt from the buffering timeout middleware
+// (see middleware.Timeout overrides).
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// Format is the wire format of a stream.
+type Format int
+
+// Supported formats.
+const (
+	// SSE is Server-Sent Events (text/event-stream).
+	SSE Format = iota
+
+	// JSONLines is one JSON value per line (application/x-ndjson).
+	JSONLines
+)
+
+// ContentType returns the media type of the format.
+func (f Format) ContentType() string {
+	if f == JSONLines {
+		return "application/x-ndjson"
+	}
+	return "text/event-stream"
+}
+
+// ErrChunkTooLarge is returned when a single chunk exceeds the decoder's
+// limit.
+var ErrChunkTooLarge = errors.New("stream: chunk too large")
+
+// Chunk is a unit of a stream. Event and ID are only used by SSE.
+type Chunk struct {
+	Event string
+	ID    string
+	Data  []byte
+}
+
+// Decoder reads chunks from a downstream body.
+type Decoder struct {
+	scanner *bufio.Scanner
+	format  Format
+}
+
+// NewDecoder returns a Decoder reading chunks in format from r. Chunks
+// larger than maxSize bytes fail with ErrChunkTooLarge (0 means 1 MiB).
+func NewDecoder(r io.Reader, format Format, maxSize int) *Decoder {
+	if maxSize <= 0 {
+		maxSize = 1 << 20
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxSize)
+	return &Decoder{scanner: scanner, format: format}
+}
+
+// Next returns the next chunk, or io.EOF at the end of the stream. SSE
+// comments and blank JSON lines are skipped.
+func (d *Decoder) Next() (Chunk, error) {
+	if d.format == JSONLines {
+		for d.scanner.Scan() {
+			line := bytes.TrimSpace(d.scanner.Bytes())
+			if len(line) > 0 {
+				return Chunk{Data: bytes.Clone(line)}, nil
+			}
+		}
+		return Chunk{}, d.err()
+	}
+
+	var (
+		c       Chunk
+		data    [][]byte
+		hasData bool
+	)
+	for d.scanner.Scan() {
+		line := d.scanner.Bytes()
+		if len(line) == 0 {
+			if hasData {
+				c.Data = bytes.Join(data, []byte("\n"))
+				return c, nil
+			}
+			c = Chunk{} // an event without data is discarded
+			continue
+		}
+		if line[0] == ':' {
+			continue // comment, often a keep-alive
+		}
+
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		value = bytes.TrimPrefix(value, []byte(" "))
+		switch string(field) {
+		case "event":
+			c.Event = string(value)
+		case "id":
+			c.ID = string(value)
+		case "data":
+			data = append(data, bytes.Clone(value))
+			hasData = true
+		}
+	}
+
+	if err := d.err(); err != io.EOF {
+		return Chunk{}, err
+	}
+	if hasData {
+		// The stream ended without the final blank line.
+		c.Data = bytes.Join(data, []byte("\n"))
+		return c, nil
+	}
+	return Chunk{}, io.EOF
+}
+
+// err returns the scanner's error, or io.EOF.
+func (d *Decoder) err() error {
+	err := d.scanner.Err()
+	switch {
+	case err == nil:
+		return io.EOF
+	case errors.Is(err, bufio.ErrTooLong):
+		return ErrChunkTooLarge
+	default:
+		return err
+	}
+}
@@ -0,0 +1,199 @@
+// Package wire observes protocol-level failures that net/http handles before
+// any handler runs: malformed requests, oversized headers, and TLS handshake
+// failures. Each failure is counted and reported as a structured Event, and
+// the plain-text 400/431 replies net/http writes are replaced by JSON bodies,
+// so attacks can be told apart from buggy clients.
+package wire
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync/atomic"
+)
+
+// Kind classifies a protocol-level failure.
+type Kind string
+
+// Supported failure kinds.
+const (
+	KindMalformedRequest Kind = "malformed_request"
+	KindHeaderTooLarge   Kind = "header_too_large"
+	KindTLSHandshake     Kind = "tls_handshake"
+	KindOther            Kind = "other"
+)
+
+// Event describes a single protocol-level failure.
+type Event struct {
+	Kind       Kind   `json:"kind"`
+	RemoteAddr string `json:"remoteAddr,omitempty"`
+	Message    string `json:"message"`
+}
+
+// Monitor counts protocol-level failures and reports them to an observer.
+type Monitor struct {
+	observe func(Event)
+	counts  map[Kind]*atomic.Uint64
+}
+
+// NewMonitor returns a Monitor calling observe, which may be nil, for every
+// failure. observe is typically a structured logger call.
+func NewMonitor(observe func(Event)) *Monitor {
+	m := &Monitor{observe: observe, counts: make(map[Kind]*atomic.Uint64)}
+	for _, kind := range []Kind{KindMalformedRequest, KindHeaderTooLarge, KindTLSHandshake, KindOther} {
+		m.counts[kind] = new(atomic.Uint64)
+	}
+	return m
+}
+
+// Snapshot returns the number of failures seen so far, by kind.
+func (m *Monitor) Snapshot() map[Kind]uint64 {
+	snap := make(map[Kind]uint64, len(m.counts))
+	for kind, n := range m.counts {
+		snap[kind] = n.Load()
+	}
+	return snap
+}
+
+// report counts e and forwards it to the observer.
+func (m *Monitor) report(e Event) {
+	n, ok := m.counts[e.Kind]
+	if !ok {
+		n = m.counts[KindOther]
+	}
+	n.Add(1)
+
+	if m.observe != nil {
+		m.observe(e)
+	}
+}
+
+// ErrorLog returns a logger for http.Server.ErrorLog that turns the server's
+// log lines into Events, so TLS handshake failures and other connection
+// errors reach the structured logger instead of stderr.
+func (m *Monitor) ErrorLog() *log.Logger {
+	return log.New(errorLogWriter{m}, "", 0)
+}
+
+// tlsHandshakePrefix starts the line net/http logs for failed handshakes.
+const tlsHandshakePrefix = "http: TLS handshake error from "
+
+// errorLogWriter parses net/http error log lines.
+type errorLogWriter struct {
+	m *Monitor
+}
+
+// Write reports one log line as an Event.
+func (w errorLogWriter) Write(p []byte) (int, error) {
+	line := strings.TrimSpace(string(p))
+
+	e := Event{Kind: KindOther, Message: line}
+	if rest, ok := strings.CutPrefix(line, tlsHandshakePrefix); ok {
+		e.Kind = KindTLSHandshake
+		if addr, msg, found := strings.Cut(rest, ": "); found {
+			e.RemoteAddr, e.Message = addr, msg
+		}
+	}
+
+	w.m.report(e)
+	return len(p), nil
+}
+
+// Listener wraps l so the plain-text replies net/http writes for malformed
+// requests and oversized headers are counted and replaced by JSON bodies.
+// It must wrap a plaintext listener; for servers terminating TLS themselves
+// the replies are encrypted and only handshake failures are observed,
+// through ErrorLog.
+func (m *Monitor) Listener(l net.Listener) net.Listener {
+	return &listener{Listener: l, m: m}
+}
+
+// listener wraps accepted connections in conn.
+type listener struct {
+	net.Listener
+	m *Monitor
+}
+
+// Accept waits for and returns the next wrapped connection.
+func (l *listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: c, m: l.m}, nil
+}
+
+// errorHeaders follows the status line of every reply net/http writes
+// directly to the connection. Handler responses always carry a Date header
+// first, so the sequence identifies the server's own replies.
+const errorHeaders = "\r\nContent-Type: text/plain; charset=utf-8\r\nConnection: close\r\n\r\n"
+
+// conn intercepts net/http's built-in error replies.
+type conn struct {
+	net.Conn
+	m *Monitor
+}
+
+// Write replaces built-in error replies with structured ones and passes
+// everything else through.
+func (c *conn) Write(p []byte) (int, error) {
+	status, detail, ok := parseErrorReply(p)
+	if !ok {
+		return c.Conn.Write(p)
+	}
+
+	kind, message := KindMalformedRequest, "malformed request"
+	if status == 431 {
+		kind, message = KindHeaderTooLarge, "request header fields too large"
+	}
+	if detail != "" {
+		message = detail
+	}
+
+	c.m.report(Event{Kind: kind, RemoteAddr: c.RemoteAddr().String(), Message: message})
+
+	body := fmt.Sprintf(`{"error":%q}`, message)
+	reply := fmt.Sprintf(
+		"HTTP/1.1 %s\r\nContent-Type: application/json\r\nContent-Length: %d\r\nConnection: close\r\n\r\n%s",
+		statusLine(p), len(body), body,
+	)
+	if _, err := c.Conn.Write([]byte(reply)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// parseErrorReply recognizes a reply written by net/http before any handler
+// ran and returns its status code and, for 4xx status errors carrying one,
+// the reason detail.
+func parseErrorReply(p []byte) (status int, detail string, ok bool) {
+	rest, found := bytes.CutPrefix(p, []byte("HTTP/1.1 "))
+	if !found {
+		return 0, "", false
+	}
+
+	line, _, found := bytes.Cut(rest, []byte(errorHeaders))
+	if !found || bytes.Contains(line, []byte("\r\n")) {
+		return 0, "", false
+	}
+
+	if _, err := fmt.Sscanf(string(line), "%d", &status); err != nil || status < 400 || status >= 500 {
+		return 0, "", false
+	}
+
+	// Status errors read "400 Bad Request: missing required Host header".
+	if _, reason, found := strings.Cut(string(line), ": "); found {
+		detail = reason
+	}
+	return status, detail, true
+}
+
+// statusLine returns the status code and text of a built-in error reply,
+// without any detail.
+func statusLine(p []byte) string {
+	line, _, _ := bytes.Cut(bytes.TrimPrefix(p, []byte("HTTP/1.1 ")), []byte(errorHeaders))
+	status, _, _ := strings.Cut(string(line), ": ")
+	return status
+}
@@ -0,0 +1,429 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// acceptGUID is appended to the client's key to compute the accept key
+// (RFC 6455 section 4.2.2).
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// MessageType is the type of a data message.
+type MessageType int
+
+// Message types, numbered as their frame opcodes.
+const (
+	TextMessage   MessageType = 1
+	BinaryMessage MessageType = 2
+)
+
+// Frame opcodes.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// Close status codes (RFC 6455 section 7.4.1).
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseNoStatus        = 1005
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseTooBig          = 1009
+	CloseInternalError   = 1011
+)
+
+// maxControlPayload is the largest payload of a control frame.
+const maxControlPayload = 125
+
+// ErrMessageTooBig is returned when a message exceeds the read limit.
+var ErrMessageTooBig = errors.New("websocket: message too big")
+
+// CloseError is returned by ReadMessage when the peer closes the
+// connection.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+// Error implements error.
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: closed by peer: %d %s", e.Code, e.Reason)
+}
+
+// UpgradeOptions configures Upgrade.
+type UpgradeOptions struct {
+	// AllowedOrigins are the origins browsers may connect from; empty
+	// allows only the request's own host. Requests without an Origin
+	// header, from non-browser clients, are always allowed.
+	AllowedOrigins []string
+
+	// Subprotocols are the supported subprotocols, in order of preference.
+	Subprotocols []string
+
+	// MaxMessageBytes caps the size of messages read, fragments included
+	// (defaults to DefaultMaxMessageBytes).
+	MaxMessageBytes int64
+}
+
+// Conn is a server-side websocket connection. Reads must come from a
+// single goroutine; writes are serialized and may come from any.
+type Conn struct {
+	conn net.Conn
+	br   *bufio.Reader
+
+	// Subprotocol is the subprotocol negotiated with the client, if any.
+	Subprotocol string
+
+	maxMessage int64
+	onPong     func()
+
+	wmu       sync.Mutex
+	bw        *bufio.Writer
+	closeSent bool
+}
+
+// Upgrade performs the websocket handshake and takes over the connection.
+// On failure it answers the request itself (400, 403, or 426) and returns
+// the error. HTTP/2 requests cannot be upgraded; browsers open websockets
+// over HTTP/1.1. Upgraded routes must be exempt from the buffering timeout
+// middleware (see middleware.Timeout overrides).
+func Upgrade(w http.ResponseWriter, r *http.Request, opts UpgradeOptions) (*Conn, error) {
+	fail := func(status int, msg string) (*Conn, error) {
+		if status == http.StatusUpgradeRequired {
+			w.Header().Set("Sec-WebSocket-Version", "13")
+		}
+		http.Error(w, msg, status)
+		return nil, errors.New("websocket: " + msg)
+	}
+
+	if r.Method != http.MethodGet || !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		return fail(http.StatusBadRequest, "not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return fail(http.StatusUpgradeRequired, "unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return fail(http.StatusBadRequest, "invalid Sec-WebSocket-Key")
+	}
+	if !originAllowed(r, opts.AllowedOrigins) {
+		return fail(http.StatusForbidden, "origin not allowed")
+	}
+
+	var subprotocol string
+	offered := headerTokens(r.Header, "Sec-WebSocket-Protocol")
+	for _, p := range opts.Subprotocols {
+		if slices.Contains(offered, p) {
+			subprotocol = p
+			break
+		}
+	}
+
+	netConn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return fail(http.StatusInternalServerError, "connection cannot be upgraded")
+	}
+	// Clear the deadlines the server set for the HTTP request.
+	_ = netConn.SetDeadline(time.Time{})
+
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	resp := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: " +
+		base64.StdEncoding.EncodeToString(sum[:]) + "\r\n"
+	if subprotocol != "" {
+		resp += "Sec-WebSocket-Protocol: " + subprotocol + "\r\n"
+	}
+	resp += "\r\n"
+	if _, err := brw.WriteString(resp); err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("websocket: %w", err)
+	}
+	if err := brw.Flush(); err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("websocket: %w", err)
+	}
+
+	maxMessage := opts.MaxMessageBytes
+	if maxMessage <= 0 {
+		maxMessage = DefaultMaxMessageBytes
+	}
+	return &Conn{
+		conn:        netConn,
+		br:          brw.Reader,
+		bw:          brw.Writer,
+		Subprotocol: subprotocol,
+		maxMessage:  maxMessage,
+	}, nil
+}
+
+// SetPongHandler sets the function called when a pong is received. It
+// must be called before reading.
+func (c *Conn) SetPongHandler(fn func()) {
+	c.onPong = fn
+}
+
+// SetReadDeadline sets the deadline for reading the next frame.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// RemoteAddr returns the address of the peer.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// ReadMessage reads the next data message. Pings are answered and pongs
+// reported to the pong handler as they arrive. When the peer closes the
+// connection, the close is acknowledged and a *CloseError returned.
+// Protocol violations close the connection with the matching status.
+func (c *Conn) ReadMessage() (MessageType, []byte, error) {
+	var (
+		typ MessageType
+		msg []byte
+	)
+	for {
+		// The frame may only fill what the message has left of the limit.
+		fin, op, payload, err := c.readFrame(uint64(c.maxMessage - int64(len(msg))))
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload, time.Time{}); err != nil {
+				return 0, nil, err
+			}
+			continue
+
+		case opPong:
+			if c.onPong != nil {
+				c.onPong()
+			}
+			continue
+
+		case opClose:
+			code, reason := CloseNoStatus, ""
+			if len(payload) >= 2 {
+				code, reason = int(binary.BigEndian.Uint16(payload)), string(payload[2:])
+			}
+			echo := code
+			if echo == CloseNoStatus {
+				echo = CloseNormal
+			}
+			_ = c.Close(echo, "")
+			return 0, nil, &CloseError{Code: code, Reason: reason}
+
+		case opText, opBinary:
+			if typ != 0 {
+				return 0, nil, c.fail(CloseProtocolError, "new message inside a fragmented one")
+			}
+			typ, msg = MessageType(op), payload
+
+		case opContinuation:
+			if typ == 0 {
+				return 0, nil, c.fail(CloseProtocolError, "continuation without a message")
+			}
+			msg = append(msg, payload...)
+
+		default:
+			return 0, nil, c.fail(CloseProtocolError, "unknown opcode")
+		}
+
+		if int64(len(msg)) > c.maxMessage {
+			_ = c.fail(CloseTooBig, "message too big")
+			return 0, nil, ErrMessageTooBig
+		}
+		if fin {
+			if typ == TextMessage && !utf8.Valid(msg) {
+				return 0, nil, c.fail(CloseInvalidPayload, "invalid UTF-8")
+			}
+			return typ, msg, nil
+		}
+	}
+}
+
+// WriteMessage sends a data message, within deadline unless it is zero.
+func (c *Conn) WriteMessage(typ MessageType, data []byte, deadline time.Time) error {
+	if typ != TextMessage && typ != BinaryMessage {
+		return errors.New("websocket: invalid message type")
+	}
+	return c.writeFrame(byte(typ), data, deadline)
+}
+
+// Ping sends a ping, within deadline unless it is zero.
+func (c *Conn) Ping(deadline time.Time) error {
+	return c.writeFrame(opPing, nil, deadline)
+}
+
+// Close sends a close frame with code and reason, unless one was sent
+// already, and closes the connection.
+func (c *Conn) Close(code int, reason string) error {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+	if len(payload) > maxControlPayload {
+		payload = payload[:maxControlPayload]
+	}
+
+	err := c.writeFrame(opClose, payload, time.Now().Add(time.Second))
+	if errors.Is(err, net.ErrClosed) {
+		err = nil
+	}
+	if cerr := c.conn.Close(); cerr != nil && !errors.Is(cerr, net.ErrClosed) && err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// fail closes the connection with code after a protocol violation.
+func (c *Conn) fail(code int, reason string) error {
+	_ = c.Close(code, reason)
+	return errors.New("websocket: " + reason)
+}
+
+// readFrame reads one frame and unmasks its payload. Data frames larger
+// than limit bytes fail with ErrMessageTooBig before their payload is
+// read.
+func (c *Conn) readFrame(limit uint64) (fin bool, op byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin, op = head[0]&0x80 != 0, head[0]&0x0F
+	if head[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, "reserved bits set")
+	}
+	if head[1]&0x80 == 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, "unmasked client frame")
+	}
+
+	size := uint64(head[1] & 0x7F)
+	switch size {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		size = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		size = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if op >= opClose && (!fin || size > maxControlPayload) {
+		return false, 0, nil, c.fail(CloseProtocolError, "invalid control frame")
+	}
+	if op < opClose && size > limit {
+		_ = c.fail(CloseTooBig, "message too big")
+		return false, 0, nil, ErrMessageTooBig
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, size)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// writeFrame writes a single unmasked frame. Nothing is written after a
+// close frame.
+func (c *Conn) writeFrame(op byte, payload []byte, deadline time.Time) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	if c.closeSent {
+		return net.ErrClosed
+	}
+	if op == opClose {
+		c.closeSent = true
+	}
+
+	_ = c.conn.SetWriteDeadline(deadline)
+
+	var head [10]byte
+	head[0] = 0x80 | op
+	n := 2
+	switch size := len(payload); {
+	case size <= 125:
+		head[1] = byte(size)
+	case size <= 0xFFFF:
+		head[1] = 126
+		binary.BigEndian.PutUint16(head[2:], uint16(size))
+		n = 4
+	default:
+		head[1] = 127
+		binary.BigEndian.PutUint64(head[2:], uint64(size))
+		n = 10
+	}
+
+	if _, err := c.bw.Write(head[:n]); err != nil {
+		return err
+	}
+	if _, err := c.bw.Write(payload); err != nil {
+		return err
+	}
+	return c.bw.Flush()
+}
+
+// originAllowed reports whether the request's Origin may connect.
+func originAllowed(r *http.Request, allowed []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if len(allowed) > 0 {
+		return slices.ContainsFunc(allowed, func(a string) bool { return strings.EqualFold(a, origin) })
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// headerContains reports whether the comma-separated header contains the
+// token, case-insensitively.
+func headerContains(h http.Header, name, token string) bool {
+	return slices.ContainsFunc(headerTokens(h, name), func(t string) bool { return strings.EqualFold(t, token) })
+}
+
+// headerTokens returns the comma-separated tokens of the header.
+func headerTokens(h http.Header, name string) []string {
+	var tokens []string
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tokens = append(tokens, t)
+			}
+		}
+	}
+	return tokens
+}
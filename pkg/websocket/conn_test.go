@@ -0,0 +1,410 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// hijackWriter is a ResponseWriter handing over one end of a pipe.
+type hijackWriter struct {
+	httptest.ResponseRecorder
+	conn net.Conn
+}
+
+func (w *hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.conn, bufio.NewReadWriter(bufio.NewReader(w.conn), bufio.NewWriter(w.conn)), nil
+}
+
+// frame is a frame received by the test client.
+type frame struct {
+	fin     bool
+	op      byte
+	payload []byte
+}
+
+// peer is the client end of an upgraded connection.
+type peer struct {
+	t      *testing.T
+	conn   net.Conn
+	writes chan []byte
+	frames chan frame
+}
+
+// handshake returns a valid handshake request.
+func handshake() *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "http://example.com/ws", nil)
+	r.Header.Set("Connection", "Upgrade")
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Sec-WebSocket-Version", "13")
+	r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	return r
+}
+
+// upgrade upgrades a connection over net.Pipe and returns both ends.
+func upgrade(t *testing.T, opts UpgradeOptions) (*Conn, *peer) {
+	t.Helper()
+	server, client := net.Pipe()
+	t.Cleanup(func() {
+		server.Close()
+		client.Close()
+	})
+
+	w := &hijackWriter{ResponseRecorder: *httptest.NewRecorder(), conn: server}
+	r := handshake()
+	r.Header.Set("Sec-WebSocket-Protocol", "chat, superchat")
+
+	type result struct {
+		conn *Conn
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		c, err := Upgrade(w, r, opts)
+		done <- result{c, err}
+	}()
+
+	br := bufio.NewReader(client)
+	resp, err := http.ReadResponse(br, r)
+	if err != nil {
+		t.Fatalf("read handshake response: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("handshake status = %d, want 101", resp.StatusCode)
+	}
+	// The accept key of the RFC 6455 section 1.3 example.
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Sec-WebSocket-Accept = %q", got)
+	}
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("Upgrade: %v", res.err)
+	}
+
+	p := &peer{t: t, conn: client, writes: make(chan []byte, 16), frames: make(chan frame, 16)}
+	go func() {
+		for b := range p.writes {
+			if _, err := client.Write(b); err != nil {
+				return
+			}
+		}
+	}()
+	go func() {
+		defer close(p.frames)
+		for {
+			f, err := readServerFrame(br)
+			if err != nil {
+				return
+			}
+			p.frames <- f
+		}
+	}()
+	t.Cleanup(func() { close(p.writes) })
+	return res.conn, p
+}
+
+// send queues a masked frame with the given header fields. size overrides
+// the payload length in the header when non-negative.
+func (p *peer) send(fin bool, op byte, payload []byte, masked bool, size int64) {
+	b0 := op
+	if fin {
+		b0 |= 0x80
+	}
+	buf := []byte{b0}
+	if size < 0 {
+		size = int64(len(payload))
+	}
+	maskBit := byte(0)
+	if masked {
+		maskBit = 0x80
+	}
+	switch {
+	case size <= 125:
+		buf = append(buf, maskBit|byte(size))
+	case size <= 0xFFFF:
+		buf = append(buf, maskBit|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(size))
+	default:
+		buf = append(buf, maskBit|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(size))
+	}
+	if masked {
+		mask := []byte{0x37, 0xfa, 0x21, 0x3d}
+		buf = append(buf, mask...)
+		for i, c := range payload {
+			buf = append(buf, c^mask[i%4])
+		}
+	} else {
+		buf = append(buf, payload...)
+	}
+	p.writes <- buf
+}
+
+// next returns the next frame the server sent.
+func (p *peer) next() frame {
+	p.t.Helper()
+	select {
+	case f, ok := <-p.frames:
+		if !ok {
+			p.t.Fatal("connection closed without a frame")
+		}
+		return f
+	case <-time.After(2 * time.Second):
+		p.t.Fatal("no frame from the server")
+	}
+	return frame{}
+}
+
+// expectClose checks the next frame closes the connection with code.
+func (p *peer) expectClose(code int) {
+	p.t.Helper()
+	f := p.next()
+	if f.op != opClose {
+		p.t.Fatalf("frame opcode = %#x, want close", f.op)
+	}
+	if len(f.payload) < 2 {
+		p.t.Fatalf("close frame without a status code")
+	}
+	if got := int(binary.BigEndian.Uint16(f.payload)); got != code {
+		p.t.Fatalf("close code = %d, want %d", got, code)
+	}
+}
+
+// readServerFrame reads an unmasked frame.
+func readServerFrame(br *bufio.Reader) (frame, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(br, head[:]); err != nil {
+		return frame{}, err
+	}
+	size := uint64(head[1] & 0x7F)
+	switch size {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(br, ext[:]); err != nil {
+			return frame{}, err
+		}
+		size = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(br, ext[:]); err != nil {
+			return frame{}, err
+		}
+		size = binary.BigEndian.Uint64(ext[:])
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(br, payload); err != nil {
+		return frame{}, err
+	}
+	return frame{fin: head[0]&0x80 != 0, op: head[0] & 0x0F, payload: payload}, nil
+}
+
+func TestUpgradeRejectsInvalidHandshakes(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(r *http.Request)
+		opts   UpgradeOptions
+		status int
+	}{
+		{"not a websocket request", func(r *http.Request) { r.Header.Del("Upgrade") }, UpgradeOptions{}, http.StatusBadRequest},
+		{"wrong version", func(r *http.Request) { r.Header.Set("Sec-WebSocket-Version", "8") }, UpgradeOptions{}, http.StatusUpgradeRequired},
+		{"short key", func(r *http.Request) { r.Header.Set("Sec-WebSocket-Key", "c2hvcnQ=") }, UpgradeOptions{}, http.StatusBadRequest},
+		{"foreign origin", func(r *http.Request) { r.Header.Set("Origin", "https://evil.example") }, UpgradeOptions{}, http.StatusForbidden},
+		{"origin not listed", func(r *http.Request) { r.Header.Set("Origin", "https://example.com") }, UpgradeOptions{AllowedOrigins: []string{"https://app.example.com"}}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := handshake()
+			tt.modify(r)
+			w := httptest.NewRecorder()
+			if _, err := Upgrade(w, r, tt.opts); err == nil {
+				t.Fatal("Upgrade succeeded")
+			}
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestUpgradeNegotiatesSubprotocol(t *testing.T) {
+	conn, _ := upgrade(t, UpgradeOptions{Subprotocols: []string{"superchat", "chat"}})
+	if conn.Subprotocol != "superchat" {
+		t.Fatalf("Subprotocol = %q, want superchat", conn.Subprotocol)
+	}
+}
+
+func TestReadMaskedMessage(t *testing.T) {
+	conn, p := upgrade(t, UpgradeOptions{})
+	p.send(true, opText, []byte("Hello"), true, -1)
+
+	typ, msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	if typ != TextMessage || string(msg) != "Hello" {
+		t.Fatalf("ReadMessage = %v %q, want text Hello", typ, msg)
+	}
+}
+
+func TestReadFragmentedMessageWithInterleavedPing(t *testing.T) {
+	conn, p := upgrade(t, UpgradeOptions{})
+	p.send(false, opBinary, []byte{1, 2}, true, -1)
+	p.send(true, opPing, []byte("are you there"), true, -1)
+	p.send(false, opContinuation, []byte{3}, true, -1)
+	p.send(true, opContinuation, []byte{4, 5}, true, -1)
+
+	typ, msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	if typ != BinaryMessage || string(msg) != "\x01\x02\x03\x04\x05" {
+		t.Fatalf("ReadMessage = %v %v, want binary 1..5", typ, msg)
+	}
+
+	f := p.next()
+	if f.op != opPong || string(f.payload) != "are you there" {
+		t.Fatalf("answer to ping = %#x %q, want pong echoing the payload", f.op, f.payload)
+	}
+}
+
+func TestReadPongCallsHandler(t *testing.T) {
+	conn, p := upgrade(t, UpgradeOptions{})
+	pongs := 0
+	conn.SetPongHandler(func() { pongs++ })
+	p.send(true, opPong, nil, true, -1)
+	p.send(true, opText, []byte("after"), true, -1)
+
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	if pongs != 1 {
+		t.Fatalf("pong handler called %d times, want 1", pongs)
+	}
+}
+
+func TestReadRejectsOversizedFrameBeforeReadingIt(t *testing.T) {
+	conn, p := upgrade(t, UpgradeOptions{})
+	// A header announcing 2^62 bytes, with no payload following: the read
+	// must fail on the header alone, without allocating.
+	p.send(true, opBinary, nil, true, 1<<62)
+
+	if _, _, err := conn.ReadMessage(); !errors.Is(err, ErrMessageTooBig) {
+		t.Fatalf("ReadMessage error = %v, want ErrMessageTooBig", err)
+	}
+	p.expectClose(CloseTooBig)
+}
+
+func TestReadAppliesDefaultLimit(t *testing.T) {
+	conn, p := upgrade(t, UpgradeOptions{})
+	p.send(true, opBinary, nil, true, DefaultMaxMessageBytes+1)
+
+	if _, _, err := conn.ReadMessage(); !errors.Is(err, ErrMessageTooBig) {
+		t.Fatalf("ReadMessage error = %v, want ErrMessageTooBig", err)
+	}
+	p.expectClose(CloseTooBig)
+}
+
+func TestReadLimitsFragmentedMessages(t *testing.T) {
+	conn, p := upgrade(t, UpgradeOptions{MaxMessageBytes: 10})
+	p.send(false, opText, []byte("123456"), true, -1)
+	p.send(true, opContinuation, []byte("789012"), true, -1)
+
+	if _, _, err := conn.ReadMessage(); !errors.Is(err, ErrMessageTooBig) {
+		t.Fatalf("ReadMessage error = %v, want ErrMessageTooBig", err)
+	}
+	p.expectClose(CloseTooBig)
+}
+
+func TestReadAcceptsMessageAtLimit(t *testing.T) {
+	conn, p := upgrade(t, UpgradeOptions{MaxMessageBytes: 10})
+	p.send(false, opText, []byte("12345"), true, -1)
+	p.send(true, opContinuation, []byte("67890"), true, -1)
+
+	if _, msg, err := conn.ReadMessage(); err != nil || string(msg) != "1234567890" {
+		t.Fatalf("ReadMessage = %q, %v, want the 10-byte message", msg, err)
+	}
+}
+
+func TestReadProtocolViolations(t *testing.T) {
+	tests := []struct {
+		name string
+		send func(p *peer)
+		code int
+	}{
+		{"unmasked frame", func(p *peer) { p.send(true, opText, []byte("hi"), false, -1) }, CloseProtocolError},
+		{"reserved bits", func(p *peer) { p.writes <- []byte{0x80 | 0x40 | opText, 0x80, 0, 0, 0, 0} }, CloseProtocolError},
+		{"fragmented control frame", func(p *peer) { p.send(false, opPing, nil, true, -1) }, CloseProtocolError},
+		{"long control frame", func(p *peer) { p.send(true, opPing, make([]byte, 126), true, -1) }, CloseProtocolError},
+		{"continuation without message", func(p *peer) { p.send(true, opContinuation, []byte("x"), true, -1) }, CloseProtocolError},
+		{"new message inside fragmented one", func(p *peer) {
+			p.send(false, opText, []byte("a"), true, -1)
+			p.send(true, opText, []byte("b"), true, -1)
+		}, CloseProtocolError},
+		{"unknown opcode", func(p *peer) { p.send(true, 0x3, nil, true, -1) }, CloseProtocolError},
+		{"invalid UTF-8", func(p *peer) { p.send(true, opText, []byte{0xff, 0xfe}, true, -1) }, CloseInvalidPayload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, p := upgrade(t, UpgradeOptions{})
+			tt.send(p)
+			if _, _, err := conn.ReadMessage(); err == nil {
+				t.Fatal("ReadMessage succeeded")
+			}
+			p.expectClose(tt.code)
+		})
+	}
+}
+
+func TestCloseHandshake(t *testing.T) {
+	conn, p := upgrade(t, UpgradeOptions{})
+	payload := binary.BigEndian.AppendUint16(nil, CloseGoingAway)
+	p.send(true, opClose, append(payload, "bye"...), true, -1)
+
+	_, _, err := conn.ReadMessage()
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) {
+		t.Fatalf("ReadMessage error = %v, want *CloseError", err)
+	}
+	if closeErr.Code != CloseGoingAway || closeErr.Reason != "bye" {
+		t.Fatalf("CloseError = %d %q, want 1001 bye", closeErr.Code, closeErr.Reason)
+	}
+	p.expectClose(CloseGoingAway)
+
+	if err := conn.WriteMessage(TextMessage, []byte("late"), time.Time{}); !errors.Is(err, net.ErrClosed) {
+		t.Fatalf("WriteMessage after close = %v, want net.ErrClosed", err)
+	}
+}
+
+func TestCloseWithoutStatusIsAnsweredNormal(t *testing.T) {
+	conn, p := upgrade(t, UpgradeOptions{})
+	p.send(true, opClose, nil, true, -1)
+
+	_, _, err := conn.ReadMessage()
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != CloseNoStatus {
+		t.Fatalf("ReadMessage error = %v, want CloseError 1005", err)
+	}
+	p.expectClose(CloseNormal)
+}
+
+func TestWriteMessage(t *testing.T) {
+	for _, size := range []int{0, 125, 126, 0xFFFF, 0x10000} {
+		conn, p := upgrade(t, UpgradeOptions{})
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i)
+		}
+		go func() { _ = conn.WriteMessage(BinaryMessage, data, time.Time{}) }()
+
+		f := p.next()
+		if !f.fin || f.op != opBinary || string(f.payload) != string(data) {
+			t.Fatalf("size %d: frame = fin %v op %#x len %d", size, f.fin, f.op, len(f.payload))
+		}
+	}
+}
@@ -0,0 +1,316 @@
+// Package websocket provides realtime push over WebSockets: Upgrade
+// performs the handshake and returns a Conn speaking the RFC 6455 framing,
+// and a Hub keeps the registry of connections with their read and write
+// pumps, ping/pong heartbeats, broadcast, and graceful close on shutdown.
+//
+// Register Hub.Shutdown as a post-traffic hook, so connections are closed
+// with 1001 (going away) and clients reconnect to another instance:
+//
+//	hooks.PostTraffic("websocket", 5*time.Second, hub.Shutdown)
+package websocket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/iamBelugaa/go-boilerplate/internal/config"
+)
+
+// Defaults applied when the configuration leaves a value unset.
+const (
+	DefaultPingInterval    = 30 * time.Second
+	DefaultPongTimeout     = 60 * time.Second
+	DefaultWriteTimeout    = 10 * time.Second
+	DefaultMaxMessageBytes = 64 << 10
+	DefaultSendBuffer      = 64
+)
+
+// Handlers are the callbacks of a Hub's connections. They are optional.
+type Handlers struct {
+	// OnConnect is called once the connection is registered.
+	OnConnect func(c *Client)
+
+	// OnMessage is called for every message, from the connection's read
+	// pump; messages of a connection are handled one at a time.
+	OnMessage func(c *Client, typ MessageType, data []byte)
+
+	// OnDisconnect is called once the connection is closed, with the
+	// error that ended it (a *CloseError when the client closed it).
+	OnDisconnect func(c *Client, err error)
+}
+
+// message is a queued outgoing message.
+type message struct {
+	typ  MessageType
+	data []byte
+}
+
+// Client is a connection registered with a Hub.
+type Client struct {
+	conn *Conn
+	ctx  context.Context
+	send chan message
+	done chan struct{}
+	once sync.Once
+}
+
+// Context returns the context of the upgrade request, without its
+// cancellation, carrying request-scoped values such as the principal.
+func (c *Client) Context() context.Context {
+	return c.ctx
+}
+
+// Conn returns the underlying connection.
+func (c *Client) Conn() *Conn {
+	return c.conn
+}
+
+// Send queues a message for the client, reporting false when the client
+// is gone. A client whose queue is full is closed as too slow.
+func (c *Client) Send(typ MessageType, data []byte) bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+
+	select {
+	case c.send <- message{typ: typ, data: data}:
+		return true
+	default:
+		c.Close(ClosePolicyViolation, "too slow")
+		return false
+	}
+}
+
+// Close closes the connection with code and reason.
+func (c *Client) Close(code int, reason string) {
+	c.once.Do(func() {
+		close(c.done)
+		_ = c.conn.Close(code, reason)
+	})
+}
+
+// Hub is the registry of a service's websocket connections. It is safe
+// for concurrent use.
+type Hub struct {
+	upgrade      UpgradeOptions
+	pingInterval time.Duration
+	pongTimeout  time.Duration
+	writeTimeout time.Duration
+	sendBuffer   int
+
+	mu      sync.Mutex
+	clients map[*Client]struct{}
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// NewHub returns a Hub configured by conf (nil uses the defaults).
+func NewHub(conf *config.WebSocket) *Hub {
+	if conf == nil {
+		conf = &config.WebSocket{}
+	}
+
+	h := &Hub{
+		upgrade: UpgradeOptions{
+			AllowedOrigins:  conf.AllowedOrigins,
+			MaxMessageBytes: conf.MaxMessageBytes,
+		},
+		pingInterval: conf.PingInterval,
+		pongTimeout:  conf.PongTimeout,
+		writeTimeout: conf.WriteTimeout,
+		sendBuffer:   conf.SendBuffer,
+		clients:      make(map[*Client]struct{}),
+	}
+	if h.upgrade.MaxMessageBytes <= 0 {
+		h.upgrade.MaxMessageBytes = DefaultMaxMessageBytes
+	}
+	if h.pingInterval <= 0 {
+		h.pingInterval = DefaultPingInterval
+	}
+	if h.pongTimeout <= h.pingInterval {
+		h.pongTimeout = max(DefaultPongTimeout, 2*h.pingInterval)
+	}
+	if h.writeTimeout <= 0 {
+		h.writeTimeout = DefaultWriteTimeout
+	}
+	if h.sendBuffer <= 0 {
+		h.sendBuffer = DefaultSendBuffer
+	}
+	return h
+}
+
+// Handler returns a handler upgrading requests to websocket connections
+// registered with the hub, answering 503 once the hub is shut down.
+func (h *Hub) Handler(handlers Handlers) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.mu.Lock()
+		if h.closed {
+			h.mu.Unlock()
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+		h.wg.Add(1)
+		h.mu.Unlock()
+		defer h.wg.Done()
+
+		conn, err := Upgrade(w, r, h.upgrade)
+		if err != nil {
+			return // Upgrade answered the request
+		}
+
+		c := &Client{
+			conn: conn,
+			ctx:  context.WithoutCancel(r.Context()),
+			send: make(chan message, h.sendBuffer),
+			done: make(chan struct{}),
+		}
+		if !h.register(c) {
+			c.Close(CloseGoingAway, "server shutting down")
+			return
+		}
+		defer h.unregister(c)
+
+		if handlers.OnConnect != nil {
+			handlers.OnConnect(c)
+		}
+
+		var pumps sync.WaitGroup
+		pumps.Add(1)
+		go func() {
+			defer pumps.Done()
+			h.writePump(c)
+		}()
+
+		err = h.readPump(c, handlers.OnMessage)
+		c.Close(CloseNormal, "")
+		pumps.Wait()
+
+		if handlers.OnDisconnect != nil {
+			handlers.OnDisconnect(c, err)
+		}
+	})
+}
+
+// Broadcast queues a message for every connected client.
+func (h *Hub) Broadcast(typ MessageType, data []byte) {
+	for _, c := range h.snapshot() {
+		c.Send(typ, data)
+	}
+}
+
+// Clients returns the number of connected clients.
+func (h *Hub) Clients() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+// Shutdown closes every connection with 1001 (going away) and refuses new
+// ones, then waits for the connections' handlers to return or ctx to be
+// done.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	h.closed = true
+	h.mu.Unlock()
+
+	for _, c := range h.snapshot() {
+		c.Close(CloseGoingAway, "server shutting down")
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// readPump reads messages until the connection fails or goes silent for
+// longer than the pong timeout.
+func (h *Hub) readPump(c *Client, onMessage func(*Client, MessageType, []byte)) error {
+	extend := func() { _ = c.conn.SetReadDeadline(time.Now().Add(h.pongTimeout)) }
+	c.conn.SetPongHandler(extend)
+	extend()
+
+	for {
+		typ, data, err := c.conn.ReadMessage()
+		if err != nil {
+			select {
+			case <-c.done:
+				var closeErr *CloseError
+				if !errors.As(err, &closeErr) {
+					return nil // closed by the server
+				}
+			default:
+			}
+			return err
+		}
+		extend()
+		if onMessage != nil {
+			onMessage(c, typ, data)
+		}
+	}
+}
+
+// writePump sends queued messages and pings until the client is closed.
+func (h *Hub) writePump(c *Client) {
+	ticker := time.NewTicker(h.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case m := <-c.send:
+			if err := c.conn.WriteMessage(m.typ, m.data, time.Now().Add(h.writeTimeout)); err != nil {
+				c.Close(CloseInternalError, "")
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.Ping(time.Now().Add(h.writeTimeout)); err != nil {
+				c.Close(CloseInternalError, "")
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// register adds c to the hub, unless it is shut down.
+func (h *Hub) register(c *Client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return false
+	}
+	h.clients[c] = struct{}{}
+	return true
+}
+
+// unregister removes c from the hub.
+func (h *Hub) unregister(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.clients, c)
+}
+
+// snapshot returns the connected clients.
+func (h *Hub) snapshot() []*Client {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	clients := make([]*Client, 0, len(h.clients))
+	for c := range h.clients {
+		clients = append(clients, c)
+	}
+	return clients
+}